@@ -9,11 +9,14 @@ import (
 
 	"github.com/TrueBlocks/trueblocks-poetry/backend/components"
 	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/secrets"
 	"github.com/TrueBlocks/trueblocks-poetry/backend/seeding"
 	"github.com/TrueBlocks/trueblocks-poetry/backend/services"
 	"github.com/TrueBlocks/trueblocks-poetry/backend/settings"
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/cache"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/export"
 	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -24,9 +27,17 @@ type App struct {
 	db           *database.DB
 	settings     *settings.Manager
 	adhoc        *components.AdHocQueryComponent
+	savedQueries *components.SavedQueryComponent
 	ttsService   *services.TTSService
 	imageService *services.ImageService
 	itemService  *services.ItemService
+	ttsCache     *cache.BoundedCache
+	imageCache   *cache.BoundedCache
+	exportLoader *export.TemplateLoader
+	exporter     *export.Renderer
+	dumpLoader   *export.DumpLoader
+	dumpRenderer *export.DumpRenderer
+	packManager  *seeding.PackManager
 }
 
 // LinkOrTagResult is the return type for CreateLinkOrRemoveTags
@@ -63,8 +74,8 @@ func (a *App) startup(ctx context.Context) {
 	// Show window after positioning
 	runtime.WindowShow(ctx)
 
-	// Determine database path from constants
-	dbPath, err := constants.GetDatabasePath()
+	// Determine database path
+	dbPath, err := paths.DatabasePath()
 	if err != nil {
 		slog.Error("Failed to get database path", "error", err)
 		os.Exit(1)
@@ -72,11 +83,24 @@ func (a *App) startup(ctx context.Context) {
 
 	slog.Info("Database path", "path", dbPath)
 
-	// Ensure data is seeded before opening database
-	if err := seeding.EnsureDataSeeded(filepath.Dir(dbPath)); err != nil {
+	// Ensure data is seeded before opening database, streaming progress to
+	// the frontend as "seed:progress" events so a splash screen can render
+	// a real progress bar instead of blocking with no feedback.
+	dataDir := filepath.Dir(dbPath)
+	if err := a.runSeeder(ctx, dataDir); err != nil {
 		slog.Warn("Failed to seed data", "error", err)
 	}
 
+	// Load the content-pack manager so InstallPack/RemovePack have
+	// something to call into, and so any already-installed pack's SQL
+	// fragment (its files were already merged into dataDir at install
+	// time) gets (re-)applied below once the database is open.
+	packManager, err := seeding.NewPackManager(dataDir)
+	if err != nil {
+		slog.Warn("Failed to initialize pack manager", "error", err)
+	}
+	a.packManager = packManager
+
 	// Initialize database
 	db, err := database.NewDB(dbPath)
 	if err != nil {
@@ -85,19 +109,78 @@ func (a *App) startup(ctx context.Context) {
 	}
 	a.db = db
 	a.adhoc = components.NewAdHocQueryComponent(db)
+	a.savedQueries = components.NewSavedQueryComponent(db, a.adhoc)
 	a.ttsService = services.NewTTSService(db)
 	a.imageService = services.NewImageService(db)
 	a.itemService = services.NewItemService(db, a.imageService)
 
-	// Run one-time data migrations
-	if err := a.runMigration1(); err != nil {
-		slog.Warn("Migration 1 failed", "error", err)
+	// Wire bounded caches for TTS/image writes so they stay under the configured limits
+	limits := a.settings.Get().CacheLimits
+	ttsCacheDir, err := paths.TTSCacheDir()
+	if err != nil {
+		slog.Warn("Failed to get TTS cache dir", "error", err)
+	} else if ttsCache, err := cache.NewBoundedCache(ttsCacheDir, limits.TTSMaxBytes, limits.TTSMaxFiles); err != nil {
+		slog.Warn("Failed to initialize TTS cache", "error", err)
+	} else {
+		a.ttsCache = ttsCache
+		a.ttsService.SetCache(ttsCache)
+		if report, err := ttsCache.Prune(); err != nil {
+			slog.Warn("Failed to prune TTS cache on startup", "error", err)
+		} else if len(report.EvictedFiles) > 0 {
+			slog.Info("Pruned TTS cache on startup", "evicted", len(report.EvictedFiles), "bytesFreed", report.BytesFreed)
+		}
+	}
+
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		slog.Warn("Failed to get images dir", "error", err)
+	} else if imageCache, err := cache.NewBoundedCache(imagesDir, limits.ImageMaxBytes, limits.ImageMaxFiles); err != nil {
+		slog.Warn("Failed to initialize image cache", "error", err)
+	} else {
+		a.imageCache = imageCache
+		a.imageService.SetCache(imageCache)
+		if report, err := imageCache.Prune(); err != nil {
+			slog.Warn("Failed to prune image cache on startup", "error", err)
+		} else if len(report.EvictedFiles) > 0 {
+			slog.Info("Pruned image cache on startup", "evicted", len(report.EvictedFiles), "bytesFreed", report.BytesFreed)
+		}
+	}
+
+	database.SetLazyCacheLimit(limits.LazyMaxBytes)
+
+	// Initialize export template loader/renderer
+	configDir, err := paths.ConfigDir()
+	if err != nil {
+		slog.Warn("Failed to get config dir for export templates", "error", err)
+	} else {
+		a.exportLoader = export.NewTemplateLoader(configDir)
+		a.exporter = export.NewRenderer(db, a.exportLoader)
+		a.dumpLoader = export.NewDumpLoader(configDir)
+		a.dumpRenderer = export.NewDumpRenderer(a.dumpLoader, parser.NewMarkdownTagRenderers())
+	}
+
+	// Run any schema/data migrations that haven't been applied yet
+	if _, err := a.RunPendingMigrations(ctx); err != nil {
+		slog.Warn("Failed to run pending migrations", "error", err)
 	}
 
 	// Sync file flags on startup
 	if err := db.SyncFileFlags(); err != nil {
 		slog.Warn("Failed to sync file flags", "error", err)
 	}
+
+	// Write ID3 metadata into any cached TTS mp3 that doesn't have it yet
+	if _, err := services.SyncTTSTags(ctx, db); err != nil {
+		slog.Warn("Failed to sync TTS tags", "error", err)
+	}
+
+	// Apply any installed content pack's SQL fragment that hasn't been
+	// applied yet - a no-op on every startup after the first.
+	if a.packManager != nil {
+		if err := seeding.ApplyPackData(db, a.packManager); err != nil {
+			slog.Warn("Failed to apply content pack data", "error", err)
+		}
+	}
 }
 
 // Capabilities defines what features are available based on configuration
@@ -109,10 +192,14 @@ type Capabilities struct {
 
 // GetCapabilities returns the available features of the application
 func (a *App) GetCapabilities() *Capabilities {
+	aiKey, err := secrets.Get("OPENAI_API_KEY")
+	if err != nil {
+		slog.Warn("Failed to read OpenAI API key from secrets store", "error", err)
+	}
 	return &Capabilities{
-		HasTTS:    os.Getenv("OPENAI_API_KEY") != "",
+		HasTTS:    services.TTSProviderConfigured(),
 		HasImages: true, // Always available
-		HasAI:     os.Getenv("OPENAI_API_KEY") != "",
+		HasAI:     aiKey != "",
 	}
 }
 
@@ -121,6 +208,74 @@ func (a *App) RunAdHocQuery(query string) ([]map[string]interface{}, error) {
 	return a.adhoc.RunAdHocQuery(query)
 }
 
+// RunAdHocQueryWithParams executes a read-only SQL query with named and/or
+// positional bindings instead of string-concatenated values.
+func (a *App) RunAdHocQueryWithParams(query string, namedParams map[string]any, positionalParams []any) ([]map[string]interface{}, error) {
+	return a.adhoc.RunAdHocQueryWithParams(query, namedParams, positionalParams)
+}
+
+// OpenAdHocCursor opens a paged cursor over query so the frontend's grid can
+// fetch large ad-hoc result sets a batch at a time instead of all at once.
+func (a *App) OpenAdHocCursor(query string, namedParams map[string]any, positionalParams []any) (string, []string, error) {
+	return a.adhoc.OpenCursor(query, namedParams, positionalParams)
+}
+
+// FetchAdHocRows returns the next n rows from a cursor opened with
+// OpenAdHocCursor.
+func (a *App) FetchAdHocRows(cursorID string, n int) ([]map[string]any, bool, error) {
+	return a.adhoc.FetchRows(cursorID, n)
+}
+
+// CloseAdHocCursor releases a cursor opened with OpenAdHocCursor before it's
+// paged to the end.
+func (a *App) CloseAdHocCursor(cursorID string) error {
+	return a.adhoc.CloseCursor(cursorID)
+}
+
+// CountAdHocQuery returns the row count query would produce, for rendering
+// an accurate grid scrollbar without fetching every row.
+func (a *App) CountAdHocQuery(query string) (int64, error) {
+	return a.adhoc.Count(query)
+}
+
+// ExplainAdHocQuery returns the EXPLAIN QUERY PLAN node tree for query, so
+// the UI can warn about a full table scan or missing index before it runs.
+func (a *App) ExplainAdHocQuery(query string) ([]components.PlanNode, error) {
+	return a.adhoc.ExplainQuery(query)
+}
+
+// CreateSavedQuery persists a named RunAdHocQuery snippet for reuse across
+// sessions.
+func (a *App) CreateSavedQuery(name, description, query string, paramSchema []components.SavedQueryParam, tags []string) error {
+	return a.savedQueries.CreateSavedQuery(name, description, query, paramSchema, tags)
+}
+
+// UpdateSavedQuery replaces an existing saved query's description, SQL
+// text, parameter schema, and tags.
+func (a *App) UpdateSavedQuery(name, description, query string, paramSchema []components.SavedQueryParam, tags []string) error {
+	return a.savedQueries.UpdateSavedQuery(name, description, query, paramSchema, tags)
+}
+
+// DeleteSavedQuery removes a saved query by name.
+func (a *App) DeleteSavedQuery(name string) error {
+	return a.savedQueries.DeleteSavedQuery(name)
+}
+
+// GetSavedQuery retrieves one saved query by name.
+func (a *App) GetSavedQuery(name string) (*components.SavedQuery, error) {
+	return a.savedQueries.GetSavedQuery(name)
+}
+
+// ListSavedQueries returns every saved query, most recently created first.
+func (a *App) ListSavedQueries() ([]components.SavedQuery, error) {
+	return a.savedQueries.ListSavedQueries()
+}
+
+// RunSavedQuery executes a saved query by name with the given bindings.
+func (a *App) RunSavedQuery(name string, namedParams map[string]any, positionalParams []any) ([]map[string]interface{}, error) {
+	return a.savedQueries.RunSaved(name, namedParams, positionalParams)
+}
+
 // GetConstants returns shared constants to the frontend
 func (a *App) GetConstants() map[string]string {
 	return map[string]string{
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/backup"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/migrations"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+)
+
+// backupProgressEvent is the Wails event BackupDatabase/RestoreDatabase
+// emit progress on, so the frontend can draw a progress bar the way
+// long-running dump/restore tools do.
+const backupProgressEvent = "backup:progress"
+
+type backupProgress struct {
+	BytesDone  int64  `json:"bytesDone"`
+	BytesTotal int64  `json:"bytesTotal"`
+	File       string `json:"file"`
+}
+
+// RestoreOptions is the Wails-bound counterpart to backup.RestoreOptions.
+type RestoreOptions = backup.RestoreOptions
+
+// BackupDatabase writes a gzip+tar archive at path containing a consistent
+// snapshot of the database (via SQLite VACUUM INTO), the images directory,
+// the TTS cache, and a manifest recording schema version, per-entry
+// SHA-256, and counts.
+func (a *App) BackupDatabase(path string) error {
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return fmt.Errorf("failed to get images directory: %w", err)
+	}
+	ttsCacheDir, err := paths.TTSCacheDir()
+	if err != nil {
+		return fmt.Errorf("failed to get TTS cache directory: %w", err)
+	}
+
+	progress := backup.ProgressFunc(func(bytesDone, bytesTotal int64, file string) {
+		runtime.EventsEmit(a.ctx, backupProgressEvent, backupProgress{BytesDone: bytesDone, BytesTotal: bytesTotal, File: file})
+	})
+
+	return backup.Create(a.db, imagesDir, ttsCacheDir, path, migrations.DefaultRegistry().MaxVersion(), progress)
+}
+
+// RestoreDatabase applies the backup archive at path, verifying every
+// entry's hash against its manifest before swapping any file in, and
+// refusing archives whose schema version this build's migrations can't
+// reach. A previous database is kept at its path plus ".bak" until the
+// restored one opens successfully.
+func (a *App) RestoreDatabase(path string, opts RestoreOptions) error {
+	dbPath, err := paths.DatabasePath()
+	if err != nil {
+		return fmt.Errorf("failed to get database path: %w", err)
+	}
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return fmt.Errorf("failed to get images directory: %w", err)
+	}
+	ttsCacheDir, err := paths.TTSCacheDir()
+	if err != nil {
+		return fmt.Errorf("failed to get TTS cache directory: %w", err)
+	}
+
+	progress := backup.ProgressFunc(func(bytesDone, bytesTotal int64, file string) {
+		runtime.EventsEmit(a.ctx, backupProgressEvent, backupProgress{BytesDone: bytesDone, BytesTotal: bytesTotal, File: file})
+	})
+
+	return backup.Restore(path, dbPath, imagesDir, ttsCacheDir, migrations.DefaultRegistry().MaxVersion(), opts, progress)
+}
@@ -8,62 +8,11 @@ import (
 	"strings"
 
 	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/secrets"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/services"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
 )
 
-func (a *App) runMigration1() error {
-	// Check if migration already ran
-	if value, _ := a.db.GetSetting("migration_1"); value == "true" {
-		slog.Info("Migration 1 already completed, skipping")
-		return nil
-	}
-
-	slog.Info("Starting migration 1: normalizing all items")
-
-	// Get all items
-	items, err := a.db.GetAllItems()
-	if err != nil {
-		return fmt.Errorf("failed to get items for migration: %w", err)
-	}
-
-	slog.Info("Migration 1: processing items", "count", len(items))
-
-	// Get TTS cache directory for cleanup
-	cacheDir, err := constants.GetTTSCacheDir()
-	if err != nil {
-		slog.Warn("Migration 1: failed to get TTS cache dir", "error", err)
-	}
-
-	// Normalize each item
-	for i, item := range items {
-		if err := a.db.UpdateItem(item); err != nil {
-			slog.Warn("Migration 1: failed to update item", "itemId", item.ItemID, "word", item.Word, "error", err)
-			continue
-		}
-
-		// Delete TTS cache for this item (same as UpdateItem service does)
-		if cacheDir != "" {
-			cacheFile := fmt.Sprintf("%s/%d.mp3", cacheDir, item.ItemID)
-			if err := os.Remove(cacheFile); err != nil && !os.IsNotExist(err) {
-				slog.Warn("Migration 1: failed to delete TTS cache", "itemId", item.ItemID, "error", err)
-			}
-		}
-
-		// Log progress every 100 items
-		if (i+1)%100 == 0 {
-			slog.Info("Migration 1: progress", "processed", i+1, "total", len(items))
-		}
-	}
-
-	// Mark migration as complete
-	if err := a.db.SetSetting("migration_1", "true"); err != nil {
-		return fmt.Errorf("failed to save migration_1 setting: %w", err)
-	}
-
-	slog.Info("Migration 1: completed successfully", "items_processed", len(items))
-	return nil
-}
-
 // CheckpointDatabase flushes WAL to main database file
 func (a *App) CheckpointDatabase() error {
 	slog.Info("[App] Checkpointing database WAL")
@@ -76,6 +25,14 @@ func (a *App) CleanOrphanedLinks() (int, error) {
 	return a.db.CleanOrphanedLinks()
 }
 
+// GarbageCollectBlobs sweeps the image and TTS cache directories for blob
+// files image_cache/tts_cache no longer reference, removing them and
+// reporting how many were removed and how many bytes were reclaimed.
+func (a *App) GarbageCollectBlobs() (services.BlobGCReport, error) {
+	slog.Info("[App] Garbage collecting orphaned blobs")
+	return services.GarbageCollectBlobs(a.db, a.imageService, a.ttsService)
+}
+
 // GetDanglingLinks returns links that point to non-existent items
 func (a *App) GetDanglingLinks() ([]map[string]interface{}, error) {
 	query := database.MustLoadQuery("dangling_links")
@@ -125,7 +82,7 @@ func (a *App) GetStats() (map[string]int, error) {
 
 // GetDatabaseFileSize returns the size of the database file in bytes
 func (a *App) GetDatabaseFileSize() (int64, error) {
-	dbPath, err := constants.GetDatabasePath()
+	dbPath, err := paths.DatabasePath()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get database path: %w", err)
 	}
@@ -207,7 +164,7 @@ func (a *App) GetEnvVars() map[string]string {
 	data, err := os.ReadFile(envPath)
 	if err != nil {
 		// Try fallback location
-		fallbackPath, err := constants.GetEnvPath()
+		fallbackPath, err := paths.EnvPath()
 		if err == nil {
 			data, err = os.ReadFile(fallbackPath)
 			if err != nil {
@@ -221,12 +178,10 @@ func (a *App) GetEnvVars() map[string]string {
 		}
 	}
 
-	// Sensitive key patterns to filter out
-	sensitivePatterns := []string{
-		"KEY", "SECRET", "TOKEN", "PASSWORD", "PASS", "AUTH", "CREDENTIAL",
-	}
-
-	// Parse .env file
+	// Parse .env file. Sensitive keys shouldn't be in here anymore - they're
+	// routed into the encrypted secrets store by SaveEnvVar - but a
+	// pre-migration or hand-edited file might still have one, so they're
+	// still masked rather than leaked if found.
 	lines := strings.Split(string(data), "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -240,18 +195,7 @@ func (a *App) GetEnvVars() map[string]string {
 			key := strings.TrimSpace(parts[0])
 			value := strings.TrimSpace(parts[1])
 
-			// Check if key contains sensitive patterns
-			keyUpper := strings.ToUpper(key)
-			isSensitive := false
-			for _, pattern := range sensitivePatterns {
-				if strings.Contains(keyUpper, pattern) {
-					isSensitive = true
-					break
-				}
-			}
-
-			// Only include non-sensitive values, mask sensitive ones
-			if isSensitive {
+			if secrets.IsSensitive(key) {
 				if value != "" {
 					envVars[key] = "***REDACTED***"
 				}
@@ -263,13 +207,29 @@ func (a *App) GetEnvVars() map[string]string {
 		}
 	}
 
+	// Merge in keys held by the encrypted secrets store, masked, so the
+	// frontend can tell they're configured without ever seeing the value.
+	secretKeys, err := secrets.Keys()
+	if err != nil {
+		slog.Warn("Failed to list secrets store keys", "error", err)
+	}
+	for _, key := range secretKeys {
+		envVars[key] = "***REDACTED***"
+	}
+
 	return envVars
 }
 
-// SaveEnvVar saves an environment variable to the .env file
+// SaveEnvVar saves an environment variable. Keys that look like credentials
+// (KEY, SECRET, TOKEN, ...) are routed into the encrypted secrets store;
+// everything else still goes to .env.
 func (a *App) SaveEnvVar(key, value string) error {
+	if secrets.IsSensitive(key) {
+		return secrets.Set(key, value)
+	}
+
 	// Determine .env path (prioritize ~/.local/share/trueblocks/poetry/.env)
-	envPath, err := constants.GetEnvPath()
+	envPath, err := paths.EnvPath()
 	if err != nil {
 		return fmt.Errorf("failed to get env path: %w", err)
 	}
@@ -327,7 +287,7 @@ func (a *App) SaveEnvVar(key, value string) error {
 
 // HasEnvFile checks if the .env file exists
 func (a *App) HasEnvFile() bool {
-	envPath, err := constants.GetEnvPath()
+	envPath, err := paths.EnvPath()
 	if err != nil {
 		return false
 	}
@@ -337,7 +297,7 @@ func (a *App) HasEnvFile() bool {
 
 // SkipAiSetup creates the .env file with a marker if it doesn't exist
 func (a *App) SkipAiSetup() error {
-	envPath, err := constants.GetEnvPath()
+	envPath, err := paths.EnvPath()
 	if err != nil {
 		return fmt.Errorf("failed to get env path: %w", err)
 	}
@@ -375,7 +335,7 @@ func (a *App) GetEnvLocation() string {
 	}
 
 	// Check fallback location
-	fallbackPath, err := constants.GetEnvPath()
+	fallbackPath, err := paths.EnvPath()
 	if err == nil {
 		if _, err := os.Stat(fallbackPath); err == nil {
 			return fallbackPath
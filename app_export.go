@@ -9,22 +9,70 @@ import (
 	"strings"
 
 	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/export"
 	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/report"
 )
 
+// ListExportTemplates returns the names of all available export templates
+// (user-saved overrides plus the built-in per-type defaults).
+func (a *App) ListExportTemplates() ([]string, error) {
+	if a.exportLoader == nil {
+		return nil, fmt.Errorf("export templates are not available")
+	}
+	return a.exportLoader.List()
+}
+
+// SaveExportTemplate saves a user-editable .hbs template under name, and
+// remembers it as the default template used by ExportItems.
+func (a *App) SaveExportTemplate(name, body string) error {
+	if a.exportLoader == nil {
+		return fmt.Errorf("export templates are not available")
+	}
+	if err := a.exportLoader.Save(name, body); err != nil {
+		return err
+	}
+	return a.settings.UpdateExportTemplate(name)
+}
+
+// ExportItems renders the given items through templateName in the requested
+// format ("md", "html", or "json") into the configured export folder, and
+// returns the path written.
+func (a *App) ExportItems(templateName string, itemIDs []int, format string) (string, error) {
+	if a.exporter == nil {
+		return "", fmt.Errorf("export is not available")
+	}
+
+	var items []database.Item
+	for _, id := range itemIDs {
+		item, err := a.db.GetItem(id)
+		if err != nil {
+			return "", fmt.Errorf("failed to get item %d: %w", id, err)
+		}
+		items = append(items, *item)
+	}
+
+	exportFolder := a.settings.Get().ExportFolder
+	return a.exporter.ExportItems(items, templateName, format, exportFolder)
+}
+
+// markdownTagRenderers is the TagRendererRegistry behind resolveTagsForMarkdown;
+// shared at package scope since it's stateless and reused by every export.
+var markdownTagRenderers = parser.NewMarkdownTagRenderers()
+
 // Example: {word:shakespeare} becomes **<small>SHAKESPEARE</small>**
 func resolveTagsForMarkdown(text string) string {
-	return parser.ReplaceTags(text, func(ref parser.Reference) string {
-		// Convert to uppercase for small caps effect and wrap in bold + small tag
-		return fmt.Sprintf("**<small>%s</small>**", strings.ToUpper(ref.Value))
-	})
+	return markdownTagRenderers.ReplaceWith(text)
 }
 
-// copyImageToExport copies an image file to the export folder's images subdirectory
-func copyImageToExport(itemID int, exportFolder string) (string, error) {
-	imagesDir, err := constants.GetImagesDir()
+// copyImageToExport copies an image file to the export folder's images
+// subdirectory. Failures other than "no image cached" are recorded on diag
+// under the "copy-image" category rather than swallowed.
+func copyImageToExport(itemID int, exportFolder string, diag *report.Diagnostics) (string, error) {
+	imagesDir, err := paths.ImagesDir()
 	if err != nil {
+		diag.Add("copy-image", itemID, "locate-images-dir", err)
 		return "", err
 	}
 
@@ -38,80 +86,81 @@ func copyImageToExport(itemID int, exportFolder string) (string, error) {
 	// Create images subdirectory in export folder
 	exportImagesDir := filepath.Join(exportFolder, "images")
 	if err := os.MkdirAll(exportImagesDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create export images directory: %w", err)
+		wrapped := fmt.Errorf("failed to create export images directory: %w", err)
+		diag.Add("copy-image", itemID, "mkdir", wrapped)
+		return "", wrapped
 	}
 
 	// Copy image to export folder
 	destPath := filepath.Join(exportImagesDir, fmt.Sprintf("%d.png", itemID))
 	data, err := os.ReadFile(srcPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read image: %w", err)
+		wrapped := fmt.Errorf("failed to read image: %w", err)
+		diag.Add("copy-image", itemID, "read", wrapped)
+		return "", wrapped
 	}
 
 	if err := os.WriteFile(destPath, data, 0644); err != nil {
-		return "", fmt.Errorf("failed to write image: %w", err)
+		wrapped := fmt.Errorf("failed to write image: %w", err)
+		diag.Add("copy-image", itemID, "write", wrapped)
+		return "", wrapped
 	}
 
 	// Return relative path for markdown
 	return fmt.Sprintf("images/%d.png", itemID), nil
 }
 
-// writeItemToMarkdown writes an item's details to the markdown builder
-func writeItemToMarkdown(item database.Item, markdown *strings.Builder, exportFolder string) {
-	fmt.Fprintf(markdown, "## %s\n\n", item.Word)
-	fmt.Fprintf(markdown, "**Type:** %s\n\n", item.Type)
-
-	// Add image if present
+// writeItemToMarkdown renders an item's details through the item.md.tmpl
+// dump template and appends the result to the markdown builder, recording
+// any image-copy or template failure on diag instead of dropping it
+// silently.
+func writeItemToMarkdown(item database.Item, markdown *strings.Builder, exportFolder string, diag *report.Diagnostics, renderer *export.DumpRenderer) {
+	ctx := export.ItemContext{
+		ItemID:      item.ItemID,
+		Word:        item.Word,
+		Type:        item.Type,
+		Definition:  item.Definition.GetOrEmpty(),
+		Derivation:  item.Derivation.GetOrEmpty(),
+		Appendicies: item.Appendicies.GetOrEmpty(),
+	}
+	if item.Source != nil {
+		ctx.Source = *item.Source
+	}
+	if item.SourcePg != nil {
+		ctx.SourcePg = *item.SourcePg
+	}
 	if item.HasImage == 1 {
-		if imagePath, err := copyImageToExport(item.ItemID, exportFolder); err == nil && imagePath != "" {
-			fmt.Fprintf(markdown, "![%s](%s)\n\n", item.Word, imagePath)
+		if imagePath, err := copyImageToExport(item.ItemID, exportFolder, diag); err == nil && imagePath != "" {
+			ctx.HasImage = true
+			ctx.ImagePath = imagePath
 		}
 	}
-
-	// Add TTS note if present
 	if item.HasTts == 1 {
-		markdown.WriteString("ðŸ”Š **Has TTS**\n\n")
+		ctx.HasTTS = true
 	}
 
-	if item.Definition != nil && *item.Definition != "" {
-		resolved := resolveTagsForMarkdown(*item.Definition)
-		fmt.Fprintf(markdown, "### Definition\n\n%s\n\n", resolved)
-	}
-
-	if item.Derivation != nil && *item.Derivation != "" {
-		resolved := resolveTagsForMarkdown(*item.Derivation)
-		fmt.Fprintf(markdown, "### Etymology\n\n%s\n\n", resolved)
-	}
-
-	if item.Appendicies != nil && *item.Appendicies != "" {
-		resolved := resolveTagsForMarkdown(*item.Appendicies)
-		fmt.Fprintf(markdown, "### Notes\n\n%s\n\n", resolved)
-	}
-
-	if (item.Source != nil && *item.Source != "") || (item.SourcePg != nil && *item.SourcePg != "") {
-		if item.Source != nil {
-			resolved := resolveTagsForMarkdown(*item.Source)
-			fmt.Fprintf(markdown, "**Source:** %s", resolved)
-		}
-		if item.SourcePg != nil && *item.SourcePg != "" {
-			fmt.Fprintf(markdown, ", p. %s", *item.SourcePg)
-		}
-		markdown.WriteString("\n\n")
+	rendered, err := renderer.RenderItem(ctx)
+	if err != nil {
+		diag.Add("export-markdown", item.ItemID, "render-item", err)
+		return
 	}
-
-	markdown.WriteString("---\n\n")
+	markdown.WriteString(rendered)
 }
 
 // ExportToJSON exports all data to a JSON file and returns the full path
-func (a *App) ExportToJSON() (string, error) {
+// plus a Diagnostics trail of anything that went wrong generating the
+// embedded reports, instead of silently dropping those errors.
+func (a *App) ExportToJSON() (string, report.Diagnostics, error) {
+	var diag report.Diagnostics
+
 	items, err := a.db.GetAllItems()
 	if err != nil {
-		return "", fmt.Errorf("failed to get items: %w", err)
+		return "", diag, fmt.Errorf("failed to get items: %w", err)
 	}
 
 	links, err := a.db.GetAllLinks()
 	if err != nil {
-		return "", fmt.Errorf("failed to get links: %w", err)
+		return "", diag, fmt.Errorf("failed to get links: %w", err)
 	}
 
 	// Separate items by type
@@ -148,19 +197,26 @@ func (a *App) ExportToJSON() (string, error) {
 	})
 
 	// Get all reports
-	unlinkedRefs, _ := a.GetUnlinkedReferences()
-	duplicates, _ := a.GetDuplicateItems()
-	orphanedItems, _ := a.GetOrphanedItems()
-	linkedNotInDef, _ := a.GetLinkedItemsNotInDefinition()
-	missingDefs, _ := a.GetItemsWithoutDefinitions()
-	unknownTypes, _ := a.GetItemsWithUnknownTypes()
-	unknownTags, _ := a.GetUnknownTags()
+	unlinkedRefs, err := a.GetUnlinkedReferences()
+	diag.Add("export-json", 0, "unlinked-references", err)
+	duplicates, err := a.GetDuplicateItems()
+	diag.Add("export-json", 0, "duplicate-items", err)
+	orphanedItems, err := a.GetOrphanedItems()
+	diag.Add("export-json", 0, "orphaned-items", err)
+	linkedNotInDef, err := a.GetLinkedItemsNotInDefinition()
+	diag.Add("export-json", 0, "linked-items-not-in-definition", err)
+	missingDefs, err := a.GetItemsWithoutDefinitions()
+	diag.Add("export-json", 0, "items-without-definitions", err)
+	unknownTypes, err := a.GetItemsWithUnknownTypes()
+	diag.Add("export-json", 0, "unknown-types", err)
+	unknownTags, err := a.GetUnknownTags()
+	diag.Add("export-json", 0, "unknown-tags", err)
 
 	// Get settings
 	s := a.settings.Get()
 
 	// Get database info
-	dbPath, _ := constants.GetDatabasePath()
+	dbPath, _ := paths.DatabasePath()
 	exportFolder := s.ExportFolder
 
 	data := map[string]interface{}{
@@ -189,11 +245,12 @@ func (a *App) ExportToJSON() (string, error) {
 			"unknownTypes":               unknownTypes,
 			"unknownTags":                unknownTags,
 		},
+		"diagnostics": diag,
 	}
 
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		return "", diag, fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
 	// Get export path from settings or use default
@@ -201,14 +258,14 @@ func (a *App) ExportToJSON() (string, error) {
 	if exportFolder == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return "", fmt.Errorf("failed to get home directory: %w", err)
+			return "", diag, fmt.Errorf("failed to get home directory: %w", err)
 		}
 		exportFolder = filepath.Join(homeDir, "Documents", "Poetry", "exports")
 	}
 
 	// Create export directory
 	if err := os.MkdirAll(exportFolder, 0755); err != nil {
-		return "", fmt.Errorf("failed to create export directory: %w", err)
+		return "", diag, fmt.Errorf("failed to create export directory: %w", err)
 	}
 
 	// Create filename
@@ -217,17 +274,21 @@ func (a *App) ExportToJSON() (string, error) {
 
 	err = os.WriteFile(fullPath, jsonData, 0644)
 	if err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+		return "", diag, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return fullPath, nil
+	return fullPath, diag, nil
 }
 
-// ExportToMarkdown exports all items to a Markdown file and returns the full path
-func (a *App) ExportToMarkdown() (string, error) {
+// ExportToMarkdown exports all items to a Markdown file and returns the full
+// path plus a Diagnostics trail of anything that went wrong along the way
+// (image copies, report generation), rendered as a "Warnings" appendix.
+func (a *App) ExportToMarkdown() (string, report.Diagnostics, error) {
+	var diag report.Diagnostics
+
 	items, err := a.db.GetAllItems()
 	if err != nil {
-		return "", fmt.Errorf("failed to get items: %w", err)
+		return "", diag, fmt.Errorf("failed to get items: %w", err)
 	}
 
 	// Separate items by type
@@ -267,21 +328,21 @@ func (a *App) ExportToMarkdown() (string, error) {
 	s := a.settings.Get()
 
 	// Get database info
-	dbPath, _ := constants.GetDatabasePath()
+	dbPath, _ := paths.DatabasePath()
 	exportFolder := s.ExportFolder
 
 	// Set up export folder early
 	if exportFolder == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return "", fmt.Errorf("failed to get home directory: %w", err)
+			return "", diag, fmt.Errorf("failed to get home directory: %w", err)
 		}
 		exportFolder = filepath.Join(homeDir, "Documents", "PoetryExports")
 	}
 
 	// Create export directory
 	if err := os.MkdirAll(exportFolder, 0755); err != nil {
-		return "", fmt.Errorf("failed to create export directory: %w", err)
+		return "", diag, fmt.Errorf("failed to create export directory: %w", err)
 	}
 
 	var markdown strings.Builder
@@ -308,28 +369,28 @@ func (a *App) ExportToMarkdown() (string, error) {
 	markdown.WriteString("# References\n\n")
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	for _, item := range references {
-		writeItemToMarkdown(item, &markdown, exportFolder)
+		writeItemToMarkdown(item, &markdown, exportFolder, &diag, a.dumpRenderer)
 	}
 
 	// Writers Section
 	markdown.WriteString("\n# Writers\n\n")
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	for _, item := range writers {
-		writeItemToMarkdown(item, &markdown, exportFolder)
+		writeItemToMarkdown(item, &markdown, exportFolder, &diag, a.dumpRenderer)
 	}
 
 	// Titles Section
 	markdown.WriteString("\n# Titles\n\n")
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	for _, item := range titles {
-		writeItemToMarkdown(item, &markdown, exportFolder)
+		writeItemToMarkdown(item, &markdown, exportFolder, &diag, a.dumpRenderer)
 	}
 
 	// Other Section
 	markdown.WriteString("\n# Other\n\n")
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	for _, item := range other {
-		writeItemToMarkdown(item, &markdown, exportFolder)
+		writeItemToMarkdown(item, &markdown, exportFolder, &diag, a.dumpRenderer)
 	}
 
 	// Add Reports Section
@@ -337,7 +398,8 @@ func (a *App) ExportToMarkdown() (string, error) {
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 
 	// Unlinked References Report
-	unlinkedRefs, _ := a.GetUnlinkedReferences()
+	unlinkedRefs, err := a.GetUnlinkedReferences()
+	diag.Add("export-markdown", 0, "unlinked-references", err)
 	markdown.WriteString(fmt.Sprintf("## Unlinked References (%d)\n\n", len(unlinkedRefs)))
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	if len(unlinkedRefs) > 0 {
@@ -354,7 +416,8 @@ func (a *App) ExportToMarkdown() (string, error) {
 	}
 
 	// Duplicate Items Report
-	duplicates, _ := a.GetDuplicateItems()
+	duplicates, err := a.GetDuplicateItems()
+	diag.Add("export-markdown", 0, "duplicate-items", err)
 	markdown.WriteString(fmt.Sprintf("## Duplicate Items (%d)\n\n", len(duplicates)))
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	if len(duplicates) > 0 {
@@ -370,7 +433,8 @@ func (a *App) ExportToMarkdown() (string, error) {
 	}
 
 	// Orphaned Items Report
-	orphanedItems, _ := a.GetOrphanedItems()
+	orphanedItems, err := a.GetOrphanedItems()
+	diag.Add("export-markdown", 0, "orphaned-items", err)
 	markdown.WriteString(fmt.Sprintf("## Orphaned Items (%d)\n\n", len(orphanedItems)))
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	if len(orphanedItems) > 0 {
@@ -386,7 +450,8 @@ func (a *App) ExportToMarkdown() (string, error) {
 	}
 
 	// Linked Items Not In Definition Report
-	linkedNotInDef, _ := a.GetLinkedItemsNotInDefinition()
+	linkedNotInDef, err := a.GetLinkedItemsNotInDefinition()
+	diag.Add("export-markdown", 0, "linked-items-not-in-definition", err)
 	markdown.WriteString(fmt.Sprintf("## Linked Items Not In Definition (%d)\n\n", len(linkedNotInDef)))
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	if len(linkedNotInDef) > 0 {
@@ -403,7 +468,8 @@ func (a *App) ExportToMarkdown() (string, error) {
 	}
 
 	// Missing Definitions Report
-	missingDefs, _ := a.GetItemsWithoutDefinitions()
+	missingDefs, err := a.GetItemsWithoutDefinitions()
+	diag.Add("export-markdown", 0, "items-without-definitions", err)
 	markdown.WriteString(fmt.Sprintf("## Items Without Definitions (%d)\n\n", len(missingDefs)))
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	if len(missingDefs) > 0 {
@@ -419,7 +485,8 @@ func (a *App) ExportToMarkdown() (string, error) {
 	}
 
 	// Unknown Types Report
-	unknownTypes, _ := a.GetItemsWithUnknownTypes()
+	unknownTypes, err := a.GetItemsWithUnknownTypes()
+	diag.Add("export-markdown", 0, "unknown-types", err)
 	markdown.WriteString(fmt.Sprintf("## Unknown Types (%d)\n\n", len(unknownTypes)))
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	if len(unknownTypes) > 0 {
@@ -435,7 +502,8 @@ func (a *App) ExportToMarkdown() (string, error) {
 	}
 
 	// Unknown Tags Report
-	unknownTags, _ := a.GetUnknownTags()
+	unknownTags, err := a.GetUnknownTags()
+	diag.Add("export-markdown", 0, "unknown-tags", err)
 	markdown.WriteString(fmt.Sprintf("## Unknown Tags (%d)\n\n", len(unknownTags)))
 	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
 	if len(unknownTags) > 0 {
@@ -451,16 +519,30 @@ func (a *App) ExportToMarkdown() (string, error) {
 		markdown.WriteString("âœ“ No unknown tags found.\n\n")
 	}
 
+	// Warnings Appendix
+	markdown.WriteString("\n\n# Warnings\n\n")
+	markdown.WriteString("[â†‘ Back to top](#top)\n\n")
+	if diag.HasErrors() {
+		markdown.WriteString("| Category | Item ID | Phase | Message |\n")
+		markdown.WriteString("|----------|---------|-------|---------|\n")
+		for _, d := range diag {
+			markdown.WriteString(fmt.Sprintf("| %s | %d | %s | %s |\n", d.Category, d.ItemID, d.Phase, d.Message))
+		}
+		markdown.WriteString("\n")
+	} else {
+		markdown.WriteString("âœ“ No warnings.\n\n")
+	}
+
 	// Create filename
 	filename := "poetry-database.md"
 	fullPath := filepath.Join(exportFolder, filename)
 
 	err = os.WriteFile(fullPath, []byte(markdown.String()), 0644)
 	if err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+		return "", diag, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return fullPath, nil
+	return fullPath, diag, nil
 }
 
 // GetSettings returns current settings
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+)
+
+// ebookChapter is one of the item sections ("References", "Writers",
+// "Titles", "Other") that both ExportToHTML and ExportToEPUB render as a
+// distinct, separately-addressable unit (an anchor range in the HTML file, a
+// spine item in the EPUB). The "Reports" section is not an ebookChapter; both
+// exporters write it separately via writeReportsToHTML.
+type ebookChapter struct {
+	ID    string
+	Title string
+	Items []database.Item
+}
+
+// anchorForItem returns the stable intra-document anchor used to link to
+// item from anywhere else in the compendium.
+func anchorForItem(itemID int) string {
+	return fmt.Sprintf("item-%d", itemID)
+}
+
+// buildEbookChapters separates items by type into the fixed chapter order
+// used by both e-book exporters, sorted alphabetically by Word within each
+// chapter.
+func buildEbookChapters(items []database.Item) []ebookChapter {
+	byType := map[string][]database.Item{}
+	for _, item := range items {
+		byType[item.Type] = append(byType[item.Type], item)
+	}
+
+	chapters := []ebookChapter{
+		{ID: "references", Title: "References", Items: byType["Reference"]},
+		{ID: "writers", Title: "Writers", Items: byType["Writer"]},
+		{ID: "titles", Title: "Titles", Items: byType["Title"]},
+		{ID: "other", Title: "Other", Items: byType["Other"]},
+	}
+	for i := range chapters {
+		sort.Slice(chapters[i].Items, func(a, b int) bool {
+			return strings.ToLower(chapters[i].Items[a].Word) < strings.ToLower(chapters[i].Items[b].Word)
+		})
+	}
+	return chapters
+}
+
+// buildBacklinks maps every item ID to the items that link to it, so each
+// chapter can render a "Cited by" section under the entry.
+func buildBacklinks(items []database.Item, links []database.Link) map[int][]database.Item {
+	byID := make(map[int]database.Item, len(items))
+	for _, item := range items {
+		byID[item.ItemID] = item
+	}
+
+	backlinks := map[int][]database.Item{}
+	for _, link := range links {
+		if src, ok := byID[link.SourceItemID]; ok {
+			backlinks[link.DestinationItemID] = append(backlinks[link.DestinationItemID], src)
+		}
+	}
+	for id, citing := range backlinks {
+		sort.Slice(citing, func(i, j int) bool {
+			return strings.ToLower(citing[i].Word) < strings.ToLower(citing[j].Word)
+		})
+		backlinks[id] = citing
+	}
+	return backlinks
+}
+
+// htmlEscape is a thin wrapper so callers read "why" at call sites that
+// intentionally escape already-resolved markdown-ish text before embedding
+// it in the single-file HTML export.
+func htmlEscape(s string) string {
+	return html.EscapeString(s)
+}
+
+// readImageBase64 returns a data: URI for the item's cached PNG, or "" if no
+// image is cached for it.
+func readImageBase64(itemID int) string {
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(imagesDir, fmt.Sprintf("%d.png", itemID)))
+	if err != nil {
+		return ""
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// writeItemToHTML renders a single item as a <section>, including its
+// image (inlined as base64), resolved tag text, and a "Cited by" backlink
+// list built from the links table.
+func writeItemToHTML(item database.Item, out *strings.Builder, backlinks map[int][]database.Item) {
+	fmt.Fprintf(out, "<section id=\"%s\" class=\"entry\">\n", anchorForItem(item.ItemID))
+	fmt.Fprintf(out, "<h3>%s</h3>\n", htmlEscape(item.Word))
+	fmt.Fprintf(out, "<p class=\"type\">%s</p>\n", htmlEscape(item.Type))
+
+	if item.HasImage == 1 {
+		if uri := readImageBase64(item.ItemID); uri != "" {
+			fmt.Fprintf(out, "<img src=\"%s\" alt=\"%s\">\n", uri, htmlEscape(item.Word))
+		}
+	}
+
+	if def := item.Definition.GetOrEmpty(); def != "" {
+		fmt.Fprintf(out, "<h4>Definition</h4>\n<p>%s</p>\n", resolveTagsForMarkdown(def))
+	}
+	if der := item.Derivation.GetOrEmpty(); der != "" {
+		fmt.Fprintf(out, "<h4>Etymology</h4>\n<p>%s</p>\n", resolveTagsForMarkdown(der))
+	}
+	if app := item.Appendicies.GetOrEmpty(); app != "" {
+		fmt.Fprintf(out, "<h4>Notes</h4>\n<p>%s</p>\n", resolveTagsForMarkdown(app))
+	}
+
+	if citing := backlinks[item.ItemID]; len(citing) > 0 {
+		out.WriteString("<h4>Cited by</h4>\n<ul class=\"cited-by\">\n")
+		for _, c := range citing {
+			fmt.Fprintf(out, "<li><a href=\"#%s\">%s</a></li>\n", anchorForItem(c.ItemID), htmlEscape(c.Word))
+		}
+		out.WriteString("</ul>\n")
+	}
+
+	out.WriteString("</section>\n<hr>\n")
+}
+
+const ebookCSS = `
+body { margin: 0; display: flex; font-family: Georgia, serif; color: #222; }
+nav#toc { position: sticky; top: 0; align-self: flex-start; height: 100vh; overflow-y: auto;
+  width: 260px; flex: 0 0 260px; background: #f4f1ea; padding: 1rem; box-sizing: border-box; border-right: 1px solid #ccc; }
+nav#toc h2 { font-size: 1rem; text-transform: uppercase; letter-spacing: .05em; }
+nav#toc ul { list-style: none; padding-left: .75rem; margin: 0 0 1rem; }
+nav#toc a { text-decoration: none; color: #333; }
+main { flex: 1 1 auto; max-width: 52rem; padding: 2rem; }
+.entry img { max-width: 100%; }
+.cited-by { font-size: .9em; color: #555; }
+`
+
+// ExportToHTML renders the full database as a single self-contained HTML
+// file: a sticky sidebar table of contents, one chapter per type plus a
+// data-quality Reports chapter, inlined base64 images, and "Cited by"
+// backlinks derived from the links table.
+func (a *App) ExportToHTML() (string, error) {
+	items, err := a.db.GetAllItems()
+	if err != nil {
+		return "", fmt.Errorf("failed to get items: %w", err)
+	}
+	links, err := a.db.GetAllLinks()
+	if err != nil {
+		return "", fmt.Errorf("failed to get links: %w", err)
+	}
+
+	exportFolder, err := resolveExportFolder(a.settings.Get().ExportFolder, "PoetryExports")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(exportFolder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	chapters := buildEbookChapters(items)
+	backlinks := buildBacklinks(items, links)
+
+	var out strings.Builder
+	out.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	out.WriteString("<title>Poetry Database</title>\n<style>")
+	out.WriteString(ebookCSS)
+	out.WriteString("</style>\n</head>\n<body>\n")
+
+	out.WriteString("<nav id=\"toc\">\n<h2>Contents</h2>\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(&out, "<h3 id=\"toc-%s\">%s</h3>\n<ul>\n", chapter.ID, htmlEscape(chapter.Title))
+		for _, item := range chapter.Items {
+			fmt.Fprintf(&out, "<li><a href=\"#%s\">%s</a></li>\n", anchorForItem(item.ItemID), htmlEscape(item.Word))
+		}
+		out.WriteString("</ul>\n")
+	}
+	out.WriteString("<h3>Reports</h3>\n<ul><li><a href=\"#reports\">Data Quality Reports</a></li></ul>\n")
+	out.WriteString("</nav>\n<main>\n")
+
+	for _, chapter := range chapters {
+		fmt.Fprintf(&out, "<h2 id=\"%s\">%s</h2>\n", chapter.ID, htmlEscape(chapter.Title))
+		for _, item := range chapter.Items {
+			writeItemToHTML(item, &out, backlinks)
+		}
+	}
+
+	out.WriteString("<h2 id=\"reports\">Data Quality Reports</h2>\n")
+	a.writeReportsToHTML(&out)
+
+	out.WriteString("</main>\n</body>\n</html>\n")
+
+	fullPath := filepath.Join(exportFolder, "poetry-database.html")
+	if err := os.WriteFile(fullPath, []byte(out.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	return fullPath, nil
+}
+
+// writeReportsToHTML renders three of the data-quality reports that
+// ExportToMarkdown produces (unlinked references, duplicate items and
+// orphaned items) as an HTML fragment, each as a bulleted list. The other
+// Markdown reports are not included.
+func (a *App) writeReportsToHTML(out *strings.Builder) {
+	unlinkedRefs, _ := a.GetUnlinkedReferences()
+	fmt.Fprintf(out, "<h3>Unlinked References (%d)</h3>\n", len(unlinkedRefs))
+	if len(unlinkedRefs) > 0 {
+		out.WriteString("<ul>\n")
+		for _, item := range unlinkedRefs {
+			fmt.Fprintf(out, "<li>%s (%s) &mdash; %v unlinked</li>\n",
+				htmlEscape(fmt.Sprint(item["word"])), htmlEscape(fmt.Sprint(item["type"])), item["refCount"])
+		}
+		out.WriteString("</ul>\n")
+	} else {
+		out.WriteString("<p>No unlinked references found.</p>\n")
+	}
+
+	duplicates, _ := a.GetDuplicateItems()
+	fmt.Fprintf(out, "<h3>Duplicate Items (%d)</h3>\n", len(duplicates))
+	if len(duplicates) > 0 {
+		out.WriteString("<ul>\n")
+		for _, item := range duplicates {
+			fmt.Fprintf(out, "<li>%s &mdash; %v</li>\n", htmlEscape(fmt.Sprint(item["strippedWord"])), item["count"])
+		}
+		out.WriteString("</ul>\n")
+	} else {
+		out.WriteString("<p>No duplicate items found.</p>\n")
+	}
+
+	orphanedItems, _ := a.GetOrphanedItems()
+	fmt.Fprintf(out, "<h3>Orphaned Items (%d)</h3>\n", len(orphanedItems))
+	if len(orphanedItems) > 0 {
+		out.WriteString("<ul>\n")
+		for _, item := range orphanedItems {
+			fmt.Fprintf(out, "<li>%s (%s)</li>\n", htmlEscape(fmt.Sprint(item["word"])), htmlEscape(fmt.Sprint(item["type"])))
+		}
+		out.WriteString("</ul>\n")
+	} else {
+		out.WriteString("<p>No orphaned items found.</p>\n")
+	}
+}
+
+// resolveExportFolder returns configured, or a default under the user's
+// Documents folder named defaultSubdir when configured is empty.
+func resolveExportFolder(configured, defaultSubdir string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, "Documents", defaultSubdir), nil
+}
+
+// --- EPUB ---
+
+// epubFile is one entry written verbatim into the EPUB zip container.
+type epubFile struct {
+	name string
+	data []byte
+}
+
+// ExportToEPUB packages the database as a valid EPUB3: References, Writers,
+// Titles, Other, and Reports as separate XHTML spine items, a toc.ncx and
+// nav.xhtml for navigation, and copied images/*.png declared in the OPF
+// manifest with their media types.
+func (a *App) ExportToEPUB() (string, error) {
+	items, err := a.db.GetAllItems()
+	if err != nil {
+		return "", fmt.Errorf("failed to get items: %w", err)
+	}
+	links, err := a.db.GetAllLinks()
+	if err != nil {
+		return "", fmt.Errorf("failed to get links: %w", err)
+	}
+
+	exportFolder, err := resolveExportFolder(a.settings.Get().ExportFolder, "PoetryExports")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(exportFolder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	chapters := buildEbookChapters(items)
+	backlinks := buildBacklinks(items, links)
+
+	var manifestItems []string
+	var spineItems []string
+	var navPoints []string
+	var files []epubFile
+
+	imagesDir, _ := paths.ImagesDir()
+	order := 1
+
+	addChapter := func(chapter ebookChapter) {
+		var body strings.Builder
+		fmt.Fprintf(&body, "<h1>%s</h1>\n", htmlEscape(chapter.Title))
+		for _, item := range chapter.Items {
+			writeItemToHTML(item, &body, backlinks)
+			if item.HasImage == 1 {
+				srcPath := filepath.Join(imagesDir, fmt.Sprintf("%d.png", item.ItemID))
+				if data, err := os.ReadFile(srcPath); err == nil {
+					imgName := fmt.Sprintf("%d.png", item.ItemID)
+					files = append(files, epubFile{name: "OEBPS/images/" + imgName, data: data})
+					manifestItems = append(manifestItems, fmt.Sprintf(
+						`<item id="img-%d" href="images/%s" media-type="image/png"/>`, item.ItemID, imgName))
+				}
+			}
+		}
+
+		xhtml := epubXHTMLPage(chapter.Title, body.String())
+		files = append(files, epubFile{name: "OEBPS/" + chapter.ID + ".xhtml", data: []byte(xhtml)})
+		manifestItems = append(manifestItems, fmt.Sprintf(
+			`<item id="%s" href="%s.xhtml" media-type="application/xhtml+xml"/>`, chapter.ID, chapter.ID))
+		spineItems = append(spineItems, fmt.Sprintf(`<itemref idref="%s"/>`, chapter.ID))
+		navPoints = append(navPoints, fmt.Sprintf(
+			`<navPoint id="navpoint-%d" playOrder="%d"><navLabel><text>%s</text></navLabel><content src="%s.xhtml"/></navPoint>`,
+			order, order, htmlEscape(chapter.Title), chapter.ID))
+		order++
+	}
+
+	for _, chapter := range chapters {
+		addChapter(chapter)
+	}
+
+	var reportsBody strings.Builder
+	reportsBody.WriteString("<h1>Data Quality Reports</h1>\n")
+	a.writeReportsToHTML(&reportsBody)
+	files = append(files, epubFile{name: "OEBPS/reports.xhtml", data: []byte(epubXHTMLPage("Reports", reportsBody.String()))})
+	manifestItems = append(manifestItems, `<item id="reports" href="reports.xhtml" media-type="application/xhtml+xml"/>`)
+	spineItems = append(spineItems, `<itemref idref="reports"/>`)
+	navPoints = append(navPoints, fmt.Sprintf(
+		`<navPoint id="navpoint-%d" playOrder="%d"><navLabel><text>Reports</text></navLabel><content src="reports.xhtml"/></navPoint>`,
+		order, order))
+
+	navXHTML := epubNavXHTML(chapters)
+	files = append(files, epubFile{name: "OEBPS/nav.xhtml", data: []byte(navXHTML)})
+	manifestItems = append(manifestItems, `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`)
+
+	tocNCX := epubTocNCX(navPoints)
+	files = append(files, epubFile{name: "OEBPS/toc.ncx", data: []byte(tocNCX)})
+	manifestItems = append(manifestItems, `<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>`)
+
+	opf := epubContentOPF(manifestItems, spineItems)
+	files = append(files, epubFile{name: "OEBPS/content.opf", data: []byte(opf)})
+	files = append(files, epubFile{name: "META-INF/container.xml", data: []byte(epubContainerXML)})
+
+	fullPath := filepath.Join(exportFolder, "poetry-database.epub")
+	if err := writeEpubZip(fullPath, files); err != nil {
+		return "", fmt.Errorf("failed to write epub: %w", err)
+	}
+	return fullPath, nil
+}
+
+const epubContainerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+func epubXHTMLPage(title, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><meta charset="utf-8"/><title>%s</title></head>
+<body>
+%s
+</body>
+</html>
+`, htmlEscape(title), body)
+}
+
+func epubNavXHTML(chapters []ebookChapter) string {
+	var links strings.Builder
+	for _, chapter := range chapters {
+		fmt.Fprintf(&links, `<li><a href="%s.xhtml">%s</a></li>`+"\n", chapter.ID, htmlEscape(chapter.Title))
+	}
+	links.WriteString(`<li><a href="reports.xhtml">Reports</a></li>` + "\n")
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><meta charset="utf-8"/><title>Table of Contents</title></head>
+<body>
+<nav epub:type="toc" id="toc"><h1>Table of Contents</h1><ol>
+%s</ol></nav>
+</body>
+</html>
+`, links.String())
+}
+
+func epubTocNCX(navPoints []string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="poetry-database"/>
+  </head>
+  <docTitle><text>Poetry Database</text></docTitle>
+  <navMap>
+    %s
+  </navMap>
+</ncx>
+`, strings.Join(navPoints, "\n    "))
+}
+
+func epubContentOPF(manifestItems, spineItems []string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="bookid">poetry-database</dc:identifier>
+    <dc:title>Poetry Database</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    %s
+  </manifest>
+  <spine toc="ncx">
+    %s
+  </spine>
+</package>
+`, strings.Join(manifestItems, "\n    "), strings.Join(spineItems, "\n    "))
+}
+
+// writeEpubZip writes files into a valid EPUB3 zip, with "mimetype" stored
+// uncompressed and first as required by the spec.
+func writeEpubZip(path string, files []epubFile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	zw := zip.NewWriter(f)
+	defer func() { _ = zw.Close() }()
+
+	mimeWriter, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := mimeWriter.Write([]byte("application/epub+zip")); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(file.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
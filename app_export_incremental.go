@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/export"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/report"
+)
+
+// ExportIncrementalStats reports what ExportIncremental actually did, so
+// callers can see the delta-export win instead of just a file path.
+type ExportIncrementalStats struct {
+	ItemsRendered int `json:"itemsRendered"`
+	ItemsSkipped  int `json:"itemsSkipped"`
+	ImagesCopied  int `json:"imagesCopied"`
+}
+
+// ExportIncremental renders the full-database Markdown dump like
+// ExportToMarkdown, but only re-renders items whose content digest changed
+// since the last run (recorded in a ManifestFilename manifest inside
+// exportFolder) and only re-copies images whose source file is newer than
+// what was recorded. Unchanged items are spliced back in from the manifest's
+// cached rendered Markdown, so large databases that haven't changed much
+// export in near-constant time.
+func (a *App) ExportIncremental() (ExportIncrementalStats, report.Diagnostics, error) {
+	var stats ExportIncrementalStats
+	var diag report.Diagnostics
+
+	items, err := a.db.GetAllItems()
+	if err != nil {
+		return stats, diag, fmt.Errorf("failed to get items: %w", err)
+	}
+
+	exportFolder, err := resolveExportFolder(a.settings.Get().ExportFolder, "PoetryExports")
+	if err != nil {
+		return stats, diag, err
+	}
+	if err := os.MkdirAll(exportFolder, 0755); err != nil {
+		return stats, diag, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	manifestPath := filepath.Join(exportFolder, export.ManifestFilename)
+	manifest, err := export.LoadManifest(manifestPath)
+	if err != nil {
+		return stats, diag, err
+	}
+
+	// Separate items by type and sort, matching ExportToMarkdown's layout.
+	byType := map[string][]database.Item{}
+	for _, item := range items {
+		byType[item.Type] = append(byType[item.Type], item)
+	}
+	sections := []struct {
+		title string
+		items []database.Item
+	}{
+		{"References", byType["Reference"]},
+		{"Writers", byType["Writer"]},
+		{"Titles", byType["Title"]},
+		{"Other", byType["Other"]},
+	}
+	for s := range sections {
+		sort.Slice(sections[s].items, func(i, j int) bool {
+			return strings.ToLower(sections[s].items[i].Word) < strings.ToLower(sections[s].items[j].Word)
+		})
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString("<a name=\"top\"></a>\n\n# Poetry Database Export (Incremental)\n\n")
+
+	seen := make(map[int]bool, len(items))
+	for _, section := range sections {
+		fmt.Fprintf(&markdown, "\n# %s\n\n", section.title)
+		for _, item := range section.items {
+			seen[item.ItemID] = true
+
+			imagePath, imageModTime, copied, err := a.syncIncrementalImage(item, exportFolder, manifest)
+			if err != nil {
+				diag.Add("export-incremental", item.ItemID, "copy-image", err)
+			}
+			if copied {
+				stats.ImagesCopied++
+			}
+
+			digest := export.ItemDigest(item)
+			if prior, ok := manifest.Items[item.ItemID]; ok && prior.Digest == digest && !copied {
+				markdown.WriteString(prior.Rendered)
+				stats.ItemsSkipped++
+				continue
+			}
+
+			ctx := export.ItemContext{
+				ItemID:      item.ItemID,
+				Word:        item.Word,
+				Type:        item.Type,
+				Definition:  item.Definition.GetOrEmpty(),
+				Derivation:  item.Derivation.GetOrEmpty(),
+				Appendicies: item.Appendicies.GetOrEmpty(),
+				HasImage:    imagePath != "",
+				ImagePath:   imagePath,
+			}
+			if item.Source != nil {
+				ctx.Source = *item.Source
+			}
+			if item.SourcePg != nil {
+				ctx.SourcePg = *item.SourcePg
+			}
+
+			rendered, err := a.dumpRenderer.RenderItem(ctx)
+			if err != nil {
+				diag.Add("export-incremental", item.ItemID, "render-item", err)
+				continue
+			}
+
+			markdown.WriteString(rendered)
+			manifest.Items[item.ItemID] = export.ManifestEntry{
+				Digest:       digest,
+				ImageModTime: imageModTime,
+				Rendered:     rendered,
+			}
+			stats.ItemsRendered++
+		}
+	}
+
+	// Drop manifest entries for items that no longer exist.
+	for id := range manifest.Items {
+		if !seen[id] {
+			delete(manifest.Items, id)
+		}
+	}
+
+	fullPath := filepath.Join(exportFolder, "poetry-database-incremental.md")
+	if err := os.WriteFile(fullPath, []byte(markdown.String()), 0644); err != nil {
+		return stats, diag, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := manifest.Save(manifestPath); err != nil {
+		diag.Add("export-incremental", 0, "save-manifest", err)
+	}
+
+	return stats, diag, nil
+}
+
+// syncIncrementalImage copies item's cached image into exportFolder only if
+// it hasn't been copied before or the source file is newer than the mtime
+// recorded in the manifest. It returns the relative image path (if any), the
+// source mtime used for the manifest, and whether a copy actually happened.
+func (a *App) syncIncrementalImage(item database.Item, exportFolder string, manifest *export.Manifest) (string, time.Time, bool, error) {
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+
+	srcPath := filepath.Join(imagesDir, fmt.Sprintf("%d.png", item.ItemID))
+	info, err := os.Stat(srcPath)
+	if os.IsNotExist(err) {
+		return "", time.Time{}, false, nil
+	}
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+
+	relPath := fmt.Sprintf("images/%d.png", item.ItemID)
+	prior, known := manifest.Items[item.ItemID]
+	if known && !info.ModTime().After(prior.ImageModTime) {
+		return relPath, prior.ImageModTime, false, nil
+	}
+
+	exportImagesDir := filepath.Join(exportFolder, "images")
+	if err := os.MkdirAll(exportImagesDir, 0755); err != nil {
+		return "", time.Time{}, false, fmt.Errorf("failed to create export images directory: %w", err)
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", time.Time{}, false, fmt.Errorf("failed to read image: %w", err)
+	}
+	destPath := filepath.Join(exportImagesDir, fmt.Sprintf("%d.png", item.ItemID))
+	if err := os.WriteFile(destPath, data, 0644); err != nil {
+		return "", time.Time{}, false, fmt.Errorf("failed to write image: %w", err)
+	}
+
+	return relPath, info.ModTime(), true, nil
+}
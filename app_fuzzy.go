@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/fuzzy"
+)
+
+// FuzzyResult represents a single fuzzy-matched candidate
+type FuzzyResult struct {
+	ItemID         int    `json:"itemId"`
+	Word           string `json:"word"`
+	Type           string `json:"type"`
+	Score          int    `json:"score"`
+	MatchPositions []int  `json:"matchPositions"`
+}
+
+// fuzzyCandidate is the internal pairing of a searchable string with the
+// identity fields that end up in a FuzzyResult.
+type fuzzyCandidate struct {
+	itemID int
+	word   string
+	typ    string
+}
+
+// FuzzySearchItems runs an in-process fuzzy match over item words and
+// returns the top `limit` results ordered by descending score.
+func (a *App) FuzzySearchItems(query string, limit int) ([]FuzzyResult, error) {
+	items, err := a.db.GetAllItems()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+
+	candidates := make([]fuzzyCandidate, 0, len(items))
+	for _, item := range items {
+		candidates = append(candidates, fuzzyCandidate{itemID: item.ItemID, word: item.Word, typ: item.Type})
+	}
+
+	return fuzzyRank(query, candidates, limit), nil
+}
+
+// FuzzySearchAll runs an in-process fuzzy match over the given scope and
+// returns every matching candidate ordered by descending score. Scope is one
+// of "items", "cliches", "names", "terms", "sources", or "" for all of them.
+func (a *App) FuzzySearchAll(query string, scope string) ([]FuzzyResult, error) {
+	var candidates []fuzzyCandidate
+
+	addItems := scope == "" || scope == "items"
+	addCliches := scope == "" || scope == "cliches"
+	addNames := scope == "" || scope == "names"
+	addTerms := scope == "" || scope == "terms"
+	addSources := scope == "" || scope == "sources"
+
+	if addItems {
+		items, err := a.db.GetAllItems()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get items: %w", err)
+		}
+		for _, item := range items {
+			candidates = append(candidates, fuzzyCandidate{itemID: item.ItemID, word: item.Word, typ: item.Type})
+		}
+	}
+
+	if addCliches {
+		cliches, err := a.db.GetAllCliches()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cliches: %w", err)
+		}
+		for _, c := range cliches {
+			candidates = append(candidates, fuzzyCandidate{itemID: c.ClicheID, word: c.Phrase, typ: "Cliche"})
+		}
+	}
+
+	if addNames {
+		names, err := a.db.GetAllNames()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get names: %w", err)
+		}
+		for _, n := range names {
+			candidates = append(candidates, fuzzyCandidate{itemID: n.NameID, word: n.Name, typ: "Name"})
+		}
+	}
+
+	if addTerms {
+		terms, err := a.db.GetAllLiteraryTerms()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get literary terms: %w", err)
+		}
+		for _, t := range terms {
+			candidates = append(candidates, fuzzyCandidate{itemID: t.TermID, word: t.Term, typ: "LiteraryTerm"})
+		}
+	}
+
+	if addSources {
+		sources, err := a.db.GetAllSources()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sources: %w", err)
+		}
+		for _, s := range sources {
+			candidates = append(candidates, fuzzyCandidate{itemID: s.SourceID, word: s.Title, typ: "Source"})
+		}
+	}
+
+	return fuzzyRank(query, candidates, 0), nil
+}
+
+// fuzzyRank scores every candidate against query, drops non-matches, and
+// returns the survivors sorted by descending score, then shorter word length,
+// then alphabetically. If limit is greater than zero, only the top `limit`
+// results are returned.
+func fuzzyRank(query string, candidates []fuzzyCandidate, limit int) []FuzzyResult {
+	results := make([]FuzzyResult, 0, len(candidates))
+	for _, c := range candidates {
+		score, positions, ok := fuzzy.Match(query, c.word)
+		if !ok {
+			continue
+		}
+		results = append(results, FuzzyResult{
+			ItemID:         c.itemID,
+			Word:           c.word,
+			Type:           c.typ,
+			Score:          score,
+			MatchPositions: positions,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if len(results[i].Word) != len(results[j].Word) {
+			return len(results[i].Word) < len(results[j].Word)
+		}
+		return strings.ToLower(results[i].Word) < strings.ToLower(results[j].Word)
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
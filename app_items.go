@@ -4,6 +4,7 @@ import (
 	"log/slog"
 
 	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/services"
 )
 
 func (a *App) SearchItems(query string) ([]database.Item, error) {
@@ -15,6 +16,20 @@ func (a *App) SearchItemsWithOptions(options database.SearchOptions) ([]database
 	return a.itemService.SearchItemsWithOptions(options)
 }
 
+// ListItems returns one cursor-paginated page of SearchItemsWithOptions,
+// with S3-style Prefix/Delimiter support for directory-style navigation of
+// large corpora without transferring the whole result set.
+func (a *App) ListItems(options database.SearchOptions) (services.ListItemsResult, error) {
+	return a.itemService.ListItems(options)
+}
+
+// GetItemsByTag returns every item indexed with the given hashtag, category,
+// or frontmatter tag. kind restricts the match to one flavor; pass "" to
+// match any.
+func (a *App) GetItemsByTag(tag string, kind string) ([]database.Item, error) {
+	return a.itemService.GetItemsByTag(tag, kind)
+}
+
 // GetItem retrieves a single item by ID
 func (a *App) GetItem(itemID int) (*database.Item, error) {
 	return a.itemService.GetItem(itemID)
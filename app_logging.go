@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/logger"
+)
+
+// SetLogLevel sets the minimum log level for a subsystem ("db", "parser",
+// "settings", "tts", "images", "wails", or "app" for everything else not in
+// one of those buckets). level is one of "debug", "info", "warn", "error".
+func (a *App) SetLogLevel(subsystem string, level string) error {
+	parsed, err := logger.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(subsystem, parsed)
+	return nil
+}
+
+// GetLogTail returns the most recent n log entries, optionally filtered to a
+// single subsystem ("" returns entries from every subsystem).
+func (a *App) GetLogTail(subsystem string, n int) ([]logger.LogEntry, error) {
+	entries, err := logger.TailLog(subsystem, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log tail: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/services"
+)
+
+// maintenanceScans tracks the in-flight maintenance scans started by the
+// *Scan Wails bindings below, keyed by the scanID the frontend generates,
+// so CancelMaintenanceScan can stop one mid-flight.
+var (
+	maintenanceScansMu sync.Mutex
+	maintenanceScans   = make(map[string]context.CancelFunc)
+)
+
+// maintenanceProgressEvent is the Wails event maintenance scans emit
+// progress on; the frontend keys off ScanID to route updates to the right
+// progress bar.
+const maintenanceProgressEvent = "maintenance:progress"
+
+type maintenanceProgress struct {
+	ScanID  string `json:"scanId"`
+	Current int    `json:"current"`
+	Total   int    `json:"total"`
+	Phase   string `json:"phase"`
+}
+
+// beginMaintenanceScan registers scanID against a cancellable child of
+// a.ctx and returns that context plus a ProgressReporter that forwards
+// updates to the frontend as maintenance:progress events. The caller must
+// defer endMaintenanceScan(scanID).
+func (a *App) beginMaintenanceScan(scanID string) (context.Context, services.ProgressReporterFunc) {
+	ctx, cancel := context.WithCancel(a.ctx)
+
+	maintenanceScansMu.Lock()
+	maintenanceScans[scanID] = cancel
+	maintenanceScansMu.Unlock()
+
+	progress := services.ProgressReporterFunc(func(current, total int, phase string) {
+		runtime.EventsEmit(a.ctx, maintenanceProgressEvent, maintenanceProgress{
+			ScanID:  scanID,
+			Current: current,
+			Total:   total,
+			Phase:   phase,
+		})
+	})
+
+	return ctx, progress
+}
+
+func endMaintenanceScan(scanID string) {
+	maintenanceScansMu.Lock()
+	delete(maintenanceScans, scanID)
+	maintenanceScansMu.Unlock()
+}
+
+// CancelMaintenanceScan cancels the in-flight maintenance scan identified by
+// scanID (one started by GetItemsWithoutDefinitionsScan,
+// GetItemsWithUnknownTypesScan, GetUnknownTagsScan, or
+// MergeDuplicateItemsScan). It's a no-op if the scan has already finished or
+// never existed.
+func (a *App) CancelMaintenanceScan(scanID string) {
+	maintenanceScansMu.Lock()
+	cancel, ok := maintenanceScans[scanID]
+	maintenanceScansMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// GetItemsWithoutDefinitionsScan is the cancellable, progress-reporting
+// counterpart to GetItemsWithoutDefinitions. scanID is chosen by the caller
+// so it can be passed to CancelMaintenanceScan while this call is pending.
+func (a *App) GetItemsWithoutDefinitionsScan(scanID string) ([]services.ItemWithoutDefinitionResult, error) {
+	ctx, progress := a.beginMaintenanceScan(scanID)
+	defer endMaintenanceScan(scanID)
+	return a.itemService.GetItemsWithoutDefinitions(ctx, progress)
+}
+
+// GetItemsWithUnknownTypesScan is the cancellable, progress-reporting
+// counterpart to GetItemsWithUnknownTypes. scanID is chosen by the caller so
+// it can be passed to CancelMaintenanceScan while this call is pending.
+func (a *App) GetItemsWithUnknownTypesScan(scanID string) ([]services.ItemWithUnknownTypeResult, error) {
+	ctx, progress := a.beginMaintenanceScan(scanID)
+	defer endMaintenanceScan(scanID)
+	return a.itemService.GetItemsWithUnknownTypes(ctx, progress)
+}
+
+// GetUnknownTagsScan is the cancellable, progress-reporting counterpart to
+// GetUnknownTags. scanID is chosen by the caller so it can be passed to
+// CancelMaintenanceScan while this call is pending.
+func (a *App) GetUnknownTagsScan(scanID string) ([]services.UnknownTagResult, error) {
+	ctx, progress := a.beginMaintenanceScan(scanID)
+	defer endMaintenanceScan(scanID)
+	return a.itemService.GetUnknownTags(ctx, progress)
+}
+
+// MergeDuplicateItemsScan is the cancellable, progress-reporting counterpart
+// to MergeDuplicateItems. scanID is chosen by the caller so it can be passed
+// to CancelMaintenanceScan while this call is pending.
+func (a *App) MergeDuplicateItemsScan(scanID string, originalID int, duplicateIDs []int) error {
+	ctx, progress := a.beginMaintenanceScan(scanID)
+	defer endMaintenanceScan(scanID)
+	return a.itemService.MergeDuplicateItems(ctx, progress, originalID, duplicateIDs)
+}
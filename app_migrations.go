@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/migrations"
+)
+
+// migrationProgressEvent is the Wails event RunPendingMigrations emits
+// progress on, so the UI can draw a progress bar the way long-running
+// dump/restore tools do.
+const migrationProgressEvent = "migrations:progress"
+
+type migrationProgress struct {
+	Version    int     `json:"version"`
+	Name       string  `json:"name"`
+	Current    int     `json:"current"`
+	Total      int     `json:"total"`
+	ETASeconds float64 `json:"etaSeconds"`
+}
+
+// RunPendingMigrations applies every migration in migrations.DefaultRegistry
+// that schema_migrations doesn't yet record as applied, emitting
+// migrations:progress events as it goes. It returns the versions it applied.
+func (a *App) RunPendingMigrations(ctx context.Context) ([]int, error) {
+	runner := migrations.NewRunner(a.db, migrations.DefaultRegistry())
+
+	var currentVersion = -1
+	var currentStart time.Time
+	report := func(version int, name string, done, total int) {
+		if version != currentVersion {
+			currentVersion = version
+			currentStart = time.Now()
+		}
+		runtime.EventsEmit(a.ctx, migrationProgressEvent, migrationProgress{
+			Version:    version,
+			Name:       name,
+			Current:    done,
+			Total:      total,
+			ETASeconds: estimateMigrationETA(currentStart, done, total),
+		})
+	}
+
+	return runner.RunPending(ctx, report)
+}
+
+// estimateMigrationETA extrapolates the remaining time for a migration from
+// the rate it's made progress at since start, returning 0 until there's
+// enough progress to extrapolate from.
+func estimateMigrationETA(start time.Time, done, total int) float64 {
+	if done <= 0 || total <= 0 || done >= total {
+		return 0
+	}
+	elapsed := time.Since(start).Seconds()
+	perItem := elapsed / float64(done)
+	return perItem * float64(total-done)
+}
+
+// DryRunPendingMigrations reports how many rows each pending migration would
+// touch, without applying any of them.
+func (a *App) DryRunPendingMigrations(ctx context.Context) ([]migrations.DryRunResult, error) {
+	runner := migrations.NewRunner(a.db, migrations.DefaultRegistry())
+	return runner.DryRunPending(ctx)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/seeding"
+)
+
+// ListInstalledPacks returns every content pack currently installed,
+// including the always-present builtin pack (see seeding.PackManager.List).
+func (a *App) ListInstalledPacks() ([]seeding.InstalledPack, error) {
+	if a.packManager == nil {
+		return nil, fmt.Errorf("pack manager not initialized")
+	}
+	return a.packManager.List(), nil
+}
+
+// InstallPack registers sourceURL (a directory, tar.gz, or http(s) URL to
+// one) as a content pack's source, installs it and everything it Requires,
+// and applies its SQL fragment, so its items show up immediately without
+// restarting the app.
+func (a *App) InstallPack(sourceURL string) error {
+	if a.packManager == nil {
+		return fmt.Errorf("pack manager not initialized")
+	}
+	name, err := a.packManager.AddSource(sourceURL)
+	if err != nil {
+		return err
+	}
+	if err := a.packManager.Install(name, ""); err != nil {
+		return err
+	}
+	return seeding.ApplyPackData(a.db, a.packManager)
+}
+
+// RemovePack removes name from packs.lock and deletes every item its SQL
+// fragment inserted.
+func (a *App) RemovePack(name string) error {
+	if a.packManager == nil {
+		return fmt.Errorf("pack manager not initialized")
+	}
+	if err := a.packManager.Remove(name); err != nil {
+		return err
+	}
+	_, err := seeding.RemovePackData(a.db, name)
+	return err
+}
@@ -1,100 +1,107 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/services"
 	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/validator"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
-func (a *App) GetUnlinkedReferences() ([]map[string]interface{}, error) {
-	// Get all items
-	allItems, err := a.db.SearchItems("") // Empty search returns all
+// loadValidationIssues fetches every item and link, runs
+// validator.ValidateItemConsistency over each item, and returns the item-by-
+// ID index alongside the combined issue list so callers can reshape a
+// filtered subset of issues into a report-specific structure without
+// re-running the engine.
+func (a *App) loadValidationIssues() (map[int]database.Item, []validator.ValidationIssue, error) {
+	items, err := a.db.GetAllItems()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get items: %w", err)
+		return nil, nil, fmt.Errorf("failed to get items: %w", err)
 	}
-
-	// Get all links
-	allLinks, err := a.db.GetAllLinks()
+	links, err := a.db.GetAllLinks()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get links: %w", err)
+		return nil, nil, fmt.Errorf("failed to get links: %w", err)
 	}
 
-	// Create a map of item words for quick lookup
-	itemsByWord := make(map[string]*database.Item)
-	for i := range allItems {
-		itemsByWord[strings.ToLower(allItems[i].Word)] = &allItems[i]
+	itemsByID := make(map[int]database.Item, len(items))
+	for _, item := range items {
+		itemsByID[item.ItemID] = item
 	}
 
-	// Create a map of links for quick lookup
-	linksMap := make(map[int]map[int]bool) // sourceId -> map[destId]bool
-	for _, link := range allLinks {
-		if linksMap[link.SourceItemID] == nil {
-			linksMap[link.SourceItemID] = make(map[int]bool)
+	linksByItem := make(map[int][]database.Link)
+	for _, link := range links {
+		linksByItem[link.SourceItemID] = append(linksByItem[link.SourceItemID], link)
+		if link.DestinationItemID != link.SourceItemID {
+			linksByItem[link.DestinationItemID] = append(linksByItem[link.DestinationItemID], link)
 		}
-		linksMap[link.SourceItemID][link.DestinationItemID] = true
 	}
 
-	// Analyze each item for unlinked references
-	var results []map[string]interface{}
+	var issues []validator.ValidationIssue
+	for _, item := range items {
+		issues = append(issues, validator.ValidateItemConsistency(item, linksByItem[item.ItemID], itemsByID)...)
+	}
+	return itemsByID, issues, nil
+}
 
-	for i := range allItems {
-		item := &allItems[i]
-		if item.Definition == nil || *item.Definition == "" {
-			continue
-		}
+// GetValidationIssues runs validator.ValidateItemConsistency over every item
+// in the database and returns the combined issue list. It's the single
+// engine behind the tag/link/poem/source checks that GetUnlinkedReferences,
+// GetLinkedItemsNotInDefinition, and GetUnknownTags reshape into their own
+// report-specific structures below.
+func (a *App) GetValidationIssues() ([]validator.ValidationIssue, error) {
+	_, issues, err := a.loadValidationIssues()
+	return issues, err
+}
 
-		// Find all {word:}, {writer:}, {title:} references in definition
-		unlinkedRefs := []map[string]string{}
-
-		// Use centralized parser
-		refs := parser.ParseReferences(*item.Definition)
-		for _, ref := range refs {
-			refType := ref.Type
-			refWord := ref.Value
-
-			// Strip possessive 's or s' from writer references
-			matchWord := refWord
-			if refType == "writer" {
-				lowerWord := strings.ToLower(refWord)
-				if strings.HasSuffix(lowerWord, "'s") {
-					matchWord = refWord[:len(refWord)-2]
-				} else if strings.HasSuffix(lowerWord, "s'") {
-					matchWord = refWord[:len(refWord)-1]
-				}
-			}
+// GetUnlinkedReferences returns, for each item, the {word:}/{writer:}/
+// {title:} tags in its definition that don't resolve to a matching item
+// ("missing") or resolve but have no outgoing link to it ("unlinked"). It's
+// a thin reshaping of GetValidationIssues's "unresolved-tag" and
+// "unlinked-tag" issues into the shape the frontend's unlinked-refs report
+// expects.
+func (a *App) GetUnlinkedReferences() ([]map[string]interface{}, error) {
+	itemsByID, issues, err := a.loadValidationIssues()
+	if err != nil {
+		return nil, err
+	}
 
-			// Check if this reference exists in items
-			matchedItem := itemsByWord[strings.ToLower(matchWord)]
-			if matchedItem == nil {
-				// Item doesn't exist
-				unlinkedRefs = append(unlinkedRefs, map[string]string{
-					"ref":    refWord,
-					"reason": "missing",
-				})
-			} else {
-				// Item exists, check if it's linked
-				if linksMap[item.ItemID] == nil || !linksMap[item.ItemID][matchedItem.ItemID] {
-					unlinkedRefs = append(unlinkedRefs, map[string]string{
-						"ref":    refWord,
-						"reason": "unlinked",
-					})
-				}
-			}
+	var order []int
+	unlinkedByItem := make(map[int][]map[string]string)
+	for _, issue := range issues {
+		var reason string
+		switch issue.Code {
+		case "unresolved-tag":
+			reason = "missing"
+		case "unlinked-tag":
+			reason = "unlinked"
+		default:
+			continue
 		}
-
-		if len(unlinkedRefs) > 0 {
-			results = append(results, map[string]interface{}{
-				"itemId":       item.ItemID,
-				"word":         item.Word,
-				"type":         item.Type,
-				"unlinkedRefs": unlinkedRefs,
-				"refCount":     len(unlinkedRefs),
-			})
+		if _, seen := unlinkedByItem[issue.ItemID]; !seen {
+			order = append(order, issue.ItemID)
 		}
+		unlinkedByItem[issue.ItemID] = append(unlinkedByItem[issue.ItemID], map[string]string{
+			"ref":    issue.Ref,
+			"reason": reason,
+		})
+	}
+
+	var results []map[string]interface{}
+	for _, itemID := range order {
+		item := itemsByID[itemID]
+		unlinkedRefs := unlinkedByItem[itemID]
+		results = append(results, map[string]interface{}{
+			"itemId":       itemID,
+			"word":         item.Word,
+			"type":         item.Type,
+			"unlinkedRefs": unlinkedRefs,
+			"refCount":     len(unlinkedRefs),
+		})
 	}
 
 	return results, nil
@@ -161,17 +168,30 @@ func stripPossessive(text string) string {
 
 // GetDuplicateItems returns a report of items with duplicate stripped names
 func (a *App) GetDuplicateItems() ([]map[string]interface{}, error) {
-	// Get all items
-	allItems, err := a.db.SearchItems("")
+	// Stream items: this report only ever looks at ItemID/Word, so there's no
+	// reason to load every item's definition/derivation/appendicies text.
+	it, err := a.db.NewRowIterator()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get items: %w", err)
 	}
+	defer it.Close()
 
 	// Group items by stripped word (case-insensitive)
-	groups := make(map[string][]database.Item)
-	for _, item := range allItems {
-		stripped := strings.ToLower(stripPossessive(item.Word))
-		groups[stripped] = append(groups[stripped], item)
+	type itemRef struct {
+		ItemID int
+		Word   string
+	}
+	groups := make(map[string][]itemRef)
+	for {
+		row, ok := it.Next()
+		if !ok {
+			break
+		}
+		stripped := strings.ToLower(stripPossessive(row.Word))
+		groups[stripped] = append(groups[stripped], itemRef{ItemID: row.ItemID, Word: row.Word})
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
 	}
 
 	// Find groups with more than one item
@@ -288,12 +308,6 @@ func (a *App) GetSelfReferentialItems() ([]map[string]interface{}, error) {
 
 // GetOrphanedItems returns items with no incoming or outgoing links
 func (a *App) GetOrphanedItems() ([]map[string]interface{}, error) {
-	// Get all items
-	allItems, err := a.db.SearchItems("")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get items: %w", err)
-	}
-
 	// Get all links
 	allLinks, err := a.db.GetAllLinks()
 	if err != nil {
@@ -307,120 +321,68 @@ func (a *App) GetOrphanedItems() ([]map[string]interface{}, error) {
 		connectedItems[link.DestinationItemID] = true
 	}
 
-	// Find items without any links
+	// Stream items: this report only checks ItemID/Word/Type against the
+	// links set, so it never needs the large text columns at all.
+	it, err := a.db.NewRowIterator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+	defer it.Close()
+
 	var results []map[string]interface{}
-	for _, item := range allItems {
-		if !connectedItems[item.ItemID] {
+	for {
+		row, ok := it.Next()
+		if !ok {
+			break
+		}
+		if !connectedItems[row.ItemID] {
 			results = append(results, map[string]interface{}{
-				"itemId": item.ItemID,
-				"word":   item.Word,
-				"type":   item.Type,
+				"itemId": row.ItemID,
+				"word":   row.Word,
+				"type":   row.Type,
 			})
 		}
 	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
 
 	return results, nil
 }
 
-// GetLinkedItemsNotInDefinition returns items that have links but those linked items aren't referenced in the definition
+// GetLinkedItemsNotInDefinition returns, for each item, the outgoing-linked
+// items that aren't referenced by any tag in its definition. It's a thin
+// reshaping of GetValidationIssues's "unreferenced-link" issues into the
+// shape the frontend's link-audit report expects.
 func (a *App) GetLinkedItemsNotInDefinition() ([]map[string]interface{}, error) {
-	// Single SQL query to get all items with their outgoing links efficiently
-	query := `
-		SELECT 
-			i.item_id,
-			i.word,
-			i.type,
-			COALESCE(i.definition, ''),
-			COALESCE(i.derivation, ''),
-			COALESCE(i.appendicies, ''),
-			dest.word as linked_word
-		FROM items i
-		INNER JOIN links l ON i.item_id = l.source_item_id
-		INNER JOIN items dest ON l.destination_item_id = dest.item_id
-		WHERE (i.definition IS NOT NULL AND TRIM(i.definition) != '')
-		   OR (i.derivation IS NOT NULL AND TRIM(i.derivation) != '')
-		   OR (i.appendicies IS NOT NULL AND TRIM(i.appendicies) != '')
-		ORDER BY i.item_id, dest.word
-	`
-
-	rows, err := a.db.Query(query)
+	itemsByID, issues, err := a.loadValidationIssues()
 	if err != nil {
-		return nil, fmt.Errorf("failed to query items with links: %w", err)
+		return nil, err
 	}
-	defer func() { _ = rows.Close() }()
 
-	// Group results by item
-	itemMap := make(map[int]map[string]interface{})
-	itemOrder := []int{}
-
-	for rows.Next() {
-		var itemID int
-		var word, itemType, linkedWord string
-		var definition, derivation, appendicies string
-
-		if err := rows.Scan(&itemID, &word, &itemType, &definition, &derivation, &appendicies, &linkedWord); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+	var order []int
+	missingByItem := make(map[int][]string)
+	for _, issue := range issues {
+		if issue.Code != "unreferenced-link" {
+			continue
 		}
-
-		// Initialize item if not seen before
-		if _, exists := itemMap[itemID]; !exists {
-			itemMap[itemID] = map[string]interface{}{
-				"itemId":            itemID,
-				"word":              word,
-				"type":              itemType,
-				"definition":        definition,
-				"derivation":        derivation,
-				"appendicies":       appendicies,
-				"linkedWords":       []string{},
-				"missingReferences": []string{},
-			}
-			itemOrder = append(itemOrder, itemID)
+		if _, seen := missingByItem[issue.ItemID]; !seen {
+			order = append(order, issue.ItemID)
 		}
-
-		// Add linked word to this item's list
-		itemData := itemMap[itemID]
-		itemData["linkedWords"] = append(itemData["linkedWords"].([]string), linkedWord)
+		missingByItem[issue.ItemID] = append(missingByItem[issue.ItemID], itemsByID[issue.DestItemID].Word)
 	}
 
-	// Now check each item's text fields for missing references
 	var results []map[string]interface{}
-	for _, itemID := range itemOrder {
-		itemData := itemMap[itemID]
-		// Combine all text fields and strip possessives from tags
-		combinedText := itemData["definition"].(string) + " " +
-			itemData["derivation"].(string) + " " +
-			itemData["appendicies"].(string)
-
-		// Strip possessives from text (e.g., {writer:Larry Stark's} -> {writer:larry stark})
-		allText := strings.ToLower(combinedText)
-		// Replace 's} with } (straight apostrophe)
-		allText = strings.ReplaceAll(allText, "'s}", "}")
-		// Replace 's} with } (curly apostrophe)
-		allText = strings.ReplaceAll(allText, "'s}", "}")
-		// Replace s'} with s} (straight apostrophe)
-		allText = strings.ReplaceAll(allText, "s'}", "s}")
-		// Replace s'} with s} (curly apostrophe)
-		allText = strings.ReplaceAll(allText, "s'}", "s}")
-
-		linkedWords := itemData["linkedWords"].([]string)
-		var missingReferences []string
-
-		for _, linkedWord := range linkedWords {
-			// Simply check if linkedWord + "}" appears in any text field (matches any tag type)
-			normalizedWord := strings.ToLower(stripPossessive(linkedWord))
-			if !strings.Contains(allText, normalizedWord+"}") {
-				missingReferences = append(missingReferences, linkedWord)
-			}
-		}
-
-		if len(missingReferences) > 0 {
-			results = append(results, map[string]interface{}{
-				"itemId":            itemData["itemId"],
-				"word":              itemData["word"],
-				"type":              itemData["type"],
-				"missingReferences": missingReferences,
-			})
-		}
+	for _, itemID := range order {
+		item := itemsByID[itemID]
+		missingReferences := missingByItem[itemID]
+		sort.Strings(missingReferences)
+		results = append(results, map[string]interface{}{
+			"itemId":            itemID,
+			"word":              item.Word,
+			"type":              item.Type,
+			"missingReferences": missingReferences,
+		})
 	}
 
 	return results, nil
@@ -428,20 +390,59 @@ func (a *App) GetLinkedItemsNotInDefinition() ([]map[string]interface{}, error)
 
 // GetItemsWithoutDefinitions returns items that have no definition or "MISSING DATA"
 func (a *App) GetItemsWithoutDefinitions() ([]map[string]interface{}, error) {
-	return a.itemService.GetItemsWithoutDefinitions()
+	return a.itemService.GetItemsWithoutDefinitions(context.Background(), nil)
 }
 
 // GetItemsWithUnknownTypes returns items whose type is not Writer, Title, or Reference
 func (a *App) GetItemsWithUnknownTypes() ([]map[string]interface{}, error) {
-	return a.itemService.GetItemsWithUnknownTypes()
+	return a.itemService.GetItemsWithUnknownTypes(context.Background(), nil)
 }
 
-// GetUnknownTags returns items with tags other than {word:, {writer:, or {title:
-func (a *App) GetUnknownTags() ([]map[string]interface{}, error) {
-	return a.itemService.GetUnknownTags()
+// GetUnknownTags returns items with tags other than {word:, {writer:, or
+// {title: (and the hashtag/category/frontmatter flavors ParseAllTags also
+// recognizes). It's a thin reshaping of GetValidationIssues's "unknown-tag"
+// issues into the services.UnknownTagResult shape the frontend expects.
+func (a *App) GetUnknownTags() ([]services.UnknownTagResult, error) {
+	itemsByID, issues, err := a.loadValidationIssues()
+	if err != nil {
+		return nil, err
+	}
+
+	var order []int
+	tagsByItem := make(map[int][]string)
+	for _, issue := range issues {
+		if issue.Code != "unknown-tag" {
+			continue
+		}
+		if _, seen := tagsByItem[issue.ItemID]; !seen {
+			order = append(order, issue.ItemID)
+		}
+		tagsByItem[issue.ItemID] = append(tagsByItem[issue.ItemID], issue.Ref)
+	}
+
+	var results []services.UnknownTagResult
+	for _, itemID := range order {
+		item := itemsByID[itemID]
+		unknownTags := tagsByItem[itemID]
+		results = append(results, services.UnknownTagResult{
+			ItemID:      itemID,
+			Word:        item.Word,
+			Type:        item.Type,
+			UnknownTags: unknownTags,
+			TagCount:    len(unknownTags),
+		})
+	}
+
+	return results, nil
 }
 
 // MergeDuplicateItems merges duplicate items into the original by redirecting links and deleting duplicates
 func (a *App) MergeDuplicateItems(originalID int, duplicateIDs []int) error {
-	return a.itemService.MergeDuplicateItems(originalID, duplicateIDs)
+	return a.itemService.MergeDuplicateItems(context.Background(), nil, originalID, duplicateIDs)
+}
+
+// UndoMerge reverses one duplicate merged by a previous MergeDuplicateItems
+// call, identified by the merge_journal ID it recorded.
+func (a *App) UndoMerge(journalID int) error {
+	return a.itemService.UndoMerge(journalID)
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/seeding"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+)
+
+// seedProgressEvent is the Wails event runSeeder emits progress on, so a
+// React splash screen can render a real progress bar while the data folder
+// is being seeded/repaired.
+const seedProgressEvent = "seed:progress"
+
+// runSeeder seeds/repairs dataDir in a goroutine (so Wails' own event loop
+// keeps pumping while it runs) and blocks until it's done, forwarding every
+// seeding.ProgressEvent to the frontend. ctx is the same context passed to
+// App.startup/shutdown, so a mid-seed shutdown cancels the goroutine
+// between tar entries instead of leaving a half-written file behind.
+func (a *App) runSeeder(ctx context.Context, dataDir string) error {
+	seeder, err := seeding.NewSeeder(dataDir)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- seeder.Run(ctx, func(event seeding.ProgressEvent) {
+			runtime.EventsEmit(ctx, seedProgressEvent, event)
+		})
+	}()
+	return <-done
+}
+
+// VerifySeed checks the data folder against the bundled data.tar.gz's
+// manifest, reporting any file that's missing, modified, or unrecognized
+// without changing anything, so the frontend can surface a "repair data"
+// action (which runs EnsureDataSeeded's own repair pass) instead of running
+// it unconditionally on every call.
+func (a *App) VerifySeed() (seeding.VerifyReport, error) {
+	dbPath, err := paths.DatabasePath()
+	if err != nil {
+		return seeding.VerifyReport{}, fmt.Errorf("failed to get database path: %w", err)
+	}
+	return seeding.NewVerifier(filepath.Dir(dbPath)).Verify()
+}
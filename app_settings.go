@@ -7,8 +7,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
 	"github.com/TrueBlocks/trueblocks-poetry/backend/settings"
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/cache"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -18,7 +20,7 @@ func (a *App) GetSettings() *settings.Settings {
 
 // GetDatabasePath returns the current database path
 func (a *App) GetDatabasePath() (string, error) {
-	return constants.GetDatabasePath()
+	return paths.DatabasePath()
 }
 
 // UpdateSettings updates all settings
@@ -125,7 +127,7 @@ type ImageCacheInfo struct {
 
 // GetTTSCacheInfo returns information about the TTS cache directory
 func (a *App) GetTTSCacheInfo() (*TTSCacheInfo, error) {
-	cacheDir, err := constants.GetTTSCacheDir()
+	cacheDir, err := paths.TTSCacheDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get TTS cache directory: %w", err)
 	}
@@ -162,7 +164,7 @@ func (a *App) GetTTSCacheInfo() (*TTSCacheInfo, error) {
 
 // GetImageCacheInfo returns information about the image cache directory
 func (a *App) GetImageCacheInfo() (*ImageCacheInfo, error) {
-	cacheDir, err := constants.GetImagesDir()
+	cacheDir, err := paths.ImagesDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get images directory: %w", err)
 	}
@@ -197,6 +199,67 @@ func (a *App) GetImageCacheInfo() (*ImageCacheInfo, error) {
 	}, nil
 }
 
+// SetTTSCacheLimit updates the TTS cache size/file-count caps, persists them, and
+// enforces the new limit immediately.
+func (a *App) SetTTSCacheLimit(maxBytes int64, maxFiles int) (*cache.EvictionReport, error) {
+	limits := a.settings.Get().CacheLimits
+	limits.TTSMaxBytes = maxBytes
+	limits.TTSMaxFiles = maxFiles
+	if err := a.settings.UpdateCacheLimits(limits); err != nil {
+		return nil, fmt.Errorf("failed to save cache limits: %w", err)
+	}
+
+	if a.ttsCache == nil {
+		return &cache.EvictionReport{}, nil
+	}
+	a.ttsCache.SetLimits(maxBytes, maxFiles)
+	return a.ttsCache.Prune()
+}
+
+// SetImageCacheLimit updates the image cache size/file-count caps, persists them, and
+// enforces the new limit immediately.
+func (a *App) SetImageCacheLimit(maxBytes int64, maxFiles int) (*cache.EvictionReport, error) {
+	limits := a.settings.Get().CacheLimits
+	limits.ImageMaxBytes = maxBytes
+	limits.ImageMaxFiles = maxFiles
+	if err := a.settings.UpdateCacheLimits(limits); err != nil {
+		return nil, fmt.Errorf("failed to save cache limits: %w", err)
+	}
+
+	if a.imageCache == nil {
+		return &cache.EvictionReport{}, nil
+	}
+	a.imageCache.SetLimits(maxBytes, maxFiles)
+	return a.imageCache.Prune()
+}
+
+// SetLazyCacheLimit updates the byte ceiling for the lazy-loaded item text
+// cache (definition/derivation/appendicies), persists it, and enforces the
+// new limit immediately.
+func (a *App) SetLazyCacheLimit(maxBytes int64) error {
+	if err := a.settings.UpdateLazyCacheLimit(maxBytes); err != nil {
+		return fmt.Errorf("failed to save cache limits: %w", err)
+	}
+	database.SetLazyCacheLimit(maxBytes)
+	return nil
+}
+
+// PruneTTSCache manually evicts least-recently-used TTS cache entries down to the configured limit.
+func (a *App) PruneTTSCache() (*cache.EvictionReport, error) {
+	if a.ttsCache == nil {
+		return &cache.EvictionReport{}, nil
+	}
+	return a.ttsCache.Prune()
+}
+
+// PruneImageCache manually evicts least-recently-used image cache entries down to the configured limit.
+func (a *App) PruneImageCache() (*cache.EvictionReport, error) {
+	if a.imageCache == nil {
+		return &cache.EvictionReport{}, nil
+	}
+	return a.imageCache.Prune()
+}
+
 // SelectExportFolder opens a directory selection dialog and saves the chosen folder
 func (a *App) SelectExportFolder() (string, error) {
 	// Open directory selection dialog
@@ -248,9 +311,10 @@ func (a *App) GetSavedSearches() []settings.SavedSearch {
 	return a.settings.GetSavedSearches()
 }
 
-// SaveSearch saves a named search query
-func (a *App) SaveSearch(name, query string, types []string, source string) error {
-	return a.settings.AddSavedSearch(name, query, types, source)
+// SaveSearch saves a named search query under folderPath (e.g.
+// "work/ethereum/txs"; "" for the root folder), tagged with tags
+func (a *App) SaveSearch(name, query string, types []string, source, folderPath string, tags []string) error {
+	return a.settings.AddSavedSearch(name, query, types, source, folderPath, tags)
 }
 
 // DeleteSavedSearch deletes a saved search by name
@@ -258,6 +322,23 @@ func (a *App) DeleteSavedSearch(name string) error {
 	return a.settings.DeleteSavedSearch(name)
 }
 
+// ListSavedSearches returns saved searches under folder, either as a flat
+// list or a nested folder tree - see settings.Manager.ListSavedSearches.
+func (a *App) ListSavedSearches(mode string, folder string) ([]settings.SavedSearchNode, error) {
+	return a.settings.ListSavedSearches(mode, folder)
+}
+
+// MoveSavedSearch moves a saved search into a different folder
+func (a *App) MoveSavedSearch(name, newFolder string) error {
+	return a.settings.MoveSavedSearch(name, newFolder)
+}
+
+// RenameFolder renames a saved search folder, moving every search in it
+// (and its subfolders) along with it
+func (a *App) RenameFolder(old, new string) error {
+	return a.settings.RenameFolder(old, new)
+}
+
 // GetAllSettings returns all settings as a map for display
 func (a *App) GetAllSettings() map[string]interface{} {
 	s := a.settings.Get()
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/settings"
+)
+
+// ExportBundleOptions is the Wails-bound counterpart to
+// settings.ExportOptions.
+type ExportBundleOptions = settings.ExportOptions
+
+// ImportBundleOptions is the Wails-bound counterpart to
+// settings.ImportOptions.
+type ImportBundleOptions = settings.ImportOptions
+
+// ImportBundleDiff is the Wails-bound counterpart to settings.ImportDiff.
+type ImportBundleDiff = settings.ImportDiff
+
+// ExportSettingsBundle writes a single JSON document at path containing the
+// current settings, saved searches, and (unless opts.ExcludeHistory)
+// history, so a user can migrate their Poetry state to another machine or
+// share a curated SavedSearches collection.
+func (a *App) ExportSettingsBundle(path string, opts ExportBundleOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return a.settings.ExportBundle(f, opts)
+}
+
+// ImportSettingsBundle reads a bundle written by ExportSettingsBundle from
+// path and applies it per opts.Mode, returning a diff describing what
+// changed (or, with opts.DryRun, what would change).
+func (a *App) ImportSettingsBundle(path string, opts ImportBundleOptions) (*ImportBundleDiff, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return a.settings.ImportBundle(f, opts)
+}
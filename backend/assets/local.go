@@ -0,0 +1,187 @@
+package assets
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalFSStore is an AssetStore backed by the existing images/tts-cache
+// directories on disk, sharded two levels deep by the first four hex
+// characters of the key - "images/ab/cd/abcdef....png" - so a single
+// directory never ends up with one entry per item in a large library.
+// Get/Exists also check the old flat "<hash><ext>" layout blobs were
+// written under before sharding, so a store isn't considered broken until
+// the shard_asset_layout migration has had a chance to move everything.
+type LocalFSStore struct {
+	imagesDir string
+	ttsDir    string
+}
+
+// NewLocalFSStore creates a LocalFSStore rooted at imagesDir/ttsDir,
+// creating both if they don't already exist.
+func NewLocalFSStore(imagesDir, ttsDir string) (*LocalFSStore, error) {
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create images directory: %w", err)
+	}
+	if err := os.MkdirAll(ttsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tts directory: %w", err)
+	}
+	return &LocalFSStore{imagesDir: imagesDir, ttsDir: ttsDir}, nil
+}
+
+func (s *LocalFSStore) dir(kind Kind) string {
+	if kind == KindTTS {
+		return s.ttsDir
+	}
+	return s.imagesDir
+}
+
+// path returns the sharded path a blob is written to: two levels of
+// two-hex-character directories carved off the front of key, then the key
+// itself as the filename. Falls back to just the key (no sharding) if it's
+// too short to carve a shard prefix from.
+func (s *LocalFSStore) path(kind Kind, key string) string {
+	if len(key) < 4 {
+		return filepath.Join(s.dir(kind), key+kind.ext())
+	}
+	return filepath.Join(s.dir(kind), key[0:2], key[2:4], key+kind.ext())
+}
+
+// legacyPath is where key used to live, before sharding: directly inside
+// the kind's directory as "<key><ext>".
+func (s *LocalFSStore) legacyPath(kind Kind, key string) string {
+	return filepath.Join(s.dir(kind), key+kind.ext())
+}
+
+func (s *LocalFSStore) Exists(kind Kind, key string) bool {
+	if _, err := os.Stat(s.path(kind, key)); err == nil {
+		return true
+	}
+	_, err := os.Stat(s.legacyPath(kind, key))
+	return err == nil
+}
+
+// ExistsMany stats every key in turn - LocalFSStore has no batch stat
+// syscall to fall back on, unlike S3Store's ListObjects - but still spares
+// callers the per-key error-handling boilerplate of calling Exists in a
+// loop themselves.
+func (s *LocalFSStore) ExistsMany(kind Kind, keys []string) map[string]bool {
+	exists := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		exists[key] = s.Exists(kind, key)
+	}
+	return exists
+}
+
+func (s *LocalFSStore) Get(kind Kind, key string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(kind, key))
+	if os.IsNotExist(err) {
+		f, err = os.Open(s.legacyPath(kind, key))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s %s: %w", kind, key, err)
+	}
+	return f, nil
+}
+
+// Set writes r to a temp file in the same (sharded) directory and renames
+// it into place, so a crash or power loss mid-write never leaves a
+// truncated blob at key's path - the same atomic-write pattern
+// settings.FileStore.Put uses.
+func (s *LocalFSStore) Set(kind Kind, key string, r io.Reader) error {
+	path := s.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create shard directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }() // no-op once the rename below succeeds
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s %s: %w", kind, key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename into place: %w", err)
+	}
+	return nil
+}
+
+// Delete removes key's blob from both the sharded path and (in case it
+// predates the shard_asset_layout migration) the legacy flat path.
+func (s *LocalFSStore) Delete(kind Kind, key string) error {
+	if err := os.Remove(s.path(kind, key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s %s: %w", kind, key, err)
+	}
+	if err := os.Remove(s.legacyPath(kind, key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete legacy %s %s: %w", kind, key, err)
+	}
+	return nil
+}
+
+// List walks kind's directory (sharded subdirectories and any pre-migration
+// flat files alike) and returns every blob's key, recovered by stripping
+// kind's extension off each matching filename. Used by SyncFileFlags'
+// orphan pass to find blobs no item references anymore.
+func (s *LocalFSStore) List(kind Kind) ([]string, error) {
+	root := s.dir(kind)
+	var keys []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), kind.ext()) {
+			return nil
+		}
+		keys = append(keys, strings.TrimSuffix(d.Name(), kind.ext()))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s blobs: %w", kind, err)
+	}
+	return keys, nil
+}
+
+// MigrateLegacyLayout moves every blob of kind still sitting at its
+// pre-sharding flat path ("<key><ext>" directly under the kind's directory)
+// into its sharded path, and reports how many it moved. It's the concrete
+// implementation the shard_asset_layout migration type-asserts for, since
+// sharding is a LocalFSStore-only concept - S3Store already addresses
+// objects by key alone, so there's nothing for it to migrate.
+func (s *LocalFSStore) MigrateLegacyLayout(kind Kind) (int, error) {
+	entries, err := os.ReadDir(s.dir(kind))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s directory: %w", kind, err)
+	}
+
+	moved := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), kind.ext()) {
+			continue
+		}
+		key := strings.TrimSuffix(entry.Name(), kind.ext())
+		shardedPath := s.path(kind, key)
+		legacyPath := s.legacyPath(kind, key)
+		if shardedPath == legacyPath {
+			continue // key too short to shard; already "migrated"
+		}
+
+		if err := os.MkdirAll(filepath.Dir(shardedPath), 0755); err != nil {
+			return moved, fmt.Errorf("failed to create shard directory for %s: %w", key, err)
+		}
+		if err := os.Rename(legacyPath, shardedPath); err != nil {
+			return moved, fmt.Errorf("failed to move %s %s into sharded layout: %w", kind, key, err)
+		}
+		moved++
+	}
+	return moved, nil
+}
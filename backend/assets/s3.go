@@ -0,0 +1,158 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Store is an AssetStore backed by an S3 bucket, for deployments that run
+// the app off a remote Postgres backend (see database.DriverPostgres) and
+// so have no single local images/tts-cache directory to share across
+// instances. Objects are stored at "<Prefix><kind>/<key><ext>".
+type S3Store struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store creates an S3Store for cfg.Bucket/cfg.Prefix/cfg.Region,
+// loading credentials the standard way (environment, shared config file, or
+// an attached instance role) via the AWS SDK's default credential chain.
+func NewS3Store(cfg Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("assets: S3 backend requires a bucket")
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, config.WithRegion(cfg.Region))
+	}
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return &S3Store{
+		client: s3.NewFromConfig(awsCfg),
+		bucket: cfg.Bucket,
+		prefix: cfg.Prefix,
+	}, nil
+}
+
+func (s *S3Store) objectKey(kind Kind, key string) string {
+	return s.prefix + string(kind) + "/" + key + kind.ext()
+}
+
+func (s *S3Store) Exists(kind Kind, key string) bool {
+	_, err := s.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(kind, key)),
+	})
+	return err == nil
+}
+
+// ExistsMany lists every object under kind's prefix once, rather than
+// issuing one HeadObject round-trip per key, then checks membership
+// in-memory - the batching win SyncFileFlags needs this interface for in
+// the first place.
+func (s *S3Store) ExistsMany(kind Kind, keys []string) map[string]bool {
+	exists := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		exists[key] = false
+	}
+
+	listPrefix := s.prefix + string(kind) + "/"
+	found := make(map[string]bool)
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(listPrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			// Fall back to reporting whatever was found before the
+			// failure - a partial answer beats none for a stats loop.
+			break
+		}
+		for _, obj := range page.Contents {
+			if obj.Key != nil {
+				found[*obj.Key] = true
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if found[listPrefix+key+kind.ext()] {
+			exists[key] = true
+		}
+	}
+	return exists
+}
+
+// List enumerates every object under kind's prefix and strips the prefix
+// and extension back off to recover each blob's key.
+func (s *S3Store) List(kind Kind) ([]string, error) {
+	listPrefix := s.prefix + string(kind) + "/"
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(listPrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s objects: %w", kind, err)
+		}
+		for _, obj := range page.Contents {
+			if obj.Key == nil {
+				continue
+			}
+			key := strings.TrimSuffix(strings.TrimPrefix(*obj.Key, listPrefix), kind.ext())
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *S3Store) Get(kind Kind, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(kind, key)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", kind, key, err)
+	}
+	return out.Body, nil
+}
+
+func (s *S3Store) Set(kind Kind, key string, r io.Reader) error {
+	_, err := s.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(kind, key)),
+		Body:   r,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put %s %s: %w", kind, key, err)
+	}
+	return nil
+}
+
+func (s *S3Store) Delete(kind Kind, key string) error {
+	_, err := s.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(kind, key)),
+	})
+	var notFound *types.NoSuchKey
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("failed to delete %s %s: %w", kind, key, err)
+	}
+	return nil
+}
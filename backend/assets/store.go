@@ -0,0 +1,83 @@
+// Package assets abstracts where item images and TTS audio blobs actually
+// live, behind a small content-addressable interface (AssetStore) backed by
+// either the local filesystem (LocalFSStore) or an S3 bucket (S3Store). Both
+// caches are already content-addressable in SQLite - image_cache/tts_cache
+// record a content hash per item - so an AssetStore is keyed by that hash
+// plus a Kind, not by item ID.
+package assets
+
+import (
+	"fmt"
+	"io"
+)
+
+// Kind identifies which cache a key belongs to, since images and TTS audio
+// live under separate prefixes/directories with different extensions.
+type Kind string
+
+const (
+	KindImage Kind = "image"
+	KindTTS   Kind = "tts"
+)
+
+// ext is the file extension (including the dot) stored blobs of kind use.
+func (k Kind) ext() string {
+	switch k {
+	case KindTTS:
+		return ".mp3"
+	default:
+		return ".png"
+	}
+}
+
+// AssetStore is the persistence backend for item images and TTS audio,
+// addressed by content hash rather than item ID (see Package doc). Get's
+// caller is responsible for closing the returned ReadCloser.
+type AssetStore interface {
+	// Exists reports whether a blob is stored under key for kind.
+	Exists(kind Kind, key string) bool
+	// ExistsMany is Exists batched across keys, for callers (SyncFileFlags)
+	// that would otherwise pay one round-trip per item.
+	ExistsMany(kind Kind, keys []string) map[string]bool
+	// Get opens the blob stored under key for kind.
+	Get(kind Kind, key string) (io.ReadCloser, error)
+	// Set stores r under key for kind, replacing any existing blob.
+	Set(kind Kind, key string, r io.Reader) error
+	// Delete removes the blob stored under key for kind. It is not an
+	// error for key not to exist.
+	Delete(kind Kind, key string) error
+	// List returns every key currently stored for kind, for callers
+	// (SyncFileFlags' orphan pass) that need to compare what's on disk
+	// against what the database still references.
+	List(kind Kind) ([]string, error)
+}
+
+// Backend names accepted by Open.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// Config configures whichever backend Open dispatches to. ImagesDir/TTSDir
+// are only meaningful for BackendLocal; Bucket/Prefix/Region only for
+// BackendS3.
+type Config struct {
+	ImagesDir string
+	TTSDir    string
+
+	Bucket string
+	Prefix string
+	Region string
+}
+
+// Open creates the AssetStore for the given backend name.
+func Open(backend string, cfg Config) (AssetStore, error) {
+	switch backend {
+	case BackendLocal:
+		return NewLocalFSStore(cfg.ImagesDir, cfg.TTSDir)
+	case BackendS3:
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported asset store backend %q", backend)
+	}
+}
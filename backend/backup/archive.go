@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProgressFunc reports backup/restore progress: bytesDone/bytesTotal track
+// overall archive size processed so far, and currentFile names the entry
+// currently being streamed.
+type ProgressFunc func(bytesDone, bytesTotal int64, currentFile string)
+
+// reportProgress calls progress if it's non-nil, so streaming loops don't
+// need a nil check of their own at every chunk.
+func reportProgress(progress ProgressFunc, bytesDone, bytesTotal int64, currentFile string) {
+	if progress != nil {
+		progress(bytesDone, bytesTotal, currentFile)
+	}
+}
+
+// progressWriter wraps an io.Writer, calling progress as bytes flow through
+// it so a large file's hash/copy loop reports incremental progress rather
+// than jumping straight from 0 to its full size.
+type progressWriter struct {
+	w          io.Writer
+	progress   ProgressFunc
+	file       string
+	bytesDone  *int64
+	bytesTotal int64
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	*pw.bytesDone += int64(n)
+	reportProgress(pw.progress, *pw.bytesDone, pw.bytesTotal, pw.file)
+	return n, err
+}
+
+// listDataFiles returns the non-hidden regular files directly inside dir
+// (no recursion), sorted by name. Hidden entries are skipped so a .trash
+// subdirectory left behind by a reversible delete never ends up in a
+// backup.
+func listDataFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		files = append(files, entry.Name())
+	}
+	return files, nil
+}
+
+// hashFile returns the SHA-256 (hex-encoded) and size of the file at path.
+func hashFile(path string) (sha string, size int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// entryTargetPath maps an archive-relative entry path back to a filesystem
+// path under dbPath/imagesDir/ttsCacheDir, or "" if the entry name isn't one
+// Restore recognizes (an unknown entry is skipped rather than failing the
+// whole restore, so a future archive format can add entries without
+// breaking older builds).
+func entryTargetPath(entryName, dbPath, imagesDir, ttsCacheDir string) string {
+	switch {
+	case entryName == DatabaseEntryName:
+		return dbPath
+	case strings.HasPrefix(entryName, ImagesEntryPrefix):
+		return filepath.Join(imagesDir, strings.TrimPrefix(entryName, ImagesEntryPrefix))
+	case strings.HasPrefix(entryName, TTSCacheEntryPrefix):
+		return filepath.Join(ttsCacheDir, strings.TrimPrefix(entryName, TTSCacheEntryPrefix))
+	default:
+		return ""
+	}
+}
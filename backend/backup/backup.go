@@ -0,0 +1,146 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// Create snapshots db, imagesDir, and ttsCacheDir into a single gzip+tar
+// archive at archivePath. The database is captured with SQLite's
+// VACUUM INTO, which produces a consistent point-in-time copy without
+// locking out concurrent writers. schemaVersion should be the highest
+// schema_migrations version applied to db, so Restore can refuse an
+// archive a future build's migrations haven't been applied to. progress
+// (may be nil) is called as each entry streams into the archive.
+func Create(db *database.DB, imagesDir, ttsCacheDir, archivePath string, schemaVersion int, progress ProgressFunc) error {
+	snapshotPath := archivePath + ".db-snapshot"
+	defer func() { _ = os.Remove(snapshotPath) }()
+
+	_ = os.Remove(snapshotPath)
+	if _, err := db.Conn().Exec("VACUUM INTO ?", snapshotPath); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+
+	imageFiles, err := listDataFiles(imagesDir)
+	if err != nil {
+		return fmt.Errorf("failed to list images: %w", err)
+	}
+	ttsFiles, err := listDataFiles(ttsCacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to list TTS cache: %w", err)
+	}
+
+	manifest := Manifest{
+		SchemaVersion: schemaVersion,
+		CreatedAt:     time.Now(),
+		ImageCount:    len(imageFiles),
+		TTSCacheCount: len(ttsFiles),
+	}
+
+	type sourcedEntry struct {
+		entryName  string
+		sourcePath string
+	}
+	var sources []sourcedEntry
+
+	dbSHA, dbSize, err := hashFile(snapshotPath)
+	if err != nil {
+		return fmt.Errorf("failed to hash database snapshot: %w", err)
+	}
+	manifest.Entries = append(manifest.Entries, ManifestEntry{Path: DatabaseEntryName, SHA256: dbSHA, Size: dbSize})
+	sources = append(sources, sourcedEntry{DatabaseEntryName, snapshotPath})
+
+	for _, name := range imageFiles {
+		path := filepath.Join(imagesDir, name)
+		sha, size, err := hashFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to hash image %s: %w", name, err)
+		}
+		entryName := ImagesEntryPrefix + name
+		manifest.Entries = append(manifest.Entries, ManifestEntry{Path: entryName, SHA256: sha, Size: size})
+		sources = append(sources, sourcedEntry{entryName, path})
+	}
+
+	for _, name := range ttsFiles {
+		path := filepath.Join(ttsCacheDir, name)
+		sha, size, err := hashFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to hash TTS cache file %s: %w", name, err)
+		}
+		entryName := TTSCacheEntryPrefix + name
+		manifest.Entries = append(manifest.Entries, ManifestEntry{Path: entryName, SHA256: sha, Size: size})
+		sources = append(sources, sourcedEntry{entryName, path})
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	archiveFile, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() { _ = archiveFile.Close() }()
+
+	gz := gzip.NewWriter(archiveFile)
+	defer func() { _ = gz.Close() }()
+	tw := tar.NewWriter(gz)
+	defer func() { _ = tw.Close() }()
+
+	if err := tw.WriteHeader(&tar.Header{Name: manifestEntryName, Size: int64(len(manifestJSON)), Mode: 0644}); err != nil {
+		return fmt.Errorf("failed to write manifest header: %w", err)
+	}
+	if _, err := tw.Write(manifestJSON); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	bytesTotal := manifest.totalBytes()
+	var bytesDone int64
+	for _, src := range sources {
+		if err := writeArchiveEntry(tw, src.entryName, src.sourcePath, progress, &bytesDone, bytesTotal); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive compression: %w", err)
+	}
+	return archiveFile.Close()
+}
+
+// writeArchiveEntry streams sourcePath into tw under entryName, reporting
+// progress as it goes.
+func writeArchiveEntry(tw *tar.Writer, entryName, sourcePath string, progress ProgressFunc, bytesDone *int64, bytesTotal int64) error {
+	f, err := os.Open(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", sourcePath, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", sourcePath, err)
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: entryName, Size: info.Size(), Mode: 0644}); err != nil {
+		return fmt.Errorf("failed to write header for %s: %w", entryName, err)
+	}
+
+	pw := &progressWriter{w: tw, progress: progress, file: entryName, bytesDone: bytesDone, bytesTotal: bytesTotal}
+	if _, err := io.Copy(pw, f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", entryName, err)
+	}
+	return nil
+}
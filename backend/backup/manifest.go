@@ -0,0 +1,48 @@
+// Package backup implements streaming, integrity-checked backup and
+// restore of the application's data: the SQLite database, the images
+// directory, and the TTS cache.
+package backup
+
+import "time"
+
+// DatabaseEntryName and the two directory prefixes are the fixed paths an
+// archive's entries are stored under, independent of the live config
+// directory layout (which can differ between the machine that created the
+// archive and the one restoring it).
+const (
+	DatabaseEntryName   = "poetry.db"
+	ImagesEntryPrefix   = "images/"
+	TTSCacheEntryPrefix = "tts-cache/"
+	manifestEntryName   = "manifest.json"
+)
+
+// ManifestEntry is one file stored in the archive: its archive-relative
+// path, its SHA-256 (hex-encoded) so Restore can verify it before touching
+// any live file, and its size for progress totals.
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Manifest describes the contents of one backup archive. SchemaVersion is
+// the highest schema_migrations version applied to the source database at
+// backup time; Restore refuses to apply an archive whose SchemaVersion is
+// higher than the restoring build's newest known migration, since that
+// build has no way to bring the restored database up to date.
+type Manifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	ImageCount    int             `json:"imageCount"`
+	TTSCacheCount int             `json:"ttsCacheCount"`
+	Entries       []ManifestEntry `json:"entries"`
+}
+
+// totalBytes sums Size across every entry, for progress reporting.
+func (m *Manifest) totalBytes() int64 {
+	var total int64
+	for _, e := range m.Entries {
+		total += e.Size
+	}
+	return total
+}
@@ -0,0 +1,218 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// RestoreOptions controls how Restore applies an archive.
+type RestoreOptions struct {
+	// VerifyOnly checks the archive's manifest and every entry's hash
+	// without writing anything to dbPath/imagesDir/ttsCacheDir - an
+	// integrity check a caller can run before committing to a restore.
+	VerifyOnly bool
+}
+
+// Restore applies the backup archive at archivePath to dbPath, imagesDir,
+// and ttsCacheDir. It stages and hash-verifies every entry in a temp
+// directory before touching anything live; only once every entry checks
+// out does it swap files in, keeping dbPath's previous contents at
+// dbPath+".bak" until the restored database has been opened successfully.
+// maxKnownSchemaVersion is this build's newest known migration version;
+// Restore refuses an archive whose manifest SchemaVersion exceeds it, since
+// this build has no migration to bring such a database up to date.
+func Restore(archivePath, dbPath, imagesDir, ttsCacheDir string, maxKnownSchemaVersion int, opts RestoreOptions, progress ProgressFunc) error {
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() { _ = archiveFile.Close() }()
+
+	gz, err := gzip.NewReader(archiveFile)
+	if err != nil {
+		return fmt.Errorf("failed to open archive as gzip: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	if header.Name != manifestEntryName {
+		return fmt.Errorf("archive is malformed: expected %s first, got %s", manifestEntryName, header.Name)
+	}
+	manifestJSON, err := io.ReadAll(tr)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.SchemaVersion > maxKnownSchemaVersion {
+		return fmt.Errorf("archive schema version %d is newer than this build supports (%d); upgrade before restoring", manifest.SchemaVersion, maxKnownSchemaVersion)
+	}
+
+	expected := make(map[string]ManifestEntry, len(manifest.Entries))
+	for _, e := range manifest.Entries {
+		expected[e.Path] = e
+	}
+
+	stagingDir, err := os.MkdirTemp("", "poetry-restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(stagingDir) }()
+
+	bytesTotal := manifest.totalBytes()
+	var bytesDone int64
+	staged := make(map[string]string, len(manifest.Entries))
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive entry: %w", err)
+		}
+
+		entry, known := expected[header.Name]
+		if !known {
+			continue // forward-compatible: ignore entries this build doesn't recognize
+		}
+
+		stagedPath := filepath.Join(stagingDir, fmt.Sprintf("%d", len(staged)))
+		sha, err := stageEntry(tr, stagedPath, progress, &bytesDone, bytesTotal, header.Name)
+		if err != nil {
+			return fmt.Errorf("failed to stage %s: %w", header.Name, err)
+		}
+		if sha != entry.SHA256 {
+			return fmt.Errorf("integrity check failed for %s: archive is corrupt", header.Name)
+		}
+		staged[header.Name] = stagedPath
+	}
+
+	for path := range expected {
+		if _, ok := staged[path]; !ok {
+			return fmt.Errorf("archive is missing entry %s listed in its manifest", path)
+		}
+	}
+
+	if opts.VerifyOnly {
+		return nil
+	}
+
+	return swapInStagedFiles(staged, dbPath, imagesDir, ttsCacheDir)
+}
+
+// stageEntry copies the current tar entry to destPath, returning its
+// SHA-256 (hex-encoded) so the caller can compare it to the manifest before
+// trusting the file.
+func stageEntry(r io.Reader, destPath string, progress ProgressFunc, bytesDone *int64, bytesTotal int64, name string) (string, error) {
+	out, err := os.Create(destPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = out.Close() }()
+
+	h := sha256.New()
+	pw := &progressWriter{w: io.MultiWriter(out, h), progress: progress, file: name, bytesDone: bytesDone, bytesTotal: bytesTotal}
+	if _, err := io.Copy(pw, r); err != nil {
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// swapInStagedFiles moves every verified staged file into place. The
+// database is handled last and most carefully: the previous file is kept at
+// dbPath+".bak" until the restored database opens successfully, so a
+// corrupt-but-hash-valid archive (or a restored schema this build can't
+// actually read) can't leave the app with no working database.
+func swapInStagedFiles(staged map[string]string, dbPath, imagesDir, ttsCacheDir string) error {
+	for name, stagedPath := range staged {
+		if name == DatabaseEntryName {
+			continue
+		}
+		target := entryTargetPath(name, dbPath, imagesDir, ttsCacheDir)
+		if target == "" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", name, err)
+		}
+		if err := copyFile(stagedPath, target); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+
+	dbStagedPath, ok := staged[DatabaseEntryName]
+	if !ok {
+		return nil
+	}
+
+	bakPath := dbPath + ".bak"
+	_ = os.Remove(bakPath)
+	if _, err := os.Stat(dbPath); err == nil {
+		if err := os.Rename(dbPath, bakPath); err != nil {
+			return fmt.Errorf("failed to back up current database: %w", err)
+		}
+	}
+
+	if err := copyFile(dbStagedPath, dbPath); err != nil {
+		_ = restoreBak(bakPath, dbPath)
+		return fmt.Errorf("failed to write restored database: %w", err)
+	}
+
+	restoredDB, err := database.NewDB(dbPath)
+	if err != nil {
+		_ = restoreBak(bakPath, dbPath)
+		return fmt.Errorf("restored database failed to open, rolled back to previous database: %w", err)
+	}
+	_ = restoredDB.Conn().Close()
+
+	_ = os.Remove(bakPath)
+	return nil
+}
+
+// restoreBak moves bakPath back to dbPath, undoing a failed restore.
+func restoreBak(bakPath, dbPath string) error {
+	if _, err := os.Stat(bakPath); err != nil {
+		return nil
+	}
+	_ = os.Remove(dbPath)
+	return os.Rename(bakPath, dbPath)
+}
+
+// copyFile copies src to dst, overwriting dst if it exists.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = out.Close() }()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
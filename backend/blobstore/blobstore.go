@@ -0,0 +1,19 @@
+// Package blobstore holds the content-addressing helpers shared by the
+// image and TTS caches, so both compute their cache keys the same way
+// instead of each hashing payloads on its own.
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Hash returns the content-addressed key for data: its SHA-256, hex-encoded.
+// ImageService and TTSService use it to name cache blobs, so two items with
+// byte-identical payloads (the same picture uploaded twice, the same text
+// spoken by two different words) land on the same file instead of storing
+// a copy per item.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
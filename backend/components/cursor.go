@@ -0,0 +1,203 @@
+package components
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCursorNotFound means cursorID doesn't name a live cursor: FetchRows
+// already exhausted and auto-closed it, CloseCursor already closed it, or
+// it sat unfetched long enough for the idle janitor to reclaim it.
+var ErrCursorNotFound = errors.New("cursor not found")
+
+// cursorIdleTimeout bounds how long an OpenCursor result can sit unfetched
+// before the idle janitor closes it, so a frontend tab the user navigated
+// away from mid-grid doesn't pin a *sql.Rows - and the read-only connection
+// backing it - open indefinitely.
+const cursorIdleTimeout = 5 * time.Minute
+
+// adhocCursor is one live result set opened by OpenCursor, tracked until
+// FetchRows exhausts it or CloseCursor/the idle janitor closes it early.
+type adhocCursor struct {
+	rows       *sql.Rows
+	columns    []string
+	lastAccess time.Time
+}
+
+// cursorRegistry is the process-wide table of live ad-hoc query cursors. It
+// is guarded by a mutex since OpenCursor, FetchRows, CloseCursor, and the
+// idle janitor all run concurrently.
+type cursorRegistry struct {
+	mu      sync.Mutex
+	cursors map[string]*adhocCursor
+}
+
+// sharedCursors is the single registry every AdHocQueryComponent instance
+// shares, mirroring the shared lazy-string cache in the database package: a
+// cursor ID is process-global, not tied to any one component value.
+var sharedCursors = newCursorRegistry()
+
+func newCursorRegistry() *cursorRegistry {
+	r := &cursorRegistry{cursors: make(map[string]*adhocCursor)}
+	go r.expireIdleLoop()
+	return r
+}
+
+func (r *cursorRegistry) expireIdleLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.expireIdle()
+	}
+}
+
+func (r *cursorRegistry) expireIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, c := range r.cursors {
+		if now.Sub(c.lastAccess) > cursorIdleTimeout {
+			_ = c.rows.Close()
+			delete(r.cursors, id)
+		}
+	}
+}
+
+func (r *cursorRegistry) open(rows *sql.Rows, columns []string) (string, error) {
+	id, err := newCursorID()
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cursors[id] = &adhocCursor{rows: rows, columns: columns, lastAccess: time.Now()}
+	return id, nil
+}
+
+func (r *cursorRegistry) get(id string) (*adhocCursor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.cursors[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCursorNotFound, id)
+	}
+	c.lastAccess = time.Now()
+	return c, nil
+}
+
+func (r *cursorRegistry) close(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.cursors[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrCursorNotFound, id)
+	}
+	delete(r.cursors, id)
+	return c.rows.Close()
+}
+
+func newCursorID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate cursor id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// OpenCursor validates and runs query (with the same optional `?`/`:name`
+// bindings as RunAdHocQueryWithParams), and returns a cursorID the frontend
+// can page through with FetchRows instead of waiting for the whole result
+// set to be scanned and marshaled up front.
+func (c *AdHocQueryComponent) OpenCursor(query string, namedParams map[string]any, positionalParams []any) (cursorID string, columns []string, err error) {
+	if err := validateReadOnlyQuery(query); err != nil {
+		return "", nil, err
+	}
+
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return "", nil, err
+	}
+	args, err := bindPlaceholders(extractPlaceholders(tokens), namedParams, positionalParams)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows, err := c.db.ReadOnlyConn().Query(query, args...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns, err = rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return "", nil, err
+	}
+
+	cursorID, err = sharedCursors.open(rows, columns)
+	if err != nil {
+		_ = rows.Close()
+		return "", nil, err
+	}
+	return cursorID, columns, nil
+}
+
+// FetchRows returns up to n more rows from cursorID, and whether the result
+// set is now exhausted. Once done is true the cursor has already been
+// closed and removed by FetchRows itself - callers don't need to also call
+// CloseCursor for the common "paged to the end" case.
+func (c *AdHocQueryComponent) FetchRows(cursorID string, n int) (rows []map[string]any, done bool, err error) {
+	cur, err := sharedCursors.get(cursorID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for len(rows) < n {
+		if !cur.rows.Next() {
+			closeErr := sharedCursors.close(cursorID)
+			if err := cur.rows.Err(); err != nil {
+				return rows, true, err
+			}
+			return rows, true, closeErr
+		}
+
+		rowMap, err := scanRowToMap(cur.rows, cur.columns)
+		if err != nil {
+			_ = sharedCursors.close(cursorID)
+			return rows, true, err
+		}
+		rows = append(rows, rowMap)
+	}
+
+	return rows, false, nil
+}
+
+// CloseCursor releases cursorID early, e.g. when the user navigates away
+// from a grid before paging to the end of its result set.
+func (c *AdHocQueryComponent) CloseCursor(cursorID string) error {
+	return sharedCursors.close(cursorID)
+}
+
+// Count returns the number of rows query would produce, by wrapping it in
+// SELECT COUNT(*) FROM (...) after the same read-only validation as
+// RunAdHocQuery, so the frontend grid can render an accurate scrollbar
+// without fetching (or even opening a cursor over) every row.
+func (c *AdHocQueryComponent) Count(query string) (int64, error) {
+	if err := validateReadOnlyQuery(query); err != nil {
+		return 0, err
+	}
+
+	trimmed := strings.TrimRight(strings.TrimSpace(query), ";")
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (%s)", trimmed)
+
+	var count int64
+	if err := c.db.ReadOnlyConn().QueryRow(countQuery).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,220 @@
+package components
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// SavedQueryParam describes one named or positional binding a saved query
+// expects, purely for the UI to render an input for it - RunSaved itself
+// still enforces bindings via the same validator RunAdHocQueryWithParams
+// uses.
+type SavedQueryParam struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"` // e.g. "string", "number", "date"; informational only
+}
+
+// SavedQuery is a persisted RunAdHocQuery snippet, so a power user's useful
+// queries survive past the session that wrote them.
+type SavedQuery struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Query       string            `json:"query"`
+	ParamSchema []SavedQueryParam `json:"paramSchema"`
+	Tags        []string          `json:"tags"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	LastRunAt   *time.Time        `json:"lastRunAt,omitempty"`
+}
+
+// SavedQueryComponent stores named RunAdHocQuery snippets in the
+// saved_queries table and runs them through the same read-only validator
+// and parameter binding as RunAdHocQueryWithParams.
+type SavedQueryComponent struct {
+	db    *database.DB
+	adhoc *AdHocQueryComponent
+}
+
+// NewSavedQueryComponent creates a new SavedQueryComponent. adhoc is reused
+// for RunSaved so saved queries go through exactly the same validation and
+// binding path as an ad-hoc one.
+func NewSavedQueryComponent(db *database.DB, adhoc *AdHocQueryComponent) *SavedQueryComponent {
+	return &SavedQueryComponent{db: db, adhoc: adhoc}
+}
+
+// CreateSavedQuery validates query as read-only and persists it under name.
+// Validating here - not only when RunSaved executes it later - means a
+// saved query can never silently rot into something the validator would
+// reject, and a typo'd write statement is caught at save time instead of
+// surfacing as a run-time error the next time someone opens the library.
+func (c *SavedQueryComponent) CreateSavedQuery(name, description, query string, paramSchema []SavedQueryParam, tags []string) error {
+	if err := validateReadOnlyQuery(query); err != nil {
+		return err
+	}
+
+	paramSchemaJSON, tagsJSON, err := encodeSavedQueryColumns(paramSchema, tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.db.Conn().Exec(`
+		INSERT INTO saved_queries (name, description, query, param_schema, tags)
+		VALUES (?, ?, ?, ?, ?)
+	`, name, description, query, paramSchemaJSON, tagsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to save query %q: %w", name, err)
+	}
+	return nil
+}
+
+// UpdateSavedQuery replaces an existing saved query's description, SQL text,
+// parameter schema, and tags, re-validating query as read-only the same way
+// CreateSavedQuery does.
+func (c *SavedQueryComponent) UpdateSavedQuery(name, description, query string, paramSchema []SavedQueryParam, tags []string) error {
+	if err := validateReadOnlyQuery(query); err != nil {
+		return err
+	}
+
+	paramSchemaJSON, tagsJSON, err := encodeSavedQueryColumns(paramSchema, tags)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.db.Conn().Exec(`
+		UPDATE saved_queries
+		SET description = ?, query = ?, param_schema = ?, tags = ?
+		WHERE name = ?
+	`, description, query, paramSchemaJSON, tagsJSON, name)
+	if err != nil {
+		return fmt.Errorf("failed to update saved query %q: %w", name, err)
+	}
+	return requireRowAffected(result, "saved query", name)
+}
+
+// DeleteSavedQuery removes a saved query by name.
+func (c *SavedQueryComponent) DeleteSavedQuery(name string) error {
+	result, err := c.db.Conn().Exec(`DELETE FROM saved_queries WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved query %q: %w", name, err)
+	}
+	return requireRowAffected(result, "saved query", name)
+}
+
+// GetSavedQuery retrieves one saved query by name.
+func (c *SavedQueryComponent) GetSavedQuery(name string) (*SavedQuery, error) {
+	row := c.db.Conn().QueryRow(`
+		SELECT name, description, query, param_schema, tags, created_at, last_run_at
+		FROM saved_queries
+		WHERE name = ?
+	`, name)
+
+	sq, err := scanSavedQuery(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("saved query %q not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved query %q: %w", name, err)
+	}
+	return &sq, nil
+}
+
+// ListSavedQueries returns every saved query, most recently created first.
+func (c *SavedQueryComponent) ListSavedQueries() ([]SavedQuery, error) {
+	rows, err := c.db.Conn().Query(`
+		SELECT name, description, query, param_schema, tags, created_at, last_run_at
+		FROM saved_queries
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved queries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var saved []SavedQuery
+	for rows.Next() {
+		sq, err := scanSavedQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved query: %w", err)
+		}
+		saved = append(saved, sq)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+	return saved, nil
+}
+
+// RunSaved looks up name and executes it through RunAdHocQueryWithParams,
+// then stamps last_run_at so the library can surface recently-used queries.
+func (c *SavedQueryComponent) RunSaved(name string, namedParams map[string]any, positionalParams []any) ([]map[string]interface{}, error) {
+	sq, err := c.GetSavedQuery(name)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := c.adhoc.RunAdHocQueryWithParams(sq.Query, namedParams, positionalParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.db.Conn().Exec(`UPDATE saved_queries SET last_run_at = CURRENT_TIMESTAMP WHERE name = ?`, name); err != nil {
+		return nil, fmt.Errorf("failed to stamp last_run_at for saved query %q: %w", name, err)
+	}
+	return results, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows, letting
+// scanSavedQuery back both GetSavedQuery and ListSavedQueries.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSavedQuery(row rowScanner) (SavedQuery, error) {
+	var sq SavedQuery
+	var paramSchemaJSON, tagsJSON string
+	var lastRunAt sql.NullTime
+
+	if err := row.Scan(&sq.Name, &sq.Description, &sq.Query, &paramSchemaJSON, &tagsJSON, &sq.CreatedAt, &lastRunAt); err != nil {
+		return SavedQuery{}, err
+	}
+
+	if err := json.Unmarshal([]byte(paramSchemaJSON), &sq.ParamSchema); err != nil {
+		return SavedQuery{}, fmt.Errorf("failed to decode param schema: %w", err)
+	}
+	if err := json.Unmarshal([]byte(tagsJSON), &sq.Tags); err != nil {
+		return SavedQuery{}, fmt.Errorf("failed to decode tags: %w", err)
+	}
+	if lastRunAt.Valid {
+		sq.LastRunAt = &lastRunAt.Time
+	}
+	return sq, nil
+}
+
+func encodeSavedQueryColumns(paramSchema []SavedQueryParam, tags []string) (paramSchemaJSON, tagsJSON string, err error) {
+	paramBytes, err := json.Marshal(paramSchema)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode param schema: %w", err)
+	}
+	tagBytes, err := json.Marshal(tags)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode tags: %w", err)
+	}
+	return string(paramBytes), string(tagBytes), nil
+}
+
+// requireRowAffected turns a zero-rows-affected Exec result into a
+// not-found error, since a WHERE name = ? UPDATE/DELETE that matches
+// nothing otherwise succeeds silently.
+func requireRowAffected(result sql.Result, kind, name string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to determine rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %q not found", kind, name)
+	}
+	return nil
+}
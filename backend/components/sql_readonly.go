@@ -0,0 +1,367 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrParseFailed means the query couldn't be tokenized at all (an
+// unterminated string/comment/identifier, or nothing parseable was found).
+// ErrDisallowedStmt means it parsed fine but isn't one of the read-only
+// statement kinds this validator accepts. ErrParamMismatch means the
+// bindings passed to RunAdHocQueryWithParams don't line up one-to-one with
+// the `?` / `:name` placeholders found in the query. Callers (and the
+// frontend, via errors.Is) can tell the three apart rather than getting one
+// generic message.
+var (
+	ErrParseFailed    = errors.New("failed to parse query")
+	ErrDisallowedStmt = errors.New("statement is not read-only")
+	ErrParamMismatch  = errors.New("query parameters do not match bindings")
+)
+
+// readOnlyPragmas are the PRAGMA names validatePragmaTokens accepts without
+// an "=" assignment. Mirrors the set the old regex validator allowed.
+var readOnlyPragmas = map[string]bool{
+	"TABLE_INFO":        true,
+	"INDEX_LIST":        true,
+	"INDEX_INFO":        true,
+	"FOREIGN_KEY_LIST":  true,
+	"DATABASE_LIST":     true,
+	"STATS":             true,
+	"SCHEMA_VERSION":    true,
+	"USER_VERSION":      true,
+	"APPLICATION_ID":    true,
+	"COMPILE_OPTIONS":   true,
+	"INTEGRITY_CHECK":   true,
+	"QUICK_CHECK":       true,
+	"FOREIGN_KEY_CHECK": true,
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokString
+	tokQuotedIdent
+	tokNumber
+	tokPunct
+)
+
+type sqlToken struct {
+	kind tokenKind
+	text string
+}
+
+// validateReadOnlyQuery ensures query is read-only (SELECT, EXPLAIN, WITH
+// resolving to SELECT, or a safe PRAGMA). Unlike the regex approach this
+// replaced, it tokenizes the query first - respecting quoted strings,
+// bracketed/backtick/quoted identifiers, and -- / * * / comments - and then
+// classifies each statement from its token stream, so a string literal
+// containing the word DROP or a column named delete_flag is never mistaken
+// for a write, and a write hidden behind an odd comment or casing is never
+// mistaken for a read.
+func validateReadOnlyQuery(query string) error {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return err
+	}
+
+	statements := splitStatements(tokens)
+	nonEmpty := 0
+	for _, stmt := range statements {
+		if len(stmt) == 0 {
+			continue
+		}
+		nonEmpty++
+		if err := validateStatement(stmt); err != nil {
+			return err
+		}
+	}
+	if nonEmpty == 0 {
+		return fmt.Errorf("%w: empty query", ErrParseFailed)
+	}
+	return nil
+}
+
+// splitStatements breaks a token stream into one slice per `;`-separated
+// statement. A `;` only ever appears as its own punctuation token here,
+// since one inside a string or comment was already consumed as part of
+// that token by tokenizeSQL.
+func splitStatements(tokens []sqlToken) [][]sqlToken {
+	var statements [][]sqlToken
+	var current []sqlToken
+	for _, t := range tokens {
+		if t.kind == tokPunct && t.text == ";" {
+			statements = append(statements, current)
+			current = nil
+			continue
+		}
+		current = append(current, t)
+	}
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+	return statements
+}
+
+// validateStatement classifies a single statement's tokens and accepts only
+// SELECT, EXPLAIN [QUERY PLAN] <read-only statement>, WITH [RECURSIVE] ...
+// resolving to SELECT, and read-only PRAGMA.
+func validateStatement(tokens []sqlToken) error {
+	if len(tokens) == 0 || tokens[0].kind != tokWord {
+		return fmt.Errorf("%w: query does not start with a recognizable statement keyword", ErrDisallowedStmt)
+	}
+
+	switch strings.ToUpper(tokens[0].text) {
+	case "SELECT":
+		return nil
+	case "EXPLAIN":
+		rest := tokens[1:]
+		if len(rest) >= 2 && strings.EqualFold(rest[0].text, "QUERY") && strings.EqualFold(rest[1].text, "PLAN") {
+			rest = rest[2:]
+		}
+		return validateStatement(rest)
+	case "WITH":
+		return validateWithStatement(tokens[1:])
+	case "PRAGMA":
+		return validatePragmaTokens(tokens[1:])
+	default:
+		return fmt.Errorf("%w: %q is not a read-only statement (only SELECT, EXPLAIN, WITH, and read-only PRAGMA are allowed)",
+			ErrDisallowedStmt, tokens[0].text)
+	}
+}
+
+// validateWithStatement walks the comma-separated `name [(cols)] AS (...)`
+// CTE list and then requires what follows to itself be a read-only
+// statement - crucially rejecting SQLite's `WITH ... INSERT/UPDATE/DELETE`
+// upsert-via-CTE form, which the old prefix check on "WITH" let through.
+func validateWithStatement(tokens []sqlToken) error {
+	i := 0
+	if i < len(tokens) && strings.EqualFold(tokens[i].text, "RECURSIVE") {
+		i++
+	}
+
+	for {
+		if i >= len(tokens) || tokens[i].kind != tokWord {
+			return fmt.Errorf("%w: expected a CTE name in WITH clause", ErrParseFailed)
+		}
+		i++
+
+		if i < len(tokens) && tokens[i].kind == tokPunct && tokens[i].text == "(" {
+			next, err := skipBalancedParens(tokens, i)
+			if err != nil {
+				return err
+			}
+			i = next
+		}
+
+		if i >= len(tokens) || tokens[i].kind != tokWord || !strings.EqualFold(tokens[i].text, "AS") {
+			return fmt.Errorf("%w: expected AS in WITH clause", ErrParseFailed)
+		}
+		i++
+
+		if i >= len(tokens) || tokens[i].kind != tokPunct || tokens[i].text != "(" {
+			return fmt.Errorf("%w: expected ( after AS in WITH clause", ErrParseFailed)
+		}
+		next, err := skipBalancedParens(tokens, i)
+		if err != nil {
+			return err
+		}
+		i = next
+
+		if i < len(tokens) && tokens[i].kind == tokPunct && tokens[i].text == "," {
+			i++
+			continue
+		}
+		break
+	}
+
+	if i >= len(tokens) {
+		return fmt.Errorf("%w: WITH clause has no terminating statement", ErrParseFailed)
+	}
+	return validateStatement(tokens[i:])
+}
+
+// skipBalancedParens returns the index just past the matching ")" for the
+// "(" at tokens[open].
+func skipBalancedParens(tokens []sqlToken, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].kind != tokPunct {
+			continue
+		}
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: unbalanced parentheses", ErrParseFailed)
+}
+
+// validatePragmaTokens accepts `PRAGMA [schema.]name` and `PRAGMA
+// [schema.]name(arg)` for any name in readOnlyPragmas, and rejects the
+// `PRAGMA name = value` assignment form outright, since that's how a PRAGMA
+// mutates state.
+func validatePragmaTokens(tokens []sqlToken) error {
+	if len(tokens) == 0 || tokens[0].kind != tokWord {
+		return fmt.Errorf("%w: PRAGMA requires a name", ErrParseFailed)
+	}
+
+	i := 0
+	name := strings.ToUpper(tokens[i].text)
+	i++
+	if i < len(tokens) && tokens[i].kind == tokPunct && tokens[i].text == "." {
+		i++
+		if i < len(tokens) && tokens[i].kind == tokWord {
+			name = strings.ToUpper(tokens[i].text)
+			i++
+		}
+	}
+
+	if !readOnlyPragmas[name] {
+		return fmt.Errorf("%w: PRAGMA %s is not a recognized read-only pragma", ErrDisallowedStmt, name)
+	}
+	if i < len(tokens) && tokens[i].kind == tokPunct && tokens[i].text == "=" {
+		return fmt.Errorf("%w: PRAGMA %s cannot be used as a write (= assignment)", ErrDisallowedStmt, name)
+	}
+	return nil
+}
+
+// tokenizeSQL lexes query into a token stream, dropping -- and /* */
+// comments entirely. It understands '...' strings, "..." and `...`
+// quoted identifiers (with '' / "" escaping), and [...] bracketed
+// identifiers, so punctuation and keywords inside any of those never leak
+// out as statement-structuring tokens.
+func tokenizeSQL(query string) ([]sqlToken, error) {
+	runes := []rune(query)
+	n := len(runes)
+	var tokens []sqlToken
+
+	for i := 0; i < n; {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '-' && i+1 < n && runes[i+1] == '-':
+			i += 2
+			for i < n && runes[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < n && runes[i+1] == '*':
+			i += 2
+			closed := false
+			for i+1 < n {
+				if runes[i] == '*' && runes[i+1] == '/' {
+					i += 2
+					closed = true
+					break
+				}
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("%w: unterminated block comment", ErrParseFailed)
+			}
+
+		case c == '\'':
+			end, err := scanQuoted(runes, i, '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlToken{kind: tokString, text: string(runes[i:end])})
+			i = end
+
+		case c == '"' || c == '`':
+			end, err := scanQuoted(runes, i, c)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlToken{kind: tokQuotedIdent, text: string(runes[i+1 : end-1])})
+			i = end
+
+		case c == '[':
+			end := i + 1
+			for end < n && runes[end] != ']' {
+				end++
+			}
+			if end >= n {
+				return nil, fmt.Errorf("%w: unterminated bracketed identifier", ErrParseFailed)
+			}
+			tokens = append(tokens, sqlToken{kind: tokQuotedIdent, text: string(runes[i+1 : end])})
+			i = end + 1
+
+		case unicode.IsDigit(c):
+			end := i
+			for end < n && (unicode.IsDigit(runes[end]) || strings.ContainsRune(".xXeE+-abcdefABCDEF", runes[end])) {
+				end++
+			}
+			tokens = append(tokens, sqlToken{kind: tokNumber, text: string(runes[i:end])})
+			i = end
+
+		case unicode.IsLetter(c) || c == '_':
+			end := i
+			for end < n && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '$') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{kind: tokWord, text: string(runes[i:end])})
+			i = end
+
+		default:
+			tokens = append(tokens, sqlToken{kind: tokPunct, text: string(c)})
+			i++
+		}
+	}
+
+	return tokens, nil
+}
+
+// placeholder is one `?` or `:name` bind parameter found in a query's token
+// stream, in the order it appears. Name is empty for a positional `?`.
+type placeholder struct {
+	name string
+}
+
+// extractPlaceholders walks tokens looking for `?` positional placeholders
+// and `:name` named placeholders, in source order. It operates on the whole
+// query's token stream (not one statement at a time) since bindings are
+// supplied per-query, matching how database/sql itself binds args.
+func extractPlaceholders(tokens []sqlToken) []placeholder {
+	var params []placeholder
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.kind == tokPunct && t.text == "?":
+			params = append(params, placeholder{})
+		case t.kind == tokPunct && t.text == ":" && i+1 < len(tokens) && tokens[i+1].kind == tokWord:
+			params = append(params, placeholder{name: tokens[i+1].text})
+			i++
+		}
+	}
+	return params
+}
+
+// scanQuoted returns the index just past the closing quote matching the
+// opening quote char at runes[start], treating a doubled quote ('' or "")
+// as an escaped literal quote rather than the terminator.
+func scanQuoted(runes []rune, start int, quote rune) (int, error) {
+	i := start + 1
+	for i < len(runes) {
+		if runes[i] == quote {
+			if i+1 < len(runes) && runes[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1, nil
+		}
+		i++
+	}
+	return 0, fmt.Errorf("%w: unterminated quoted string or identifier", ErrParseFailed)
+}
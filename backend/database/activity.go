@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// defaultActor is recorded against every activity row until the app grows a
+// real user/session concept (there's none today - poetry is single-user).
+const defaultActor = "local"
+
+// Activity is one row of the activity table: a record of a single create,
+// update, or delete against an item or link, with enough of the prior state
+// in BeforeJSON for RevertActivity to reconstruct it.
+type Activity struct {
+	ActivityID int        `json:"activityId" db:"activity_id"`
+	Actor      string     `json:"actor" db:"actor"`
+	EntityType string     `json:"entityType" db:"entity_type"`
+	EntityID   int        `json:"entityId" db:"entity_id"`
+	Action     string     `json:"action" db:"action"`
+	BeforeJSON *string    `json:"beforeJson" db:"before_json"`
+	AfterJSON  *string    `json:"afterJson" db:"after_json"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at" ts_type:"Date"`
+	RevertedAt *time.Time `json:"revertedAt" db:"reverted_at" ts_type:"Date"`
+}
+
+// Entity types and actions recorded in the activity table. Kept as
+// string constants (rather than a Go type) since they're stored as plain
+// TEXT columns and filtered on directly in ActivityFilter.
+const (
+	ActivityEntityItem = "item"
+	ActivityEntityLink = "link"
+
+	ActivityActionCreate = "create"
+	ActivityActionUpdate = "update"
+	ActivityActionDelete = "delete"
+)
+
+// ActivityFilter narrows GetActivity's results. Zero-valued fields are
+// ignored, matching the pattern SearchOptions uses for its optional filters.
+type ActivityFilter struct {
+	EntityType string
+	EntityID   int
+	Actor      string
+	Action     string
+	After      time.Time
+	Before     time.Time
+}
+
+// recordActivity inserts one activity row via ex (either db.conn or a Tx's
+// underlying *sqlx.Tx, both of which satisfy execer), capturing before/after
+// as JSON snapshots. Either snapshot may be nil: a create has no before
+// state, a delete has no after state.
+func recordActivity(ex execer, entityType string, entityID int, action string, before, after interface{}) error {
+	beforeJSON, err := marshalActivitySnapshot(before)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity before-state: %w", err)
+	}
+	afterJSON, err := marshalActivitySnapshot(after)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity after-state: %w", err)
+	}
+
+	_, err = ex.Exec(`
+		INSERT INTO activity (actor, entity_type, entity_id, action, before_json, after_json)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, defaultActor, entityType, entityID, action, beforeJSON, afterJSON)
+	if err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	return nil
+}
+
+// itemOrNil converts item (possibly nil, when the lookup it came from found
+// nothing) into an interface{} suitable for recordActivity's before/after
+// parameters. Passing a typed nil *Item straight through would produce a
+// non-nil interface{} that json.Marshal encodes as "null" rather than the
+// bare nil marshalActivitySnapshot checks for, so this dereferences first.
+func itemOrNil(item *Item) interface{} {
+	if item == nil {
+		return nil
+	}
+	return *item
+}
+
+// linkOrNil is itemOrNil for *Link.
+func linkOrNil(link *Link) interface{} {
+	if link == nil {
+		return nil
+	}
+	return *link
+}
+
+// marshalActivitySnapshot JSON-encodes v for storage in before_json/
+// after_json, returning nil for a nil v instead of the literal string "null".
+func marshalActivitySnapshot(v interface{}) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// GetActivity returns activity rows matching filter, most recent first.
+// Every non-zero field on filter narrows the result: EntityType/EntityID
+// restrict to one entity, Actor/Action match exactly, and After/Before bound
+// created_at (either end may be left zero for an open-ended range).
+func (db *DB) GetActivity(filter ActivityFilter) ([]Activity, error) {
+	query := `
+		SELECT activity_id, actor, entity_type, entity_id, action, before_json, after_json, created_at, reverted_at
+		FROM activity
+		WHERE 1=1
+	`
+	var args []interface{}
+
+	if filter.EntityType != "" {
+		query += " AND entity_type = ?"
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query += " AND entity_id = ?"
+		args = append(args, filter.EntityID)
+	}
+	if filter.Actor != "" {
+		query += " AND actor = ?"
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		query += " AND action = ?"
+		args = append(args, filter.Action)
+	}
+	if !filter.After.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, filter.After)
+	}
+	if !filter.Before.IsZero() {
+		query += " AND created_at <= ?"
+		args = append(args, filter.Before)
+	}
+	query += " ORDER BY activity_id DESC"
+
+	var activities []Activity
+	if err := db.conn.Select(&activities, db.rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to get activity: %w", err)
+	}
+	return activities, nil
+}
+
+// RevertActivity undoes a single activity row by reapplying its
+// before_json snapshot: an update is reverted by restoring the prior item
+// fields, and a delete is reverted by recreating the item exactly as it
+// stood (including its original id) or the link with its original source,
+// destination and type (under a newly assigned link id). A create can't be reverted this
+// way (there's no before state) - callers should DeleteItem/DeleteLink
+// instead. Reverting is itself recorded as a new activity row, and the
+// reverted row is stamped so it can't be reverted twice.
+func (db *DB) RevertActivity(id int) error {
+	var act Activity
+	if err := db.conn.Get(&act, db.rebind(`
+		SELECT activity_id, actor, entity_type, entity_id, action, before_json, after_json, created_at, reverted_at
+		FROM activity WHERE activity_id = ?
+	`), id); err != nil {
+		return fmt.Errorf("failed to get activity %d: %w", id, err)
+	}
+	if act.RevertedAt != nil {
+		return fmt.Errorf("activity %d was already reverted", id)
+	}
+	if act.BeforeJSON == nil {
+		return fmt.Errorf("activity %d has no prior state to revert to", id)
+	}
+
+	return db.WithTx(context.Background(), func(tx *Tx) error {
+		switch act.EntityType {
+		case ActivityEntityItem:
+			var item Item
+			if err := json.Unmarshal([]byte(*act.BeforeJSON), &item); err != nil {
+				return fmt.Errorf("failed to decode item snapshot: %w", err)
+			}
+			switch act.Action {
+			case ActivityActionUpdate:
+				if err := tx.UpdateItem(item); err != nil {
+					return fmt.Errorf("failed to restore item %d: %w", item.ItemID, err)
+				}
+			case ActivityActionDelete:
+				if _, err := tx.CreateItem(item); err != nil {
+					return fmt.Errorf("failed to recreate item %d: %w", item.ItemID, err)
+				}
+			default:
+				return fmt.Errorf("activity %d's action %q can't be reverted", id, act.Action)
+			}
+		case ActivityEntityLink:
+			var link Link
+			if err := json.Unmarshal([]byte(*act.BeforeJSON), &link); err != nil {
+				return fmt.Errorf("failed to decode link snapshot: %w", err)
+			}
+			switch act.Action {
+			case ActivityActionDelete:
+				if err := tx.CreateLink(link.SourceItemID, link.DestinationItemID, link.LinkType); err != nil {
+					return fmt.Errorf("failed to recreate link: %w", err)
+				}
+			default:
+				return fmt.Errorf("activity %d's action %q can't be reverted", id, act.Action)
+			}
+		default:
+			return fmt.Errorf("activity %d's entity type %q can't be reverted", id, act.EntityType)
+		}
+
+		if _, err := tx.tx.Exec(tx.db.rebind(`UPDATE activity SET reverted_at = CURRENT_TIMESTAMP WHERE activity_id = ?`), id); err != nil {
+			return fmt.Errorf("failed to mark activity %d reverted: %w", id, err)
+		}
+		return nil
+	})
+}
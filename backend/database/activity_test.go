@@ -0,0 +1,110 @@
+package database
+
+import "testing"
+
+func TestCreateItemRecordsActivity(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	id, err := db.CreateItem(Item{Word: "onomatopoeia", Type: "Reference"})
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	activities, err := db.GetActivity(ActivityFilter{EntityType: ActivityEntityItem, EntityID: id})
+	if err != nil {
+		t.Fatalf("GetActivity failed: %v", err)
+	}
+	if len(activities) != 1 {
+		t.Fatalf("expected 1 activity, got %d", len(activities))
+	}
+	if activities[0].Action != ActivityActionCreate {
+		t.Errorf("expected action %q, got %q", ActivityActionCreate, activities[0].Action)
+	}
+	if activities[0].BeforeJSON != nil {
+		t.Errorf("expected no before-state for a create, got %q", *activities[0].BeforeJSON)
+	}
+	if activities[0].AfterJSON == nil {
+		t.Errorf("expected an after-state for a create")
+	}
+}
+
+func TestRevertActivityRestoresUpdatedItem(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	originalDef := "the original definition"
+	id, err := db.CreateItem(Item{Word: "palimpsest", Type: "Reference", Definition: NewLazyString(&originalDef)})
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	updatedDef := "a revised definition"
+	item, err := db.GetItem(id)
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	item.Definition = NewLazyString(&updatedDef)
+	if err := db.UpdateItem(*item); err != nil {
+		t.Fatalf("UpdateItem failed: %v", err)
+	}
+
+	activities, err := db.GetActivity(ActivityFilter{EntityType: ActivityEntityItem, EntityID: id, Action: ActivityActionUpdate})
+	if err != nil {
+		t.Fatalf("GetActivity failed: %v", err)
+	}
+	if len(activities) != 1 {
+		t.Fatalf("expected 1 update activity, got %d", len(activities))
+	}
+
+	if err := db.RevertActivity(activities[0].ActivityID); err != nil {
+		t.Fatalf("RevertActivity failed: %v", err)
+	}
+
+	reverted, err := db.GetItem(id)
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	if got := reverted.Definition.GetOrEmpty(); got != originalDef {
+		t.Errorf("expected definition %q after revert, got %q", originalDef, got)
+	}
+
+	if err := db.RevertActivity(activities[0].ActivityID); err == nil {
+		t.Errorf("expected reverting an already-reverted activity to fail")
+	}
+}
+
+func TestRevertActivityRecreatesDeletedItem(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	def := "before it was deleted"
+	id, err := db.CreateItem(Item{Word: "ephemeral", Type: "Reference", Definition: NewLazyString(&def)})
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	if err := db.DeleteItem(id); err != nil {
+		t.Fatalf("DeleteItem failed: %v", err)
+	}
+
+	activities, err := db.GetActivity(ActivityFilter{EntityType: ActivityEntityItem, EntityID: id, Action: ActivityActionDelete})
+	if err != nil {
+		t.Fatalf("GetActivity failed: %v", err)
+	}
+	if len(activities) != 1 {
+		t.Fatalf("expected 1 delete activity, got %d", len(activities))
+	}
+
+	if err := db.RevertActivity(activities[0].ActivityID); err != nil {
+		t.Fatalf("RevertActivity failed: %v", err)
+	}
+
+	restored, err := db.GetItem(id)
+	if err != nil {
+		t.Fatalf("GetItem failed after revert: %v", err)
+	}
+	if got := restored.Definition.GetOrEmpty(); got != def {
+		t.Errorf("expected definition %q after revert, got %q", def, got)
+	}
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"sync"
+)
+
+// ChangeOp identifies the kind of write a ChangeEvent describes.
+type ChangeOp string
+
+const (
+	ChangeInsert ChangeOp = "insert"
+	ChangeUpdate ChangeOp = "update"
+	ChangeDelete ChangeOp = "delete"
+)
+
+// ChangeEvent describes a single row-level write observed on the database,
+// published to every channel returned by Subscribe so callers like the
+// TUI/CLI can refresh views reactively instead of polling.
+type ChangeEvent struct {
+	Op     ChangeOp
+	Table  string
+	ItemID int64
+}
+
+// changeHub fans a single stream of ChangeEvents out to every active
+// Subscribe call. Delivery is best-effort: a subscriber that falls behind
+// has its oldest pending event dropped rather than blocking the write that
+// triggered the publish.
+type changeHub struct {
+	mu   sync.Mutex
+	subs map[chan ChangeEvent]struct{}
+}
+
+func newChangeHub() *changeHub {
+	return &changeHub{subs: make(map[chan ChangeEvent]struct{})}
+}
+
+func (h *changeHub) subscribe() chan ChangeEvent {
+	ch := make(chan ChangeEvent, 16)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *changeHub) unsubscribe(ch chan ChangeEvent) {
+	h.mu.Lock()
+	delete(h.subs, ch)
+	h.mu.Unlock()
+	close(ch)
+}
+
+func (h *changeHub) publish(ev ChangeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+			// Subscriber is behind; drop its oldest pending event to make
+			// room rather than blocking the writer.
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel of ChangeEvents for every insert/update/delete
+// observed on the database, until ctx is canceled. For SQLite this is
+// driven by sqlite3's update_hook (wired up in openSQLite). Backends without
+// hook support (Postgres, for now - see the related LISTEN/NOTIFY request)
+// still return a channel, it just never receives anything, so callers can
+// Subscribe unconditionally regardless of backend.
+func (db *DB) Subscribe(ctx context.Context) (<-chan ChangeEvent, error) {
+	ch := db.hub.subscribe()
+	go func() {
+		<-ctx.Done()
+		db.hub.unsubscribe(ch)
+	}()
+	return ch, nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"os"
+	"testing"
+)
+
+// conformanceDBs returns every backend TestConformanceCRUD runs against:
+// SQLite always (via setupTestDB), and Postgres too when POETRY_POSTGRES_DSN
+// is set, since standing up a Postgres server isn't something this suite can
+// assume.
+func conformanceDBs(t *testing.T) map[string]*DB {
+	t.Helper()
+
+	dbs := map[string]*DB{
+		DriverSQLite: setupTestDB(t),
+	}
+
+	if dsn := os.Getenv("POETRY_POSTGRES_DSN"); dsn != "" {
+		pg, err := Open(DriverPostgres, dsn)
+		if err != nil {
+			t.Fatalf("failed to open POETRY_POSTGRES_DSN: %v", err)
+		}
+		t.Cleanup(func() { _ = pg.Close() })
+		dbs[DriverPostgres] = pg
+	}
+
+	return dbs
+}
+
+// TestConformanceCRUD runs the same CRUD sequence against every backend
+// returned by conformanceDBs, so CreateItem/GetItemByWord/UpdateItem/
+// CreateLink/GetItemLinks/ToggleItemMark/GetMarkedItems/DeleteItem/
+// SearchItems behave identically whether rebind is a no-op (SQLite) or
+// rewriting "?" to "$N" (Postgres).
+func TestConformanceCRUD(t *testing.T) {
+	for driver, db := range conformanceDBs(t) {
+		t.Run(driver, func(t *testing.T) {
+			def := "The art of rhythmical composition"
+			id, err := db.CreateItem(Item{Word: "poetry", Type: "Reference", Definition: NewLazyString(&def)})
+			if err != nil {
+				t.Fatalf("CreateItem failed: %v", err)
+			}
+
+			byWord, err := db.GetItemByWord("POETRY")
+			if err != nil {
+				t.Fatalf("GetItemByWord failed: %v", err)
+			}
+			if byWord.ItemID != id {
+				t.Errorf("GetItemByWord: expected item %d, got %d", id, byWord.ItemID)
+			}
+
+			otherDef := "A literary work"
+			otherID, err := db.CreateItem(Item{Word: "poem", Type: "Reference", Definition: NewLazyString(&otherDef)})
+			if err != nil {
+				t.Fatalf("CreateItem (second item) failed: %v", err)
+			}
+
+			if err := db.CreateLink(id, otherID, "related"); err != nil {
+				t.Fatalf("CreateLink failed: %v", err)
+			}
+			links, err := db.GetItemLinks(id)
+			if err != nil {
+				t.Fatalf("GetItemLinks failed: %v", err)
+			}
+			if len(links) != 1 {
+				t.Fatalf("expected 1 link, got %d", len(links))
+			}
+
+			if err := db.ToggleItemMark(id, true); err != nil {
+				t.Fatalf("ToggleItemMark failed: %v", err)
+			}
+			marked, err := db.GetMarkedItems()
+			if err != nil {
+				t.Fatalf("GetMarkedItems failed: %v", err)
+			}
+			if len(marked) != 1 || marked[0].ItemID != id {
+				t.Fatalf("expected only item %d marked, got %+v", id, marked)
+			}
+
+			updatedDef := "The art of rhythmical composition in verse"
+			if err := db.UpdateItem(Item{ItemID: id, Word: "poetry", Type: "Reference", Definition: NewLazyString(&updatedDef)}); err != nil {
+				t.Fatalf("UpdateItem failed: %v", err)
+			}
+			reloaded, err := db.GetItemByWord("poetry")
+			if err != nil {
+				t.Fatalf("GetItemByWord after update failed: %v", err)
+			}
+			gotDef, _ := reloaded.Definition.Get()
+			if gotDef == nil || *gotDef != updatedDef {
+				t.Errorf("expected updated definition %q, got %v", updatedDef, gotDef)
+			}
+
+			hits, err := db.SearchItems("verse")
+			if err != nil {
+				t.Fatalf("SearchItems failed: %v", err)
+			}
+			found := false
+			for _, item := range hits {
+				if item.ItemID == id {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected SearchItems(%q) to find item %d, got %+v", "verse", id, hits)
+			}
+
+			if err := db.DeleteItem(id); err != nil {
+				t.Fatalf("DeleteItem failed: %v", err)
+			}
+			if _, err := db.GetItemByWord("poetry"); err == nil {
+				t.Error("expected GetItemByWord to fail after DeleteItem")
+			}
+		})
+	}
+}
@@ -1,17 +1,28 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
-	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/assets"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/flagstore"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/fts5"
 	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/jmoiron/sqlx"
 	"github.com/mattn/go-sqlite3"
 )
 
@@ -21,21 +32,192 @@ import (
 func init() {
 	sql.Register("sqlite3_regexp", &sqlite3.SQLiteDriver{
 		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
-			return conn.RegisterFunc("regexp", func(re, s string) (bool, error) {
+			if err := conn.RegisterFunc("regexp", func(re, s string) (bool, error) {
 				return regexp.MatchString(re, s)
-			}, true)
+			}, true); err != nil {
+				return err
+			}
+
+			// Wire the connection's update_hook to whichever changeHub is
+			// "pending" - see openSQLite, which holds pendingHubMu for the
+			// duration of opening a DB so every connection it establishes
+			// (main + read-only) picks up that DB's hub rather than a
+			// concurrently-opening one's.
+			pendingHubMu.Lock()
+			hub := pendingHub
+			pendingHubMu.Unlock()
+			if hub != nil {
+				conn.RegisterUpdateHook(func(op int, _, table string, rowID int64) {
+					hub.publish(ChangeEvent{Op: changeOpFromSQLite(op), Table: table, ItemID: rowID})
+				})
+			}
+			return nil
 		},
 	})
 }
 
+// pendingHub and pendingHubMu let the "sqlite3_regexp" driver's ConnectHook
+// (registered once, globally, in init above) bind each new connection's
+// update_hook to the changeHub of whichever DB is in the middle of opening
+// it. openSQLite holds pendingHubMu for its whole body so this works even
+// though ConnectHook itself has no way to know which *DB a connection
+// belongs to.
+var (
+	pendingHubMu sync.Mutex
+	pendingHub   *changeHub
+)
+
+// changeOpFromSQLite maps sqlite3's update_hook operation codes to the
+// backend-agnostic ChangeOp Subscribe exposes.
+func changeOpFromSQLite(op int) ChangeOp {
+	switch op {
+	case sqlite3.SQLITE_INSERT:
+		return ChangeInsert
+	case sqlite3.SQLITE_UPDATE:
+		return ChangeUpdate
+	case sqlite3.SQLITE_DELETE:
+		return ChangeDelete
+	default:
+		return ChangeOp(fmt.Sprintf("unknown(%d)", op))
+	}
+}
+
 // DB represents the database connection
 type DB struct {
-	conn *sql.DB
+	conn   *sqlx.DB
+	roConn *sqlx.DB
+
+	// driver is the backend this DB was opened with (DriverSQLite or
+	// DriverPostgres, see backend.go). It gates driver-specific behavior:
+	// rebind's "?" -> "$N" placeholder rewriting and SearchItems' choice of
+	// full-text strategy (FTS5/LIKE vs tsvector).
+	driver string
+
+	// searchIndex is the Bleve full-text index kept alongside the SQLite
+	// store (see search_index.go). It is nil when the index directory
+	// couldn't be opened or created, or when the backend isn't SQLite, in
+	// which case SearchItemsRanked falls back to the LIKE-based SearchItems
+	// and IndexItem/UnindexItem are no-ops.
+	searchIndex bleve.Index
+
+	// hub fans out ChangeEvents to every Subscribe caller. Always
+	// non-nil once Open/openSQLite/openPostgres returns.
+	hub *changeHub
+
+	// stmts caches *sqlx.Stmt handles by (already-rebound) query text, so
+	// hot paths - GetItem/GetItemByWord reads, and single-row writes like
+	// CreateLink/DeleteItem/ToggleItemMark - pay SQL parse and plan cost
+	// once instead of on every call. See prepared. Tx's CRUD methods reuse
+	// the same cache via Tx.stmt, which rebinds a cached statement to run
+	// inside the transaction instead of preparing the query text again.
+	stmts   map[string]*sqlx.Stmt
+	stmtsMu sync.Mutex
+
+	// assets is where image/TTS blobs are actually read from and written
+	// to (see backend/assets). It defaults to a LocalFSStore over the
+	// existing images/tts-cache directories; callers running against a
+	// remote Postgres backend can swap in an S3Store via SetAssetStore.
+	assets assets.AssetStore
+
+	// resolver resolves a reference word (as it appears in a poem's text)
+	// to an existing item, trying possessive/plural/verb-form variants
+	// (see WordResolver). Defaults to NewWordResolver(nil); a future
+	// stemmer-backed resolver can be swapped in via SetWordResolver.
+	resolver WordResolver
+
+	// flags is where SyncFileFlags persists each item's has_image/has_tts
+	// state (see backend/flagstore). Defaults to a flagstore.SQLiteFlagStore
+	// over this DB's own connection, so has_image/has_tts stay queryable
+	// from SQL (SearchItemsWithOptions' filters rely on this); callers that
+	// opt into flagstore.BoltFlagStore via SetFlagStore give that up in
+	// exchange for avoiding the cgo-dependent SQLite path.
+	flags flagstore.FlagStore
+}
+
+// SetFlagStore overrides the FlagStore SyncFileFlags batches has_image/
+// has_tts updates into, in place of the flagstore.SQLiteFlagStore
+// openSQLite/openPostgres construct by default. See flagstore.BoltFlagStore
+// for the tradeoff this gives up.
+func (db *DB) SetFlagStore(store flagstore.FlagStore) {
+	db.flags = store
+}
+
+// SetWordResolver overrides the WordResolver CreateLinkOrRemoveTags (and
+// any parser/tag-fixup caller of ResolveWord) uses in place of the default
+// rule-based cascade - e.g. to plug in an FTS5 tokenizer-backed stemmer.
+func (db *DB) SetWordResolver(resolver WordResolver) {
+	db.resolver = resolver
+}
+
+// ResolveWord resolves refWord to an existing item via db's WordResolver,
+// trying exact match, case-fold, possessive strip, plural, and verb-form
+// candidates in turn. It's exposed publicly so the parser/tag-fixup
+// pipeline can reuse the same cascade CreateLinkOrRemoveTags uses.
+func (db *DB) ResolveWord(refWord string) (*Item, MatchReason, error) {
+	return db.resolver.Resolve(db, refWord)
+}
+
+// SetAssetStore overrides the AssetStore SyncFileFlags checks blobs
+// against, in place of the LocalFSStore openSQLite/openPostgres construct
+// by default. Deployments backed by assets.S3Store (see backend/assets)
+// call this once after Open/NewDB.
+func (db *DB) SetAssetStore(store assets.AssetStore) {
+	db.assets = store
+}
+
+// legacyLayoutMigrator is the optional interface an AssetStore backend can
+// implement to support ShardAssetLayout - only assets.LocalFSStore does,
+// since sharding by hash prefix is a filesystem-directory concern that a
+// key-addressed store like assets.S3Store has no equivalent of.
+type legacyLayoutMigrator interface {
+	MigrateLegacyLayout(kind assets.Kind) (int, error)
+}
+
+// ShardAssetLayout moves any image/TTS blob still stored under the
+// pre-sharding flat layout into the two-level, hash-prefix-sharded layout
+// (see assets.LocalFSStore), and reports how many blobs it moved. It's a
+// no-op returning 0 for backends, like assets.S3Store, that don't implement
+// legacyLayoutMigrator.
+func (db *DB) ShardAssetLayout() (int, error) {
+	migrator, ok := db.assets.(legacyLayoutMigrator)
+	if !ok {
+		return 0, nil
+	}
+
+	moved := 0
+	for _, kind := range []assets.Kind{assets.KindImage, assets.KindTTS} {
+		n, err := migrator.MigrateLegacyLayout(kind)
+		moved += n
+		if err != nil {
+			return moved, fmt.Errorf("failed to shard %s layout: %w", kind, err)
+		}
+	}
+	return moved, nil
 }
 
 // Conn returns the underlying sql.DB connection
 func (db *DB) Conn() *sql.DB {
-	return db.conn
+	return db.conn.DB
+}
+
+// Assets returns the AssetStore SyncFileFlags checks blobs against, so
+// callers outside this package (services.SyncTTSTags) can read and rewrite
+// a cached blob's bytes through the same abstraction rather than assuming a
+// local filesystem layout.
+func (db *DB) Assets() assets.AssetStore {
+	return db.assets
+}
+
+// ReadOnlyConn returns a dedicated connection opened with SQLite's
+// query_only pragma, so a statement that slips past a validator (e.g.
+// AdHocQueryComponent's) still cannot mutate the database. Falls back to
+// the regular connection if the read-only one couldn't be opened (older
+// callers that build a DB directly rather than via NewDB).
+func (db *DB) ReadOnlyConn() *sql.DB {
+	if db.roConn != nil {
+		return db.roConn.DB
+	}
+	return db.conn.DB
 }
 
 // Item represents a word/term entry
@@ -43,30 +225,47 @@ type Item struct {
 	ItemID      int              `json:"itemId"`
 	Word        string           `json:"word"`
 	Type        string           `json:"type"`
-	Definition  *string          `json:"definition"`
+	Definition  LazyString       `json:"definition"`
 	ParsedDef   []parser.Segment `json:"parsedDefinition,omitempty"`
-	Derivation  *string          `json:"derivation"`
-	Appendicies *string          `json:"appendicies"`
+	Derivation  LazyString       `json:"derivation"`
+	Appendicies LazyString       `json:"appendicies"`
 	Source      *string          `json:"source"`
 	SourcePg    *string          `json:"sourcePg"`
 	Mark        *string          `json:"mark"`
+	ImageFiles  []string         `json:"imageFiles,omitempty"`
 	CreatedAt   time.Time        `json:"createdAt" ts_type:"Date"`
 	ModifiedAt  time.Time        `json:"modifiedAt" ts_type:"Date"`
 }
 
+// Images returns the item's image variants (e.g. "hero", "thumb", "alt_1")
+// as recorded by the last SyncFileFlags run, or an empty slice if none were
+// loaded or none exist. Only GetItem/GetItemByWord/GetRandomItem populate
+// ImageFiles today; an Item from any other query simply reports no images.
+func (i Item) Images() []string {
+	if i.ImageFiles == nil {
+		return []string{}
+	}
+	return i.ImageFiles
+}
+
 // Link represents a relationship between items
 type Link struct {
-	LinkID            int       `json:"linkId"`
-	SourceItemID      int       `json:"sourceItemId"`
-	DestinationItemID int       `json:"destinationItemId"`
-	LinkType          string    `json:"linkType"`
-	CreatedAt         time.Time `json:"createdAt" ts_type:"Date"`
+	LinkID            int       `json:"linkId" db:"link_id"`
+	SourceItemID      int       `json:"sourceItemId" db:"source_item_id"`
+	DestinationItemID int       `json:"destinationItemId" db:"destination_item_id"`
+	LinkType          string    `json:"linkType" db:"link_type"`
+	CreatedAt         time.Time `json:"createdAt" ts_type:"Date" db:"created_at"`
 }
 
 // GraphData represents a subset of the graph
 type GraphData struct {
 	Items []Item `json:"items"`
 	Links []Link `json:"links"`
+
+	// Distance maps each Items entry's ItemID to its BFS hop count from
+	// GetEgoGraph's centerNodeID (0 for the center itself), so callers can
+	// render a concentric layout without recomputing the traversal.
+	Distance map[int]int `json:"distance"`
 }
 
 // ItemWithStats includes connection statistics
@@ -79,42 +278,42 @@ type ItemWithStats struct {
 
 // Cliche represents a cliche entry
 type Cliche struct {
-	ClicheID   int       `json:"clicheId"`
-	Phrase     string    `json:"phrase"`
-	Definition *string   `json:"definition"`
-	CreatedAt  time.Time `json:"createdAt" ts_type:"Date"`
+	ClicheID   int       `json:"clicheId" db:"cliche_id"`
+	Phrase     string    `json:"phrase" db:"phrase"`
+	Definition *string   `json:"definition" db:"definition"`
+	CreatedAt  time.Time `json:"createdAt" ts_type:"Date" db:"created_at"`
 }
 
 // Name represents a proper name entry
 type Name struct {
-	NameID      int       `json:"nameId"`
-	Name        string    `json:"name"`
-	Type        *string   `json:"type"`
-	Gender      *string   `json:"gender"`
-	Description *string   `json:"description"`
-	Notes       *string   `json:"notes"`
-	CreatedAt   time.Time `json:"createdAt" ts_type:"Date"`
+	NameID      int       `json:"nameId" db:"name_id"`
+	Name        string    `json:"name" db:"name"`
+	Type        *string   `json:"type" db:"type"`
+	Gender      *string   `json:"gender" db:"gender"`
+	Description *string   `json:"description" db:"description"`
+	Notes       *string   `json:"notes" db:"notes"`
+	CreatedAt   time.Time `json:"createdAt" ts_type:"Date" db:"created_at"`
 }
 
 // LiteraryTerm represents a literary term entry
 type LiteraryTerm struct {
-	TermID        int       `json:"termId"`
-	Term          string    `json:"term"`
-	Type          *string   `json:"type"`
-	Definition    *string   `json:"definition"`
-	Examples      *string   `json:"examples"`
-	Notes         *string   `json:"notes"`
-	CreatedAt     time.Time `json:"createdAt" ts_type:"Date"`
-	ExistsInItems bool      `json:"existsInItems"`
+	TermID        int       `json:"termId" db:"term_id"`
+	Term          string    `json:"term" db:"term"`
+	Type          *string   `json:"type" db:"type"`
+	Definition    *string   `json:"definition" db:"definition"`
+	Examples      *string   `json:"examples" db:"examples"`
+	Notes         *string   `json:"notes" db:"notes"`
+	CreatedAt     time.Time `json:"createdAt" ts_type:"Date" db:"created_at"`
+	ExistsInItems bool      `json:"existsInItems" db:"exists_in_items"`
 }
 
 // Source represents a reference source entry
 type Source struct {
-	SourceID  int       `json:"sourceId"`
-	Title     string    `json:"title"`
-	Author    *string   `json:"author"`
-	Notes     *string   `json:"notes"`
-	CreatedAt time.Time `json:"createdAt" ts_type:"Date"`
+	SourceID  int       `json:"sourceId" db:"source_id"`
+	Title     string    `json:"title" db:"title"`
+	Author    *string   `json:"author" db:"author"`
+	Notes     *string   `json:"notes" db:"notes"`
+	CreatedAt time.Time `json:"createdAt" ts_type:"Date" db:"created_at"`
 }
 
 // SearchOptions represents advanced search parameters
@@ -126,6 +325,62 @@ type SearchOptions struct {
 	CaseSensitive bool     `json:"caseSensitive"` // Case sensitivity for regex mode
 	HasImage      bool     `json:"hasImage"`      // Filter items that have images
 	HasTts        bool     `json:"hasTts"`        // Filter items that have TTS audio
+	ExcludeTypes  []string `json:"excludeTypes"`  // Exclude item types, applied after Types
+
+	// Marks filters on the items.mark column. An empty slice means no mark
+	// filtering. The sentinels "any" and "none" mean "has some mark" and
+	// "has no mark" respectively (mark is nil/empty for most items); any
+	// other value is matched against mark literally, so a deployment that
+	// starts using mark for something other than ToggleItemMark's "1" can
+	// filter on its own values too.
+	Marks []string `json:"marks"`
+
+	MinConnections int `json:"minConnections"` // Minimum link count (source or destination), 0 means no minimum
+	MaxConnections int `json:"maxConnections"` // Maximum link count (source or destination), 0 means no maximum
+
+	// LinkedTo restricts results to items reachable from one of these item
+	// IDs within LinkedToDepth hops of the links table (undirected - either
+	// source or destination). An empty slice means no connection filtering.
+	LinkedTo []int `json:"linkedTo"`
+	// LinkedToDepth caps the traversal LinkedTo performs; 0 defaults to 2.
+	// It exists to keep the recursive CTE bounded on a densely linked
+	// graph rather than letting it walk the whole items table.
+	LinkedToDepth int `json:"linkedToDepth"`
+
+	CreatedAfter   time.Time `json:"createdAfter"`   // Zero value means no lower bound
+	CreatedBefore  time.Time `json:"createdBefore"`  // Zero value means no upper bound
+	ModifiedAfter  time.Time `json:"modifiedAfter"`  // Zero value means no lower bound
+	ModifiedBefore time.Time `json:"modifiedBefore"` // Zero value means no upper bound
+
+	// MissingFields filters to items where each named column is NULL or
+	// empty, e.g. []string{"derivation"} for items still missing an
+	// etymology. Unrecognized names are ignored rather than erroring, since
+	// this is most often built from a fixed UI checkbox list.
+	MissingFields []string `json:"missingFields"`
+
+	// Fields restricts FTS5 mode to one or more items_fts columns (word,
+	// definition, derivation, appendicies) - equivalent to prefixing every
+	// unscoped term in Query with "word:", "definition:", etc. via OR.
+	// Ignored outside FTS5 mode (empty Query, UseRegex). A column scope
+	// written directly into Query (e.g. "definition:sonnet") always wins
+	// over Fields for that term - see fts5.ScopeFields.
+	Fields []string `json:"fields"`
+
+	// WithSnippet and WithHighlight are reserved for SearchItemsWithSnippets'
+	// FTS5 snippet()/highlight() output, which it currently always includes
+	// in mode with a non-empty, non-regex Query regardless of these flags -
+	// see SearchItemsWithSnippets. SearchItemsWithOptions itself ignores
+	// both fields since it returns []Item, not []SearchResult.
+	WithSnippet   bool `json:"withSnippet"`
+	WithHighlight bool `json:"withHighlight"`
+
+	// Pagination, modeled on S3 ListObjects-style cursor listing. These are
+	// consumed by ItemService.ListItems rather than by SearchItemsWithOptions
+	// itself, since pagination is applied to the already-ordered result set.
+	MaxKeys           int    `json:"maxKeys"`           // Max items per page (0 means use the default page size)
+	Prefix            string `json:"prefix"`            // Only include items whose Word starts with this prefix
+	Delimiter         string `json:"delimiter"`         // Collapse items sharing a prefix up to this delimiter into Prefixes
+	ContinuationToken string `json:"continuationToken"` // Opaque token from a prior ListItemsResult.NextContinuationToken
 }
 
 // DashboardStats represents extended database statistics
@@ -141,33 +396,68 @@ type DashboardStats struct {
 	ErrorCount  int `json:"errorCount"`  // Sum of Orphans + Stubs
 }
 
+// SearchResult is one ranked match from SearchItemsWithSnippets: the item
+// itself, a relevance Rank, an ellipsized Snippet built from whichever
+// column(s) matched, and Highlights holding a "<b>...</b>"-marked copy of
+// each of fts5.Fields keyed by field name, for rendering the matched terms
+// inline. Rank's direction is backend-specific - SQLite's bm25 is lower-is-
+// better, Postgres's ts_rank is higher-is-better - so it's only meaningful
+// for sorting results from a single call, not for comparing across drivers.
+type SearchResult struct {
+	Item       Item              `json:"item"`
+	Rank       float64           `json:"rank"`
+	Snippet    string            `json:"snippet,omitempty"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
 // HubItem represents a highly connected item
 type HubItem struct {
-	ItemID    int     `json:"itemId"`
-	Word      string  `json:"word"`
-	LinkCount int     `json:"linkCount"`
-	Mark      *string `json:"mark"`
-}
-
-// normalizeFTS5Query converts lowercase boolean operators to uppercase for FTS5
-// and replaces hyphens with spaces to avoid FTS5 column operator syntax errors
-func normalizeFTS5Query(query string) string {
-	// Replace hyphens with spaces to prevent FTS5 from treating them as column operators
-	query = strings.ReplaceAll(query, "-", " ")
-
-	// Replace word-boundary surrounded boolean operators (case-insensitive)
-	re := regexp.MustCompile(`(?i)\b(and|or|not)\b`)
-	return re.ReplaceAllStringFunc(query, func(match string) string {
-		return strings.ToUpper(match)
-	})
+	ItemID    int     `json:"itemId" db:"item_id"`
+	Word      string  `json:"word" db:"word"`
+	LinkCount int     `json:"linkCount" db:"link_count"`
+	Mark      *string `json:"mark" db:"mark"`
+}
+
+// buildFTS5Query parses query with the fts5 package's grammar (terms,
+// phrases, prefix "foo*", NEAR/N, field scoping, grouped AND/OR/NOT) and
+// renders it to a MATCH expression, scoped to fields if given. It replaces
+// the old normalizeFTS5Query, which only uppercased boolean keywords and
+// stripped hyphens.
+func buildFTS5Query(query string, fields []string) string {
+	node := fts5.Parse(query)
+	if len(fields) > 0 {
+		node = fts5.ScopeFields(node, fields)
+	}
+	return fts5.Build(node)
 }
 
-// NewDB creates a new database connection
+// NewDB creates a new SQLite-backed database connection. It's a thin
+// wrapper around Open(DriverSQLite, dbPath) kept for the many existing
+// callers that only ever spoke SQLite.
 func NewDB(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3_regexp", dbPath)
+	return Open(DriverSQLite, dbPath)
+}
+
+// openSQLite creates a new SQLite database connection at dbPath.
+func openSQLite(dbPath string) (*DB, error) {
+	hub := newChangeHub()
+
+	// Hold pendingHubMu for the rest of this function so every connection
+	// opened below (main + read-only) binds its update_hook to hub instead
+	// of a different DB's, should another Open race with this one. See the
+	// "sqlite3_regexp" ConnectHook in init above.
+	pendingHubMu.Lock()
+	pendingHub = hub
+	defer func() {
+		pendingHub = nil
+		pendingHubMu.Unlock()
+	}()
+
+	sqlConn, err := sql.Open("sqlite3_regexp", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	conn := sqlx.NewDb(sqlConn, DriverSQLite)
 
 	// Configure SQLite for concurrent writes
 	// WAL mode allows concurrent readers during writes
@@ -185,6 +475,179 @@ func NewDB(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
+	// Ensure the tags index table exists. Databases shipped before the
+	// extended tag grammar (hashtags/categories/frontmatter) won't have it,
+	// so it's created here rather than assumed present like the rest of the
+	// schema.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS tags (
+			tag_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			item_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			tag TEXT NOT NULL COLLATE NOCASE,
+			FOREIGN KEY (item_id) REFERENCES items(item_id) ON DELETE CASCADE,
+			UNIQUE(item_id, kind, tag)
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create tags table: %w", err)
+	}
+	if _, err := conn.Exec("CREATE INDEX IF NOT EXISTS idx_tags_kind_tag ON tags(kind, tag COLLATE NOCASE)"); err != nil {
+		return nil, fmt.Errorf("failed to create tags index: %w", err)
+	}
+
+	// Ensure the TTS content-hash table exists, for the same reason as tags:
+	// databases shipped before the content-addressable TTS cache won't have
+	// it. It maps an item to the hash of the text it last spoke, so
+	// UpdateItem can tell whether the Definition actually changed.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS tts_cache (
+			item_id INTEGER PRIMARY KEY,
+			content_hash TEXT NOT NULL,
+			FOREIGN KEY (item_id) REFERENCES items(item_id) ON DELETE CASCADE
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create tts_cache table: %w", err)
+	}
+	if _, err := conn.Exec("CREATE INDEX IF NOT EXISTS idx_tts_cache_hash ON tts_cache(content_hash)"); err != nil {
+		return nil, fmt.Errorf("failed to create tts_cache index: %w", err)
+	}
+
+	// Ensure the image content-hash table exists, mirroring tts_cache: it
+	// maps an item to the hash of the image blob it's currently pointing
+	// at, so identical images across items share one file on disk and
+	// GarbageCollectBlobs can tell when a blob has no refs left.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS image_cache (
+			item_id INTEGER PRIMARY KEY,
+			content_hash TEXT NOT NULL,
+			FOREIGN KEY (item_id) REFERENCES items(item_id) ON DELETE CASCADE
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create image_cache table: %w", err)
+	}
+	if _, err := conn.Exec("CREATE INDEX IF NOT EXISTS idx_image_cache_hash ON image_cache(content_hash)"); err != nil {
+		return nil, fmt.Errorf("failed to create image_cache index: %w", err)
+	}
+
+	// Ensure the image_variants table exists, mirroring image_cache: it maps
+	// an item to a named image variant (e.g. "thumb", "alt_1") beyond the
+	// single "hero" image image_cache already tracks - see SyncFileFlags and
+	// Item.Images.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS image_variants (
+			item_id INTEGER NOT NULL,
+			variant TEXT NOT NULL,
+			content_hash TEXT NOT NULL,
+			PRIMARY KEY (item_id, variant),
+			FOREIGN KEY (item_id) REFERENCES items(item_id) ON DELETE CASCADE
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create image_variants table: %w", err)
+	}
+	if _, err := conn.Exec("CREATE INDEX IF NOT EXISTS idx_image_variants_hash ON image_variants(content_hash)"); err != nil {
+		return nil, fmt.Errorf("failed to create image_variants index: %w", err)
+	}
+
+	// Ensure the content-pack tracking table exists: one row per content
+	// pack (see backend/seeding.PackManager) whose SQL fragment has already
+	// been applied, so seeding.ApplyPackData's idempotency check survives
+	// across restarts without re-running a pack's inserts every time.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS content_packs_applied (
+			pack_name TEXT PRIMARY KEY,
+			version TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create content_packs_applied table: %w", err)
+	}
+
+	// Ensure the merge undo journal exists, for the same reason as tags and
+	// tts_cache above: it backs a feature (resumable, undoable
+	// MergeDuplicateItems) added after the schema in data.tar.gz was built.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS merge_journal (
+			journal_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			original_item_id INTEGER NOT NULL,
+			duplicate_item_id INTEGER NOT NULL,
+			undo_data TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			undone_at DATETIME
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create merge_journal table: %w", err)
+	}
+
+	// Ensure the schema_migrations table exists, for the same reason as the
+	// tables above: it backs the versioned migrations.Runner, recording which
+	// migrations have already been applied so they're never rerun.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	// Ensure the saved_queries table exists, for the same reason as the
+	// tables above: it backs SavedQueryComponent, added after the schema in
+	// data.tar.gz was built.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS saved_queries (
+			name TEXT PRIMARY KEY,
+			description TEXT NOT NULL DEFAULT '',
+			query TEXT NOT NULL,
+			param_schema TEXT NOT NULL DEFAULT '[]',
+			tags TEXT NOT NULL DEFAULT '[]',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_run_at DATETIME
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create saved_queries table: %w", err)
+	}
+
+	// Ensure the saved_searches table exists, for the same reason as
+	// saved_queries above: it backs SaveSearch/ListSavedSearches/
+	// RunSavedSearch, added after the schema in data.tar.gz was built.
+	// Unlike saved_queries, which stores a raw SQL snippet, options_json
+	// stores an encoded SearchOptions, so RunSavedSearch replays it
+	// through SearchItemsWithOptions instead of executing SQL directly.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS saved_searches (
+			saved_search_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			options_json TEXT NOT NULL DEFAULT '{}',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create saved_searches table: %w", err)
+	}
+
+	// Ensure the activity table exists, for the same reason as the tables
+	// above: it backs the audit trail recordActivity/GetActivity/
+	// RevertActivity write and read, added after the schema in data.tar.gz
+	// was built.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS activity (
+			activity_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			actor TEXT NOT NULL DEFAULT '',
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER NOT NULL,
+			action TEXT NOT NULL,
+			before_json TEXT,
+			after_json TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			reverted_at DATETIME
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create activity table: %w", err)
+	}
+	if _, err := conn.Exec("CREATE INDEX IF NOT EXISTS idx_activity_entity ON activity(entity_type, entity_id)"); err != nil {
+		return nil, fmt.Errorf("failed to create activity entity index: %w", err)
+	}
+
 	// Drop FTS5 triggers if they exist (FTS5 module not available)
 	// This allows CRUD operations to work without FTS5
 	triggers := []string{"items_ai", "items_ad", "items_au", "cliches_ai", "cliches_ad", "cliches_au", "literary_terms_ai", "literary_terms_ad", "literary_terms_au"}
@@ -199,7 +662,53 @@ func NewDB(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{conn: conn}, nil
+	// Open a second, read-only handle (query_only pragma) for callers like
+	// AdHocQueryComponent that run user-supplied SQL: even if a validator
+	// bug let a mutating statement through, this connection refuses to
+	// execute it at the SQLite level.
+	roSQLConn, err := sql.Open("sqlite3_regexp", dbPath+"?mode=ro&_query_only=1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read-only database handle: %w", err)
+	}
+	if err := roSQLConn.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping read-only database handle: %w", err)
+	}
+	roConn := sqlx.NewDb(roSQLConn, DriverSQLite)
+
+	// Open (or create) the Bleve full-text index alongside the database
+	// file. A failure here isn't fatal - SearchItemsRanked and IndexItem/
+	// UnindexItem fall back to behaving as if no index exists.
+	searchIndex, err := openOrCreateSearchIndex(filepath.Join(filepath.Dir(dbPath), searchIndexDir))
+	if err != nil {
+		slog.Warn("[DB] Search index unavailable, falling back to LIKE-based search", "error", err)
+		searchIndex = nil
+	}
+
+	// Default to a LocalFSStore over the existing images/tts-cache
+	// directories; SetAssetStore can swap this for assets.S3Store after
+	// Open returns.
+	assetStore, err := defaultAssetStore()
+	if err != nil {
+		slog.Warn("[DB] Asset store unavailable, SyncFileFlags will see every item as missing its blob", "error", err)
+		assetStore = nil
+	}
+
+	return &DB{conn: conn, roConn: roConn, driver: DriverSQLite, searchIndex: searchIndex, hub: hub, assets: assetStore, resolver: NewWordResolver(nil), flags: flagstore.NewSQLiteFlagStore(conn.DB, nil)}, nil
+}
+
+// defaultAssetStore builds the LocalFSStore both openSQLite and openPostgres
+// default DB.assets to, over the images/tts-cache directories paths already
+// resolves.
+func defaultAssetStore() (assets.AssetStore, error) {
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get images dir: %w", err)
+	}
+	ttsDir, err := paths.TTSCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tts dir: %w", err)
+	}
+	return assets.Open(assets.BackendLocal, assets.Config{ImagesDir: imagesDir, TTSDir: ttsDir})
 }
 
 // Checkpoint flushes the WAL to the main database file
@@ -245,6 +754,31 @@ func (db *DB) Close() error {
 	if err != nil {
 		slog.Warn("[DB] Warning: WAL checkpoint failed", "error", err)
 	}
+	if db.roConn != nil {
+		if err := db.roConn.Close(); err != nil {
+			slog.Warn("[DB] Warning: failed to close read-only handle", "error", err)
+		}
+	}
+	if db.searchIndex != nil {
+		if err := db.searchIndex.Close(); err != nil {
+			slog.Warn("[DB] Warning: failed to close search index", "error", err)
+		}
+	}
+	if db.flags != nil {
+		if err := db.flags.Close(); err != nil {
+			slog.Warn("[DB] Warning: failed to close flag store", "error", err)
+		}
+	}
+
+	db.stmtsMu.Lock()
+	for _, stmt := range db.stmts {
+		if err := stmt.Close(); err != nil {
+			slog.Warn("[DB] Warning: failed to close prepared statement", "error", err)
+		}
+	}
+	db.stmts = nil
+	db.stmtsMu.Unlock()
+
 	return db.conn.Close()
 }
 
@@ -286,7 +820,7 @@ func (db *DB) GetExtendedStats() (*DashboardStats, error) {
 
 	// Orphans (Items with no links)
 	var orphanCount int
-	queryOrphans := MustLoadQuery("orphans")
+	queryOrphans := db.mustLoadDialectQuery("orphans")
 	if err := db.conn.QueryRow(queryOrphans).Scan(&orphanCount); err != nil {
 		return nil, fmt.Errorf("failed to count orphans: %w", err)
 	}
@@ -294,86 +828,72 @@ func (db *DB) GetExtendedStats() (*DashboardStats, error) {
 	// Quotes (Titles with brackets in definition)
 	// Note: This SQL query approximates the logic in parser.IsPoem()
 	// We use LIKE for performance instead of fetching all rows to check balanced brackets
-	queryQuotes := MustLoadQuery("quotes_count")
+	queryQuotes := db.mustLoadDialectQuery("quotes_count")
 	if err := db.conn.QueryRow(queryQuotes).Scan(&stats.QuoteCount); err != nil {
 		return nil, fmt.Errorf("failed to count quotes: %w", err)
 	}
 
 	// Cited (Items with a source)
-	queryCited := MustLoadQuery("cited_count")
+	queryCited := db.mustLoadDialectQuery("cited_count")
 	if err := db.conn.QueryRow(queryCited).Scan(&stats.CitedCount); err != nil {
 		return nil, fmt.Errorf("failed to count cited items: %w", err)
 	}
 
 	// Stubs (Items with no definition)
 	var stubCount int
-	queryStubs := MustLoadQuery("stubs_count")
+	queryStubs := db.mustLoadDialectQuery("stubs_count")
 	if err := db.conn.QueryRow(queryStubs).Scan(&stubCount); err != nil {
 		return nil, fmt.Errorf("failed to count stubs: %w", err)
 	}
 
 	// Writers
-	queryWriters := MustLoadQuery("writers_count")
+	queryWriters := db.mustLoadDialectQuery("writers_count")
 	if err := db.conn.QueryRow(queryWriters).Scan(&stats.WriterCount); err != nil {
 		return nil, fmt.Errorf("failed to count writers: %w", err)
 	}
 
-	// Poets (Writers with image and poems)
-	queryWritersList := MustLoadQuery("writers")
-	rows, err := db.conn.Query(queryWritersList)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query writers for poet count: %w", err)
+	// Poets (Writers with image and poems). writerPoemCounts is a single
+	// JOIN across writers/links/titles instead of one poems_for_writer
+	// round-trip per writer, and has_image is a plain column now instead of
+	// an os.Stat call per writer - see backend/assets and SyncFileFlags,
+	// which keeps it in sync.
+	var writerPoemCounts []struct {
+		WriterID  int  `db:"writer_id"`
+		HasImage  bool `db:"has_image"`
+		PoemCount int  `db:"poem_count"`
 	}
-	defer func() { _ = rows.Close() }()
-
-	imagesDir, err := constants.GetImagesDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get images dir: %w", err)
+	queryWriterPoemCounts := db.mustLoadDialectQuery("writer_poem_counts")
+	if err := db.conn.Select(&writerPoemCounts, queryWriterPoemCounts); err != nil {
+		return nil, fmt.Errorf("failed to query writer poem counts: %w", err)
 	}
 
 	poetCount := 0
-	for rows.Next() {
-		var itemId int
-		if err := rows.Scan(&itemId); err != nil {
-			continue
-		}
-
-		// Check image
-		imagePath := filepath.Join(imagesDir, fmt.Sprintf("%d.png", itemId))
-		if _, err := os.Stat(imagePath); os.IsNotExist(err) {
+	for _, wpc := range writerPoemCounts {
+		if wpc.PoemCount == 0 || !wpc.HasImage {
 			continue
 		}
 
-		// Check linked poems (incoming links from Titles)
-		var poemCount int
-		queryPoems := MustLoadQuery("poems_for_writer")
-		if err := db.conn.QueryRow(queryPoems, itemId).Scan(&poemCount); err != nil {
-			continue
-		}
-
-		if poemCount > 0 {
-			poetCount++
-		}
+		poetCount++
 	}
 	stats.PoetCount = poetCount
 
 	// Titles
-	queryTitles := MustLoadQuery("titles_count")
+	queryTitles := db.mustLoadDialectQuery("titles_count")
 	if err := db.conn.QueryRow(queryTitles).Scan(&stats.TitleCount); err != nil {
 		return nil, fmt.Errorf("failed to count titles: %w", err)
 	}
 
 	// Words (Reference)
-	queryWords := MustLoadQuery("words_count")
+	queryWords := db.mustLoadDialectQuery("words_count")
 	if err := db.conn.QueryRow(queryWords).Scan(&stats.WordCount); err != nil {
 		return nil, fmt.Errorf("failed to count words: %w", err)
 	}
 
 	// Self Referential Items
 	var selfRefCount int
-	querySelfRef := MustLoadQuery("self_ref_items")
+	querySelfRef := db.mustLoadDialectQuery("self_ref_items")
 
-	rows, err = db.conn.Query(querySelfRef)
+	rows, err := db.conn.Query(querySelfRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query self ref items: %w", err)
 	}
@@ -433,49 +953,30 @@ func (db *DB) GetExtendedStats() (*DashboardStats, error) {
 	return stats, nil
 }
 
-// GetPoetIds returns a list of item IDs for writers that have an image and at least one poem
+// GetPoetIds returns a list of item IDs for writers that have an image and
+// at least one poem. has_image is a plain column (see backend/assets and
+// SyncFileFlags) and the poem check an EXISTS subquery, so this is a single
+// indexed query instead of the old per-writer os.Stat + poem-count
+// round-trip.
 func (db *DB) GetPoetIds() ([]int, error) {
-	rows, err := db.conn.Query("SELECT item_id FROM items WHERE type = 'Writer'")
-	if err != nil {
-		return nil, fmt.Errorf("failed to query writers: %w", err)
-	}
-	defer func() { _ = rows.Close() }()
-
-	imagesDir, err := constants.GetImagesDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get images dir: %w", err)
-	}
+	hasImageLiteral := "1"
+	if db.driver == DriverPostgres {
+		hasImageLiteral = "true"
+	}
+	query := db.rebind(fmt.Sprintf(`
+		SELECT w.item_id
+		FROM items w
+		WHERE w.type = 'Writer' AND w.has_image = %s AND EXISTS (
+			SELECT 1 FROM links l
+			JOIN items i ON l.source_item_id = i.item_id
+			WHERE l.destination_item_id = w.item_id AND i.type = 'Title'
+		)
+	`, hasImageLiteral))
 
 	var poetIds []int
-	for rows.Next() {
-		var itemId int
-		if err := rows.Scan(&itemId); err != nil {
-			continue
-		}
-
-		// Check image
-		imagePath := filepath.Join(imagesDir, fmt.Sprintf("%d.png", itemId))
-		if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-			continue
-		}
-
-		// Check linked poems (incoming links from Titles)
-		var poemCount int
-		queryPoems := `
-			SELECT COUNT(*) 
-			FROM links l 
-			JOIN items i ON l.source_item_id = i.item_id 
-			WHERE l.destination_item_id = ? AND i.type = 'Title'
-		`
-		if err := db.conn.QueryRow(queryPoems, itemId).Scan(&poemCount); err != nil {
-			continue
-		}
-
-		if poemCount > 0 {
-			poetIds = append(poetIds, itemId)
-		}
+	if err := db.conn.Select(&poetIds, query); err != nil {
+		return nil, fmt.Errorf("failed to query poet ids: %w", err)
 	}
-
 	return poetIds, nil
 }
 
@@ -491,25 +992,10 @@ func (db *DB) GetTopHubs(limit int) ([]HubItem, error) {
 		LIMIT ?
 	`
 
-	rows, err := db.conn.Query(query, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get top hubs: %w", err)
-	}
-	defer func() { _ = rows.Close() }()
-
 	var hubs []HubItem
-	for rows.Next() {
-		var hub HubItem
-		if err := rows.Scan(&hub.ItemID, &hub.Word, &hub.LinkCount, &hub.Mark); err != nil {
-			return nil, fmt.Errorf("failed to scan hub item: %w", err)
-		}
-		hubs = append(hubs, hub)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration failed: %w", err)
+	if err := db.conn.Select(&hubs, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to get top hubs: %w", err)
 	}
-
 	return hubs, nil
 }
 
@@ -523,91 +1009,155 @@ func (db *DB) GetMarkedItems() ([]Item, error) {
 		ORDER BY modified_at DESC
 	`
 
-	rows, err := db.conn.Query(query)
+	items, err := db.queryItems(db.rebind(query))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get marked items: %w", err)
 	}
-	defer func() { _ = rows.Close() }()
-
-	return db.scanItems(rows)
+	return items, nil
 }
 
-// SearchItems performs search on items using LIKE
+// SearchItems performs search on items using an FTS5 MATCH with a LIKE
+// fallback (SQLite) or a tsvector query (Postgres, see postgres.go).
 func (db *DB) SearchItems(query string) ([]Item, error) {
-	var sqlQuery string
-	var rows *sql.Rows
-	var err error
+	if db.driver == DriverPostgres {
+		return db.searchItemsPostgres(query)
+	}
 
 	if query == "" {
 		// Return all items if query is empty (for reference matching)
-		sqlQuery = `
+		sqlQuery := `
 			SELECT item_id, word, type, definition, derivation,
 			       appendicies, source, source_pg, mark, created_at, modified_at
 			FROM items
 			ORDER BY word
 		`
-		rows, err = db.conn.Query(sqlQuery)
-	} else {
-		// Normalize FTS5 query (convert lowercase and/or/not to uppercase)
-		normalizedQuery := normalizeFTS5Query(query)
-
-		// Try FTS5 search first for better performance and relevance ranking
-		sqlQuery = `
-			SELECT items.item_id, items.word, items.type, items.definition, items.derivation,
-			       items.appendicies, items.source, items.source_pg, items.mark, 
-			       items.created_at, items.modified_at
-			FROM items_fts
-			JOIN items ON items.item_id = items_fts.rowid
-			WHERE items_fts MATCH ?
-			ORDER BY rank
-		`
-		rows, err = db.conn.Query(sqlQuery, normalizedQuery)
+		items, err := db.queryItems(sqlQuery)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+		return items, nil
+	}
 
+	// Build a safe FTS5 MATCH expression (see pkg/fts5) and try FTS5 search
+	// first for better performance and relevance ranking.
+	normalizedQuery := buildFTS5Query(query, nil)
+	ftsQuery := `
+		SELECT items.item_id, items.word, items.type, items.definition, items.derivation,
+		       items.appendicies, items.source, items.source_pg, items.mark,
+		       items.created_at, items.modified_at
+		FROM items_fts
+		JOIN items ON items.item_id = items_fts.rowid
+		WHERE items_fts MATCH ?
+		ORDER BY rank
+	`
+	items, err := db.queryItems(ftsQuery, normalizedQuery)
+	if err != nil {
 		// If FTS5 fails (module not available or query syntax error), fall back to LIKE
+		slog.Warn("[SearchItems] FTS5 search failed, falling back to LIKE", "error", err)
+		searchTerm := "%" + query + "%"
+		likeQuery := `
+			SELECT item_id, word, type, definition, derivation,
+			       appendicies, source, source_pg, mark, created_at, modified_at
+			FROM items
+			WHERE word LIKE ? OR definition LIKE ? OR derivation LIKE ? OR appendicies LIKE ?
+			ORDER BY
+				CASE WHEN LOWER(word) = LOWER(?) THEN 0 ELSE 1 END,
+				word
+		`
+		items, err = db.queryItems(likeQuery, searchTerm, searchTerm, searchTerm, searchTerm, query)
 		if err != nil {
-			slog.Warn("[SearchItems] FTS5 search failed, falling back to LIKE", "error", err)
-			searchTerm := "%" + query + "%"
-			sqlQuery = `
-				SELECT item_id, word, type, definition, derivation,
-				       appendicies, source, source_pg, mark, created_at, modified_at
-				FROM items
-				WHERE word LIKE ? OR definition LIKE ? OR derivation LIKE ? OR appendicies LIKE ?
-				ORDER BY 
-					CASE WHEN LOWER(word) = LOWER(?) THEN 0 ELSE 1 END,
-					word
-			`
-			rows, err = db.conn.Query(sqlQuery, searchTerm, searchTerm, searchTerm, searchTerm, query)
+			return nil, fmt.Errorf("search failed: %w", err)
 		}
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("search failed: %w", err)
+	return items, nil
+}
+
+// missingFieldColumns whitelists the items columns MissingFields may
+// reference, so a caller-supplied field name can never be spliced into SQL
+// as anything other than one of these literals.
+var missingFieldColumns = map[string]string{
+	"definition":  "definition",
+	"derivation":  "derivation",
+	"appendicies": "appendicies",
+	"source":      "source",
+}
+
+// buildMarksClause turns options.Marks into a WHERE fragment and its bind
+// args. "any"/"none" check for a non-empty/empty mark column; any other
+// value is matched literally against mark via IN (...).
+func buildMarksClause(marks []string) (string, []interface{}) {
+	if len(marks) == 0 {
+		return "", nil
 	}
-	defer func() { _ = rows.Close() }()
 
-	var items []Item
-	for rows.Next() {
-		var item Item
-		err := rows.Scan(
-			&item.ItemID, &item.Word, &item.Type, &item.Definition,
-			&item.Derivation, &item.Appendicies, &item.Source, &item.SourcePg,
-			&item.Mark, &item.CreatedAt, &item.ModifiedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan item: %w", err)
+	var ors []string
+	var args []interface{}
+	for _, m := range marks {
+		switch m {
+		case "any":
+			ors = append(ors, "(items.mark IS NOT NULL AND items.mark != '')")
+		case "none":
+			ors = append(ors, "(items.mark IS NULL OR items.mark = '')")
+		default:
+			ors = append(ors, "items.mark = ?")
+			args = append(args, m)
 		}
-		items = append(items, item)
 	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration failed: %w", err)
+// buildLinkedToCTE renders the recursive CTE backing SearchOptions.LinkedTo:
+// starting from linkedTo, it walks the (undirected) links table up to depth
+// hops and collects every item_id reached. depth <= 0 defaults to 2 hops to
+// keep the traversal bounded on a densely linked graph. Returns an empty
+// string when linkedTo is empty, meaning no CTE is needed.
+func buildLinkedToCTE(linkedTo []int, depth int) (string, []interface{}) {
+	if len(linkedTo) == 0 {
+		return "", nil
+	}
+	if depth <= 0 {
+		depth = 2
 	}
 
-	return items, nil
+	placeholders := make([]string, len(linkedTo))
+	args := make([]interface{}, len(linkedTo), len(linkedTo)+1)
+	for i, id := range linkedTo {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	args = append(args, depth)
+
+	cte := fmt.Sprintf(`
+		WITH RECURSIVE reachable(item_id, depth) AS (
+			SELECT item_id, 0 FROM items WHERE item_id IN (%s)
+			UNION
+			SELECT CASE WHEN l.source_item_id = r.item_id THEN l.destination_item_id ELSE l.source_item_id END, r.depth + 1
+			FROM links l
+			JOIN reachable r ON l.source_item_id = r.item_id OR l.destination_item_id = r.item_id
+			WHERE r.depth < ?
+		)
+	`, strings.Join(placeholders, ","))
+	return cte, args
+}
+
+// withLinkedToCTE prepends cte to sqlQuery and cteArgs to args, since
+// WITH RECURSIVE must precede the SELECT it scopes and its placeholders
+// are therefore bound before everything else in the statement. It's a
+// no-op when cte is empty.
+func withLinkedToCTE(sqlQuery string, args []interface{}, cte string, cteArgs []interface{}) (string, []interface{}) {
+	if cte == "" {
+		return sqlQuery, args
+	}
+	return cte + sqlQuery, append(append([]interface{}{}, cteArgs...), args...)
 }
 
 // SearchItemsWithOptions performs search with advanced filtering options
 func (db *DB) SearchItemsWithOptions(options SearchOptions) ([]Item, error) {
+	if db.driver == DriverPostgres {
+		return db.searchItemsWithOptionsPostgres(options)
+	}
+
 	var sqlQuery string
 	var args []interface{}
 	var whereClauses []string
@@ -622,6 +1172,15 @@ func (db *DB) SearchItemsWithOptions(options SearchOptions) ([]Item, error) {
 		whereClauses = append(whereClauses, fmt.Sprintf("items.type IN (%s)", strings.Join(placeholders, ",")))
 	}
 
+	if len(options.ExcludeTypes) > 0 {
+		placeholders := make([]string, len(options.ExcludeTypes))
+		for i, t := range options.ExcludeTypes {
+			placeholders[i] = "?"
+			args = append(args, t)
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf("items.type NOT IN (%s)", strings.Join(placeholders, ",")))
+	}
+
 	if options.Source != "" {
 		whereClauses = append(whereClauses, "items.source = ?")
 		args = append(args, options.Source)
@@ -635,6 +1194,48 @@ func (db *DB) SearchItemsWithOptions(options SearchOptions) ([]Item, error) {
 		whereClauses = append(whereClauses, "items.has_tts = 1")
 	}
 
+	if marksClause, marksArgs := buildMarksClause(options.Marks); marksClause != "" {
+		whereClauses = append(whereClauses, marksClause)
+		args = append(args, marksArgs...)
+	}
+
+	if options.MinConnections > 0 {
+		whereClauses = append(whereClauses, "(SELECT COUNT(*) FROM links l WHERE l.source_item_id = items.item_id OR l.destination_item_id = items.item_id) >= ?")
+		args = append(args, options.MinConnections)
+	}
+	if options.MaxConnections > 0 {
+		whereClauses = append(whereClauses, "(SELECT COUNT(*) FROM links l WHERE l.source_item_id = items.item_id OR l.destination_item_id = items.item_id) <= ?")
+		args = append(args, options.MaxConnections)
+	}
+
+	if !options.CreatedAfter.IsZero() {
+		whereClauses = append(whereClauses, "items.created_at >= ?")
+		args = append(args, options.CreatedAfter)
+	}
+	if !options.CreatedBefore.IsZero() {
+		whereClauses = append(whereClauses, "items.created_at <= ?")
+		args = append(args, options.CreatedBefore)
+	}
+	if !options.ModifiedAfter.IsZero() {
+		whereClauses = append(whereClauses, "items.modified_at >= ?")
+		args = append(args, options.ModifiedAfter)
+	}
+	if !options.ModifiedBefore.IsZero() {
+		whereClauses = append(whereClauses, "items.modified_at <= ?")
+		args = append(args, options.ModifiedBefore)
+	}
+
+	for _, field := range options.MissingFields {
+		if col, ok := missingFieldColumns[field]; ok {
+			whereClauses = append(whereClauses, fmt.Sprintf("(items.%s IS NULL OR items.%s = '')", col, col))
+		}
+	}
+
+	reachableCTE, reachableArgs := buildLinkedToCTE(options.LinkedTo, options.LinkedToDepth)
+	if reachableCTE != "" {
+		whereClauses = append(whereClauses, "items.item_id IN (SELECT item_id FROM reachable)")
+	}
+
 	// Empty query returns all items with filters
 	if options.Query == "" {
 		sqlQuery = `
@@ -646,11 +1247,12 @@ func (db *DB) SearchItemsWithOptions(options SearchOptions) ([]Item, error) {
 			sqlQuery += " WHERE " + strings.Join(whereClauses, " AND ")
 		}
 		sqlQuery += " ORDER BY word"
-		rows, err := db.conn.Query(sqlQuery, args...)
+		sqlQuery, execArgs := withLinkedToCTE(sqlQuery, args, reachableCTE, reachableArgs)
+		items, err := db.queryItems(sqlQuery, execArgs...)
 		if err != nil {
 			return nil, fmt.Errorf("search failed: %w", err)
 		}
-		return scanItems(rows)
+		return items, nil
 	}
 
 	// Regex mode
@@ -673,15 +1275,16 @@ func (db *DB) SearchItemsWithOptions(options SearchOptions) ([]Item, error) {
 
 		regexArgs := []interface{}{searchTerm, searchTerm, searchTerm, searchTerm}
 		regexArgs = append(regexArgs, args...)
-		rows, err := db.conn.Query(sqlQuery, regexArgs...)
+		sqlQuery, regexArgs = withLinkedToCTE(sqlQuery, regexArgs, reachableCTE, reachableArgs)
+		items, err := db.queryItems(sqlQuery, regexArgs...)
 		if err != nil {
 			return nil, fmt.Errorf("regex search failed: %w", err)
 		}
-		return scanItems(rows)
+		return items, nil
 	}
 
 	// FTS5 mode with filters
-	normalizedQuery := normalizeFTS5Query(options.Query)
+	normalizedQuery := buildFTS5Query(options.Query, options.Fields)
 	sqlQuery = `
 		SELECT items.item_id, items.word, items.type, items.definition, items.derivation,
 		       items.appendicies, items.source, items.source_pg, items.mark,
@@ -697,7 +1300,8 @@ func (db *DB) SearchItemsWithOptions(options SearchOptions) ([]Item, error) {
 	}
 	sqlQuery += " ORDER BY rank"
 
-	rows, err := db.conn.Query(sqlQuery, ftsArgs...)
+	ftsQueryText, ftsExecArgs := withLinkedToCTE(sqlQuery, ftsArgs, reachableCTE, reachableArgs)
+	items, err := db.queryItems(ftsQueryText, ftsExecArgs...)
 	if err != nil {
 		// Fallback to LIKE search
 		slog.Warn("[SearchItemsWithOptions] FTS5 search failed, falling back to LIKE", "error", err)
@@ -715,104 +1319,288 @@ func (db *DB) SearchItemsWithOptions(options SearchOptions) ([]Item, error) {
 		}
 		sqlQuery += " ORDER BY CASE WHEN LOWER(word) = LOWER(?) THEN 0 ELSE 1 END, word"
 		likeArgs = append(likeArgs, options.Query)
-		rows, err = db.conn.Query(sqlQuery, likeArgs...)
+		sqlQuery, likeArgs = withLinkedToCTE(sqlQuery, likeArgs, reachableCTE, reachableArgs)
+		items, err = db.queryItems(sqlQuery, likeArgs...)
 		if err != nil {
 			return nil, fmt.Errorf("search failed: %w", err)
 		}
 	}
 
-	return scanItems(rows)
-}
-
-// scanItems is a helper to scan rows into Item slice
-func scanItems(rows *sql.Rows) ([]Item, error) {
-	defer func() { _ = rows.Close() }()
-	var items []Item
-	for rows.Next() {
-		var item Item
-		err := rows.Scan(
-			&item.ItemID, &item.Word, &item.Type, &item.Definition,
-			&item.Derivation, &item.Appendicies, &item.Source, &item.SourcePg,
-			&item.Mark, &item.CreatedAt, &item.ModifiedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan item: %w", err)
-		}
-		items = append(items, item)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration failed: %w", err)
-	}
 	return items, nil
 }
 
-// GetItem retrieves a single item by item_id
-func (db *DB) GetItem(itemID int) (*Item, error) {
-	query := `
-		SELECT item_id, word, type, definition, derivation,
-		       appendicies, source, source_pg, mark, created_at, modified_at
-		FROM items
-		WHERE item_id = ?
-	`
+// searchResultRow mirrors searchResultCols (see SearchItemsWithSnippets) for
+// sqlx's Select - itemRow's columns plus the FTS5 rank/snippet/highlight
+// columns that have no place on Item itself.
+type searchResultRow struct {
+	itemRow
+	Rank                 float64 `db:"rank"`
+	Snippet              string  `db:"snippet"`
+	HighlightWord        string  `db:"highlight_word"`
+	HighlightDefinition  string  `db:"highlight_definition"`
+	HighlightDerivation  string  `db:"highlight_derivation"`
+	HighlightAppendicies string  `db:"highlight_appendicies"`
+}
 
-	item := &Item{}
-	err := db.conn.QueryRow(query, itemID).Scan(
-		&item.ItemID, &item.Word, &item.Type, &item.Definition,
-		&item.Derivation, &item.Appendicies, &item.Source, &item.SourcePg,
-		&item.Mark, &item.CreatedAt, &item.ModifiedAt,
-	)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("item not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get item: %w", err)
+func (r searchResultRow) toSearchResult() SearchResult {
+	return SearchResult{
+		Item:    r.itemRow.toItem(),
+		Rank:    r.Rank,
+		Snippet: r.Snippet,
+		Highlights: map[string]string{
+			"word":        r.HighlightWord,
+			"definition":  r.HighlightDefinition,
+			"derivation":  r.HighlightDerivation,
+			"appendicies": r.HighlightAppendicies,
+		},
 	}
-
-	return item, nil
 }
 
-// GetRandomItem retrieves a random item from the database
-func (db *DB) GetRandomItem() (*Item, error) {
-	query := `
-		SELECT item_id, word, type, definition, derivation,
-		       appendicies, source, source_pg, mark, created_at, modified_at
-		FROM items
-		ORDER BY RANDOM()
-		LIMIT 1
-	`
-
-	item := &Item{}
-	err := db.conn.QueryRow(query).Scan(
-		&item.ItemID, &item.Word, &item.Type, &item.Definition,
-		&item.Derivation, &item.Appendicies, &item.Source, &item.SourcePg,
-		&item.Mark, &item.CreatedAt, &item.ModifiedAt,
-	)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("no items found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get random item: %w", err)
+// searchResultCols selects items_fts' bm25 rank alongside snippet() (a
+// single ellipsized excerpt from whichever column matched, column index -1
+// meaning "pick automatically") and one highlight() call per items_fts
+// column, aliased to highlight_<field> so searchResultRow can scan them by
+// name regardless of items_fts' physical column order.
+const searchResultCols = `items.item_id, items.word, items.type, items.definition, items.derivation,
+	       items.appendicies, items.source, items.source_pg, items.mark,
+	       items.created_at, items.modified_at,
+	       bm25(items_fts) AS rank,
+	       snippet(items_fts, -1, '<mark>', '</mark>', '…', 32) AS snippet,
+	       highlight(items_fts, 0, '<b>', '</b>') AS highlight_word,
+	       highlight(items_fts, 1, '<b>', '</b>') AS highlight_definition,
+	       highlight(items_fts, 2, '<b>', '</b>') AS highlight_derivation,
+	       highlight(items_fts, 3, '<b>', '</b>') AS highlight_appendicies`
+
+// SearchItemsWithSnippets is SearchItemsWithOptions' FTS5-ranked
+// counterpart: instead of []Item it returns []SearchResult, carrying each
+// hit's bm25 Rank and a snippet()/highlight() excerpt for rendering a
+// search-results page with the matched terms marked up. It only covers
+// FTS5 mode - an empty Query or UseRegex falls back to
+// SearchItemsWithOptions, wrapping each Item as a SearchResult with no
+// Rank/Snippet/Highlights, since neither LIKE nor REGEXP matching produces
+// an FTS5 rank to sort by.
+func (db *DB) SearchItemsWithSnippets(options SearchOptions) ([]SearchResult, error) {
+	if db.driver == DriverPostgres {
+		return db.searchItemsWithSnippetsPostgres(options)
+	}
+	if options.Query == "" || options.UseRegex {
+		items, err := db.SearchItemsWithOptions(options)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]SearchResult, len(items))
+		for i, item := range items {
+			results[i] = SearchResult{Item: item}
+		}
+		return results, nil
 	}
 
-	return item, nil
-}
-
-// GetItemByWord retrieves a single item by word (case-insensitive)
-func (db *DB) GetItemByWord(word string) (*Item, error) {
-	query := `
-		SELECT item_id, word, type, definition, derivation,
-		       appendicies, source, source_pg, mark, created_at, modified_at
-		FROM items
-		WHERE LOWER(word) = LOWER(?)
-		LIMIT 1
-	`
+	var whereClauses []string
+	var args []interface{}
+	if len(options.Types) > 0 {
+		placeholders := make([]string, len(options.Types))
+		for i, t := range options.Types {
+			placeholders[i] = "?"
+			args = append(args, t)
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf("items.type IN (%s)", strings.Join(placeholders, ",")))
+	}
+	if options.Source != "" {
+		whereClauses = append(whereClauses, "items.source = ?")
+		args = append(args, options.Source)
+	}
+	if options.HasImage {
+		whereClauses = append(whereClauses, "items.has_image = 1")
+	}
+	if options.HasTts {
+		whereClauses = append(whereClauses, "items.has_tts = 1")
+	}
+
+	normalizedQuery := buildFTS5Query(options.Query, options.Fields)
+	sqlQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM items_fts
+		JOIN items ON items.item_id = items_fts.rowid
+		WHERE items_fts MATCH ?
+	`, searchResultCols)
+	ftsArgs := []interface{}{normalizedQuery}
+	if len(whereClauses) > 0 {
+		sqlQuery += " AND " + strings.Join(whereClauses, " AND ")
+		ftsArgs = append(ftsArgs, args...)
+	}
+	sqlQuery += " ORDER BY rank"
+
+	var rows []searchResultRow
+	if err := db.conn.Select(&rows, sqlQuery, ftsArgs...); err != nil {
+		return nil, fmt.Errorf("ranked search failed: %w", err)
+	}
+	results := make([]SearchResult, len(rows))
+	for i, r := range rows {
+		results[i] = r.toSearchResult()
+	}
+	return results, nil
+}
+
+// SavedSearch is a persisted SearchOptions preset, so a user's filter
+// combination (keyword, source, connection counts, LinkedTo, ...) survives
+// past the session that built it - the structured-query counterpart of
+// components.SavedQuery's raw SQL snippets.
+type SavedSearch struct {
+	SavedSearchID int           `json:"savedSearchId"`
+	Name          string        `json:"name"`
+	Options       SearchOptions `json:"options"`
+	CreatedAt     time.Time     `json:"createdAt" ts_type:"Date"`
+}
+
+// savedSearchRow mirrors the saved_searches table for sqlx's Select/Get;
+// Options is stored encoded (OptionsJSON) since SearchOptions has no direct
+// SQL representation, the same reason SavedQuery keeps param_schema/tags as
+// JSON columns rather than separate tables.
+type savedSearchRow struct {
+	SavedSearchID int       `db:"saved_search_id"`
+	Name          string    `db:"name"`
+	OptionsJSON   string    `db:"options_json"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+func (r savedSearchRow) toSavedSearch() (SavedSearch, error) {
+	var opts SearchOptions
+	if err := json.Unmarshal([]byte(r.OptionsJSON), &opts); err != nil {
+		return SavedSearch{}, fmt.Errorf("failed to decode saved search %q options: %w", r.Name, err)
+	}
+	return SavedSearch{
+		SavedSearchID: r.SavedSearchID,
+		Name:          r.Name,
+		Options:       opts,
+		CreatedAt:     r.CreatedAt,
+	}, nil
+}
+
+// SaveSearch persists opts under name so it can be recalled later via
+// ListSavedSearches/RunSavedSearch, and returns the new saved_searches row's
+// id. SQLite-only for now, like saved_queries - Postgres has no
+// saved_searches table (see openPostgres).
+func (db *DB) SaveSearch(name string, opts SearchOptions) (int, error) {
+	optionsJSON, err := json.Marshal(opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode search options: %w", err)
+	}
+
+	result, err := db.conn.Exec(`
+		INSERT INTO saved_searches (name, options_json) VALUES (?, ?)
+	`, name, string(optionsJSON))
+	if err != nil {
+		return 0, fmt.Errorf("failed to save search %q: %w", name, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine saved search id: %w", err)
+	}
+	return int(id), nil
+}
+
+// ListSavedSearches returns every saved search, most recently created first.
+func (db *DB) ListSavedSearches() ([]SavedSearch, error) {
+	var rows []savedSearchRow
+	if err := db.conn.Select(&rows, `
+		SELECT saved_search_id, name, options_json, created_at
+		FROM saved_searches
+		ORDER BY created_at DESC
+	`); err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+
+	searches := make([]SavedSearch, 0, len(rows))
+	for _, r := range rows {
+		ss, err := r.toSavedSearch()
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, ss)
+	}
+	return searches, nil
+}
+
+// RunSavedSearch looks up id and replays its stored SearchOptions through
+// SearchItemsWithOptions, so a saved search always reflects the current
+// data rather than a stale snapshot.
+func (db *DB) RunSavedSearch(id int) ([]Item, error) {
+	var row savedSearchRow
+	if err := db.conn.Get(&row, `
+		SELECT saved_search_id, name, options_json, created_at
+		FROM saved_searches
+		WHERE saved_search_id = ?
+	`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("saved search %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get saved search %d: %w", id, err)
+	}
+
+	ss, err := row.toSavedSearch()
+	if err != nil {
+		return nil, err
+	}
+	return db.SearchItemsWithOptions(ss.Options)
+}
+
+// GetItem retrieves a single item by item_id. The query is prepared once
+// and cached (see DB.prepared) since this is one of the hottest read paths.
+func (db *DB) GetItem(itemID int) (*Item, error) {
+	query := db.rebind(`
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, image_files_json, created_at, modified_at
+		FROM items
+		WHERE item_id = ?
+	`)
+
+	item, err := db.getItemRowPrepared(query, itemID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("item not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// GetRandomItem retrieves a random item from the database. The query is
+// prepared once and cached (see DB.prepared) since this is one of the
+// hottest read paths.
+func (db *DB) GetRandomItem() (*Item, error) {
+	query := `
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, image_files_json, created_at, modified_at
+		FROM items
+		ORDER BY RANDOM()
+		LIMIT 1
+	`
+
+	item, err := db.getItemRowPrepared(query)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no items found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get random item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// GetItemByWord retrieves a single item by word (case-insensitive). The
+// query is prepared once and cached (see DB.prepared) since this is one of
+// the hottest read paths.
+func (db *DB) GetItemByWord(word string) (*Item, error) {
+	query := db.rebind(`
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, image_files_json, created_at, modified_at
+		FROM items
+		WHERE LOWER(word) = LOWER(?)
+		LIMIT 1
+	`)
 
-	item := &Item{}
-	err := db.conn.QueryRow(query, word).Scan(
-		&item.ItemID, &item.Word, &item.Type, &item.Definition,
-		&item.Derivation, &item.Appendicies, &item.Source, &item.SourcePg,
-		&item.Mark, &item.CreatedAt, &item.ModifiedAt,
-	)
+	item, err := db.getItemRowPrepared(query, word)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("item not found")
 	}
@@ -820,7 +1608,13 @@ func (db *DB) GetItemByWord(word string) (*Item, error) {
 		return nil, fmt.Errorf("failed to get item: %w", err)
 	}
 
-	return item, nil
+	return &item, nil
+}
+
+// StripPossessive removes possessive suffixes from text, handling both regular (') and curly (') apostrophes.
+// Examples: "Shakespeare's" -> "Shakespeare", "Burns'" -> "Burns"
+func StripPossessive(text string) string {
+	return stripPossessive(text)
 }
 
 // stripPossessive removes possessive suffixes from text, handling both regular (') and curly (') apostrophes.
@@ -844,168 +1638,587 @@ func stripPossessive(text string) string {
 // CreateLinkOrRemoveTags attempts to create a link to the referenced word.
 // If the referenced word doesn't exist, it removes the reference tags from the source item's text fields.
 // Returns: linkCreated (bool), message (string), error
+// The lookup-then-write sequence runs inside a single transaction (see
+// Tx.CreateLinkOrRemoveTags) so a failed tag-removal update can't leave a
+// half-applied change alongside a skipped link, or vice versa.
 func (db *DB) CreateLinkOrRemoveTags(sourceItemID int, refWord string) (bool, string, error) {
 	slog.Debug("[CreateLinkOrRemoveTags] START", "sourceItemID", sourceItemID, "refWord", refWord)
 
-	matchWord := stripPossessive(refWord)
-	if matchWord != refWord {
-		slog.Debug("[CreateLinkOrRemoveTags] Stripped possessive", "original", refWord, "stripped", matchWord)
+	var linkCreated bool
+	var message string
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		var err error
+		linkCreated, message, err = tx.CreateLinkOrRemoveTags(sourceItemID, refWord)
+		return err
+	})
+	if err != nil {
+		slog.Error("[CreateLinkOrRemoveTags] FAILED", "error", err)
+		return false, "", err
+	}
+
+	slog.Debug("[CreateLinkOrRemoveTags] SUCCESS", "linkCreated", linkCreated, "message", message)
+	return linkCreated, message, nil
+}
+
+// GetItemLinks retrieves all links for an item (both incoming and outgoing)
+func (db *DB) GetItemLinks(itemID int) ([]Link, error) {
+	query := `
+		SELECT link_id, source_item_id, destination_item_id, link_type, created_at
+		FROM links
+		WHERE source_item_id = ? OR destination_item_id = ?
+		ORDER BY created_at DESC
+	`
+
+	var links []Link
+	if err := db.conn.Select(&links, db.rebind(query), itemID, itemID); err != nil {
+		return nil, fmt.Errorf("failed to get links: %w", err)
 	}
+	return links, nil
+}
 
-	// Try to find the destination item
-	slog.Debug("[CreateLinkOrRemoveTags] Calling GetItemByWord", "word", matchWord)
-	destItem, err := db.GetItemByWord(matchWord)
+// IncomingLinkSummary is the per-item result of GetIncomingLinkSummaries:
+// how many incoming links an item has, and the source of the last one seen
+// (only meaningful when Count == 1, matching how callers use it today).
+type IncomingLinkSummary struct {
+	Count      int
+	SourceID   int
+	SourceWord string
+}
+
+// GetIncomingLinkSummaries returns, for every item with at least one
+// incoming link, its incoming link count and (arbitrary, but stable for a
+// given link set) source item ID/word. It's a single JOIN query, used in
+// place of one GetItemLinks + GetItem round trip per item in maintenance
+// scans that only need this summary.
+func (db *DB) GetIncomingLinkSummaries() (map[int]IncomingLinkSummary, error) {
+	rows, err := db.conn.Query(`
+		SELECT links.destination_item_id, links.source_item_id, items.word
+		FROM links
+		JOIN items ON items.item_id = links.source_item_id
+	`)
 	if err != nil {
-		slog.Error("[CreateLinkOrRemoveTags] GetItemByWord ERROR", "error", err)
-	} else if destItem == nil {
-		slog.Debug("[CreateLinkOrRemoveTags] GetItemByWord returned nil item (no error)")
-	} else {
-		slog.Debug("[CreateLinkOrRemoveTags] GetItemByWord SUCCESS", "itemID", destItem.ItemID, "word", destItem.Word)
-	}
-
-	if err == nil && destItem != nil {
-		// Item exists - try to create the link
-		slog.Debug("[CreateLinkOrRemoveTags] Attempting to create link", "source", sourceItemID, "dest", destItem.ItemID)
-		linkErr := db.CreateLink(sourceItemID, destItem.ItemID, "reference")
-		if linkErr == nil {
-			slog.Debug("[CreateLinkOrRemoveTags] Link created successfully")
-			return true, fmt.Sprintf("Added link to %s", destItem.Word), nil
+		return nil, fmt.Errorf("failed to query incoming link summaries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	summaries := make(map[int]IncomingLinkSummary)
+	for rows.Next() {
+		var destID, srcID int
+		var srcWord string
+		if err := rows.Scan(&destID, &srcID, &srcWord); err != nil {
+			return nil, fmt.Errorf("failed to scan incoming link summary row: %w", err)
 		}
-		slog.Warn("[CreateLinkOrRemoveTags] CreateLink FAILED - will remove tag instead", "error", linkErr)
-		// Link creation failed, fall through to remove tag
+		summary := summaries[destID]
+		summary.Count++
+		summary.SourceID = srcID
+		summary.SourceWord = srcWord
+		summaries[destID] = summary
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return summaries, nil
+}
 
-	// Item doesn't exist or link creation failed - remove the reference tags
-	slog.Debug("[CreateLinkOrRemoveTags] Removing tags - getting source item", "sourceItemID", sourceItemID)
-	sourceItem, err := db.GetItem(sourceItemID)
+// GetRecentItems retrieves recently modified items
+func (db *DB) GetRecentItems(limit int) ([]Item, error) {
+	query := `
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, created_at, modified_at
+		FROM items
+		ORDER BY modified_at DESC
+		LIMIT ?
+	`
+
+	items, err := db.queryItems(query, limit)
 	if err != nil {
-		slog.Error("[CreateLinkOrRemoveTags] GetItem FAILED", "error", err)
-		return false, "", fmt.Errorf("failed to get source item: %w", err)
+		return nil, fmt.Errorf("failed to get recent items: %w", err)
 	}
-	slog.Debug("[CreateLinkOrRemoveTags] Got source item", "word", sourceItem.Word)
+	return items, nil
+}
+
+// CreateItem creates a new item. It runs inside a transaction (see
+// Tx.CreateItem) only so that its activity-log row (see recordActivity)
+// can't end up recorded without the insert, or vice versa.
+func (db *DB) CreateItem(item Item) (int, error) {
+	var id int
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		var err error
+		id, err = tx.CreateItem(item)
+		return err
+	})
+	return id, err
+}
 
-	// Build regex to match reference tags with optional possessive forms
-	regex, err := parser.GetPossessiveReferenceRegex(matchWord)
+// CreateItems inserts items in a single transaction, returning the assigned
+// item_id for each in the same order. It exists for callers seeding many
+// rows at once (bulk imports, tests): CreateItem works fine one at a time,
+// but wrapping a loop of CreateItem calls in a transaction here saves the
+// per-call IndexItem/syncItemTags bookkeeping each caller would otherwise
+// have to skip and redo itself.
+func (db *DB) CreateItems(items []Item) ([]int64, error) {
+	tx, err := db.conn.Beginx()
 	if err != nil {
-		slog.Error("[CreateLinkOrRemoveTags] Failed to compile regex", "error", err)
-		return false, "", fmt.Errorf("failed to compile regex: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	slog.Debug("[CreateLinkOrRemoveTags] Regex pattern", "pattern", regex.String())
+	defer func() { _ = tx.Rollback() }()
 
-	// Remove tags from all text fields, keeping the actual word
-	updatedDefinition := ""
-	defChanged := false
-	if sourceItem.Definition != nil {
-		originalDef := *sourceItem.Definition
-		updatedDefinition = regex.ReplaceAllString(originalDef, "$1")
-		defChanged = originalDef != updatedDefinition
-	}
+	ids := make([]int64, len(items))
+	rows := make([]itemRow, len(items))
+	for i, item := range items {
+		definition, derivation, appendicies, err := resolveTextFields(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve text fields for item %q: %w", item.Word, err)
+		}
+		rows[i] = itemRowFromItem(item, definition, derivation, appendicies)
 
-	updatedDerivation := ""
-	derChanged := false
-	if sourceItem.Derivation != nil {
-		originalDer := *sourceItem.Derivation
-		updatedDerivation = regex.ReplaceAllString(originalDer, "$1")
-		derChanged = originalDer != updatedDerivation
+		id, err := db.insertItemRow(tx, rows[i])
+		if err != nil {
+			if isUniqueViolation(err) {
+				return nil, fmt.Errorf("an item with the word '%s' already exists", item.Word)
+			}
+			return nil, fmt.Errorf("failed to create item %q: %w", item.Word, err)
+		}
+		ids[i] = id
 	}
 
-	updatedAppendicies := ""
-	appChanged := false
-	if sourceItem.Appendicies != nil {
-		originalApp := *sourceItem.Appendicies
-		updatedAppendicies = regex.ReplaceAllString(originalApp, "$1")
-		appChanged = originalApp != updatedAppendicies
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Check if anything actually changed
-	if !defChanged && !derChanged && !appChanged {
-		slog.Debug("[CreateLinkOrRemoveTags] Nothing changed - returning success")
-		return false, "No changes needed", nil
+	for i, item := range items {
+		id := ids[i]
+		row := rows[i]
+		item.ItemID = int(id)
+		item.Definition = NewLazyString(row.Definition)
+		item.Derivation = NewLazyString(row.Derivation)
+		item.Appendicies = NewLazyString(row.Appendicies)
+		if err := syncItemTags(db.conn, int(id), row.Definition, row.Appendicies); err != nil {
+			slog.Warn("[CreateItems] Failed to sync tags index", "id", id, "error", err)
+		}
+		if err := db.IndexItem(item); err != nil {
+			slog.Warn("[CreateItems] Failed to update search index", "id", id, "error", err)
+		}
 	}
 
-	slog.Debug("[CreateLinkOrRemoveTags] Changes detected", "defChanged", defChanged, "derChanged", derChanged, "appChanged", appChanged)
+	return ids, nil
+}
 
-	// Update the item
-	sourceItem.Definition = &updatedDefinition
-	sourceItem.Derivation = &updatedDerivation
-	sourceItem.Appendicies = &updatedAppendicies
+// namedExecer is satisfied by both *sqlx.DB and *sqlx.Tx, so insertItemRow
+// can run CreateItem's single insert and CreateItems' per-row inserts
+// (inside one transaction) through the same code path.
+type namedExecer interface {
+	NamedExec(query string, arg interface{}) (sql.Result, error)
+	NamedQuery(query string, arg interface{}) (*sqlx.Rows, error)
+}
 
-	slog.Debug("[CreateLinkOrRemoveTags] Calling UpdateItem")
-	err = db.UpdateItem(*sourceItem)
+// insertItemRow inserts row via ex, omitting item_id from the statement
+// when it's zero so SQLite/Postgres auto-assign the primary key instead of
+// every zero-valued row colliding on item_id=0. Returns the assigned id.
+func (db *DB) insertItemRow(ex namedExecer, row itemRow) (int64, error) {
+	columns := "word, type, definition, derivation, appendicies, source, source_pg, mark"
+	placeholders := ":word, :type, :definition, :derivation, :appendicies, :source, :source_pg, :mark"
+	if row.ItemID != 0 {
+		columns = "item_id, " + columns
+		placeholders = ":item_id, " + placeholders
+	}
+	query := fmt.Sprintf("INSERT INTO items (%s) VALUES (%s)", columns, placeholders)
+
+	// lib/pq doesn't support Result.LastInsertId (Postgres has no universal
+	// "last inserted rowid"), so Postgres asks for the id back via RETURNING
+	// instead of Exec+LastInsertId.
+	if db.driver == DriverPostgres {
+		rows, err := ex.NamedQuery(query+" RETURNING item_id", row)
+		if err != nil {
+			return 0, err
+		}
+		defer func() { _ = rows.Close() }()
+		if !rows.Next() {
+			return 0, fmt.Errorf("insert did not return item_id")
+		}
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		return id, rows.Err()
+	}
+
+	result, err := ex.NamedExec(query, row)
 	if err != nil {
-		slog.Error("[CreateLinkOrRemoveTags] UpdateItem FAILED", "error", err)
-		return false, "", fmt.Errorf("failed to update item: %w", err)
+		return 0, err
 	}
+	return result.LastInsertId()
+}
 
-	slog.Info("[CreateLinkOrRemoveTags] SUCCESS - tags removed")
-	return false, fmt.Sprintf("Removed non-existent reference to %s", matchWord), nil
+// execer is satisfied by both *sqlx.DB and *sqlx.Tx, letting syncItemTags
+// run inside Tx's mirrored CRUD methods through the same code used outside
+// a transaction.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
 }
 
-// GetItemLinks retrieves all links for an item (both incoming and outgoing)
-func (db *DB) GetItemLinks(itemID int) ([]Link, error) {
+// syncItemTags recomputes the tags table rows for an item from the
+// hashtag/category/frontmatter tags found in its definition and appendicies,
+// replacing whatever was indexed for it before. It's called after every
+// CreateItem/UpdateItem so GetItemsByTag stays in sync with the text.
+func syncItemTags(ex execer, itemID int, definition, appendicies *string) error {
+	if _, err := ex.Exec("DELETE FROM tags WHERE item_id = ?", itemID); err != nil {
+		return fmt.Errorf("failed to clear tags for item %d: %w", itemID, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, text := range []*string{definition, appendicies} {
+		if text == nil || *text == "" {
+			continue
+		}
+		for _, ref := range parser.ParseAllTags(*text) {
+			if ref.Type != parser.TagTypeHashtag && ref.Type != parser.TagTypeCategory && ref.Type != parser.TagTypeFrontmatter {
+				continue
+			}
+			key := ref.Type + "\x00" + strings.ToLower(ref.Value)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, err := ex.Exec(
+				"INSERT OR IGNORE INTO tags (item_id, kind, tag) VALUES (?, ?, ?)",
+				itemID, ref.Type, ref.Value,
+			); err != nil {
+				return fmt.Errorf("failed to insert tag %q for item %d: %w", ref.Value, itemID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetItemsByTag returns every item whose indexed tags include tag. kind
+// restricts the match to one tag flavor (parser.TagTypeHashtag,
+// parser.TagTypeCategory, or parser.TagTypeFrontmatter); an empty kind
+// matches any flavor.
+func (db *DB) GetItemsByTag(tag, kind string) ([]Item, error) {
 	query := `
-		SELECT link_id, source_item_id, destination_item_id, link_type, created_at
-		FROM links
-		WHERE source_item_id = ? OR destination_item_id = ?
-		ORDER BY created_at DESC
+		SELECT items.item_id, items.word, items.type, items.definition, items.derivation,
+		       items.appendicies, items.source, items.source_pg, items.mark,
+		       items.created_at, items.modified_at
+		FROM items
+		JOIN tags ON tags.item_id = items.item_id
+		WHERE tags.tag = ? COLLATE NOCASE
 	`
+	args := []interface{}{tag}
+	if kind != "" {
+		query += " AND tags.kind = ?"
+		args = append(args, kind)
+	}
+	query += " ORDER BY items.word"
 
-	rows, err := db.conn.Query(query, itemID, itemID)
+	items, err := db.queryItems(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get links: %w", err)
+		return nil, fmt.Errorf("failed to query items by tag: %w", err)
 	}
-	defer func() { _ = rows.Close() }()
+	return items, nil
+}
 
-	return db.scanLinks(rows)
+// GetTTSCacheHash returns the content hash tts_cache has on record for
+// itemID, or "" if the item has never had TTS audio generated for it.
+func (db *DB) GetTTSCacheHash(itemID int) (string, error) {
+	var hash string
+	err := db.conn.QueryRow("SELECT content_hash FROM tts_cache WHERE item_id = ?", itemID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query TTS cache hash: %w", err)
+	}
+	return hash, nil
 }
 
-// GetRecentItems retrieves recently modified items
-func (db *DB) GetRecentItems(limit int) ([]Item, error) {
-	query := `
-		SELECT item_id, word, type, definition, derivation,
-		       appendicies, source, source_pg, mark, created_at, modified_at
+// SetTTSCacheHash records contentHash as the TTS content hash for itemID,
+// replacing whatever was recorded before.
+func (db *DB) SetTTSCacheHash(itemID int, contentHash string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO tts_cache (item_id, content_hash) VALUES (?, ?)
+		ON CONFLICT(item_id) DO UPDATE SET content_hash = excluded.content_hash
+	`, itemID, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to set TTS cache hash: %w", err)
+	}
+	return nil
+}
+
+// DeleteTTSCacheHash removes itemID's tts_cache row, e.g. once its
+// Definition no longer has any spoken text.
+func (db *DB) DeleteTTSCacheHash(itemID int) error {
+	if _, err := db.conn.Exec("DELETE FROM tts_cache WHERE item_id = ?", itemID); err != nil {
+		return fmt.Errorf("failed to delete TTS cache hash: %w", err)
+	}
+	return nil
+}
+
+// CountTTSCacheRefs returns how many items currently reference contentHash,
+// so a caller can tell whether the cached .mp3 for that hash is still in use
+// before garbage-collecting it.
+func (db *DB) CountTTSCacheRefs(contentHash string) (int, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM tts_cache WHERE content_hash = ?", contentHash).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count TTS cache references: %w", err)
+	}
+	return count, nil
+}
+
+// TTSTagCandidate is one row TTSTagCandidates returns: an item with cached
+// TTS audio that services.SyncTTSTags hasn't yet written ID3 metadata into.
+type TTSTagCandidate struct {
+	ItemID   int     `db:"item_id"`
+	Word     string  `db:"word"`
+	Source   *string `db:"source"`
+	SourcePg *string `db:"source_pg"`
+	TTSHash  string  `db:"content_hash"`
+}
+
+// TTSTagCandidates returns every item with cached TTS audio (a tts_cache
+// row) whose tts_tagged flag is still false, for services.SyncTTSTags to
+// work through.
+func (db *DB) TTSTagCandidates() ([]TTSTagCandidate, error) {
+	query := db.rebind(`
+		SELECT items.item_id, items.word, items.source, items.source_pg, tts_cache.content_hash
 		FROM items
-		ORDER BY modified_at DESC
-		LIMIT ?
-	`
+		JOIN tts_cache ON tts_cache.item_id = items.item_id
+		WHERE items.tts_tagged = ?
+	`)
 
-	rows, err := db.conn.Query(query, limit)
+	var candidates []TTSTagCandidate
+	if err := db.conn.Select(&candidates, query, false); err != nil {
+		return nil, fmt.Errorf("failed to query TTS tag candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+// SetTTSTagged marks itemID's cached TTS audio as having had ID3 metadata
+// written into it, so the next SyncTTSTags pass skips it.
+func (db *DB) SetTTSTagged(itemID int) error {
+	query := db.rebind("UPDATE items SET tts_tagged = ? WHERE item_id = ?")
+	if _, err := db.conn.Exec(query, true, itemID); err != nil {
+		return fmt.Errorf("failed to mark item %d as TTS-tagged: %w", itemID, err)
+	}
+	return nil
+}
+
+// IsPackApplied reports whether a content pack named packName has already
+// had its SQL fragment applied at version, per content_packs_applied - so
+// seeding.ApplyPackData can skip re-running it on every startup.
+func (db *DB) IsPackApplied(packName, version string) (bool, error) {
+	query := db.rebind("SELECT version FROM content_packs_applied WHERE pack_name = ?")
+	var applied string
+	err := db.conn.Get(&applied, query, packName)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get recent items: %w", err)
+		return false, fmt.Errorf("failed to check pack %q applied state: %w", packName, err)
 	}
-	defer func() { _ = rows.Close() }()
+	return applied == version, nil
+}
 
-	return db.scanItems(rows)
+// RecordPackApplied records that packName's SQL fragment has been applied at
+// version, so IsPackApplied returns true for it from now on.
+func (db *DB) RecordPackApplied(packName, version string) error {
+	query := db.rebind(`
+		INSERT INTO content_packs_applied (pack_name, version)
+		VALUES (?, ?)
+		ON CONFLICT (pack_name) DO UPDATE SET version = excluded.version, applied_at = CURRENT_TIMESTAMP
+	`)
+	if _, err := db.conn.Exec(query, packName, version); err != nil {
+		return fmt.Errorf("failed to record pack %q as applied: %w", packName, err)
+	}
+	return nil
 }
 
-// CreateItem creates a new item
-func (db *DB) CreateItem(item Item) (int, error) {
-	sql := `
-		INSERT INTO items (
-			item_id, word, type, definition, derivation,
-			appendicies, source, source_pg, mark
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
+// DeletePackItems deletes every item tagged with pack_name = packName (via
+// ON DELETE CASCADE, their links/tags/caches go with them), and clears
+// packName from content_packs_applied so a later re-install re-applies its
+// SQL fragment. It returns how many items were deleted.
+func (db *DB) DeletePackItems(packName string) (int64, error) {
+	query := db.rebind("DELETE FROM items WHERE pack_name = ?")
+	result, err := db.conn.Exec(query, packName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete items for pack %q: %w", packName, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted items for pack %q: %w", packName, err)
+	}
 
-	result, err := db.conn.Exec(sql,
-		item.ItemID, item.Word, item.Type, item.Definition,
-		item.Derivation, item.Appendicies, item.Source,
-		item.SourcePg, item.Mark,
-	)
+	clearQuery := db.rebind("DELETE FROM content_packs_applied WHERE pack_name = ?")
+	if _, err := db.conn.Exec(clearQuery, packName); err != nil {
+		return deleted, fmt.Errorf("failed to clear applied record for pack %q: %w", packName, err)
+	}
+	return deleted, nil
+}
+
+// GetImageCacheHash returns the content hash image_cache has on record for
+// itemID, or "" if the item has no image.
+func (db *DB) GetImageCacheHash(itemID int) (string, error) {
+	var hash string
+	err := db.conn.QueryRow("SELECT content_hash FROM image_cache WHERE item_id = ?", itemID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
 	if err != nil {
-		// Check for unique constraint violation
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			return 0, fmt.Errorf("an item with the word '%s' already exists", item.Word)
-		}
-		return 0, fmt.Errorf("failed to create item: %w", err)
+		return "", fmt.Errorf("failed to query image cache hash: %w", err)
 	}
+	return hash, nil
+}
 
-	id, err := result.LastInsertId()
+// SetImageCacheHash records contentHash as the image content hash for
+// itemID, replacing whatever was recorded before.
+func (db *DB) SetImageCacheHash(itemID int, contentHash string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO image_cache (item_id, content_hash) VALUES (?, ?)
+		ON CONFLICT(item_id) DO UPDATE SET content_hash = excluded.content_hash
+	`, itemID, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to set image cache hash: %w", err)
+	}
+	return nil
+}
+
+// DeleteImageCacheHash removes itemID's image_cache row, e.g. once its
+// image has been removed.
+func (db *DB) DeleteImageCacheHash(itemID int) error {
+	if _, err := db.conn.Exec("DELETE FROM image_cache WHERE item_id = ?", itemID); err != nil {
+		return fmt.Errorf("failed to delete image cache hash: %w", err)
+	}
+	return nil
+}
+
+// CountImageCacheRefs returns how many items currently reference
+// contentHash, so a caller can tell whether the cached image for that hash
+// is still in use before garbage-collecting it.
+func (db *DB) CountImageCacheRefs(contentHash string) (int, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM image_cache WHERE content_hash = ?", contentHash).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+		return 0, fmt.Errorf("failed to count image cache references: %w", err)
 	}
+	return count, nil
+}
 
-	return int(id), nil
+// SetImageVariantHash records contentHash as itemID's image variant named
+// variant (e.g. "thumb", "alt_1"), alongside its single "hero" hash in
+// image_cache.
+func (db *DB) SetImageVariantHash(itemID int, variant, contentHash string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO image_variants (item_id, variant, content_hash) VALUES (?, ?, ?)
+		ON CONFLICT(item_id, variant) DO UPDATE SET content_hash = excluded.content_hash
+	`, itemID, variant, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to set image variant hash: %w", err)
+	}
+	return nil
+}
+
+// GetImageVariantHashes returns itemID's variant name -> content hash map,
+// not including its "hero" hash (see GetImageCacheHash).
+func (db *DB) GetImageVariantHashes(itemID int) (map[string]string, error) {
+	var rows []struct {
+		Variant     string `db:"variant"`
+		ContentHash string `db:"content_hash"`
+	}
+	if err := db.conn.Select(&rows, "SELECT variant, content_hash FROM image_variants WHERE item_id = ?", itemID); err != nil {
+		return nil, fmt.Errorf("failed to get image variant hashes: %w", err)
+	}
+	hashes := make(map[string]string, len(rows))
+	for _, r := range rows {
+		hashes[r.Variant] = r.ContentHash
+	}
+	return hashes, nil
+}
+
+// DeleteImageVariantHash removes itemID's variant row, e.g. once that
+// variant's image has been removed.
+func (db *DB) DeleteImageVariantHash(itemID int, variant string) error {
+	if _, err := db.conn.Exec("DELETE FROM image_variants WHERE item_id = ? AND variant = ?", itemID, variant); err != nil {
+		return fmt.Errorf("failed to delete image variant hash: %w", err)
+	}
+	return nil
+}
+
+// SetImageFiles records itemID's full list of present image variant names
+// (see Item.Images) as JSON in items.image_files_json, for SyncFileFlags to
+// keep in sync with what's actually on disk.
+func (db *DB) SetImageFiles(itemID int, variants []string) error {
+	if variants == nil {
+		variants = []string{}
+	}
+	encoded, err := json.Marshal(variants)
+	if err != nil {
+		return fmt.Errorf("failed to encode image files: %w", err)
+	}
+	updateQuery := db.rebind("UPDATE items SET image_files_json = ? WHERE item_id = ?")
+	if _, err := db.conn.Exec(updateQuery, string(encoded), itemID); err != nil {
+		return fmt.Errorf("failed to set image files for item %d: %w", itemID, err)
+	}
+	return nil
+}
+
+// AllTTSCacheHashes returns every distinct content_hash currently recorded
+// in tts_cache, for GarbageCollectBlobs to compare against what's on disk.
+func (db *DB) AllTTSCacheHashes() ([]string, error) {
+	return db.distinctHashes("tts_cache")
+}
+
+// AllImageCacheHashes returns every distinct content_hash currently
+// recorded for images - the single "hero" hash in image_cache plus any
+// named variants in image_variants (see SetImageVariantHash) - for
+// GarbageCollectBlobs to compare against what's on disk.
+func (db *DB) AllImageCacheHashes() ([]string, error) {
+	hero, err := db.distinctHashes("image_cache")
+	if err != nil {
+		return nil, err
+	}
+	variants, err := db.distinctHashes("image_variants")
+	if err != nil {
+		return nil, err
+	}
+	return append(hero, variants...), nil
+}
+
+// distinctHashes returns every distinct content_hash in table, which must
+// be one of the fixed table names above (never user input).
+func (db *DB) distinctHashes(table string) ([]string, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("SELECT DISTINCT content_hash FROM %s", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s hashes: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan %s hash: %w", table, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// resolveTextFields resolves an item's three lazy text columns to plain
+// *string values suitable for binding into an INSERT/UPDATE statement.
+func resolveTextFields(item Item) (definition, derivation, appendicies *string, err error) {
+	if definition, err = item.Definition.Get(); err != nil {
+		return nil, nil, nil, err
+	}
+	if derivation, err = item.Derivation.Get(); err != nil {
+		return nil, nil, nil, err
+	}
+	if appendicies, err = item.Appendicies.Get(); err != nil {
+		return nil, nil, nil, err
+	}
+	return definition, derivation, appendicies, nil
 }
 
 // normalizeDefinition converts {word: ...} references to lowercase
@@ -1021,122 +2234,72 @@ func normalizeDefinition(text *string) {
 		return ref.Original
 	})
 
-	// Strip line numbers if detected (e.g. "Line of text   5")
-	if parser.HasLineNumbers(*text) {
-		*text = parser.StripLineNumbers(*text)
-	}
-}
-
-// UpdateItem updates an existing item
-func (db *DB) UpdateItem(item Item) error { // Normalize {word: ...} references to lowercase
-	normalizeDefinition(item.Definition)
-	normalizeDefinition(item.Derivation)
-	normalizeDefinition(item.Appendicies)
-	sql := `
-		UPDATE items SET
-			word = ?, type = ?, definition = ?, derivation = ?,
-			appendicies = ?, source = ?, source_pg = ?, mark = ?,
-			modified_at = CURRENT_TIMESTAMP
-		WHERE item_id = ?
-	`
-
-	result, err := db.conn.Exec(sql,
-		item.Word, item.Type, item.Definition, item.Derivation,
-		item.Appendicies, item.Source, item.SourcePg, item.Mark,
-		item.ItemID,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to update item: %w", err)
+	// Strip line numbers if detected (e.g. "Line of text   5")
+	if parser.HasLineNumbers(*text) {
+		*text = parser.StripLineNumbers(*text)
 	}
+}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-	if rows == 0 {
-		// Item doesn't exist, create it instead
-		_, err := db.CreateItem(item)
+// UpdateItem updates item, falling back to creating it if no row matches
+// its item_id. The update and the fallback create run inside a single
+// transaction (see Tx.UpdateOrCreateItem) so the two writes can't race with
+// a concurrent delete of the same item between them.
+func (db *DB) UpdateItem(item Item) error {
+	return db.WithTx(context.Background(), func(tx *Tx) error {
+		_, err := tx.UpdateOrCreateItem(item)
 		return err
-	}
-
-	return nil
+	})
 }
 
-// ToggleItemMark toggles the mark field for an item
+// ToggleItemMark toggles the mark field for an item. It runs inside a
+// transaction (see Tx.ToggleItemMark) so its activity-log row can't end up
+// recorded without the toggle, or vice versa. Inside the transaction the
+// UPDATE still runs through the db.prepared statement cache, via Tx.stmt.
 func (db *DB) ToggleItemMark(itemID int, marked bool) error {
-	var markVal *string
-	if marked {
-		s := "1"
-		markVal = &s
-	}
-
-	query := `UPDATE items SET mark = ?, modified_at = CURRENT_TIMESTAMP WHERE item_id = ?`
-	_, err := db.conn.Exec(query, markVal, itemID)
-	if err != nil {
-		return fmt.Errorf("failed to toggle item mark: %w", err)
-	}
-	return nil
+	return db.WithTx(context.Background(), func(tx *Tx) error {
+		return tx.ToggleItemMark(itemID, marked)
+	})
 }
 
-// DeleteItem deletes an item
+// DeleteItem deletes an item. It runs inside a transaction (see
+// Tx.DeleteItem) so its activity-log row (with the item's pre-delete state,
+// letting RevertActivity recreate it) can't end up recorded without the
+// delete, or vice versa.
 func (db *DB) DeleteItem(itemID int) error {
 	slog.Info("[DB] DeleteItem called", "itemID", itemID)
-	result, err := db.conn.Exec("DELETE FROM items WHERE item_id = ?", itemID)
-	if err != nil {
-		slog.Error("[DB] DeleteItem SQL exec failed", "error", err)
-		return fmt.Errorf("failed to delete item: %w", err)
-	}
-
-	rows, err := result.RowsAffected()
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		return tx.DeleteItem(itemID)
+	})
 	if err != nil {
-		slog.Error("[DB] DeleteItem failed to get rows affected", "error", err)
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-	slog.Info("[DB] DeleteItem affected rows", "rows", rows)
-	if rows == 0 {
-		slog.Warn("[DB] DeleteItem found no item", "itemID", itemID)
-		return fmt.Errorf("item not found")
+		slog.Error("[DB] DeleteItem failed", "error", err)
+		return err
 	}
-
 	slog.Info("[DB] DeleteItem succeeded", "itemID", itemID)
 	return nil
 }
 
-// CreateLink creates a link between two items
+// CreateLink creates a link between two items. It runs inside a transaction
+// (see Tx.CreateLink) so its activity-log row can't end up recorded without
+// the insert, or vice versa.
 func (db *DB) CreateLink(sourceID, destID int, linkType string) error {
-	sql := `
-		INSERT INTO links (source_item_id, destination_item_id, link_type)
-		VALUES (?, ?, ?)
-	`
-
-	_, err := db.conn.Exec(sql, sourceID, destID, linkType)
-	if err != nil {
-		return fmt.Errorf("failed to create link: %w", err)
-	}
-
-	return nil
+	return db.WithTx(context.Background(), func(tx *Tx) error {
+		return tx.CreateLink(sourceID, destID, linkType)
+	})
 }
 
-// DeleteLink deletes a link
+// DeleteLink deletes a link. It runs inside a transaction (see
+// Tx.DeleteLink) so its activity-log row (with the link's pre-delete state,
+// letting RevertActivity recreate it) can't end up recorded without the
+// delete, or vice versa.
 func (db *DB) DeleteLink(linkID int) error {
 	slog.Info("[DB] DeleteLink called", "linkID", linkID)
-	result, err := db.conn.Exec("DELETE FROM links WHERE link_id = ?", linkID)
-	if err != nil {
-		slog.Error("[DB] DeleteLink SQL exec failed", "error", err)
-		return fmt.Errorf("failed to delete link: %w", err)
-	}
-
-	rows, err := result.RowsAffected()
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		return tx.DeleteLink(linkID)
+	})
 	if err != nil {
-		slog.Error("[DB] DeleteLink failed to get rows affected", "error", err)
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-	slog.Info("[DB] DeleteLink affected rows", "rows", rows)
-	if rows == 0 {
-		slog.Warn("[DB] DeleteLink found no link", "linkID", linkID)
-		return fmt.Errorf("link not found")
+		slog.Error("[DB] DeleteLink failed", "error", err)
+		return err
 	}
-
 	slog.Info("[DB] DeleteLink succeeded", "linkID", linkID)
 	return nil
 }
@@ -1179,134 +2342,84 @@ func (db *DB) UpdateLinksSource(oldItemID, newItemID int) error {
 	return nil
 }
 
-// Helper functions
-
-func (db *DB) scanItems(rows *sql.Rows) ([]Item, error) {
-	var items []Item
-	for rows.Next() {
-		item := Item{}
-		err := rows.Scan(
-			&item.ItemID, &item.Word, &item.Type, &item.Definition,
-			&item.Derivation, &item.Appendicies, &item.Source, &item.SourcePg,
-			&item.Mark, &item.CreatedAt, &item.ModifiedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan item: %w", err)
-		}
-		items = append(items, item)
-	}
-	return items, nil
-}
-
-func (db *DB) scanLinks(rows *sql.Rows) ([]Link, error) {
-	var links []Link
-	for rows.Next() {
-		link := Link{}
-		err := rows.Scan(
-			&link.LinkID, &link.SourceItemID,
-			&link.DestinationItemID, &link.LinkType, &link.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan link: %w", err)
-		}
-		links = append(links, link)
-	}
-	return links, nil
-}
-
 // GetAllItems returns all items
 func (db *DB) GetAllItems() ([]Item, error) {
-	query := MustLoadQuery("all_items")
-	rows, err := db.conn.Query(query)
+	items, err := db.queryItems(MustLoadQuery("all_items"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all items: %w", err)
 	}
-	defer func() { _ = rows.Close() }()
-	return db.scanItems(rows)
+	return items, nil
 }
 
 // GetAllLinks returns all links
 func (db *DB) GetAllLinks() ([]Link, error) {
-	query := MustLoadQuery("all_links")
-	rows, err := db.conn.Query(query)
-	if err != nil {
+	var links []Link
+	if err := db.conn.Select(&links, MustLoadQuery("all_links")); err != nil {
 		return nil, fmt.Errorf("failed to get all links: %w", err)
 	}
-	defer func() { _ = rows.Close() }()
-	return db.scanLinks(rows)
+	return links, nil
 }
 
-// GetEgoGraph returns the ego graph for a given node
-func (db *DB) GetEgoGraph(centerNodeID int, depth int) (*GraphData, error) {
-	if depth < 1 {
-		depth = 1
-	}
-
-	// Use a map to store unique node IDs
-	nodeIDs := make(map[int]bool)
-	nodeIDs[centerNodeID] = true
-
-	// Current frontier
-	frontier := []int{centerNodeID}
-
-	for i := 0; i < depth; i++ {
-		if len(frontier) == 0 {
-			break
-		}
-
-		// Build query for neighbors
-		placeholders := make([]string, len(frontier))
-		args := make([]interface{}, len(frontier)*2)
-		for j, id := range frontier {
-			placeholders[j] = "?"
-			args[j] = id
-			args[len(frontier)+j] = id
-		}
-
-		query := fmt.Sprintf(`
-			SELECT source_item_id, destination_item_id 
-			FROM links 
-			WHERE source_item_id IN (%s) OR destination_item_id IN (%s)
-		`, strings.Join(placeholders, ","), strings.Join(placeholders, ","))
-
-		rows, err := db.conn.Query(query, args...)
-		if err != nil {
-			return nil, fmt.Errorf("failed to query neighbors: %w", err)
-		}
-
-		var newFrontier []int
-		for rows.Next() {
-			var src, dst int
-			if err := rows.Scan(&src, &dst); err != nil {
-				_ = rows.Close()
-				return nil, fmt.Errorf("failed to scan neighbors: %w", err)
-			}
+// egoGraphNodeLimit is GetEgoGraph's hard cap on the number of nodes a
+// single call can return, so a request against a densely linked hub (or a
+// deep depth) can't walk an unbounded fraction of the graph in one query.
+const egoGraphNodeLimit = 500
+
+// egoGraphNodeRow is one row of the "ego" recursive CTE GetEgoGraph builds:
+// an item reachable from centerNodeID, and its minimum hop count to get
+// there.
+type egoGraphNodeRow struct {
+	ItemID   int `db:"item_id"`
+	Distance int `db:"distance"`
+}
 
-			if !nodeIDs[src] {
-				nodeIDs[src] = true
-				newFrontier = append(newFrontier, src)
-			}
-			if !nodeIDs[dst] {
-				nodeIDs[dst] = true
-				newFrontier = append(newFrontier, dst)
-			}
-		}
-		_ = rows.Close()
-		frontier = newFrontier
+// GetEgoGraph returns the induced subgraph of every item within depth hops
+// of centerNodeID (undirected - a link counts in either direction), via a
+// single recursive CTE walking the links table rather than one query per
+// BFS level. depth < 0 is treated as 0, meaning just centerNodeID itself.
+// Results are capped at egoGraphNodeLimit nodes, closest to centerNodeID
+// first, so a traversal that would otherwise keep growing past it is
+// truncated rather than rejected.
+func (db *DB) GetEgoGraph(centerNodeID int, depth int) (*GraphData, error) {
+	if depth < 0 {
+		depth = 0
+	}
+
+	// "ego" walks outward from centerNodeID: the base case is the center
+	// itself at distance 0, and each recursive step follows every link
+	// touching the current node to its other endpoint at distance+1,
+	// stopping once r.distance reaches depth. Duplicate (item_id, distance)
+	// pairs reached via parallel edges are deduped by UNION; an item
+	// reached at more than one distance (a cycle) still needs the final
+	// GROUP BY/MIN to collapse to its shortest distance.
+	nodesQuery := `
+		WITH RECURSIVE ego(item_id, distance) AS (
+			SELECT ?, 0
+			UNION
+			SELECT CASE WHEN l.source_item_id = r.item_id THEN l.destination_item_id ELSE l.source_item_id END, r.distance + 1
+			FROM links l
+			JOIN ego r ON l.source_item_id = r.item_id OR l.destination_item_id = r.item_id
+			WHERE r.distance < ?
+		)
+		SELECT item_id, MIN(distance) AS distance
+		FROM ego
+		GROUP BY item_id
+		ORDER BY distance, item_id
+		LIMIT ?
+	`
 
-		// Hard limit check (500 nodes)
-		if len(nodeIDs) > 500 {
-			break
-		}
+	var nodeRows []egoGraphNodeRow
+	if err := db.conn.Select(&nodeRows, db.rebind(nodesQuery), centerNodeID, depth, egoGraphNodeLimit); err != nil {
+		return nil, fmt.Errorf("failed to walk ego graph: %w", err)
 	}
 
-	// Convert map to slice
-	ids := make([]int, 0, len(nodeIDs))
-	for id := range nodeIDs {
-		ids = append(ids, id)
+	ids := make([]int, len(nodeRows))
+	distance := make(map[int]int, len(nodeRows))
+	for i, r := range nodeRows {
+		ids[i] = r.ItemID
+		distance[r.ItemID] = r.Distance
 	}
 
-	// Fetch Items
 	placeholders := make([]string, len(ids))
 	args := make([]interface{}, len(ids))
 	for i, id := range ids {
@@ -1314,147 +2427,90 @@ func (db *DB) GetEgoGraph(centerNodeID int, depth int) (*GraphData, error) {
 		args[i] = id
 	}
 
-	queryItems := fmt.Sprintf(`
-		SELECT item_id, word, type, definition, derivation, appendicies, source, source_pg, mark, created_at, modified_at 
-		FROM items 
+	itemsQuery := fmt.Sprintf(`
+		SELECT item_id, word, type, definition, derivation, appendicies, source, source_pg, mark, created_at, modified_at
+		FROM items
 		WHERE item_id IN (%s)
 		ORDER BY word
 	`, strings.Join(placeholders, ","))
 
-	rowsItems, err := db.conn.Query(queryItems, args...)
+	items, err := db.queryItems(db.rebind(itemsQuery), args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get items: %w", err)
 	}
-	defer func() { _ = rowsItems.Close() }()
-
-	items, err := db.scanItems(rowsItems)
-	if err != nil {
-		return nil, err
-	}
-
-	// Fetch Links (induced subgraph)
-	// We want links where BOTH source and destination are in our set of IDs
-	// Re-use placeholders and args as they are the same (list of IDs)
 
-	// We need to pass the list of IDs twice for the two IN clauses
+	// Fetch links (induced subgraph): both endpoints must be in our node
+	// set, so the list of IDs is needed once per IN clause.
 	argsLinks := make([]interface{}, len(ids)*2)
 	copy(argsLinks, args)
 	copy(argsLinks[len(ids):], args)
 
 	queryLinks := fmt.Sprintf(`
-		SELECT link_id, source_item_id, destination_item_id, link_type, created_at 
-		FROM links 
+		SELECT link_id, source_item_id, destination_item_id, link_type, created_at
+		FROM links
 		WHERE source_item_id IN (%s) AND destination_item_id IN (%s)
 		ORDER BY link_id
 	`, strings.Join(placeholders, ","), strings.Join(placeholders, ","))
 
-	rowsLinks, err := db.conn.Query(queryLinks, argsLinks...)
-	if err != nil {
+	var links []Link
+	if err := db.conn.Select(&links, db.rebind(queryLinks), argsLinks...); err != nil {
 		return nil, fmt.Errorf("failed to get links: %w", err)
 	}
-	defer func() { _ = rowsLinks.Close() }()
-
-	links, err := db.scanLinks(rowsLinks)
-	if err != nil {
-		return nil, err
-	}
 
 	return &GraphData{
-		Items: items,
-		Links: links,
+		Items:    items,
+		Links:    links,
+		Distance: distance,
 	}, nil
 }
 
 // GetAllCliches returns all cliches
 func (db *DB) GetAllCliches() ([]Cliche, error) {
-	query := MustLoadQuery("all_cliches")
-	rows, err := db.conn.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get all cliches: %w", err)
-	}
-	defer func() { _ = rows.Close() }()
-
 	var cliches []Cliche
-	for rows.Next() {
-		var c Cliche
-		if err := rows.Scan(&c.ClicheID, &c.Phrase, &c.Definition, &c.CreatedAt); err != nil {
-			return nil, err
-		}
-		cliches = append(cliches, c)
+	if err := db.conn.Select(&cliches, MustLoadQuery("all_cliches")); err != nil {
+		return nil, fmt.Errorf("failed to get all cliches: %w", err)
 	}
-	return cliches, rows.Err()
+	return cliches, nil
 }
 
 // GetAllNames returns all names
 func (db *DB) GetAllNames() ([]Name, error) {
-	query := MustLoadQuery("all_names")
-	rows, err := db.conn.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get all names: %w", err)
-	}
-	defer func() { _ = rows.Close() }()
-
 	var names []Name
-	for rows.Next() {
-		var n Name
-		if err := rows.Scan(&n.NameID, &n.Name, &n.Type, &n.Gender, &n.Description, &n.Notes, &n.CreatedAt); err != nil {
-			return nil, err
-		}
-		names = append(names, n)
+	if err := db.conn.Select(&names, MustLoadQuery("all_names")); err != nil {
+		return nil, fmt.Errorf("failed to get all names: %w", err)
 	}
-	return names, rows.Err()
+	return names, nil
 }
 
 // GetAllLiteraryTerms returns all literary terms
 func (db *DB) GetAllLiteraryTerms() ([]LiteraryTerm, error) {
 	query := `
-		SELECT 
-			t.term_id, 
-			t.term, 
+		SELECT
+			t.term_id,
+			t.term,
 			t.type,
-			t.definition, 
-			t.examples, 
-			t.notes, 
+			t.definition,
+			t.examples,
+			t.notes,
 			t.created_at,
 			(SELECT COUNT(*) FROM items WHERE word = t.term) > 0 as exists_in_items
 		FROM literary_terms t
 		ORDER BY t.term
 	`
-	rows, err := db.conn.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get all literary terms: %w", err)
-	}
-	defer func() { _ = rows.Close() }()
-
 	var terms []LiteraryTerm
-	for rows.Next() {
-		var t LiteraryTerm
-		if err := rows.Scan(&t.TermID, &t.Term, &t.Type, &t.Definition, &t.Examples, &t.Notes, &t.CreatedAt, &t.ExistsInItems); err != nil {
-			return nil, err
-		}
-		terms = append(terms, t)
+	if err := db.conn.Select(&terms, query); err != nil {
+		return nil, fmt.Errorf("failed to get all literary terms: %w", err)
 	}
-	return terms, rows.Err()
+	return terms, nil
 }
 
 // GetAllSources returns all sources
 func (db *DB) GetAllSources() ([]Source, error) {
-	query := MustLoadQuery("all_sources")
-	rows, err := db.conn.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get all sources: %w", err)
-	}
-	defer func() { _ = rows.Close() }()
-
 	var sources []Source
-	for rows.Next() {
-		var s Source
-		if err := rows.Scan(&s.SourceID, &s.Title, &s.Author, &s.Notes, &s.CreatedAt); err != nil {
-			return nil, err
-		}
-		sources = append(sources, s)
+	if err := db.conn.Select(&sources, MustLoadQuery("all_sources")); err != nil {
+		return nil, fmt.Errorf("failed to get all sources: %w", err)
 	}
-	return sources, rows.Err()
+	return sources, nil
 }
 
 // GetGenderByFirstName returns the gender ("male", "female", or empty string) for a given first name
@@ -1480,86 +2536,15 @@ func (db *DB) GetGenderByFirstName(firstName string) (string, error) {
 	return "", nil
 }
 
-// MergeLiteraryTerm merges a literary term into an existing item
+// MergeLiteraryTerm folds a literary_terms row into its matching items row
+// (appending the term's definition and deleting the literary_terms row) in
+// a single transaction (see Tx.MergeLiteraryTerm), so a failure partway
+// through can't leave the term both merged into items and still present in
+// literary_terms for a retry to merge again.
 func (db *DB) MergeLiteraryTerm(termID int) error {
-	// 1. Get the literary term
-	var term LiteraryTerm
-	err := db.conn.QueryRow(`
-		SELECT term_id, term, definition, examples, notes 
-		FROM literary_terms 
-		WHERE term_id = ?
-	`, termID).Scan(&term.TermID, &term.Term, &term.Definition, &term.Examples, &term.Notes)
-	if err != nil {
-		return fmt.Errorf("failed to get literary term: %w", err)
-	}
-
-	// 2. Find the matching item (case-sensitive)
-	var item Item
-	err = db.conn.QueryRow(`
-		SELECT item_id, word, definition, source 
-		FROM items 
-		WHERE word = ? COLLATE BINARY
-	`, term.Term).Scan(&item.ItemID, &item.Word, &item.Definition, &item.Source)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("matching item not found for term: %s", term.Term)
-		}
-		return fmt.Errorf("failed to find matching item: %w", err)
-	}
-
-	// 3. Prepare updated fields
-	newDef := ""
-	if item.Definition != nil {
-		newDef = *item.Definition
-	}
-
-	termDef := ""
-	if term.Definition != nil {
-		termDef = *term.Definition
-	}
-
-	// Clean up replacement characters
-	termDef = strings.ReplaceAll(termDef, "\ufffd", "\"")
-	// Clean up HTML tags
-	termDef = strings.ReplaceAll(termDef, "<p>", "\n\n")
-	termDef = strings.ReplaceAll(termDef, "</p>", "")
-
-	if termDef != "" {
-		if newDef != "" {
-			newDef += "\n\n----\n\n"
-		}
-		newDef += termDef
-	}
-
-	newSource := ""
-	if item.Source != nil {
-		newSource = *item.Source
-	}
-	if newSource != "" {
-		newSource += "; "
-	}
-	newSource += "from literary term table"
-
-	// 4. Update the item
-	_, err = db.conn.Exec(`
-		UPDATE items 
-		SET definition = ?, source = ?, modified_at = CURRENT_TIMESTAMP 
-		WHERE item_id = ?
-	`, newDef, newSource, item.ItemID)
-	if err != nil {
-		return fmt.Errorf("failed to update item: %w", err)
-	}
-
-	// 5. Delete the literary term
-	_, err = db.conn.Exec(`
-		DELETE FROM literary_terms 
-		WHERE term_id = ?
-	`, termID)
-	if err != nil {
-		return fmt.Errorf("failed to delete literary term: %w", err)
-	}
-
-	return nil
+	return db.WithTx(context.Background(), func(tx *Tx) error {
+		return tx.MergeLiteraryTerm(termID)
+	})
 }
 
 // DeleteLiteraryTerm permanently deletes a literary term
@@ -1603,75 +2588,202 @@ func (db *DB) SetSetting(key, value string) error {
 // This function is idempotent and can be called multiple times safely.
 func (db *DB) SyncFileFlags() error {
 	slog.Info("Starting file flags sync...")
-	
-	// Get all item IDs
-	rows, err := db.conn.Query("SELECT item_id FROM items")
-	if err != nil {
-		return fmt.Errorf("failed to query items: %w", err)
-	}
-	defer func() { _ = rows.Close() }()
-	
+
 	var itemIDs []int
-	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
-			return fmt.Errorf("failed to scan item ID: %w", err)
-		}
-		itemIDs = append(itemIDs, id)
-	}
-	
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating items: %w", err)
-	}
-	
-	// Get directory paths
-	imagesDir, err := constants.GetImagesDir()
-	if err != nil {
-		return fmt.Errorf("failed to get images directory: %w", err)
-	}
-	
-	ttsDir, err := constants.GetTTSCacheDir()
-	if err != nil {
-		return fmt.Errorf("failed to get TTS cache directory: %w", err)
+	if err := db.conn.Select(&itemIDs, "SELECT item_id FROM items"); err != nil {
+		return fmt.Errorf("failed to query items: %w", err)
 	}
-	
-	// Check for existing files and update flags
+
+	updates := make(map[int]flagstore.Flags, len(itemIDs))
+
+	// Check for existing blobs and update flags. image_cache/image_variants/
+	// tts_cache are content-addressable, so each item's blob lives under its
+	// recorded hash rather than a fixed "<id>.png"/"<id>.mp3" name - see
+	// backend/assets, which db.assets checks against instead of stat-ing a
+	// path directly. Beyond mere presence, verifyAssetDigest recomputes each
+	// blob's hash and compares it to the one items record - recorded
+	// hash it recomputes doubles as a free corruption check: a blob whose
+	// bytes no longer match its own filename is treated the same as a
+	// missing one.
 	imageCount := 0
 	ttsCount := 0
-	
+	corruptCount := 0
+
 	for _, itemID := range itemIDs {
-		var hasImage, hasTTS int
-		
-		// Check for image file
-		imagePath := filepath.Join(imagesDir, fmt.Sprintf("%d.png", itemID))
-		if _, err := os.Stat(imagePath); err == nil {
-			hasImage = 1
+		var hasTTS bool
+
+		variants, err := db.syncItemImageVariants(itemID)
+		if err != nil {
+			return err
+		}
+		if len(variants.present) > 0 {
 			imageCount++
 		}
-		
-		// Check for TTS file
-		ttsPath := filepath.Join(ttsDir, fmt.Sprintf("%d.mp3", itemID))
-		if _, err := os.Stat(ttsPath); err == nil {
-			hasTTS = 1
-			ttsCount++
+		corruptCount += variants.corrupt
+		if err := db.SetImageFiles(itemID, variants.present); err != nil {
+			return err
 		}
-		
-		// Update database flags
-		_, err := db.conn.Exec(`
-			UPDATE items 
-			SET has_image = ?, has_tts = ?
-			WHERE item_id = ?
-		`, hasImage, hasTTS, itemID)
-		
-		if err != nil {
-			return fmt.Errorf("failed to update flags for item %d: %w", itemID, err)
+
+		if hash, err := db.GetTTSCacheHash(itemID); err == nil && hash != "" && db.assets != nil {
+			if db.assets.Exists(assets.KindTTS, hash) {
+				if db.verifyAssetDigest(assets.KindTTS, hash) {
+					hasTTS = true
+					ttsCount++
+				} else {
+					slog.Warn("[SyncFileFlags] TTS blob failed digest check", "itemID", itemID, "hash", hash)
+					corruptCount++
+				}
+			}
 		}
+
+		updates[itemID] = flagstore.Flags{HasImage: len(variants.present) > 0, HasTTS: hasTTS}
+	}
+
+	// One batched write - a single transaction regardless of backend -
+	// instead of one UPDATE per item, which dominated cold-sync time on
+	// libraries with thousands of items.
+	if err := db.flags.SetBatch(updates); err != nil {
+		return fmt.Errorf("failed to persist synced flags: %w", err)
 	}
-	
-	slog.Info("File flags sync complete", 
+
+	slog.Info("File flags sync complete",
 		"total_items", len(itemIDs),
 		"images_found", imageCount,
-		"tts_found", ttsCount)
-	
+		"tts_found", ttsCount,
+		"corrupt_blobs", corruptCount)
+
+	if db.assets != nil {
+		if err := db.gcOrphanedAssets(); err != nil {
+			slog.Warn("[SyncFileFlags] orphaned asset cleanup failed", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// imageVariantSync is syncItemImageVariants' result: present is the sorted
+// list of variant names ("hero" first, then whatever's in image_variants)
+// whose blob actually exists and passes its digest check; corrupt counts
+// variants whose blob exists but failed that check.
+type imageVariantSync struct {
+	present []string
+	corrupt int
+}
+
+// syncItemImageVariants checks itemID's "hero" image (image_cache) and every
+// named variant (image_variants) against db.assets, and reports which are
+// actually present and intact (see verifyAssetDigest). The result feeds both
+// has_image (len(present) > 0) and image_files_json (present itself, via
+// Item.Images) in SyncFileFlags.
+func (db *DB) syncItemImageVariants(itemID int) (imageVariantSync, error) {
+	var result imageVariantSync
+	if db.assets == nil {
+		return result, nil
+	}
+
+	check := func(variant, hash string) {
+		if hash == "" || !db.assets.Exists(assets.KindImage, hash) {
+			return
+		}
+		if db.verifyAssetDigest(assets.KindImage, hash) {
+			result.present = append(result.present, variant)
+		} else {
+			slog.Warn("[SyncFileFlags] image blob failed digest check", "itemID", itemID, "variant", variant, "hash", hash)
+			result.corrupt++
+		}
+	}
+
+	heroHash, err := db.GetImageCacheHash(itemID)
+	if err != nil {
+		return result, fmt.Errorf("failed to get image cache hash for item %d: %w", itemID, err)
+	}
+	check("hero", heroHash)
+
+	variantHashes, err := db.GetImageVariantHashes(itemID)
+	if err != nil {
+		return result, fmt.Errorf("failed to get image variant hashes for item %d: %w", itemID, err)
+	}
+	variantNames := make([]string, 0, len(variantHashes))
+	for variant := range variantHashes {
+		variantNames = append(variantNames, variant)
+	}
+	sort.Strings(variantNames)
+	for _, variant := range variantNames {
+		check(variant, variantHashes[variant])
+	}
+
+	return result, nil
+}
+
+// verifyAssetDigest recomputes the SHA-256 digest of the blob stored under
+// hash for kind and reports whether it still matches hash itself - a blob
+// is content-addressed by definition, so any mismatch means the file was
+// corrupted or truncated on disk after being written.
+func (db *DB) verifyAssetDigest(kind assets.Kind, hash string) bool {
+	r, err := db.assets.Get(kind, hash)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = r.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return false
+	}
+	return hex.EncodeToString(h.Sum(nil)) == hash
+}
+
+// gcOrphanedAssets deletes every image/TTS blob no item's image_cache/
+// image_variants/tts_cache row references anymore - leftovers from a
+// deleted item, a merge, or a definition edit that moved an item onto a
+// different content hash. Since blobs are content-addressed, two items
+// sharing identical generated audio or imagery already point at the same
+// hash and so the same blob; nothing extra is needed to get that
+// deduplication, this pass only ever removes blobs nothing points at
+// anymore.
+func (db *DB) gcOrphanedAssets() error {
+	deleted, err := db.gcOrphanedAssetsOfKind(assets.KindImage, db.AllImageCacheHashes)
+	if err != nil {
+		return err
+	}
+	deletedTTS, err := db.gcOrphanedAssetsOfKind(assets.KindTTS, db.AllTTSCacheHashes)
+	if err != nil {
+		return err
+	}
+	if deleted+deletedTTS > 0 {
+		slog.Info("[SyncFileFlags] removed orphaned blobs", "images", deleted, "tts", deletedTTS)
+	}
 	return nil
 }
+
+// gcOrphanedAssetsOfKind is gcOrphanedAssets for one Kind, given the
+// referenced-hashes lookup (AllImageCacheHashes/AllTTSCacheHashes) that
+// defines what's still live for it.
+func (db *DB) gcOrphanedAssetsOfKind(kind assets.Kind, referencedHashes func() ([]string, error)) (int, error) {
+	referenced, err := referencedHashes()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list referenced %s hashes: %w", kind, err)
+	}
+	keep := make(map[string]bool, len(referenced))
+	for _, hash := range referenced {
+		keep[hash] = true
+	}
+
+	stored, err := db.assets.List(kind)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list stored %s blobs: %w", kind, err)
+	}
+
+	deleted := 0
+	for _, key := range stored {
+		if keep[key] {
+			continue
+		}
+		if err := db.assets.Delete(kind, key); err != nil {
+			slog.Warn("[SyncFileFlags] failed to delete orphaned blob", "kind", kind, "key", key, "error", err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
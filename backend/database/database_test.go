@@ -1,10 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/TrueBlocks/trueblocks-poetry/backend/flagstore"
+	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -102,7 +107,7 @@ func TestNormalizeDefinitionNilPointer(t *testing.T) {
 }
 
 // setupTestDB creates a temporary SQLite database for testing
-func setupTestDB(t *testing.T) *DB {
+func setupTestDB(t testing.TB) *DB {
 	t.Helper()
 
 	// Create temporary directory
@@ -127,6 +132,11 @@ CREATE TABLE IF NOT EXISTS items (
     source TEXT,
     source_pg TEXT,
     mark TEXT,
+    image_files_json TEXT,
+    has_image INTEGER NOT NULL DEFAULT 0,
+    has_tts INTEGER NOT NULL DEFAULT 0,
+    tts_tagged INTEGER NOT NULL DEFAULT 0,
+    pack_name TEXT,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     modified_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
@@ -148,6 +158,94 @@ CREATE INDEX IF NOT EXISTS idx_items_word ON items(word COLLATE NOCASE);
 CREATE INDEX IF NOT EXISTS idx_items_type ON items(type);
 CREATE INDEX IF NOT EXISTS idx_links_source ON links(source_item_id);
 CREATE INDEX IF NOT EXISTS idx_links_destination ON links(destination_item_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    tag_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    item_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    tag TEXT NOT NULL COLLATE NOCASE,
+    FOREIGN KEY (item_id) REFERENCES items(item_id) ON DELETE CASCADE,
+    UNIQUE(item_id, kind, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tags_kind_tag ON tags(kind, tag COLLATE NOCASE);
+
+CREATE TABLE IF NOT EXISTS tts_cache (
+    item_id INTEGER PRIMARY KEY,
+    content_hash TEXT NOT NULL,
+    FOREIGN KEY (item_id) REFERENCES items(item_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tts_cache_hash ON tts_cache(content_hash);
+
+CREATE TABLE IF NOT EXISTS image_cache (
+    item_id INTEGER PRIMARY KEY,
+    content_hash TEXT NOT NULL,
+    FOREIGN KEY (item_id) REFERENCES items(item_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_cache_hash ON image_cache(content_hash);
+
+CREATE TABLE IF NOT EXISTS image_variants (
+    item_id INTEGER NOT NULL,
+    variant TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    PRIMARY KEY (item_id, variant),
+    FOREIGN KEY (item_id) REFERENCES items(item_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_variants_hash ON image_variants(content_hash);
+
+CREATE TABLE IF NOT EXISTS merge_journal (
+    journal_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    original_item_id INTEGER NOT NULL,
+    duplicate_item_id INTEGER NOT NULL,
+    undo_data TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    undone_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS content_packs_applied (
+    pack_name TEXT PRIMARY KEY,
+    version TEXT NOT NULL,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS literary_terms (
+    term_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    term TEXT NOT NULL,
+    type TEXT,
+    definition TEXT,
+    examples TEXT,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS activity (
+    activity_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor TEXT NOT NULL DEFAULT '',
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    before_json TEXT,
+    after_json TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    reverted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_entity ON activity(entity_type, entity_id);
 `
 
 	if _, err := sqlDB.Exec(schema); err != nil {
@@ -155,7 +253,7 @@ CREATE INDEX IF NOT EXISTS idx_links_destination ON links(destination_item_id);
 		t.Fatalf("Failed to execute schema: %v", err)
 	}
 
-	db := &DB{conn: sqlDB}
+	db := &DB{conn: sqlx.NewDb(sqlDB, DriverSQLite), driver: DriverSQLite, hub: newChangeHub(), resolver: NewWordResolver(nil), flags: flagstore.NewSQLiteFlagStore(sqlDB, nil)}
 	return db
 }
 
@@ -167,7 +265,7 @@ func TestCreateItem(t *testing.T) {
 	item := Item{
 		Word:       "poetry",
 		Type:       "Reference",
-		Definition: &def,
+		Definition: NewLazyString(&def),
 	}
 
 	id, err := db.CreateItem(item)
@@ -175,11 +273,10 @@ func TestCreateItem(t *testing.T) {
 		t.Fatalf("CreateItem failed: %v", err)
 	}
 
-	// Note: CreateItem inserts item_id=0 when ItemID is not set, which becomes
-	// row 0 in SQLite. This is a quirk of the implementation.
-	// The important thing is that we can retrieve the item.
-	if id < 0 {
-		t.Errorf("Expected non-negative item ID, got %d", id)
+	// CreateItem now omits item_id from the INSERT when it's zero, so
+	// SQLite auto-assigns the row and the id is always positive.
+	if id <= 0 {
+		t.Errorf("Expected positive item ID, got %d", id)
 	}
 
 	// Verify item was created by retrieving by word
@@ -252,7 +349,7 @@ func TestUpdateItem(t *testing.T) {
 	item := Item{
 		Word:       "poetry",
 		Type:       "Reference",
-		Definition: &origDef,
+		Definition: NewLazyString(&origDef),
 	}
 	id, err := db.CreateItem(item)
 	if err != nil {
@@ -263,8 +360,8 @@ func TestUpdateItem(t *testing.T) {
 	item.ItemID = id
 	updatedDef := "Updated definition"
 	derivation := "From Latin poeta"
-	item.Definition = &updatedDef
-	item.Derivation = &derivation
+	item.Definition = NewLazyString(&updatedDef)
+	item.Derivation = NewLazyString(&derivation)
 	if err := db.UpdateItem(item); err != nil {
 		t.Fatalf("UpdateItem failed: %v", err)
 	}
@@ -275,11 +372,11 @@ func TestUpdateItem(t *testing.T) {
 		t.Fatalf("GetItem failed: %v", err)
 	}
 
-	if updated.Definition == nil || *updated.Definition != "Updated definition" {
-		t.Errorf("Expected updated definition, got %v", updated.Definition)
+	if got := updated.Definition.GetOrEmpty(); got != "Updated definition" {
+		t.Errorf("Expected updated definition, got %q", got)
 	}
-	if updated.Derivation == nil || *updated.Derivation != "From Latin poeta" {
-		t.Errorf("Expected derivation, got %v", updated.Derivation)
+	if got := updated.Derivation.GetOrEmpty(); got != "From Latin poeta" {
+		t.Errorf("Expected derivation, got %q", got)
 	}
 }
 
@@ -351,35 +448,39 @@ func TestSearchItems(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	// Create test items one at a time to avoid ID conflicts
-	// Note: CreateItem uses ItemID=0 for all new items, which causes PRIMARY KEY conflicts
-	// on subsequent inserts. This is a known limitation we work around by creating items
-	// in separate test scenarios.
-
+	// Seed several items in one transaction via CreateItems, now that
+	// CreateItem/CreateItems no longer collide on a shared ItemID=0.
 	def1 := "The art of verse"
-	item1 := Item{Word: "poetry", Type: "Reference", Definition: &def1}
-	_, err := db.CreateItem(item1)
+	def2 := "A lyric poem"
+	def3 := "A book of maps"
+	ids, err := db.CreateItems([]Item{
+		{Word: "poetry", Type: "Reference", Definition: NewLazyString(&def1)},
+		{Word: "poem", Type: "Reference", Definition: NewLazyString(&def2)},
+		{Word: "atlas", Type: "Reference", Definition: NewLazyString(&def3)},
+	})
 	if err != nil {
-		t.Fatalf("Failed to create item1: %v", err)
+		t.Fatalf("CreateItems failed: %v", err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("Expected 3 ids, got %d", len(ids))
 	}
 
-	// Test search by word
+	// Test search by exact word
 	results, err := db.SearchItems("poetry")
 	if err != nil {
 		t.Fatalf("SearchItems failed: %v", err)
 	}
-	if len(results) < 1 {
-		t.Errorf("Expected at least 1 result for 'poetry', got %d", len(results))
+	if len(results) != 1 || results[0].Word != "poetry" {
+		t.Errorf("Expected exactly 1 result for 'poetry', got %v", results)
 	}
 
-	// Test search by partial match requires multiple items, but we can't create them
-	// due to the ItemID=0 limitation. Test with existing item.
+	// Test search by partial match across multiple rows
 	results, err = db.SearchItems("poe")
 	if err != nil {
 		t.Fatalf("SearchItems for 'poe' failed: %v", err)
 	}
-	if len(results) < 1 {
-		t.Errorf("Expected at least 1 result for 'poe', got %d", len(results))
+	if len(results) != 2 {
+		t.Errorf("Expected 2 results for 'poe' (poetry, poem), got %d", len(results))
 	}
 
 	// Test search by definition content
@@ -387,8 +488,17 @@ func TestSearchItems(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SearchItems for 'verse' failed: %v", err)
 	}
-	if len(results) < 1 {
-		t.Errorf("Expected at least 1 result for definition search, got %d", len(results))
+	if len(results) != 1 || results[0].Word != "poetry" {
+		t.Errorf("Expected 1 result matching 'poetry' definition, got %v", results)
+	}
+
+	// Test search with no match
+	results, err = db.SearchItems("nonexistentterm")
+	if err != nil {
+		t.Fatalf("SearchItems for 'nonexistentterm' failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected 0 results for 'nonexistentterm', got %d", len(results))
 	}
 }
 
@@ -429,6 +539,179 @@ func TestToggleItemMark(t *testing.T) {
 	}
 }
 
+func TestSyncItemTagsAndGetItemsByTag(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	def := "a poem about #grief and #loss"
+	item := Item{
+		Word:       "elegy",
+		Type:       "Reference",
+		Definition: NewLazyString(&def),
+	}
+	id, err := db.CreateItem(item)
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	results, err := db.GetItemsByTag("grief", "hashtag")
+	if err != nil {
+		t.Fatalf("GetItemsByTag failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ItemID != id {
+		t.Fatalf("expected item %d tagged 'grief', got %+v", id, results)
+	}
+
+	// Updating the definition should drop tags no longer present.
+	updatedDef := "a poem about #loss alone"
+	item.ItemID = id
+	item.Definition = NewLazyString(&updatedDef)
+	if err := db.UpdateItem(item); err != nil {
+		t.Fatalf("UpdateItem failed: %v", err)
+	}
+
+	if results, err := db.GetItemsByTag("grief", ""); err != nil {
+		t.Fatalf("GetItemsByTag failed: %v", err)
+	} else if len(results) != 0 {
+		t.Errorf("expected 'grief' tag to be removed after update, got %+v", results)
+	}
+
+	if results, err := db.GetItemsByTag("loss", ""); err != nil {
+		t.Fatalf("GetItemsByTag failed: %v", err)
+	} else if len(results) != 1 || results[0].ItemID != id {
+		t.Errorf("expected item %d still tagged 'loss', got %+v", id, results)
+	}
+}
+
+func TestTTSCacheHashRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	def := "a poem"
+	id, err := db.CreateItem(Item{Word: "echo", Type: "Reference", Definition: NewLazyString(&def)})
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	if hash, err := db.GetTTSCacheHash(id); err != nil {
+		t.Fatalf("GetTTSCacheHash failed: %v", err)
+	} else if hash != "" {
+		t.Fatalf("expected no TTS cache hash before one is set, got %q", hash)
+	}
+
+	if err := db.SetTTSCacheHash(id, "abc123"); err != nil {
+		t.Fatalf("SetTTSCacheHash failed: %v", err)
+	}
+	if hash, err := db.GetTTSCacheHash(id); err != nil {
+		t.Fatalf("GetTTSCacheHash failed: %v", err)
+	} else if hash != "abc123" {
+		t.Fatalf("expected hash 'abc123', got %q", hash)
+	}
+
+	if count, err := db.CountTTSCacheRefs("abc123"); err != nil {
+		t.Fatalf("CountTTSCacheRefs failed: %v", err)
+	} else if count != 1 {
+		t.Fatalf("expected 1 reference to 'abc123', got %d", count)
+	}
+
+	// Re-setting the hash for the same item should update, not duplicate.
+	if err := db.SetTTSCacheHash(id, "def456"); err != nil {
+		t.Fatalf("SetTTSCacheHash failed: %v", err)
+	}
+	if count, err := db.CountTTSCacheRefs("abc123"); err != nil {
+		t.Fatalf("CountTTSCacheRefs failed: %v", err)
+	} else if count != 0 {
+		t.Fatalf("expected 'abc123' to have no references after re-hash, got %d", count)
+	}
+
+	if err := db.DeleteTTSCacheHash(id); err != nil {
+		t.Fatalf("DeleteTTSCacheHash failed: %v", err)
+	}
+	if hash, err := db.GetTTSCacheHash(id); err != nil {
+		t.Fatalf("GetTTSCacheHash failed: %v", err)
+	} else if hash != "" {
+		t.Fatalf("expected no TTS cache hash after delete, got %q", hash)
+	}
+}
+
+func TestWithTxCommit(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var id1, id2 int
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		var err error
+		id1, err = tx.CreateItem(Item{Word: "poetry", Type: "Reference"})
+		if err != nil {
+			return err
+		}
+		id2, err = tx.CreateItem(Item{Word: "verse", Type: "Reference"})
+		if err != nil {
+			return err
+		}
+		return tx.CreateLink(id1, id2, "reference")
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+
+	if _, err := db.GetItem(id1); err != nil {
+		t.Errorf("expected item %d to exist after commit: %v", id1, err)
+	}
+	links, err := db.GetItemLinks(id1)
+	if err != nil {
+		t.Fatalf("GetItemLinks failed: %v", err)
+	}
+	if len(links) != 1 || links[0].DestinationItemID != id2 {
+		t.Errorf("expected a link from %d to %d, got %v", id1, id2, links)
+	}
+}
+
+func TestWithTxRollback(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	wantErr := errors.New("boom")
+	var id int
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		var err error
+		id, err = tx.CreateItem(Item{Word: "poetry", Type: "Reference"})
+		if err != nil {
+			return err
+		}
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected WithTx to return the inner error, got %v", err)
+	}
+
+	if _, err := db.GetItem(id); err == nil {
+		t.Errorf("expected item %d to have been rolled back", id)
+	}
+}
+
+func TestChangeHubPublishSubscribe(t *testing.T) {
+	hub := newChangeHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := (&DB{hub: hub}).Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	hub.publish(ChangeEvent{Op: ChangeInsert, Table: "items", ItemID: 7})
+
+	select {
+	case ev := <-ch:
+		if ev.Op != ChangeInsert || ev.Table != "items" || ev.ItemID != 7 {
+			t.Errorf("unexpected event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a ChangeEvent, got none")
+	}
+}
+
 // Note: GetItemByWord, GetDuplicateItems, and CreateLinkOrRemoveTags require database integration tests
 // These would need:
 // 1. Test database setup/teardown
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+)
+
+// createEgoGraphItem is a small helper wrapping CreateItem for tests that
+// only care about an item's ID, not its other fields.
+func createEgoGraphItem(t *testing.T, db *DB, word string) int {
+	t.Helper()
+	id, err := db.CreateItem(Item{Word: word, Type: "Reference"})
+	if err != nil {
+		t.Fatalf("CreateItem(%q) failed: %v", word, err)
+	}
+	return id
+}
+
+func TestGetEgoGraphDepthZero(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	center := createEgoGraphItem(t, db, "center")
+	neighbor := createEgoGraphItem(t, db, "neighbor")
+	if err := db.CreateLink(center, neighbor, "related"); err != nil {
+		t.Fatalf("CreateLink failed: %v", err)
+	}
+
+	graph, err := db.GetEgoGraph(center, 0)
+	if err != nil {
+		t.Fatalf("GetEgoGraph failed: %v", err)
+	}
+	if len(graph.Items) != 1 || graph.Items[0].ItemID != center {
+		t.Fatalf("expected only the center item at depth 0, got %+v", graph.Items)
+	}
+	if len(graph.Links) != 0 {
+		t.Errorf("expected no links at depth 0, got %+v", graph.Links)
+	}
+	if graph.Distance[center] != 0 {
+		t.Errorf("expected center distance 0, got %d", graph.Distance[center])
+	}
+}
+
+func TestGetEgoGraphDepthOne(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	center := createEgoGraphItem(t, db, "center")
+	neighbor := createEgoGraphItem(t, db, "neighbor")
+	distant := createEgoGraphItem(t, db, "distant")
+	if err := db.CreateLink(center, neighbor, "related"); err != nil {
+		t.Fatalf("CreateLink failed: %v", err)
+	}
+	if err := db.CreateLink(neighbor, distant, "related"); err != nil {
+		t.Fatalf("CreateLink failed: %v", err)
+	}
+
+	graph, err := db.GetEgoGraph(center, 1)
+	if err != nil {
+		t.Fatalf("GetEgoGraph failed: %v", err)
+	}
+	if len(graph.Items) != 2 {
+		t.Fatalf("expected center + neighbor at depth 1, got %+v", graph.Items)
+	}
+	if graph.Distance[center] != 0 {
+		t.Errorf("expected center distance 0, got %d", graph.Distance[center])
+	}
+	if graph.Distance[neighbor] != 1 {
+		t.Errorf("expected neighbor distance 1, got %d", graph.Distance[neighbor])
+	}
+	if _, ok := graph.Distance[distant]; ok {
+		t.Errorf("distant item should not be reachable at depth 1")
+	}
+}
+
+func TestGetEgoGraphDepthN(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// A chain: center - a - b - c, three hops apart at most.
+	center := createEgoGraphItem(t, db, "center")
+	a := createEgoGraphItem(t, db, "a")
+	b := createEgoGraphItem(t, db, "b")
+	c := createEgoGraphItem(t, db, "c")
+	for _, link := range [][2]int{{center, a}, {a, b}, {b, c}} {
+		if err := db.CreateLink(link[0], link[1], "related"); err != nil {
+			t.Fatalf("CreateLink failed: %v", err)
+		}
+	}
+
+	graph, err := db.GetEgoGraph(center, 3)
+	if err != nil {
+		t.Fatalf("GetEgoGraph failed: %v", err)
+	}
+	if len(graph.Items) != 4 {
+		t.Fatalf("expected all 4 items within depth 3, got %+v", graph.Items)
+	}
+	wantDistance := map[int]int{center: 0, a: 1, b: 2, c: 3}
+	for id, want := range wantDistance {
+		if got := graph.Distance[id]; got != want {
+			t.Errorf("item %d: expected distance %d, got %d", id, want, got)
+		}
+	}
+	if len(graph.Links) != 3 {
+		t.Errorf("expected 3 induced links, got %d", len(graph.Links))
+	}
+}
+
+func TestGetEgoGraphHandlesCycles(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// A triangle: center - a - b - center.
+	center := createEgoGraphItem(t, db, "center")
+	a := createEgoGraphItem(t, db, "a")
+	b := createEgoGraphItem(t, db, "b")
+	for _, link := range [][2]int{{center, a}, {a, b}, {b, center}} {
+		if err := db.CreateLink(link[0], link[1], "related"); err != nil {
+			t.Fatalf("CreateLink failed: %v", err)
+		}
+	}
+
+	graph, err := db.GetEgoGraph(center, 5)
+	if err != nil {
+		t.Fatalf("GetEgoGraph failed: %v", err)
+	}
+	if len(graph.Items) != 3 {
+		t.Fatalf("expected exactly 3 distinct items despite the cycle, got %+v", graph.Items)
+	}
+	if graph.Distance[a] != 1 || graph.Distance[b] != 1 {
+		t.Errorf("expected both a and b one hop from center, got a=%d b=%d", graph.Distance[a], graph.Distance[b])
+	}
+	if len(graph.Links) != 3 {
+		t.Errorf("expected all 3 triangle links in the induced subgraph, got %d", len(graph.Links))
+	}
+}
+
+func TestGetEgoGraphTruncatesAtNodeLimit(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	center := createEgoGraphItem(t, db, "center")
+	for i := 0; i < egoGraphNodeLimit+10; i++ {
+		leaf := createEgoGraphItem(t, db, fmt.Sprintf("leaf%d", i))
+		if err := db.CreateLink(center, leaf, "related"); err != nil {
+			t.Fatalf("CreateLink failed: %v", err)
+		}
+	}
+
+	graph, err := db.GetEgoGraph(center, 1)
+	if err != nil {
+		t.Fatalf("GetEgoGraph failed: %v", err)
+	}
+	if len(graph.Items) != egoGraphNodeLimit {
+		t.Fatalf("expected truncation at %d nodes, got %d", egoGraphNodeLimit, len(graph.Items))
+	}
+	if graph.Distance[center] != 0 {
+		t.Errorf("expected the center to survive truncation (closest nodes kept first), got distance %d", graph.Distance[center])
+	}
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// ftsSchemaVersionSetting is the settings key RebuildFTS5Index stamps once
+// items_fts and its sync triggers are in place, via the existing
+// GetSetting/SetSetting machinery - the same idempotency mechanism
+// schema_migrations backs for the migrations.Runner.
+const ftsSchemaVersionSetting = "fts5_schema_version"
+
+// ftsSchemaVersion is bumped whenever RebuildFTS5Index's table or trigger
+// definitions change in a way an existing installation needs to pick up.
+const ftsSchemaVersion = "1"
+
+// ftsTriggers maps each items_fts sync trigger (named to match the
+// items_ai/items_ad/items_au triggers openSQLite already knows to drop when
+// FTS5 isn't available) to its CREATE TRIGGER statement.
+var ftsTriggers = map[string]string{
+	"items_ai": `
+		CREATE TRIGGER items_ai AFTER INSERT ON items BEGIN
+			INSERT INTO items_fts(rowid, word, definition, derivation, appendicies)
+			VALUES (new.item_id, new.word, new.definition, new.derivation, new.appendicies);
+		END
+	`,
+	"items_ad": `
+		CREATE TRIGGER items_ad AFTER DELETE ON items BEGIN
+			INSERT INTO items_fts(items_fts, rowid, word, definition, derivation, appendicies)
+			VALUES ('delete', old.item_id, old.word, old.definition, old.derivation, old.appendicies);
+		END
+	`,
+	"items_au": `
+		CREATE TRIGGER items_au AFTER UPDATE ON items BEGIN
+			INSERT INTO items_fts(items_fts, rowid, word, definition, derivation, appendicies)
+			VALUES ('delete', old.item_id, old.word, old.definition, old.derivation, old.appendicies);
+			INSERT INTO items_fts(rowid, word, definition, derivation, appendicies)
+			VALUES (new.item_id, new.word, new.definition, new.derivation, new.appendicies);
+		END
+	`,
+}
+
+// RebuildFTS5Index creates the items_fts external-content FTS5 virtual
+// table and its items_ai/items_ad/items_au sync triggers if they don't
+// already exist, repopulates items_fts from the current contents of items,
+// and stamps ftsSchemaVersionSetting so a later call is a no-op. Databases
+// built from the pre-built data.tar.gz already ship items_fts and its
+// triggers; this is what lets a database created from scratch by
+// openSQLite - or an existing one after a future ftsSchemaVersion bump -
+// reach the same state, so SearchItems/SearchItemsWithOptions/
+// SearchItemsWithSnippets' FTS5 path works unconditionally.
+func (db *DB) RebuildFTS5Index() error {
+	if db.driver == DriverPostgres {
+		return fmt.Errorf("FTS5 is a SQLite feature; Postgres searches via tsvector instead")
+	}
+
+	version, err := db.GetSetting(ftsSchemaVersionSetting)
+	if err != nil {
+		return fmt.Errorf("failed to read fts5 schema version: %w", err)
+	}
+	if version == ftsSchemaVersion {
+		return nil
+	}
+
+	if _, err := db.conn.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS items_fts USING fts5(
+			word, definition, derivation, appendicies,
+			content='items', content_rowid='item_id'
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create items_fts table: %w", err)
+	}
+
+	for name, ddl := range ftsTriggers {
+		if _, err := db.conn.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %s", name)); err != nil {
+			return fmt.Errorf("failed to drop stale %s trigger: %w", name, err)
+		}
+		if _, err := db.conn.Exec(ddl); err != nil {
+			return fmt.Errorf("failed to create %s trigger: %w", name, err)
+		}
+	}
+
+	if _, err := db.conn.Exec(`INSERT INTO items_fts(items_fts) VALUES ('rebuild')`); err != nil {
+		return fmt.Errorf("failed to populate items_fts: %w", err)
+	}
+
+	if err := db.SetSetting(ftsSchemaVersionSetting, ftsSchemaVersion); err != nil {
+		return fmt.Errorf("failed to stamp fts5 schema version: %w", err)
+	}
+
+	slog.Info("[DB] Rebuilt FTS5 index")
+	return nil
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultLazyCacheBytes is the default ceiling for the shared lazy-string
+// cache, per the "delayed intern" pattern: big text columns are only pulled
+// off disk once something actually asks for them, and evicted in LRU order
+// once the cache grows past this size.
+const defaultLazyCacheBytes = 32 * 1024 * 1024
+
+// LazyString is a deferred reference to one of an item's large text columns
+// (definition, derivation, appendicies). Most callers never notice it: when
+// the value is already in hand (e.g. a full row was just scanned, or the
+// frontend supplied it via JSON), Get returns it directly with no extra
+// work. When it's constructed by a RowIterator instead, Get fetches and
+// caches the column from the database the first time it's needed.
+type LazyString struct {
+	db     *DB
+	itemID int
+	column string
+	raw    *string
+}
+
+// NewLazyString wraps a value that's already known, so Get never needs to
+// touch the database.
+func NewLazyString(value *string) LazyString {
+	return LazyString{raw: value}
+}
+
+// newLazyRef defers loading column for itemID until Get is called.
+func newLazyRef(db *DB, itemID int, column string) LazyString {
+	return LazyString{db: db, itemID: itemID, column: column}
+}
+
+// Get returns the column's text, loading and caching it from the database on
+// first use if it wasn't already known. A nil *string with a nil error means
+// the column is NULL.
+func (l LazyString) Get() (*string, error) {
+	if l.raw != nil {
+		return l.raw, nil
+	}
+	if l.db == nil {
+		return nil, nil
+	}
+
+	key := lazyKey{itemID: l.itemID, column: l.column}
+	if cached, ok := sharedLazyCache.get(key); ok {
+		return cached, nil
+	}
+
+	if !lazyColumns[l.column] {
+		return nil, fmt.Errorf("lazystring: invalid column %q", l.column)
+	}
+
+	// l.column is validated against lazyColumns above, so this is never
+	// attacker-controlled SQL.
+	query := "SELECT " + l.column + " FROM items WHERE item_id = ?"
+	var value *string
+	if err := l.db.conn.QueryRow(query, l.itemID).Scan(&value); err != nil {
+		return nil, fmt.Errorf("failed to load %s for item %d: %w", l.column, l.itemID, err)
+	}
+
+	sharedLazyCache.put(key, value)
+	return value, nil
+}
+
+// GetOrEmpty returns the resolved value, or "" if it is NULL or a load error
+// occurs. Convenient for callers that only want best-effort text, such as
+// regex scans over a field that may or may not be set.
+func (l LazyString) GetOrEmpty() string {
+	v, err := l.Get()
+	if err != nil || v == nil {
+		return ""
+	}
+	return *v
+}
+
+// MarshalJSON resolves the lazy value before encoding, so the frontend sees
+// a plain string (or null) exactly as if the field were a *string. For a
+// RowIterator-sourced LazyString this goes through Get, so the column is
+// loaded from the database (or the shared cache) just as a direct Get would.
+func (l LazyString) MarshalJSON() ([]byte, error) {
+	v, err := l.Get()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON accepts a plain string or null, matching the wire format the
+// frontend already sends for these fields.
+func (l *LazyString) UnmarshalJSON(data []byte) error {
+	var v *string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	l.raw = v
+	return nil
+}
+
+var lazyColumns = map[string]bool{
+	"definition":  true,
+	"derivation":  true,
+	"appendicies": true,
+}
+
+type lazyKey struct {
+	itemID int
+	column string
+}
+
+type lazyEntry struct {
+	value    *string
+	size     int64
+	lastUsed time.Time
+}
+
+// lazyCache is a process-wide, size-bounded cache of lazily-loaded item text
+// columns, evicted in LRU order once the configured byte ceiling is exceeded.
+type lazyCache struct {
+	mu       sync.Mutex
+	entries  map[lazyKey]*lazyEntry
+	size     int64
+	maxBytes int64
+}
+
+var sharedLazyCache = &lazyCache{
+	entries:  make(map[lazyKey]*lazyEntry),
+	maxBytes: defaultLazyCacheBytes,
+}
+
+// SetLazyCacheLimit configures the byte ceiling of the shared lazy-string
+// cache. The default is 32 MB.
+func SetLazyCacheLimit(maxBytes int64) {
+	sharedLazyCache.mu.Lock()
+	defer sharedLazyCache.mu.Unlock()
+	sharedLazyCache.maxBytes = maxBytes
+	sharedLazyCache.evictLocked()
+}
+
+func (c *lazyCache) get(key lazyKey) (*string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e.lastUsed = time.Now()
+	return e.value, true
+}
+
+func (c *lazyCache) put(key lazyKey, value *string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	size := int64(0)
+	if value != nil {
+		size = int64(len(*value))
+	}
+
+	if existing, ok := c.entries[key]; ok {
+		c.size -= existing.size
+	}
+	c.entries[key] = &lazyEntry{value: value, size: size, lastUsed: time.Now()}
+	c.size += size
+
+	c.evictLocked()
+}
+
+func (c *lazyCache) evictLocked() {
+	if c.maxBytes <= 0 || c.size <= c.maxBytes {
+		return
+	}
+
+	keys := make([]lazyKey, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].lastUsed.Before(c.entries[keys[j]].lastUsed)
+	})
+
+	for _, k := range keys {
+		if c.size <= c.maxBytes {
+			break
+		}
+		c.size -= c.entries[k].size
+		delete(c.entries, k)
+	}
+}
@@ -0,0 +1,413 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+)
+
+// sqlExecutor is satisfied by both *sql.DB and *sql.Tx, so the query helpers
+// below can run either standalone or inside the *sql.Tx that
+// ItemService.MergeDuplicateItems opens for each duplicate,
+// without duplicating the SQL for each case.
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// MergeJournalEntry is one row of the merge_journal table: a record of a
+// past merge of one duplicate (ItemService.MergeDuplicateItems) that
+// ItemService.UndoMerge can replay in reverse.
+type MergeJournalEntry struct {
+	JournalID       int
+	OriginalItemID  int
+	DuplicateItemID int
+	UndoData        string
+	UndoneAt        *string
+}
+
+// getLinksTouchingItem returns every link that has itemID as either its
+// source or destination, so a caller redirecting those links can journal
+// enough to put them back afterward.
+func getLinksTouchingItem(ex sqlExecutor, itemID int) ([]Link, error) {
+	rows, err := ex.Query(`
+		SELECT link_id, source_item_id, destination_item_id, link_type, created_at
+		FROM links WHERE source_item_id = ? OR destination_item_id = ?
+	`, itemID, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links touching item %d: %w", itemID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var links []Link
+	for rows.Next() {
+		var l Link
+		if err := rows.Scan(&l.LinkID, &l.SourceItemID, &l.DestinationItemID, &l.LinkType, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// redirectLinkField repoints the source or destination (per field, which
+// must be "source_item_id" or "destination_item_id") of every link in
+// linkIDs from oldItemID to newItemID.
+func redirectLinkField(ex sqlExecutor, field string, linkIDs []int, newItemID int) error {
+	for _, linkID := range linkIDs {
+		query := fmt.Sprintf("UPDATE links SET %s = ? WHERE link_id = ?", field)
+		if _, err := ex.Exec(query, newItemID, linkID); err != nil {
+			return fmt.Errorf("failed to redirect link %d: %w", linkID, err)
+		}
+	}
+	return nil
+}
+
+// scanItemTxRow scans a full item row (in itemRow's column order) out of a
+// *sql.Row. getItemTx works through the sqlExecutor interface so it runs
+// standalone or inside a merge's *sql.Tx alike, which rules out sqlx's
+// StructScan (it needs a *sqlx.Row/*sqlx.Rows) - this is the one remaining
+// manual scan path for that reason.
+func scanItemTxRow(row *sql.Row) (Item, error) {
+	var r itemRow
+	err := row.Scan(
+		&r.ItemID, &r.Word, &r.Type, &r.Definition, &r.Derivation,
+		&r.Appendicies, &r.Source, &r.SourcePg, &r.Mark, &r.CreatedAt, &r.ModifiedAt,
+	)
+	if err != nil {
+		return Item{}, err
+	}
+	return r.toItem(), nil
+}
+
+// getItemTx fetches a single item row through ex, so it can be read inside
+// a merge's transaction (via a *sql.Tx) as well as standalone (via the DB's
+// own *sql.DB).
+func getItemTx(ex sqlExecutor, itemID int) (*Item, error) {
+	query := `
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, created_at, modified_at
+		FROM items
+		WHERE item_id = ?
+	`
+	item, err := scanItemTxRow(ex.QueryRow(query, itemID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("item not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	return &item, nil
+}
+
+// deleteItemTx deletes an item row through ex (standalone or inside a
+// transaction); DeleteItemTx is its entry point for callers holding a
+// *sql.Tx.
+func deleteItemTx(ex sqlExecutor, itemID int) error {
+	result, err := ex.Exec("DELETE FROM items WHERE item_id = ?", itemID)
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("item not found")
+	}
+	return nil
+}
+
+// insertItemTx recreates item with its original item_id, for UndoMerge
+// restoring a duplicate that was deleted by a prior merge.
+func insertItemTx(ex sqlExecutor, item Item) error {
+	definition, derivation, appendicies, err := resolveTextFields(item)
+	if err != nil {
+		return fmt.Errorf("failed to resolve text fields: %w", err)
+	}
+	_, err = ex.Exec(`
+		INSERT INTO items (
+			item_id, word, type, definition, derivation,
+			appendicies, source, source_pg, mark
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, item.ItemID, item.Word, item.Type, definition,
+		derivation, appendicies, item.Source, item.SourcePg, item.Mark)
+	if err != nil {
+		return fmt.Errorf("failed to restore item %d: %w", item.ItemID, err)
+	}
+	return nil
+}
+
+// RecordMergeJournal inserts a new merge_journal row inside tx, capturing
+// undoData (the caller's JSON-encoded undo record) for originalID/duplicateID
+// so UndoMerge can reverse the merge later.
+func (db *DB) RecordMergeJournal(tx *sql.Tx, originalID, duplicateID int, undoData string) (int, error) {
+	result, err := tx.Exec(`
+		INSERT INTO merge_journal (original_item_id, duplicate_item_id, undo_data)
+		VALUES (?, ?, ?)
+	`, originalID, duplicateID, undoData)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record merge journal: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get merge journal id: %w", err)
+	}
+	return int(id), nil
+}
+
+// GetMergeJournalEntry returns the merge_journal row for journalID.
+func (db *DB) GetMergeJournalEntry(journalID int) (*MergeJournalEntry, error) {
+	var e MergeJournalEntry
+	err := db.conn.QueryRow(`
+		SELECT journal_id, original_item_id, duplicate_item_id, undo_data, undone_at
+		FROM merge_journal WHERE journal_id = ?
+	`, journalID).Scan(&e.JournalID, &e.OriginalItemID, &e.DuplicateItemID, &e.UndoData, &e.UndoneAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("merge journal entry %d not found", journalID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge journal entry: %w", err)
+	}
+	return &e, nil
+}
+
+// MarkMergeJournalUndone stamps journalID's undone_at, so UndoMerge
+// refuses to replay the same journal entry twice.
+func (db *DB) MarkMergeJournalUndone(journalID int) error {
+	result, err := db.conn.Exec(`
+		UPDATE merge_journal SET undone_at = CURRENT_TIMESTAMP
+		WHERE journal_id = ? AND undone_at IS NULL
+	`, journalID)
+	if err != nil {
+		return fmt.Errorf("failed to mark merge journal undone: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("merge journal entry %d not found or already undone", journalID)
+	}
+	return nil
+}
+
+// MovedLink records that a link was redirected from one item to another by
+// a merge, so UndoMerge knows which link/field pairs to point back.
+type MovedLink struct {
+	LinkID int    `json:"linkId"`
+	Field  string `json:"field"` // "source_item_id" or "destination_item_id"
+}
+
+// itemFlagColumns is the allowlist GetItemFlagTx/SetItemFlagTx check against,
+// since the column name is interpolated into SQL rather than bound.
+var itemFlagColumns = map[string]bool{"has_tts": true, "has_image": true}
+
+// MergeRedirectLinksTx repoints every link touching duplicateID (as source
+// or destination) to originalID instead, inside tx, returning the link/field
+// pairs it moved so UndoMerge can put them back.
+func (db *DB) MergeRedirectLinksTx(tx *sql.Tx, originalID, duplicateID int) ([]MovedLink, error) {
+	links, err := getLinksTouchingItem(tx, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	var moved []MovedLink
+	for _, l := range links {
+		if l.SourceItemID == duplicateID {
+			if err := redirectLinkField(tx, "source_item_id", []int{l.LinkID}, originalID); err != nil {
+				return nil, err
+			}
+			moved = append(moved, MovedLink{LinkID: l.LinkID, Field: "source_item_id"})
+		}
+		if l.DestinationItemID == duplicateID {
+			if err := redirectLinkField(tx, "destination_item_id", []int{l.LinkID}, originalID); err != nil {
+				return nil, err
+			}
+			moved = append(moved, MovedLink{LinkID: l.LinkID, Field: "destination_item_id"})
+		}
+	}
+	return moved, nil
+}
+
+// RestoreLinksTx points every link in moved back at duplicateID, reversing a
+// prior MergeRedirectLinksTx.
+func (db *DB) RestoreLinksTx(tx *sql.Tx, moved []MovedLink, duplicateID int) error {
+	for _, m := range moved {
+		if err := redirectLinkField(tx, m.Field, []int{m.LinkID}, duplicateID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetItemTx fetches a single item row inside tx.
+func (db *DB) GetItemTx(tx *sql.Tx, itemID int) (*Item, error) {
+	return getItemTx(tx, itemID)
+}
+
+// DeleteItemTx deletes an item row inside tx.
+func (db *DB) DeleteItemTx(tx *sql.Tx, itemID int) error {
+	return deleteItemTx(tx, itemID)
+}
+
+// InsertItemTx recreates item (with its original item_id) inside tx, for
+// UndoMerge restoring a duplicate a prior merge deleted.
+func (db *DB) InsertItemTx(tx *sql.Tx, item Item) error {
+	return insertItemTx(tx, item)
+}
+
+// SyncItemTagsTx resyncs the tags table for item inside tx; used by
+// UndoMerge after InsertItemTx, since the restored row's tags were
+// cascade-deleted along with it.
+func (db *DB) SyncItemTagsTx(tx *sql.Tx, item Item) error {
+	definition, _, appendicies, err := resolveTextFields(item)
+	if err != nil {
+		return fmt.Errorf("failed to resolve text fields: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM tags WHERE item_id = ?", item.ItemID); err != nil {
+		return fmt.Errorf("failed to clear tags for item %d: %w", item.ItemID, err)
+	}
+	seen := make(map[string]bool)
+	for _, text := range []*string{definition, appendicies} {
+		if text == nil || *text == "" {
+			continue
+		}
+		for _, ref := range parser.ParseAllTags(*text) {
+			if ref.Type != parser.TagTypeHashtag && ref.Type != parser.TagTypeCategory && ref.Type != parser.TagTypeFrontmatter {
+				continue
+			}
+			key := ref.Type + "\x00" + strings.ToLower(ref.Value)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, err := tx.Exec(
+				"INSERT OR IGNORE INTO tags (item_id, kind, tag) VALUES (?, ?, ?)",
+				item.ItemID, ref.Type, ref.Value,
+			); err != nil {
+				return fmt.Errorf("failed to insert tag %q for item %d: %w", ref.Value, item.ItemID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetTTSCacheHashTx returns the content hash tts_cache has on record for
+// itemID inside tx, or "" if there isn't one.
+func (db *DB) GetTTSCacheHashTx(tx *sql.Tx, itemID int) (string, error) {
+	var hash string
+	err := tx.QueryRow("SELECT content_hash FROM tts_cache WHERE item_id = ?", itemID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query TTS cache hash: %w", err)
+	}
+	return hash, nil
+}
+
+// SetTTSCacheHashTx records contentHash as itemID's TTS content hash inside
+// tx, e.g. to restore a link UndoMerge is reversing.
+func (db *DB) SetTTSCacheHashTx(tx *sql.Tx, itemID int, contentHash string) error {
+	_, err := tx.Exec(`
+		INSERT INTO tts_cache (item_id, content_hash) VALUES (?, ?)
+		ON CONFLICT(item_id) DO UPDATE SET content_hash = excluded.content_hash
+	`, itemID, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to set TTS cache hash: %w", err)
+	}
+	return nil
+}
+
+// CountOtherTTSCacheRefsTx returns how many items other than excludeItemID
+// currently reference contentHash inside tx, so a caller can tell whether a
+// cached .mp3 would become orphaned if excludeItemID's row went away.
+func (db *DB) CountOtherTTSCacheRefsTx(tx *sql.Tx, contentHash string, excludeItemID int) (int, error) {
+	var count int
+	err := tx.QueryRow(
+		"SELECT COUNT(*) FROM tts_cache WHERE content_hash = ? AND item_id != ?",
+		contentHash, excludeItemID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count TTS cache references: %w", err)
+	}
+	return count, nil
+}
+
+// GetImageCacheHashTx returns the content hash image_cache has on record
+// for itemID inside tx, or "" if there isn't one.
+func (db *DB) GetImageCacheHashTx(tx *sql.Tx, itemID int) (string, error) {
+	var hash string
+	err := tx.QueryRow("SELECT content_hash FROM image_cache WHERE item_id = ?", itemID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query image cache hash: %w", err)
+	}
+	return hash, nil
+}
+
+// SetImageCacheHashTx records contentHash as itemID's image content hash
+// inside tx, e.g. to restore a link UndoMerge is reversing.
+func (db *DB) SetImageCacheHashTx(tx *sql.Tx, itemID int, contentHash string) error {
+	_, err := tx.Exec(`
+		INSERT INTO image_cache (item_id, content_hash) VALUES (?, ?)
+		ON CONFLICT(item_id) DO UPDATE SET content_hash = excluded.content_hash
+	`, itemID, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to set image cache hash: %w", err)
+	}
+	return nil
+}
+
+// CountOtherImageCacheRefsTx returns how many items other than
+// excludeItemID currently reference contentHash inside tx, so a caller can
+// tell whether a cached image would become orphaned if excludeItemID's row
+// went away.
+func (db *DB) CountOtherImageCacheRefsTx(tx *sql.Tx, contentHash string, excludeItemID int) (int, error) {
+	var count int
+	err := tx.QueryRow(
+		"SELECT COUNT(*) FROM image_cache WHERE content_hash = ? AND item_id != ?",
+		contentHash, excludeItemID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count image cache references: %w", err)
+	}
+	return count, nil
+}
+
+// GetItemFlagTx reads a boolean items column (has_tts or has_image) for
+// itemID inside tx.
+func (db *DB) GetItemFlagTx(tx *sql.Tx, itemID int, column string) (bool, error) {
+	if !itemFlagColumns[column] {
+		return false, fmt.Errorf("invalid item flag column %q", column)
+	}
+	var v int
+	query := fmt.Sprintf("SELECT %s FROM items WHERE item_id = ?", column)
+	if err := tx.QueryRow(query, itemID).Scan(&v); err != nil {
+		return false, fmt.Errorf("failed to query %s flag: %w", column, err)
+	}
+	return v != 0, nil
+}
+
+// SetItemFlagTx sets a boolean items column (has_tts or has_image) for
+// itemID inside tx.
+func (db *DB) SetItemFlagTx(tx *sql.Tx, itemID int, column string, value bool) error {
+	if !itemFlagColumns[column] {
+		return fmt.Errorf("invalid item flag column %q", column)
+	}
+	v := 0
+	if value {
+		v = 1
+	}
+	query := fmt.Sprintf("UPDATE items SET %s = ? WHERE item_id = ?", column)
+	if _, err := tx.Exec(query, v, itemID); err != nil {
+		return fmt.Errorf("failed to update %s flag: %w", column, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import "fmt"
+
+// AppliedMigrationVersions returns the set of migration versions recorded in
+// schema_migrations, for a migrations.Runner to diff against its registry.
+func (db *DB) AppliedMigrationVersions() (map[int]bool, error) {
+	rows, err := db.conn.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// RecordMigrationApplied inserts version/name into schema_migrations inside
+// its own transaction, marking it done so a migrations.Runner never reruns
+// it.
+func (db *DB) RecordMigrationApplied(version int, name string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration record transaction: %w", err)
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO schema_migrations (version, name) VALUES (?, ?)",
+		version, name,
+	); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to record migration %d as applied: %w", version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration record transaction: %w", err)
+	}
+	return nil
+}
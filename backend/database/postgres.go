@@ -0,0 +1,348 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/flagstore"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// openPostgres covers the subset of the schema exercised by the
+// placeholder-rewritten CRUD methods (CreateItem, GetItemByWord, UpdateItem,
+// DeleteItem, CreateLink, GetItemLinks, ToggleItemMark, GetMarkedItems,
+// SearchItems) - items, links, and tags. Unlike SQLite, where the schema
+// ships pre-built inside data.tar.gz, Postgres has nothing to open, so this
+// creates it from scratch.
+func openPostgres(dsn string) (*DB, error) {
+	sqlConn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	conn := sqlx.NewDb(sqlConn, DriverPostgres)
+	if err := conn.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	if _, err := conn.Exec(`CREATE EXTENSION IF NOT EXISTS citext`); err != nil {
+		return nil, fmt.Errorf("failed to create citext extension: %w", err)
+	}
+
+	// word is citext so GetItemByWord's LOWER(word) = LOWER(?) comparison
+	// (left untouched by the driver-agnostic query) is redundant but
+	// correct: citext already compares case-insensitively, and the
+	// expression index below keeps the comparison index-backed either way.
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS items (
+			item_id SERIAL PRIMARY KEY,
+			word CITEXT NOT NULL,
+			type TEXT NOT NULL,
+			definition TEXT,
+			derivation TEXT,
+			appendicies TEXT,
+			source TEXT,
+			source_pg TEXT,
+			mark TEXT,
+			image_files_json TEXT,
+			has_image BOOLEAN NOT NULL DEFAULT false,
+			has_tts BOOLEAN NOT NULL DEFAULT false,
+			tts_tagged BOOLEAN NOT NULL DEFAULT false,
+			pack_name TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			modified_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			search_vector TSVECTOR GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(word, '')), 'A') ||
+				setweight(to_tsvector('english', coalesce(definition, '')), 'B') ||
+				setweight(to_tsvector('english', coalesce(derivation, '')), 'C')
+			) STORED
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create items table: %w", err)
+	}
+	if _, err := conn.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_items_word ON items (LOWER(word))`); err != nil {
+		return nil, fmt.Errorf("failed to create items word index: %w", err)
+	}
+	if _, err := conn.Exec(`CREATE INDEX IF NOT EXISTS idx_items_search_vector ON items USING GIN (search_vector)`); err != nil {
+		return nil, fmt.Errorf("failed to create items search index: %w", err)
+	}
+
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS links (
+			link_id SERIAL PRIMARY KEY,
+			source_item_id INTEGER NOT NULL REFERENCES items(item_id) ON DELETE CASCADE,
+			destination_item_id INTEGER NOT NULL REFERENCES items(item_id) ON DELETE CASCADE,
+			link_type TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create links table: %w", err)
+	}
+
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS tags (
+			tag_id SERIAL PRIMARY KEY,
+			item_id INTEGER NOT NULL REFERENCES items(item_id) ON DELETE CASCADE,
+			kind TEXT NOT NULL,
+			tag CITEXT NOT NULL,
+			UNIQUE(item_id, kind, tag)
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create tags table: %w", err)
+	}
+	if _, err := conn.Exec(`CREATE INDEX IF NOT EXISTS idx_tags_kind_tag ON tags (kind, LOWER(tag))`); err != nil {
+		return nil, fmt.Errorf("failed to create tags index: %w", err)
+	}
+
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS content_packs_applied (
+			pack_name TEXT PRIMARY KEY,
+			version TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create content_packs_applied table: %w", err)
+	}
+
+	// Postgres doesn't drive ChangeEvents yet (that needs a LISTEN/NOTIFY
+	// trigger per the related request), but Subscribe must still work, so
+	// every DB gets a hub - this one just never publishes to it.
+	assetStore, err := defaultAssetStore()
+	if err != nil {
+		slog.Warn("[DB] Asset store unavailable, SyncFileFlags will see every item as missing its blob", "error", err)
+		assetStore = nil
+	}
+
+	return &DB{conn: conn, driver: DriverPostgres, hub: newChangeHub(), assets: assetStore, resolver: NewWordResolver(nil), flags: flagstore.NewSQLiteFlagStore(conn.DB, rebindPostgres)}, nil
+}
+
+// searchItemsPostgres is SearchItems' Postgres path: it ranks matches with
+// ts_rank over the generated search_vector column instead of SQLite's FTS5
+// virtual table / LIKE fallback.
+func (db *DB) searchItemsPostgres(query string) ([]Item, error) {
+	if query == "" {
+		items, err := db.queryItems(`
+			SELECT item_id, word, type, definition, derivation,
+			       appendicies, source, source_pg, mark, created_at, modified_at
+			FROM items
+			ORDER BY word
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+		return items, nil
+	}
+
+	items, err := db.queryItems(`
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, created_at, modified_at
+		FROM items
+		WHERE search_vector @@ plainto_tsquery('english', $1)
+		ORDER BY ts_rank(search_vector, plainto_tsquery('english', $1)) DESC, word
+	`, query)
+	if err != nil {
+		slog.Warn("[SearchItems] tsvector search failed, falling back to LIKE", "error", err)
+		searchTerm := "%" + query + "%"
+		items, err = db.queryItems(`
+			SELECT item_id, word, type, definition, derivation,
+			       appendicies, source, source_pg, mark, created_at, modified_at
+			FROM items
+			WHERE word ILIKE $1 OR definition ILIKE $1 OR derivation ILIKE $1 OR appendicies ILIKE $1
+			ORDER BY word
+		`, searchTerm)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+	}
+
+	return items, nil
+}
+
+// searchItemsWithOptionsPostgres is SearchItemsWithOptions' Postgres path:
+// it mirrors the SQLite query's filters, regex mode, and fallback, but
+// builds "$N" placeholders as clauses are appended (since Postgres has no
+// positional "?" to rebind) and swaps FTS5/REGEXP for tsvector/"~".
+func (db *DB) searchItemsWithOptionsPostgres(options SearchOptions) ([]Item, error) {
+	const selectCols = `item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, created_at, modified_at`
+
+	// filterClauses builds the type/source/has_image/has_tts WHERE clauses
+	// with "$N" placeholders numbered starting right after termArgs, since
+	// those always precede the filters in this function's queries.
+	filterClauses := func(termArgs []interface{}) (string, []interface{}) {
+		args := append([]interface{}{}, termArgs...)
+		var clauses []string
+		if len(options.Types) > 0 {
+			placeholders := make([]string, len(options.Types))
+			for i, t := range options.Types {
+				args = append(args, t)
+				placeholders[i] = fmt.Sprintf("$%d", len(args))
+			}
+			clauses = append(clauses, fmt.Sprintf("items.type IN (%s)", strings.Join(placeholders, ",")))
+		}
+		if options.Source != "" {
+			args = append(args, options.Source)
+			clauses = append(clauses, fmt.Sprintf("items.source = $%d", len(args)))
+		}
+		if options.HasImage {
+			clauses = append(clauses, "items.has_image = true")
+		}
+		if options.HasTts {
+			clauses = append(clauses, "items.has_tts = true")
+		}
+		return strings.Join(clauses, " AND "), args
+	}
+
+	if options.Query == "" {
+		whereSQL, args := filterClauses(nil)
+		sqlQuery := "SELECT " + selectCols + " FROM items"
+		if whereSQL != "" {
+			sqlQuery += " WHERE " + whereSQL
+		}
+		sqlQuery += " ORDER BY word"
+		items, err := db.queryItems(sqlQuery, args...)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+		return items, nil
+	}
+
+	if options.UseRegex {
+		op := "~"
+		if !options.CaseSensitive {
+			op = "~*"
+		}
+		whereSQL, args := filterClauses([]interface{}{options.Query})
+		sqlQuery := fmt.Sprintf(`
+			SELECT %s
+			FROM items
+			WHERE (coalesce(word, '') %s $1 OR coalesce(definition, '') %s $1 OR coalesce(derivation, '') %s $1 OR coalesce(appendicies, '') %s $1)
+		`, selectCols, op, op, op, op)
+		if whereSQL != "" {
+			sqlQuery += " AND " + whereSQL
+		}
+		sqlQuery += " ORDER BY word"
+
+		items, err := db.queryItems(sqlQuery, args...)
+		if err != nil {
+			return nil, fmt.Errorf("regex search failed: %w", err)
+		}
+		return items, nil
+	}
+
+	whereSQL, args := filterClauses([]interface{}{options.Query})
+	sqlQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM items
+		WHERE search_vector @@ plainto_tsquery('english', $1)
+	`, selectCols)
+	if whereSQL != "" {
+		sqlQuery += " AND " + whereSQL
+	}
+	sqlQuery += " ORDER BY ts_rank(search_vector, plainto_tsquery('english', $1)) DESC, word"
+
+	items, err := db.queryItems(sqlQuery, args...)
+	if err != nil {
+		slog.Warn("[SearchItemsWithOptions] tsvector search failed, falling back to LIKE", "error", err)
+		likeWhereSQL, likeArgs := filterClauses([]interface{}{"%" + options.Query + "%"})
+		sqlQuery = fmt.Sprintf(`
+			SELECT %s
+			FROM items
+			WHERE (word ILIKE $1 OR definition ILIKE $1 OR derivation ILIKE $1 OR appendicies ILIKE $1)
+		`, selectCols)
+		if likeWhereSQL != "" {
+			sqlQuery += " AND " + likeWhereSQL
+		}
+		sqlQuery += " ORDER BY word"
+		items, err = db.queryItems(sqlQuery, likeArgs...)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+	}
+
+	return items, nil
+}
+
+// searchResultColsPostgres is searchResultCols' Postgres equivalent: rank
+// comes from ts_rank instead of bm25 (higher is better here, the opposite of
+// SQLite's bm25 - see SearchResult.Rank), and snippet()/highlight() have no
+// Postgres FTS equivalent, so both are built from ts_headline instead - one
+// call across word+definition for the snippet, one per column for the
+// per-field highlights, keeping the same highlight_<field> aliases so
+// searchResultRow scans identically on both backends.
+const searchResultColsPostgres = `items.item_id, items.word, items.type, items.definition, items.derivation,
+	       items.appendicies, items.source, items.source_pg, items.mark,
+	       items.created_at, items.modified_at,
+	       ts_rank(items.search_vector, plainto_tsquery('english', $1)) AS rank,
+	       ts_headline('english', coalesce(items.definition, items.word), plainto_tsquery('english', $1), 'MaxFragments=1, MaxWords=32, MinWords=8') AS snippet,
+	       ts_headline('english', coalesce(items.word, ''), plainto_tsquery('english', $1)) AS highlight_word,
+	       ts_headline('english', coalesce(items.definition, ''), plainto_tsquery('english', $1)) AS highlight_definition,
+	       ts_headline('english', coalesce(items.derivation, ''), plainto_tsquery('english', $1)) AS highlight_derivation,
+	       ts_headline('english', coalesce(items.appendicies, ''), plainto_tsquery('english', $1)) AS highlight_appendicies`
+
+// searchItemsWithSnippetsPostgres is SearchItemsWithSnippets' Postgres path,
+// mirroring searchItemsWithOptionsPostgres's filter-building but against
+// tsvector/ts_headline instead of FTS5 - see searchResultColsPostgres. Like
+// its SQLite counterpart, it only covers the non-empty, non-regex query
+// case; the empty-query and regex paths fall back to
+// searchItemsWithOptionsPostgres with no rank or excerpt.
+func (db *DB) searchItemsWithSnippetsPostgres(options SearchOptions) ([]SearchResult, error) {
+	if options.Query == "" || options.UseRegex {
+		items, err := db.searchItemsWithOptionsPostgres(options)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]SearchResult, len(items))
+		for i, item := range items {
+			results[i] = SearchResult{Item: item}
+		}
+		return results, nil
+	}
+
+	filterClauses := func(termArgs []interface{}) (string, []interface{}) {
+		args := append([]interface{}{}, termArgs...)
+		var clauses []string
+		if len(options.Types) > 0 {
+			placeholders := make([]string, len(options.Types))
+			for i, t := range options.Types {
+				args = append(args, t)
+				placeholders[i] = fmt.Sprintf("$%d", len(args))
+			}
+			clauses = append(clauses, fmt.Sprintf("items.type IN (%s)", strings.Join(placeholders, ",")))
+		}
+		if options.Source != "" {
+			args = append(args, options.Source)
+			clauses = append(clauses, fmt.Sprintf("items.source = $%d", len(args)))
+		}
+		if options.HasImage {
+			clauses = append(clauses, "items.has_image = true")
+		}
+		if options.HasTts {
+			clauses = append(clauses, "items.has_tts = true")
+		}
+		return strings.Join(clauses, " AND "), args
+	}
+
+	whereSQL, args := filterClauses([]interface{}{options.Query})
+	sqlQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM items
+		WHERE search_vector @@ plainto_tsquery('english', $1)
+	`, searchResultColsPostgres)
+	if whereSQL != "" {
+		sqlQuery += " AND " + whereSQL
+	}
+	sqlQuery += " ORDER BY rank DESC, word"
+
+	var rows []searchResultRow
+	if err := db.conn.Select(&rows, sqlQuery, args...); err != nil {
+		return nil, fmt.Errorf("ranked search failed: %w", err)
+	}
+	results := make([]SearchResult, len(rows))
+	for i, r := range rows {
+		results[i] = r.toSearchResult()
+	}
+	return results, nil
+}
@@ -32,3 +32,28 @@ func MustLoadQuery(name string) string {
 	}
 	return query
 }
+
+// loadDialectQuery loads name, preferring a "<name>.postgres.sql" override
+// over the shared "<name>.sql" when db is a Postgres backend. None of the
+// stat queries in queries/ currently need Postgres-specific SQL - they're
+// all portable COUNT/LIKE/IN statements - but GetExtendedStats routes
+// through this instead of the bare LoadQuery so one can be dropped in next
+// to the shared file (e.g. "orphans.postgres.sql") without touching Go code.
+func (db *DB) loadDialectQuery(name string) (string, error) {
+	if db.driver == DriverPostgres {
+		if query, err := LoadQuery(name + ".postgres"); err == nil {
+			return query, nil
+		}
+	}
+	return LoadQuery(name)
+}
+
+// mustLoadDialectQuery is loadDialectQuery, panicking on error - for
+// call sites that, like MustLoadQuery, already assume the query exists.
+func (db *DB) mustLoadDialectQuery(name string) string {
+	query, err := db.loadDialectQuery(name)
+	if err != nil {
+		panic(err)
+	}
+	return query
+}
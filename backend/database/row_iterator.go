@@ -0,0 +1,70 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// IteratorRow is a single row yielded by a RowIterator. ItemID, Word, and
+// Type are always populated; the large text columns are deferred LazyString
+// references that only touch the database if a report actually calls Get on
+// them after a cheap pre-filter.
+type IteratorRow struct {
+	ItemID      int
+	Word        string
+	Type        string
+	Definition  LazyString
+	Derivation  LazyString
+	Appendicies LazyString
+}
+
+// RowIterator streams items one at a time without loading their large text
+// columns, so report scans that reject most rows on a cheap pre-filter never
+// pay for the definition/derivation/appendicies columns they don't need.
+type RowIterator struct {
+	db   *DB
+	rows *sql.Rows
+	err  error
+}
+
+// NewRowIterator streams every item's identifying fields in word order,
+// deferring the large text columns to lazy, on-demand loads.
+func (db *DB) NewRowIterator() (*RowIterator, error) {
+	rows, err := db.conn.Query("SELECT item_id, word, type FROM items ORDER BY word")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items: %w", err)
+	}
+	return &RowIterator{db: db, rows: rows}, nil
+}
+
+// Next advances to the next row, returning false once rows are exhausted (or
+// an error occurred, available via Err).
+func (it *RowIterator) Next() (*IteratorRow, bool) {
+	if !it.rows.Next() {
+		return nil, false
+	}
+
+	var row IteratorRow
+	if err := it.rows.Scan(&row.ItemID, &row.Word, &row.Type); err != nil {
+		it.err = fmt.Errorf("failed to scan item: %w", err)
+		return nil, false
+	}
+
+	row.Definition = newLazyRef(it.db, row.ItemID, "definition")
+	row.Derivation = newLazyRef(it.db, row.ItemID, "derivation")
+	row.Appendicies = newLazyRef(it.db, row.ItemID, "appendicies")
+	return &row, true
+}
+
+// Err returns the first error encountered during iteration, if any.
+func (it *RowIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.rows.Err()
+}
+
+// Close releases the underlying rows.
+func (it *RowIterator) Close() error {
+	return it.rows.Close()
+}
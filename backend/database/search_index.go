@@ -0,0 +1,248 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/mapping"
+)
+
+// searchIndexDir is the name of the Bleve index directory kept alongside
+// the SQLite database file.
+const searchIndexDir = "search.bleve"
+
+// searchIndexDoc is the document shape indexed into Bleve for each item -
+// the four text fields SearchItems' LIKE fallback matches against, plus
+// source.
+type searchIndexDoc struct {
+	Word        string `json:"word"`
+	Definition  string `json:"definition"`
+	Derivation  string `json:"derivation"`
+	Appendicies string `json:"appendicies"`
+	Source      string `json:"source"`
+}
+
+// SearchOpts controls SearchItemsRanked.
+type SearchOpts struct {
+	Limit int // max hits to return; <= 0 means a default of 20
+}
+
+// SearchHit is one ranked result from SearchItemsRanked: the matched item,
+// its Bleve relevance score, and an HTML-highlighted snippet ("<mark>...
+// </mark>") of whichever field matched, if any.
+type SearchHit struct {
+	Item    Item    `json:"item"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// buildSearchIndexMapping builds the mapping openOrCreateSearchIndex uses
+// when creating a fresh index: definition/derivation/appendicies/source get
+// English tokenization, stop-word removal, porter stemming, and unicode
+// normalization via Bleve's built-in "en" analyzer, while word is indexed
+// with the keyword analyzer (no tokenization or stemming) so it can be
+// matched exactly.
+func buildSearchIndexMapping() *mapping.IndexMappingImpl {
+	englishField := bleve.NewTextFieldMapping()
+	englishField.Analyzer = en.AnalyzerName
+
+	wordField := bleve.NewTextFieldMapping()
+	wordField.Analyzer = keyword.Name
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("word", wordField)
+	doc.AddFieldMappingsAt("definition", englishField)
+	doc.AddFieldMappingsAt("derivation", englishField)
+	doc.AddFieldMappingsAt("appendicies", englishField)
+	doc.AddFieldMappingsAt("source", englishField)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = en.AnalyzerName
+	return m
+}
+
+// openOrCreateSearchIndex opens the Bleve index at path, creating it with
+// buildSearchIndexMapping if it doesn't exist yet.
+func openOrCreateSearchIndex(path string) (bleve.Index, error) {
+	index, err := bleve.Open(path)
+	if err == nil {
+		return index, nil
+	}
+	if !errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		return nil, fmt.Errorf("failed to open search index: %w", err)
+	}
+
+	index, err = bleve.New(path, buildSearchIndexMapping())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search index: %w", err)
+	}
+	return index, nil
+}
+
+// searchIndexDocID is the Bleve document ID an item is indexed under.
+func searchIndexDocID(itemID int) string {
+	return strconv.Itoa(itemID)
+}
+
+// IndexItem adds or replaces item in the Bleve search index. It is a no-op
+// (returning nil) if the index isn't available, so callers like
+// CreateItem/UpdateItem can call it unconditionally and only need to log a
+// warning on a real failure.
+func (db *DB) IndexItem(item Item) error {
+	if db.searchIndex == nil {
+		return nil
+	}
+
+	definition, err := item.Definition.Get()
+	if err != nil {
+		return fmt.Errorf("failed to resolve definition for indexing: %w", err)
+	}
+	derivation, err := item.Derivation.Get()
+	if err != nil {
+		return fmt.Errorf("failed to resolve derivation for indexing: %w", err)
+	}
+	appendicies, err := item.Appendicies.Get()
+	if err != nil {
+		return fmt.Errorf("failed to resolve appendicies for indexing: %w", err)
+	}
+
+	doc := searchIndexDoc{
+		Word:        item.Word,
+		Definition:  stringOrEmpty(definition),
+		Derivation:  stringOrEmpty(derivation),
+		Appendicies: stringOrEmpty(appendicies),
+		Source:      stringOrEmpty(item.Source),
+	}
+
+	if err := db.searchIndex.Index(searchIndexDocID(item.ItemID), doc); err != nil {
+		return fmt.Errorf("failed to index item %d: %w", item.ItemID, err)
+	}
+	return nil
+}
+
+// UnindexItem removes itemID from the Bleve search index. Like IndexItem,
+// it is a no-op if the index isn't available.
+func (db *DB) UnindexItem(itemID int) error {
+	if db.searchIndex == nil {
+		return nil
+	}
+	if err := db.searchIndex.Delete(searchIndexDocID(itemID)); err != nil {
+		return fmt.Errorf("failed to unindex item %d: %w", itemID, err)
+	}
+	return nil
+}
+
+// RebuildSearchIndex regenerates the Bleve index from every item currently
+// in SQLite, for use at startup after the index directory was deleted, or
+// once corruption is detected. Items are re-indexed one at a time rather
+// than the index being dropped and recreated first, so a failure partway
+// through leaves only the items after it stale instead of losing the whole
+// index.
+func (db *DB) RebuildSearchIndex() error {
+	if db.searchIndex == nil {
+		return fmt.Errorf("search index is not available")
+	}
+
+	items, err := db.queryItems(`
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, created_at, modified_at
+		FROM items
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query items for reindex: %w", err)
+	}
+
+	for _, item := range items {
+		if err := db.IndexItem(item); err != nil {
+			return fmt.Errorf("failed to index item %d: %w", item.ItemID, err)
+		}
+	}
+
+	slog.Info("[DB] Rebuilt search index", "items", len(items))
+	return nil
+}
+
+// SearchItemsRanked runs query against the Bleve index, returning items
+// ordered by Bleve's relevance score with a highlighted snippet of
+// whichever field matched. If the index isn't available - not yet built,
+// or the directory couldn't be opened - it falls back to the LIKE-based
+// SearchItems so callers always get a result, just without ranking or
+// snippets.
+func (db *DB) SearchItemsRanked(query string, opts SearchOpts) ([]SearchHit, error) {
+	if db.searchIndex == nil {
+		return db.likeSearchHits(query)
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	req := bleve.NewSearchRequestOptions(bleve.NewQueryStringQuery(query), limit, 0, false)
+	req.Highlight = bleve.NewHighlight()
+	req.Fields = []string{"word"}
+
+	result, err := db.searchIndex.Search(req)
+	if err != nil {
+		slog.Warn("[SearchItemsRanked] Bleve search failed, falling back to LIKE", "error", err)
+		return db.likeSearchHits(query)
+	}
+
+	hits := make([]SearchHit, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		itemID, err := strconv.Atoi(hit.ID)
+		if err != nil {
+			continue
+		}
+		item, err := db.GetItem(itemID)
+		if err != nil {
+			continue
+		}
+		hits = append(hits, SearchHit{
+			Item:    *item,
+			Score:   hit.Score,
+			Snippet: firstFragment(hit.Fragments),
+		})
+	}
+	return hits, nil
+}
+
+// likeSearchHits runs the LIKE-based SearchItems and wraps its results as
+// unranked, unsnippeted SearchHits, for SearchItemsRanked's fallback path.
+func (db *DB) likeSearchHits(query string) ([]SearchHit, error) {
+	items, err := db.SearchItems(query)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]SearchHit, len(items))
+	for i, item := range items {
+		hits[i] = SearchHit{Item: item}
+	}
+	return hits, nil
+}
+
+// firstFragment returns the first highlighted fragment found across the
+// indexed text fields, preferring definition (the field most often worth
+// showing a reader) over the rest.
+func firstFragment(fragments map[string][]string) string {
+	for _, field := range []string{"definition", "derivation", "appendicies", "source", "word"} {
+		if frags := fragments[field]; len(frags) > 0 {
+			return frags[0]
+		}
+	}
+	return ""
+}
+
+// stringOrEmpty dereferences s, returning "" for nil instead of panicking.
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
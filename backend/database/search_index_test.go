@@ -0,0 +1,94 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+func TestIndexItemUnindexItemNoopWithoutIndex(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if err := db.IndexItem(Item{ItemID: 1, Word: "poetry"}); err != nil {
+		t.Errorf("IndexItem should be a no-op without a search index, got error: %v", err)
+	}
+	if err := db.UnindexItem(1); err != nil {
+		t.Errorf("UnindexItem should be a no-op without a search index, got error: %v", err)
+	}
+}
+
+func TestRebuildSearchIndexWithoutIndex(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if err := db.RebuildSearchIndex(); err == nil {
+		t.Error("expected an error rebuilding a search index that isn't available")
+	}
+}
+
+func TestSearchItemsRankedFallsBackToLikeWithoutIndex(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	def := "The art of verse"
+	if _, err := db.CreateItem(Item{Word: "poetry", Type: "Reference", Definition: NewLazyString(&def)}); err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	hits, err := db.SearchItemsRanked("poetry", SearchOpts{})
+	if err != nil {
+		t.Fatalf("SearchItemsRanked failed: %v", err)
+	}
+	if len(hits) < 1 {
+		t.Fatalf("expected at least 1 hit for 'poetry', got %d", len(hits))
+	}
+	if hits[0].Score != 0 || hits[0].Snippet != "" {
+		t.Errorf("expected an unranked, unsnippeted hit from the LIKE fallback, got %+v", hits[0])
+	}
+}
+
+func TestSearchItemsRankedUsesBleveIndex(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	index, err := bleve.NewMemOnly(buildSearchIndexMapping())
+	if err != nil {
+		t.Fatalf("failed to create in-memory search index: %v", err)
+	}
+	defer index.Close()
+	db.searchIndex = index
+
+	def1 := "The art of verse"
+	if _, err := db.CreateItem(Item{Word: "poetry", Type: "Reference", Definition: NewLazyString(&def1)}); err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+	def2 := "A literary work using verse and rhythm"
+	if _, err := db.CreateItem(Item{Word: "poem", Type: "Reference", Definition: NewLazyString(&def2)}); err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	hits, err := db.SearchItemsRanked("verse", SearchOpts{})
+	if err != nil {
+		t.Fatalf("SearchItemsRanked failed: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits for 'verse', got %d: %+v", len(hits), hits)
+	}
+	for _, h := range hits {
+		if h.Score <= 0 {
+			t.Errorf("expected a positive relevance score from Bleve, got %v for %q", h.Score, h.Item.Word)
+		}
+	}
+
+	if err := db.DeleteItem(hits[0].Item.ItemID); err != nil {
+		t.Fatalf("DeleteItem failed: %v", err)
+	}
+	hits, err = db.SearchItemsRanked("verse", SearchOpts{})
+	if err != nil {
+		t.Fatalf("SearchItemsRanked failed: %v", err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("expected 1 hit for 'verse' after deleting one item, got %d", len(hits))
+	}
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// itemRow mirrors the items table's columns 1:1 for sqlx's Get/Select.
+// Item's Definition/Derivation/Appendicies are LazyString, which isn't a
+// sql.Scanner, so the handful of methods that adopt sqlx scan into itemRow
+// first and convert via toItem rather than StructScan-ing Item directly.
+type itemRow struct {
+	ItemID         int       `db:"item_id"`
+	Word           string    `db:"word"`
+	Type           string    `db:"type"`
+	Definition     *string   `db:"definition"`
+	Derivation     *string   `db:"derivation"`
+	Appendicies    *string   `db:"appendicies"`
+	Source         *string   `db:"source"`
+	SourcePg       *string   `db:"source_pg"`
+	Mark           *string   `db:"mark"`
+	ImageFilesJSON *string   `db:"image_files_json"`
+	CreatedAt      time.Time `db:"created_at"`
+	ModifiedAt     time.Time `db:"modified_at"`
+}
+
+func (r itemRow) toItem() Item {
+	return Item{
+		ItemID:      r.ItemID,
+		Word:        r.Word,
+		Type:        r.Type,
+		Definition:  NewLazyString(r.Definition),
+		Derivation:  NewLazyString(r.Derivation),
+		Appendicies: NewLazyString(r.Appendicies),
+		Source:      r.Source,
+		SourcePg:    r.SourcePg,
+		Mark:        r.Mark,
+		ImageFiles:  decodeImageFiles(r.ImageFilesJSON),
+		CreatedAt:   r.CreatedAt,
+		ModifiedAt:  r.ModifiedAt,
+	}
+}
+
+// decodeImageFiles decodes an items.image_files_json column value into the
+// image variant list Item.Images exposes. A nil or unparseable value (a
+// query that didn't select the column, or an item SyncFileFlags hasn't
+// synced yet) decodes to nil rather than an error, since "no images" and
+// "not yet loaded" are indistinguishable to a caller either way.
+func decodeImageFiles(raw *string) []string {
+	if raw == nil {
+		return nil
+	}
+	var files []string
+	if err := json.Unmarshal([]byte(*raw), &files); err != nil {
+		return nil
+	}
+	return files
+}
+
+func itemRowsToItems(rows []itemRow) []Item {
+	items := make([]Item, len(rows))
+	for i, r := range rows {
+		items[i] = r.toItem()
+	}
+	return items
+}
+
+// itemRowFromItem builds the itemRow sqlx binds CreateItem/CreateItems/
+// UpdateItem's NamedExec calls against, resolving the lazy text fields to
+// plain strings up front.
+func itemRowFromItem(item Item, definition, derivation, appendicies *string) itemRow {
+	return itemRow{
+		ItemID:      item.ItemID,
+		Word:        item.Word,
+		Type:        item.Type,
+		Definition:  definition,
+		Derivation:  derivation,
+		Appendicies: appendicies,
+		Source:      item.Source,
+		SourcePg:    item.SourcePg,
+		Mark:        item.Mark,
+	}
+}
+
+// getItemRow runs a single-row item query through sqlx's Get and converts
+// the scanned itemRow to an Item. It returns sql.ErrNoRows unchanged so
+// callers can keep comparing against it exactly as they did with QueryRow.
+func (db *DB) getItemRow(query string, args ...interface{}) (Item, error) {
+	var row itemRow
+	if err := db.conn.Get(&row, query, args...); err != nil {
+		return Item{}, err
+	}
+	return row.toItem(), nil
+}
+
+// queryItems runs a multi-row item query through sqlx's Select.
+func (db *DB) queryItems(query string, args ...interface{}) ([]Item, error) {
+	var rows []itemRow
+	if err := db.conn.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+	return itemRowsToItems(rows), nil
+}
+
+// prepared returns a cached *sqlx.Stmt for query, preparing and caching it
+// on first use. query must already be in the connection's native
+// placeholder style (run it through rebind first for Postgres callers).
+// Callers on a hot path - GetItem, GetItemByWord, GetRandomItem - use this
+// instead of db.conn.Get/Select directly so repeat calls skip SQL parsing
+// and (for Postgres) query planning.
+func (db *DB) prepared(query string) (*sqlx.Stmt, error) {
+	db.stmtsMu.Lock()
+	defer db.stmtsMu.Unlock()
+
+	if stmt, ok := db.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := db.conn.Preparex(query)
+	if err != nil {
+		return nil, err
+	}
+	if db.stmts == nil {
+		db.stmts = make(map[string]*sqlx.Stmt)
+	}
+	db.stmts[query] = stmt
+	return stmt, nil
+}
+
+// getItemRowPrepared is getItemRow routed through the prepared-statement
+// cache, for call sites (GetItem, GetItemByWord, GetRandomItem) whose query
+// text is fixed and reused on every call.
+func (db *DB) getItemRowPrepared(query string, args ...interface{}) (Item, error) {
+	stmt, err := db.prepared(query)
+	if err != nil {
+		return Item{}, err
+	}
+	var row itemRow
+	if err := stmt.Get(&row, args...); err != nil {
+		return Item{}, err
+	}
+	return row.toItem(), nil
+}
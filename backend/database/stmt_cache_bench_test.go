@@ -0,0 +1,44 @@
+package database
+
+import "testing"
+
+// BenchmarkCreateLinkBulk exercises the kind of tight loop an import run
+// produces - one CreateLink call per parsed reference. It exists to show
+// the DB.prepared cache (see sqlx.go) pays SQL parse/plan cost once for the
+// INSERT rather than once per call.
+func BenchmarkCreateLinkBulk(b *testing.B) {
+	db := setupTestDB(b)
+	defer db.Close()
+
+	sourceID, err := db.CreateItem(Item{Word: "source", Type: "Reference"})
+	if err != nil {
+		b.Fatalf("CreateItem failed: %v", err)
+	}
+	destIDs := make([]int, b.N)
+	for i := range destIDs {
+		id, err := db.CreateItem(Item{Word: wordForBenchIndex(i), Type: "Reference"})
+		if err != nil {
+			b.Fatalf("CreateItem failed: %v", err)
+		}
+		destIDs[i] = id
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := db.CreateLink(sourceID, destIDs[i], "related"); err != nil {
+			b.Fatalf("CreateLink failed: %v", err)
+		}
+	}
+}
+
+func wordForBenchIndex(i int) string {
+	digits := "0123456789abcdefghijklmnopqrstuvwxyz"
+	word := make([]byte, 0, 12)
+	if i == 0 {
+		word = append(word, digits[0])
+	}
+	for n := i; n > 0; n /= len(digits) {
+		word = append(word, digits[n%len(digits)])
+	}
+	return "bench-" + string(word)
+}
@@ -0,0 +1,562 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+	"github.com/jmoiron/sqlx"
+)
+
+// Tx mirrors DB's CRUD surface inside a single SQL transaction, so callers
+// can compose several writes ("create item A, create item B, link them") as
+// one atomic unit via WithTx. It holds a reference back to db so it can
+// reuse db.rebind/db.driver and the Bleve IndexItem/UnindexItem
+// bookkeeping, which runs outside the SQL transaction (the syncItemTags
+// refresh of the tags table runs inside it, through tx).
+type Tx struct {
+	tx *sqlx.Tx
+	db *DB
+}
+
+// WithTx runs fn inside a new transaction, committing if fn returns nil and
+// rolling back otherwise (including if fn panics, in which case the panic
+// is re-thrown after the rollback). ctx governs the transaction's lifetime:
+// canceling it aborts the transaction even if fn is still running.
+func (db *DB) WithTx(ctx context.Context, fn func(tx *Tx) error) (err error) {
+	sqlxTx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	tx := &Tx{tx: sqlxTx, db: db}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = sqlxTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rbErr := sqlxTx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback also failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := sqlxTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateItem mirrors DB.CreateItem, inserting item as part of tx's
+// transaction instead of a standalone statement.
+func (tx *Tx) CreateItem(item Item) (int, error) {
+	definition, derivation, appendicies, err := resolveTextFields(item)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve text fields: %w", err)
+	}
+	row := itemRowFromItem(item, definition, derivation, appendicies)
+
+	id, err := tx.db.insertItemRow(tx.tx, row)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("an item with the word '%s' already exists", item.Word)
+		}
+		return 0, fmt.Errorf("failed to create item: %w", err)
+	}
+
+	if err := syncItemTags(tx.tx, int(id), definition, appendicies); err != nil {
+		slog.Warn("[Tx.CreateItem] Failed to sync tags index", "id", id, "error", err)
+	}
+
+	item.ItemID = int(id)
+	item.Definition = NewLazyString(definition)
+	item.Derivation = NewLazyString(derivation)
+	item.Appendicies = NewLazyString(appendicies)
+	if err := tx.db.IndexItem(item); err != nil {
+		slog.Warn("[Tx.CreateItem] Failed to update search index", "id", id, "error", err)
+	}
+	if err := recordActivity(tx.tx, ActivityEntityItem, int(id), ActivityActionCreate, nil, item); err != nil {
+		slog.Warn("[Tx.CreateItem] Failed to record activity", "id", id, "error", err)
+	}
+
+	return int(id), nil
+}
+
+// UpdateItem mirrors DB.UpdateItem inside tx's transaction. Unlike
+// DB.UpdateItem it does not fall back to creating the item when no row
+// matches - that fallback would otherwise turn an update into an implicit,
+// easy-to-miss create in the middle of a caller's transaction, so callers
+// who want create-or-update inside a Tx should call CreateItem explicitly.
+func (tx *Tx) UpdateItem(item Item) error {
+	before, _ := tx.GetItem(item.ItemID)
+
+	definition, derivation, appendicies, err := resolveTextFields(item)
+	if err != nil {
+		return fmt.Errorf("failed to resolve text fields: %w", err)
+	}
+
+	normalizeDefinition(definition)
+	normalizeDefinition(derivation)
+	normalizeDefinition(appendicies)
+	item.Definition = NewLazyString(definition)
+	item.Derivation = NewLazyString(derivation)
+	item.Appendicies = NewLazyString(appendicies)
+
+	query := `
+		UPDATE items SET
+			word = :word, type = :type, definition = :definition, derivation = :derivation,
+			appendicies = :appendicies, source = :source, source_pg = :source_pg, mark = :mark,
+			modified_at = CURRENT_TIMESTAMP
+		WHERE item_id = :item_id
+	`
+	result, err := tx.tx.NamedExec(query, itemRowFromItem(item, definition, derivation, appendicies))
+	if err != nil {
+		return fmt.Errorf("failed to update item: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("item not found")
+	}
+
+	if err := syncItemTags(tx.tx, item.ItemID, definition, appendicies); err != nil {
+		slog.Warn("[Tx.UpdateItem] Failed to sync tags index", "id", item.ItemID, "error", err)
+	}
+	if err := tx.db.IndexItem(item); err != nil {
+		slog.Warn("[Tx.UpdateItem] Failed to update search index", "id", item.ItemID, "error", err)
+	}
+	if err := recordActivity(tx.tx, ActivityEntityItem, item.ItemID, ActivityActionUpdate, itemOrNil(before), item); err != nil {
+		slog.Warn("[Tx.UpdateItem] Failed to record activity", "id", item.ItemID, "error", err)
+	}
+
+	return nil
+}
+
+// UpdateOrCreateItem mirrors DB.UpdateItem's original update-or-create
+// behavior, but as a single step inside tx's transaction: it updates item,
+// and if no row matched, creates it instead. Returns the updated or newly
+// created item's ID.
+func (tx *Tx) UpdateOrCreateItem(item Item) (int, error) {
+	before, _ := tx.GetItem(item.ItemID)
+
+	definition, derivation, appendicies, err := resolveTextFields(item)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve text fields: %w", err)
+	}
+
+	normalizeDefinition(definition)
+	normalizeDefinition(derivation)
+	normalizeDefinition(appendicies)
+	item.Definition = NewLazyString(definition)
+	item.Derivation = NewLazyString(derivation)
+	item.Appendicies = NewLazyString(appendicies)
+
+	query := `
+		UPDATE items SET
+			word = :word, type = :type, definition = :definition, derivation = :derivation,
+			appendicies = :appendicies, source = :source, source_pg = :source_pg, mark = :mark,
+			modified_at = CURRENT_TIMESTAMP
+		WHERE item_id = :item_id
+	`
+	result, err := tx.tx.NamedExec(query, itemRowFromItem(item, definition, derivation, appendicies))
+	if err != nil {
+		return 0, fmt.Errorf("failed to update item: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return tx.CreateItem(item)
+	}
+
+	if err := syncItemTags(tx.tx, item.ItemID, definition, appendicies); err != nil {
+		slog.Warn("[Tx.UpdateOrCreateItem] Failed to sync tags index", "id", item.ItemID, "error", err)
+	}
+	if err := tx.db.IndexItem(item); err != nil {
+		slog.Warn("[Tx.UpdateOrCreateItem] Failed to update search index", "id", item.ItemID, "error", err)
+	}
+	if err := recordActivity(tx.tx, ActivityEntityItem, item.ItemID, ActivityActionUpdate, itemOrNil(before), item); err != nil {
+		slog.Warn("[Tx.UpdateOrCreateItem] Failed to record activity", "id", item.ItemID, "error", err)
+	}
+
+	return item.ItemID, nil
+}
+
+// GetItem mirrors DB.GetItem, reading through tx's transaction so it sees
+// writes already made earlier in the same transaction.
+func (tx *Tx) GetItem(itemID int) (*Item, error) {
+	var row itemRow
+	query := tx.db.rebind(`
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, created_at, modified_at
+		FROM items
+		WHERE item_id = ?
+	`)
+	if err := tx.tx.Get(&row, query, itemID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("item not found")
+		}
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	item := row.toItem()
+	return &item, nil
+}
+
+// GetItemByWord mirrors DB.GetItemByWord, reading through tx's transaction.
+func (tx *Tx) GetItemByWord(word string) (*Item, error) {
+	var row itemRow
+	query := tx.db.rebind(`
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, created_at, modified_at
+		FROM items
+		WHERE LOWER(word) = LOWER(?)
+		LIMIT 1
+	`)
+	if err := tx.tx.Get(&row, query, word); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("item not found")
+		}
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	item := row.toItem()
+	return &item, nil
+}
+
+// ResolveWord is DB.ResolveWord run inside tx's transaction, for callers
+// composing a reference lookup with other writes in the same transaction.
+func (tx *Tx) ResolveWord(refWord string) (*Item, MatchReason, error) {
+	return tx.db.resolver.ResolveTx(tx, refWord)
+}
+
+// MergeLiteraryTerm mirrors DB.MergeLiteraryTerm's SELECT->UPDATE->DELETE
+// sequence inside tx's transaction, so a failure partway through (e.g. the
+// item update succeeding but the literary_terms delete failing) leaves
+// neither table changed instead of merging the same term twice on retry.
+func (tx *Tx) MergeLiteraryTerm(termID int) error {
+	var term LiteraryTerm
+	err := tx.tx.QueryRow(`
+		SELECT term_id, term, definition, examples, notes
+		FROM literary_terms
+		WHERE term_id = ?
+	`, termID).Scan(&term.TermID, &term.Term, &term.Definition, &term.Examples, &term.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to get literary term: %w", err)
+	}
+
+	var itemID int
+	var word string
+	var itemDefinition, itemSource *string
+	err = tx.tx.QueryRow(`
+		SELECT item_id, word, definition, source
+		FROM items
+		WHERE word = ? COLLATE BINARY
+	`, term.Term).Scan(&itemID, &word, &itemDefinition, &itemSource)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("matching item not found for term: %s", term.Term)
+		}
+		return fmt.Errorf("failed to find matching item: %w", err)
+	}
+
+	newDef := ""
+	if itemDefinition != nil {
+		newDef = *itemDefinition
+	}
+
+	termDef := ""
+	if term.Definition != nil {
+		termDef = *term.Definition
+	}
+
+	// Clean up replacement characters
+	termDef = strings.ReplaceAll(termDef, "\ufffd", "\"")
+	// Clean up HTML tags
+	termDef = strings.ReplaceAll(termDef, "<p>", "\n\n")
+	termDef = strings.ReplaceAll(termDef, "</p>", "")
+
+	if termDef != "" {
+		if newDef != "" {
+			newDef += "\n\n----\n\n"
+		}
+		newDef += termDef
+	}
+
+	newSource := ""
+	if itemSource != nil {
+		newSource = *itemSource
+	}
+	if newSource != "" {
+		newSource += "; "
+	}
+	newSource += "from literary term table"
+
+	before, _ := tx.GetItem(itemID)
+
+	if _, err := tx.tx.Exec(`
+		UPDATE items
+		SET definition = ?, source = ?, modified_at = CURRENT_TIMESTAMP
+		WHERE item_id = ?
+	`, newDef, newSource, itemID); err != nil {
+		return fmt.Errorf("failed to update item: %w", err)
+	}
+
+	if after, err := tx.GetItem(itemID); err == nil {
+		if err := recordActivity(tx.tx, ActivityEntityItem, itemID, ActivityActionUpdate, itemOrNil(before), *after); err != nil {
+			slog.Warn("[Tx.MergeLiteraryTerm] Failed to record activity", "itemID", itemID, "error", err)
+		}
+	}
+
+	if _, err := tx.tx.Exec(`
+		DELETE FROM literary_terms
+		WHERE term_id = ?
+	`, termID); err != nil {
+		return fmt.Errorf("failed to delete literary term: %w", err)
+	}
+
+	return nil
+}
+
+// CreateLinkOrRemoveTags mirrors DB.CreateLinkOrRemoveTags's
+// Resolve->CreateLink-or-SELECT->UpdateItem sequence inside tx's
+// transaction, so a failed tag-removal update can't leave a half-created
+// link (or vice versa) for the same reference.
+func (tx *Tx) CreateLinkOrRemoveTags(sourceItemID int, refWord string) (bool, string, error) {
+	matchWord := stripPossessive(refWord)
+
+	destItem, _, err := tx.db.resolver.ResolveTx(tx, refWord)
+	if err == nil && destItem != nil {
+		if linkErr := tx.CreateLink(sourceItemID, destItem.ItemID, "reference"); linkErr == nil {
+			return true, fmt.Sprintf("Added link to %s", destItem.Word), nil
+		}
+		// Link creation failed, fall through to remove tag
+	}
+
+	sourceItem, err := tx.GetItem(sourceItemID)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get source item: %w", err)
+	}
+
+	regex, err := parser.GetPossessiveReferenceRegex(matchWord)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to compile regex: %w", err)
+	}
+
+	updatedDefinition := ""
+	defChanged := false
+	if originalDef := sourceItem.Definition.GetOrEmpty(); originalDef != "" {
+		updatedDefinition = regex.ReplaceAllString(originalDef, "$1")
+		defChanged = originalDef != updatedDefinition
+	}
+
+	updatedDerivation := ""
+	derChanged := false
+	if originalDer := sourceItem.Derivation.GetOrEmpty(); originalDer != "" {
+		updatedDerivation = regex.ReplaceAllString(originalDer, "$1")
+		derChanged = originalDer != updatedDerivation
+	}
+
+	updatedAppendicies := ""
+	appChanged := false
+	if originalApp := sourceItem.Appendicies.GetOrEmpty(); originalApp != "" {
+		updatedAppendicies = regex.ReplaceAllString(originalApp, "$1")
+		appChanged = originalApp != updatedAppendicies
+	}
+
+	if !defChanged && !derChanged && !appChanged {
+		return false, "No changes needed", nil
+	}
+
+	sourceItem.Definition = NewLazyString(&updatedDefinition)
+	sourceItem.Derivation = NewLazyString(&updatedDerivation)
+	sourceItem.Appendicies = NewLazyString(&updatedAppendicies)
+
+	if err := tx.UpdateItem(*sourceItem); err != nil {
+		return false, "", fmt.Errorf("failed to update item: %w", err)
+	}
+
+	return false, fmt.Sprintf("Removed non-existent reference to %s", matchWord), nil
+}
+
+// stmt returns db's cached prepared statement for query (see DB.prepared)
+// rebound to run inside tx, via sqlx.Tx.Stmtx - the standard database/sql
+// way to reuse a statement prepared on the parent connection within a
+// transaction. This is what lets Tx's CRUD methods share the same
+// cached-statement win as their DB counterparts instead of reparsing query
+// text on every call.
+func (tx *Tx) stmt(query string) (*sqlx.Stmt, error) {
+	s, err := tx.db.prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.tx.Stmtx(s), nil
+}
+
+// DeleteItem mirrors DB.DeleteItem inside tx's transaction.
+func (tx *Tx) DeleteItem(itemID int) error {
+	before, _ := tx.GetItem(itemID)
+
+	stmt, err := tx.stmt(tx.db.rebind("DELETE FROM items WHERE item_id = ?"))
+	if err != nil {
+		return fmt.Errorf("failed to prepare delete item statement: %w", err)
+	}
+	result, err := stmt.Exec(itemID)
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("item not found")
+	}
+
+	if err := tx.db.UnindexItem(itemID); err != nil {
+		slog.Warn("[Tx.DeleteItem] Failed to update search index", "itemID", itemID, "error", err)
+	}
+	if err := recordActivity(tx.tx, ActivityEntityItem, itemID, ActivityActionDelete, itemOrNil(before), nil); err != nil {
+		slog.Warn("[Tx.DeleteItem] Failed to record activity", "itemID", itemID, "error", err)
+	}
+	return nil
+}
+
+// CreateLink mirrors DB.CreateLink inside tx's transaction.
+func (tx *Tx) CreateLink(sourceID, destID int, linkType string) error {
+	stmt, err := tx.stmt(tx.db.rebind(`
+		INSERT INTO links (source_item_id, destination_item_id, link_type)
+		VALUES (?, ?, ?)
+	`))
+	if err != nil {
+		return fmt.Errorf("failed to prepare create link statement: %w", err)
+	}
+	result, err := stmt.Exec(sourceID, destID, linkType)
+	if err != nil {
+		return fmt.Errorf("failed to create link: %w", err)
+	}
+
+	linkID, _ := result.LastInsertId()
+	link := Link{LinkID: int(linkID), SourceItemID: sourceID, DestinationItemID: destID, LinkType: linkType}
+	if err := recordActivity(tx.tx, ActivityEntityLink, int(linkID), ActivityActionCreate, nil, link); err != nil {
+		slog.Warn("[Tx.CreateLink] Failed to record activity", "linkID", linkID, "error", err)
+	}
+	return nil
+}
+
+// DeleteLink mirrors DB.DeleteLink inside tx's transaction.
+func (tx *Tx) DeleteLink(linkID int) error {
+	before, _ := tx.getLink(linkID)
+
+	stmt, err := tx.stmt(tx.db.rebind("DELETE FROM links WHERE link_id = ?"))
+	if err != nil {
+		return fmt.Errorf("failed to prepare delete link statement: %w", err)
+	}
+	result, err := stmt.Exec(linkID)
+	if err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("link not found")
+	}
+
+	if err := recordActivity(tx.tx, ActivityEntityLink, linkID, ActivityActionDelete, linkOrNil(before), nil); err != nil {
+		slog.Warn("[Tx.DeleteLink] Failed to record activity", "linkID", linkID, "error", err)
+	}
+	return nil
+}
+
+// getLink looks up a single link row by id, for DeleteLink's before-state
+// snapshot (RevertActivity needs the full row to recreate it).
+func (tx *Tx) getLink(linkID int) (*Link, error) {
+	var link Link
+	query := tx.db.rebind(`
+		SELECT link_id, source_item_id, destination_item_id, link_type, created_at
+		FROM links
+		WHERE link_id = ?
+	`)
+	if err := tx.tx.Get(&link, query, linkID); err != nil {
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+	return &link, nil
+}
+
+// ToggleItemMark mirrors DB.ToggleItemMark inside tx's transaction.
+func (tx *Tx) ToggleItemMark(itemID int, marked bool) error {
+	before, _ := tx.GetItem(itemID)
+
+	var markVal *string
+	if marked {
+		s := "1"
+		markVal = &s
+	}
+
+	stmt, err := tx.stmt(tx.db.rebind(`UPDATE items SET mark = ?, modified_at = CURRENT_TIMESTAMP WHERE item_id = ?`))
+	if err != nil {
+		return fmt.Errorf("failed to prepare toggle mark statement: %w", err)
+	}
+	if _, err := stmt.Exec(markVal, itemID); err != nil {
+		return fmt.Errorf("failed to toggle item mark: %w", err)
+	}
+
+	if after, err := tx.GetItem(itemID); err == nil {
+		if err := recordActivity(tx.tx, ActivityEntityItem, itemID, ActivityActionUpdate, itemOrNil(before), *after); err != nil {
+			slog.Warn("[Tx.ToggleItemMark] Failed to record activity", "itemID", itemID, "error", err)
+		}
+	}
+	return nil
+}
+
+// CreateItemContext is CreateItem with a context.Context, for callers that
+// need to cancel or time out a single insert outside of a WithTx block.
+// CreateItem is CreateItemContext(context.Background(), item).
+func (db *DB) CreateItemContext(ctx context.Context, item Item) (int, error) {
+	var id int
+	err := db.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		id, err = tx.CreateItem(item)
+		return err
+	})
+	return id, err
+}
+
+// UpdateItemContext is UpdateItem with a context.Context. Unlike UpdateItem
+// it does not fall back to creating the item when no row matches, matching
+// Tx.UpdateItem's behavior (see its doc comment).
+func (db *DB) UpdateItemContext(ctx context.Context, item Item) error {
+	return db.WithTx(ctx, func(tx *Tx) error {
+		return tx.UpdateItem(item)
+	})
+}
+
+// DeleteItemContext is DeleteItem with a context.Context.
+func (db *DB) DeleteItemContext(ctx context.Context, itemID int) error {
+	return db.WithTx(ctx, func(tx *Tx) error {
+		return tx.DeleteItem(itemID)
+	})
+}
+
+// CreateLinkContext is CreateLink with a context.Context.
+func (db *DB) CreateLinkContext(ctx context.Context, sourceID, destID int, linkType string) error {
+	return db.WithTx(ctx, func(tx *Tx) error {
+		return tx.CreateLink(sourceID, destID, linkType)
+	})
+}
+
+// ToggleItemMarkContext is ToggleItemMark with a context.Context.
+func (db *DB) ToggleItemMarkContext(ctx context.Context, itemID int, marked bool) error {
+	return db.WithTx(ctx, func(tx *Tx) error {
+		return tx.ToggleItemMark(itemID, marked)
+	})
+}
@@ -0,0 +1,167 @@
+package database
+
+import "testing"
+
+func TestUpdateItemCreatesWhenMissing(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	def := "A word that doesn't exist yet"
+	item := Item{
+		ItemID:     9999,
+		Word:       "neologism",
+		Type:       "Reference",
+		Definition: NewLazyString(&def),
+	}
+
+	if err := db.UpdateItem(item); err != nil {
+		t.Fatalf("UpdateItem failed: %v", err)
+	}
+
+	created, err := db.GetItemByWord("neologism")
+	if err != nil {
+		t.Fatalf("GetItemByWord failed: %v", err)
+	}
+	if got := created.Definition.GetOrEmpty(); got != def {
+		t.Errorf("expected definition %q, got %q", def, got)
+	}
+}
+
+func TestMergeLiteraryTermMergesAndDeletes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	itemDef := "Existing definition"
+	itemID, err := db.CreateItem(Item{Word: "Alliteration", Type: "Reference", Definition: NewLazyString(&itemDef)})
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	result, err := db.conn.Exec(`
+		INSERT INTO literary_terms (term, definition, examples, notes) VALUES (?, ?, ?, ?)
+	`, "Alliteration", "Repetition of initial consonant sounds", "", "")
+	if err != nil {
+		t.Fatalf("failed to seed literary term: %v", err)
+	}
+	termID64, err := result.LastInsertId()
+	if err != nil {
+		t.Fatalf("failed to get literary term id: %v", err)
+	}
+	termID := int(termID64)
+
+	if err := db.MergeLiteraryTerm(termID); err != nil {
+		t.Fatalf("MergeLiteraryTerm failed: %v", err)
+	}
+
+	merged, err := db.GetItem(itemID)
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	if got := merged.Definition.GetOrEmpty(); got != itemDef+"\n\n----\n\nRepetition of initial consonant sounds" {
+		t.Errorf("expected merged definition, got %q", got)
+	}
+
+	var count int
+	if err := db.conn.Get(&count, "SELECT COUNT(*) FROM literary_terms WHERE term_id = ?", termID); err != nil {
+		t.Fatalf("failed to count literary_terms: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected the literary term to be deleted after merging, found %d rows", count)
+	}
+}
+
+func TestMergeLiteraryTermLeavesTermIntactWithoutMatchingItem(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	result, err := db.conn.Exec(`
+		INSERT INTO literary_terms (term, definition, examples, notes) VALUES (?, ?, ?, ?)
+	`, "Zeugma", "A figure of speech", "", "")
+	if err != nil {
+		t.Fatalf("failed to seed literary term: %v", err)
+	}
+	termID64, err := result.LastInsertId()
+	if err != nil {
+		t.Fatalf("failed to get literary term id: %v", err)
+	}
+	termID := int(termID64)
+
+	if err := db.MergeLiteraryTerm(termID); err == nil {
+		t.Fatal("expected an error when no item matches the term")
+	}
+
+	var count int
+	if err := db.conn.Get(&count, "SELECT COUNT(*) FROM literary_terms WHERE term_id = ?", termID); err != nil {
+		t.Fatalf("failed to count literary_terms: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the failed merge to leave the literary term in place, found %d rows", count)
+	}
+}
+
+func TestCreateLinkOrRemoveTagsCreatesLink(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	def := "References {word: shakespeare}"
+	sourceID, err := db.CreateItem(Item{Word: "sonnet", Type: "Reference", Definition: NewLazyString(&def)})
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+	destID, err := db.CreateItem(Item{Word: "shakespeare", Type: "Writer"})
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	linkCreated, _, err := db.CreateLinkOrRemoveTags(sourceID, "shakespeare")
+	if err != nil {
+		t.Fatalf("CreateLinkOrRemoveTags failed: %v", err)
+	}
+	if !linkCreated {
+		t.Fatal("expected a link to be created for an existing item")
+	}
+
+	links, err := db.GetItemLinks(sourceID)
+	if err != nil {
+		t.Fatalf("GetItemLinks failed: %v", err)
+	}
+	found := false
+	for _, l := range links {
+		if l.SourceItemID == sourceID && l.DestinationItemID == destID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a link from %d to %d, got %+v", sourceID, destID, links)
+	}
+}
+
+func TestCreateLinkOrRemoveTagsRemovesTagWhenItemMissing(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	def := "References {word: nonexistentwriter}"
+	sourceID, err := db.CreateItem(Item{Word: "ode", Type: "Reference", Definition: NewLazyString(&def)})
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	linkCreated, message, err := db.CreateLinkOrRemoveTags(sourceID, "nonexistentwriter")
+	if err != nil {
+		t.Fatalf("CreateLinkOrRemoveTags failed: %v", err)
+	}
+	if linkCreated {
+		t.Fatal("expected no link to be created for a missing item")
+	}
+	if message == "" {
+		t.Error("expected a non-empty status message")
+	}
+
+	updated, err := db.GetItem(sourceID)
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	if got := updated.Definition.GetOrEmpty(); got == def {
+		t.Errorf("expected the reference tag to be removed from the definition, got %q", got)
+	}
+}
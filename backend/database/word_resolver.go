@@ -0,0 +1,239 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MatchReason records which transform in WordResolver's cascade produced a
+// match, so callers (and logs) can tell a clean hit from one that only
+// worked because the reference was misspelled or inflected.
+type MatchReason string
+
+const (
+	MatchReasonExact      MatchReason = "exact"
+	MatchReasonCaseFold   MatchReason = "case_fold"
+	MatchReasonPossessive MatchReason = "possessive"
+	MatchReasonPlural     MatchReason = "plural"
+	MatchReasonVerbForm   MatchReason = "verb_form"
+	MatchReasonStemmer    MatchReason = "stemmer"
+)
+
+// Stemmer contributes extra candidate spellings for a word, beyond
+// wordResolver's own fixed cascade (possessive strip, plural, verb-form
+// back-off). The default resolver uses noStemmer, which contributes
+// nothing; an SQLite FTS5 tokenizer-backed stemmer (or a dedicated
+// `stemmer` package) can be plugged in later via NewWordResolver without
+// touching the cascade itself.
+type Stemmer interface {
+	// Stem returns zero or more additional forms of word worth trying.
+	Stem(word string) []string
+}
+
+// noStemmer is the zero-value Stemmer: no extra candidates.
+type noStemmer struct{}
+
+func (noStemmer) Stem(word string) []string { return nil }
+
+// WordResolver resolves a raw reference word - as it appears in a poem's
+// text, e.g. "Shakespeare's" or "nightingales" - to an existing items.word
+// row, trying a cascade of English-inflection transforms before giving up.
+// It replaces the old stripPossessive-then-GetItemByWord pair, which only
+// ever tried one transform.
+type WordResolver interface {
+	// Resolve looks up refWord against db, trying each cascade candidate in
+	// turn, and returns the first matching item along with which candidate
+	// matched. It returns a nil item (and empty reason) rather than an
+	// error when nothing matches.
+	Resolve(db *DB, refWord string) (*Item, MatchReason, error)
+
+	// ResolveTx is Resolve run inside tx's transaction, for callers (like
+	// Tx.CreateLinkOrRemoveTags) composing the lookup with other writes.
+	ResolveTx(tx *Tx, refWord string) (*Item, MatchReason, error)
+}
+
+// wordResolver is WordResolver's default implementation: it builds every
+// cascade candidate up front and resolves them with a single
+// `WHERE word IN (...) COLLATE NOCASE` query, rather than one round trip
+// per transform.
+type wordResolver struct {
+	stemmer Stemmer
+}
+
+// NewWordResolver returns the default WordResolver. A nil stemmer uses
+// noStemmer; pass a real Stemmer to extend the cascade with, e.g., an FTS5
+// tokenizer-backed stemmer without changing any call site.
+func NewWordResolver(stemmer Stemmer) WordResolver {
+	if stemmer == nil {
+		stemmer = noStemmer{}
+	}
+	return &wordResolver{stemmer: stemmer}
+}
+
+// wordCandidate is one cascade attempt: a spelling to try, and the reason
+// it would be reported as if it's the one that matches.
+type wordCandidate struct {
+	word   string
+	reason MatchReason
+}
+
+// candidates builds refWord's cascade in priority order - exact, case-fold,
+// possessive strip, simple plural rules, verb-form back-off, then whatever
+// the stemmer adds - deduplicating case-insensitively so the first (highest
+// priority) reason for a given spelling wins.
+func (r *wordResolver) candidates(refWord string) []wordCandidate {
+	var out []wordCandidate
+	seen := make(map[string]bool)
+	add := func(word string, reason MatchReason) {
+		if word == "" {
+			return
+		}
+		key := strings.ToLower(word)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		out = append(out, wordCandidate{word: word, reason: reason})
+	}
+
+	add(refWord, MatchReasonExact)
+	add(strings.ToLower(refWord), MatchReasonCaseFold)
+	add(stripPossessive(refWord), MatchReasonPossessive)
+	for _, p := range pluralCandidates(refWord) {
+		add(p, MatchReasonPlural)
+	}
+	for _, v := range verbFormCandidates(refWord) {
+		add(v, MatchReasonVerbForm)
+	}
+	for _, s := range r.stemmer.Stem(refWord) {
+		add(s, MatchReasonStemmer)
+	}
+	return out
+}
+
+// pluralCandidates returns refWord's possible singular forms under a few
+// common English pluralization rules: "-ies" -> "-y", "-es" -> "" and
+// plain "-s" -> "". Several rules often fire on the same word (e.g.
+// "nightingales" ends in both "es" and "s"); that's fine, since only the
+// candidate that matches a real row is ever used.
+func pluralCandidates(word string) []string {
+	var out []string
+	if strings.HasSuffix(word, "ies") && len(word) > 3 {
+		out = append(out, word[:len(word)-3]+"y")
+	}
+	if strings.HasSuffix(word, "es") && len(word) > 2 {
+		out = append(out, word[:len(word)-2])
+	}
+	if strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 1 {
+		out = append(out, word[:len(word)-1])
+	}
+	return out
+}
+
+// verbFormCandidates returns refWord's possible base-verb forms for the
+// "-ing" and "-ed" suffixes, including the doubled-consonant case ("running"
+// -> "run", "stopped" -> "stop") and the dropped-"e" case ("writing" ->
+// "write", "loved" -> "love").
+func verbFormCandidates(word string) []string {
+	var out []string
+	for _, suffix := range []string{"ing", "ed"} {
+		if !strings.HasSuffix(word, suffix) || len(word) <= len(suffix)+1 {
+			continue
+		}
+		base := word[:len(word)-len(suffix)]
+		out = append(out, base, base+"e")
+		if hasDoubledFinalConsonant(base) {
+			out = append(out, base[:len(base)-1])
+		}
+	}
+	return out
+}
+
+// hasDoubledFinalConsonant reports whether s ends with the same consonant
+// twice (e.g. "runn", "stopp"), the shape left behind after stripping
+// "-ing"/"-ed" from a doubled-consonant verb form.
+func hasDoubledFinalConsonant(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	last, prev := s[len(s)-1], s[len(s)-2]
+	return last == prev && isConsonantByte(last)
+}
+
+func isConsonantByte(b byte) bool {
+	switch b {
+	case 'a', 'e', 'i', 'o', 'u', 'A', 'E', 'I', 'O', 'U':
+		return false
+	}
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// buildQuery and resolveRows are shared by Resolve and ResolveTx: build
+// the candidate list and IN-clause query once, then pick whichever row (of
+// however many) corresponds to the highest-priority candidate.
+func (r *wordResolver) resolveRows(cands []wordCandidate, rows []itemRow) (*Item, MatchReason) {
+	for _, c := range cands {
+		for _, row := range rows {
+			if strings.EqualFold(row.Word, c.word) {
+				item := row.toItem()
+				return &item, c.reason
+			}
+		}
+	}
+	return nil, ""
+}
+
+func (r *wordResolver) buildQuery(cands []wordCandidate) (string, []interface{}, error) {
+	words := make([]string, len(cands))
+	for i, c := range cands {
+		words[i] = c.word
+	}
+	query, args, err := sqlx.In(`
+		SELECT item_id, word, type, definition, derivation,
+		       appendicies, source, source_pg, mark, created_at, modified_at
+		FROM items
+		WHERE word COLLATE NOCASE IN (?)
+	`, words)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build word resolver query: %w", err)
+	}
+	return query, args, nil
+}
+
+// Resolve implements WordResolver.
+func (r *wordResolver) Resolve(db *DB, refWord string) (*Item, MatchReason, error) {
+	cands := r.candidates(refWord)
+	if len(cands) == 0 {
+		return nil, "", nil
+	}
+	query, args, err := r.buildQuery(cands)
+	if err != nil {
+		return nil, "", err
+	}
+	var rows []itemRow
+	if err := db.conn.Select(&rows, db.rebind(query), args...); err != nil {
+		return nil, "", fmt.Errorf("failed to resolve word %q: %w", refWord, err)
+	}
+	item, reason := r.resolveRows(cands, rows)
+	return item, reason, nil
+}
+
+// ResolveTx implements WordResolver.
+func (r *wordResolver) ResolveTx(tx *Tx, refWord string) (*Item, MatchReason, error) {
+	cands := r.candidates(refWord)
+	if len(cands) == 0 {
+		return nil, "", nil
+	}
+	query, args, err := r.buildQuery(cands)
+	if err != nil {
+		return nil, "", err
+	}
+	var rows []itemRow
+	if err := tx.tx.Select(&rows, tx.db.rebind(query), args...); err != nil {
+		return nil, "", fmt.Errorf("failed to resolve word %q: %w", refWord, err)
+	}
+	item, reason := r.resolveRows(cands, rows)
+	return item, reason, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import "testing"
+
+func TestPluralCandidates(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"nightingales", "nightingale"},
+		{"butterflies", "butterfly"},
+		{"foxes", "fox"},
+	}
+
+	for _, tt := range tests {
+		found := false
+		for _, c := range pluralCandidates(tt.input) {
+			if c == tt.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("pluralCandidates(%q) did not include %q: %v", tt.input, tt.want, pluralCandidates(tt.input))
+		}
+	}
+}
+
+func TestVerbFormCandidates(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"running", "run"},
+		{"writing", "write"},
+		{"stopped", "stop"},
+		{"loved", "love"},
+	}
+
+	for _, tt := range tests {
+		found := false
+		for _, c := range verbFormCandidates(tt.input) {
+			if c == tt.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("verbFormCandidates(%q) did not include %q: %v", tt.input, tt.want, verbFormCandidates(tt.input))
+		}
+	}
+}
+
+func TestWordResolverResolvesPossessiveAndPlural(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.CreateItem(Item{Word: "Burns", Type: "Writer"}); err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+	if _, err := db.CreateItem(Item{Word: "nightingale", Type: "Reference"}); err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+
+	tests := []struct {
+		refWord    string
+		wantWord   string
+		wantReason MatchReason
+	}{
+		{"Burns's", "Burns", MatchReasonPossessive},
+		{"nightingales", "nightingale", MatchReasonPlural},
+	}
+
+	for _, tt := range tests {
+		item, reason, err := db.ResolveWord(tt.refWord)
+		if err != nil {
+			t.Fatalf("ResolveWord(%q) failed: %v", tt.refWord, err)
+		}
+		if item == nil {
+			t.Fatalf("ResolveWord(%q) found no match", tt.refWord)
+		}
+		if item.Word != tt.wantWord {
+			t.Errorf("ResolveWord(%q) = %q; want %q", tt.refWord, item.Word, tt.wantWord)
+		}
+		if reason != tt.wantReason {
+			t.Errorf("ResolveWord(%q) reason = %q; want %q", tt.refWord, reason, tt.wantReason)
+		}
+	}
+}
+
+func TestWordResolverNoMatch(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	item, _, err := db.ResolveWord("nonexistentwriter")
+	if err != nil {
+		t.Fatalf("ResolveWord failed: %v", err)
+	}
+	if item != nil {
+		t.Errorf("expected no match, got %+v", item)
+	}
+}
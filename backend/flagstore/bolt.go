@@ -0,0 +1,109 @@
+package flagstore
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// flagsBucket is the single bucket BoltFlagStore keeps all flags in, keyed
+// by big-endian item ID.
+var flagsBucket = []byte("flags")
+
+// BoltFlagStore is a FlagStore backed by a standalone BoltDB file, for
+// deployments that want flag storage off the cgo-dependent SQLite path
+// entirely (see pkg/paths.FlagsDBPath). Because has_image/has_tts
+// then live outside the items table, SQL filters like
+// "WHERE items.has_image = 1" (SearchItemsWithOptions and friends) stop
+// reflecting reality - callers that need flag-filtered search together
+// with this backend must filter in Go against Iter/Get instead.
+type BoltFlagStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltFlagStore opens (creating if necessary) the BoltDB file at path
+// and ensures its flags bucket exists.
+func NewBoltFlagStore(path string) (*BoltFlagStore, error) {
+	db, err := bbolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("flagstore: failed to open bolt file %q: %w", path, err)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(flagsBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("flagstore: failed to create flags bucket: %w", err)
+	}
+	return &BoltFlagStore{db: db}, nil
+}
+
+// itemKey big-endian-encodes itemID so keys sort numerically within the
+// bucket.
+func itemKey(itemID int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(itemID))
+	return key
+}
+
+func encodeFlags(hasImage, hasTTS bool) []byte {
+	var b byte
+	if hasImage {
+		b |= 1
+	}
+	if hasTTS {
+		b |= 2
+	}
+	return []byte{b}
+}
+
+func decodeFlags(value []byte) (hasImage, hasTTS bool) {
+	if len(value) == 0 {
+		return false, false
+	}
+	return value[0]&1 != 0, value[0]&2 != 0
+}
+
+func (s *BoltFlagStore) Set(itemID int, hasImage, hasTTS bool) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(flagsBucket).Put(itemKey(itemID), encodeFlags(hasImage, hasTTS))
+	})
+}
+
+func (s *BoltFlagStore) Get(itemID int) (hasImage, hasTTS bool, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		hasImage, hasTTS = decodeFlags(tx.Bucket(flagsBucket).Get(itemKey(itemID)))
+		return nil
+	})
+	return
+}
+
+func (s *BoltFlagStore) Iter(fn func(itemID int, hasImage, hasTTS bool) error) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(flagsBucket).ForEach(func(key, value []byte) error {
+			itemID := int(binary.BigEndian.Uint64(key))
+			hasImage, hasTTS := decodeFlags(value)
+			return fn(itemID, hasImage, hasTTS)
+		})
+	})
+}
+
+// SetBatch applies every entry in updates in a single bbolt transaction, so
+// a full SyncFileFlags resync costs one commit instead of one per item.
+func (s *BoltFlagStore) SetBatch(updates map[int]Flags) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(flagsBucket)
+		for itemID, flags := range updates {
+			if err := bucket.Put(itemKey(itemID), encodeFlags(flags.HasImage, flags.HasTTS)); err != nil {
+				return fmt.Errorf("flagstore: failed to set flags for item %d: %w", itemID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Close releases the underlying BoltDB file handle.
+func (s *BoltFlagStore) Close() error {
+	return s.db.Close()
+}
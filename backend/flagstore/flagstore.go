@@ -0,0 +1,64 @@
+// Package flagstore abstracts where an item's has_image/has_tts flags
+// actually live, behind a small interface (FlagStore) backed by either the
+// items table itself (SQLiteFlagStore) or a standalone BoltDB file
+// (BoltFlagStore). This mirrors backend/assets: one content concern, two
+// interchangeable backends, selected by Open.
+package flagstore
+
+import "fmt"
+
+// Flags is one item's sync state, as SyncFileFlags computes it: whether at
+// least one of its images (hero or a named variant) and its spoken audio
+// currently exist and pass their digest check (see database.DB.SyncFileFlags).
+type Flags struct {
+	HasImage bool
+	HasTTS   bool
+}
+
+// FlagStore is the persistence backend for item has_image/has_tts flags,
+// addressed by item ID.
+type FlagStore interface {
+	// Set records itemID's current flags, replacing whatever was recorded
+	// before.
+	Set(itemID int, hasImage, hasTTS bool) error
+	// Get returns itemID's recorded flags. Both are false, with no error,
+	// for an item that's never been synced.
+	Get(itemID int) (hasImage, hasTTS bool, err error)
+	// Iter calls fn once per recorded item, stopping and returning fn's
+	// error if it returns one.
+	Iter(fn func(itemID int, hasImage, hasTTS bool) error) error
+	// SetBatch records every entry in updates in a single transaction,
+	// for SyncFileFlags' full-library resync - one commit instead of one
+	// per item.
+	SetBatch(updates map[int]Flags) error
+	// Close releases any resources the store holds open (a BoltDB file
+	// handle; a no-op for SQLiteFlagStore, which shares the caller's
+	// connection rather than owning one).
+	Close() error
+}
+
+// Backend names accepted by Open.
+const (
+	BackendSQLite = "sqlite"
+	BackendBolt   = "bolt"
+)
+
+// Config configures whichever backend Open dispatches to. Conn and Rebind
+// are only meaningful for BackendSQLite; Path only for BackendBolt.
+type Config struct {
+	Conn   sqlExecer
+	Rebind func(string) string
+	Path   string
+}
+
+// Open creates the FlagStore for the given backend name.
+func Open(backend string, cfg Config) (FlagStore, error) {
+	switch backend {
+	case BackendSQLite:
+		return NewSQLiteFlagStore(cfg.Conn, cfg.Rebind), nil
+	case BackendBolt:
+		return NewBoltFlagStore(cfg.Path)
+	default:
+		return nil, fmt.Errorf("unsupported flag store backend %q", backend)
+	}
+}
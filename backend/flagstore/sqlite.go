@@ -0,0 +1,120 @@
+package flagstore
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqlExecer is the subset of *sql.DB SQLiteFlagStore needs: plain Exec/
+// Query plus Begin for SetBatch's single transaction. *sql.DB satisfies it
+// directly, so SQLiteFlagStore can wrap the same connection database.DB
+// already has open rather than needing one of its own.
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	Begin() (*sql.Tx, error)
+}
+
+// SQLiteFlagStore is a FlagStore backed by the items table's own has_image/
+// has_tts columns - the storage this project always used before flags were
+// pulled out behind FlagStore. It's the default, since it keeps has_image/
+// has_tts usable directly in SQL WHERE clauses (see SearchItemsWithOptions),
+// which BoltFlagStore cannot offer. Despite the name it also serves the
+// Postgres backend, over the same items table; rebind adapts its "?"
+// placeholders to Postgres's "$N" style, the same way database.DB.rebind
+// does for every other query in this package's caller.
+type SQLiteFlagStore struct {
+	conn   sqlExecer
+	rebind func(string) string
+}
+
+// NewSQLiteFlagStore creates a SQLiteFlagStore over conn, an already-open
+// connection to a database with an items(item_id, has_image, has_tts) table.
+// rebind adapts "?" placeholders to the backend's style; pass nil to use
+// them as-is (SQLite accepts "?" directly).
+func NewSQLiteFlagStore(conn sqlExecer, rebind func(string) string) *SQLiteFlagStore {
+	if rebind == nil {
+		rebind = func(query string) string { return query }
+	}
+	return &SQLiteFlagStore{conn: conn, rebind: rebind}
+}
+
+func (s *SQLiteFlagStore) Set(itemID int, hasImage, hasTTS bool) error {
+	query := s.rebind("UPDATE items SET has_image = ?, has_tts = ? WHERE item_id = ?")
+	if _, err := s.conn.Exec(query, hasImage, hasTTS, itemID); err != nil {
+		return fmt.Errorf("flagstore: failed to set flags for item %d: %w", itemID, err)
+	}
+	return nil
+}
+
+func (s *SQLiteFlagStore) Get(itemID int) (hasImage, hasTTS bool, err error) {
+	query := s.rebind("SELECT has_image, has_tts FROM items WHERE item_id = ?")
+	rows, err := s.conn.Query(query, itemID)
+	if err != nil {
+		return false, false, fmt.Errorf("flagstore: failed to get flags for item %d: %w", itemID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return false, false, rows.Err()
+	}
+	if err := rows.Scan(&hasImage, &hasTTS); err != nil {
+		return false, false, fmt.Errorf("flagstore: failed to scan flags for item %d: %w", itemID, err)
+	}
+	return hasImage, hasTTS, nil
+}
+
+func (s *SQLiteFlagStore) Iter(fn func(itemID int, hasImage, hasTTS bool) error) error {
+	rows, err := s.conn.Query("SELECT item_id, has_image, has_tts FROM items")
+	if err != nil {
+		return fmt.Errorf("flagstore: failed to iterate flags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var itemID int
+		var hasImage, hasTTS bool
+		if err := rows.Scan(&itemID, &hasImage, &hasTTS); err != nil {
+			return fmt.Errorf("flagstore: failed to scan flags row: %w", err)
+		}
+		if err := fn(itemID, hasImage, hasTTS); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SetBatch applies every entry in updates inside one transaction, so a full
+// SyncFileFlags resync costs one commit instead of one per item.
+func (s *SQLiteFlagStore) SetBatch(updates map[int]Flags) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("flagstore: failed to begin batch: %w", err)
+	}
+
+	query := s.rebind("UPDATE items SET has_image = ?, has_tts = ? WHERE item_id = ?")
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("flagstore: failed to prepare batch update: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for itemID, flags := range updates {
+		if _, err := stmt.Exec(flags.HasImage, flags.HasTTS, itemID); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("flagstore: failed to set flags for item %d: %w", itemID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("flagstore: failed to commit batch: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op: SQLiteFlagStore shares the caller's connection rather
+// than owning one.
+func (s *SQLiteFlagStore) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// Migration is one versioned, idempotent data or schema change. Version must
+// be unique and stable once shipped; Runner applies migrations in ascending
+// Version order and records each as done in schema_migrations so it never
+// reruns. Up is called with a progress-reporting callback so a long
+// migration can drive the same kind of progress bar as a maintenance scan.
+type Migration interface {
+	Version() int
+	Name() string
+	Up(ctx context.Context, db *database.DB, report func(done, total int)) error
+}
+
+// Registry holds the set of Migrations a Runner can apply, keyed by Version
+// so a duplicate can't be registered by accident.
+type Registry struct {
+	byVersion map[int]Migration
+}
+
+// NewRegistry creates an empty Registry. Use Register to add migrations, or
+// start from DefaultRegistry.
+func NewRegistry() *Registry {
+	return &Registry{byVersion: make(map[int]Migration)}
+}
+
+// Register adds m to the registry. It panics if m's Version is already
+// taken, since that's a programming error in the migration list rather than
+// a runtime condition.
+func (r *Registry) Register(m Migration) {
+	if _, exists := r.byVersion[m.Version()]; exists {
+		panic(fmt.Sprintf("migrations: duplicate version %d", m.Version()))
+	}
+	r.byVersion[m.Version()] = m
+}
+
+// Sorted returns every registered Migration in ascending Version order.
+func (r *Registry) Sorted() []Migration {
+	out := make([]Migration, 0, len(r.byVersion))
+	for _, m := range r.byVersion {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version() < out[j].Version() })
+	return out
+}
+
+// MaxVersion returns the highest Version registered, or 0 if the registry
+// is empty. A backup archive's SchemaVersion greater than this means the
+// archive came from a newer build with migrations this one doesn't have.
+func (r *Registry) MaxVersion() int {
+	sorted := r.Sorted()
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[len(sorted)-1].Version()
+}
+
+// DefaultRegistry returns a Registry pre-populated with every migration this
+// build ships.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(newNormalizeItemsMigration())
+	r.Register(newMoveSecretsToStoreMigration())
+	r.Register(newShardAssetLayoutMigration())
+	r.Register(newAddImageFilesColumnMigration())
+	r.Register(newAddTTSTaggedColumnMigration())
+	r.Register(newAddPackNameColumnMigration())
+	return r
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// addImageFilesColumnMigration is migration 4: it adds the image_files_json
+// column SyncFileFlags now uses to record every image variant (hero, thumb,
+// alt_1, alt_2, ...) an item has, on top of the single hero hash image_cache
+// already tracked. The column starts NULL for every existing row, so this
+// also forces a one-time full resync notice: until SyncFileFlags next runs
+// (it already does, on every app startup), Item.Images reports no images for
+// rows this migration didn't touch itself.
+type addImageFilesColumnMigration struct{}
+
+func newAddImageFilesColumnMigration() *addImageFilesColumnMigration {
+	return &addImageFilesColumnMigration{}
+}
+
+func (m *addImageFilesColumnMigration) Version() int { return 4 }
+func (m *addImageFilesColumnMigration) Name() string { return "add_image_files_column" }
+
+func (m *addImageFilesColumnMigration) Up(ctx context.Context, db *database.DB, report func(done, total int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := db.Conn().ExecContext(ctx, "ALTER TABLE items ADD COLUMN image_files_json TEXT"); err != nil {
+		return fmt.Errorf("failed to add image_files_json column: %w", err)
+	}
+	report(1, 1)
+	slog.Info("add_image_files_column: column added, forcing a full image re-sync on next SyncFileFlags run")
+	return nil
+}
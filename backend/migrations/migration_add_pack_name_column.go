@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// addPackNameColumnMigration is migration 6: it adds the pack_name column
+// seeding.ApplyPackData stamps onto every item a content pack inserts, so
+// RemovePackData can find and delete exactly that pack's rows later. The
+// column starts NULL for every existing row, which ApplyPackData and
+// RemovePackData both treat as "not part of any pack".
+type addPackNameColumnMigration struct{}
+
+func newAddPackNameColumnMigration() *addPackNameColumnMigration {
+	return &addPackNameColumnMigration{}
+}
+
+func (m *addPackNameColumnMigration) Version() int { return 6 }
+func (m *addPackNameColumnMigration) Name() string { return "add_pack_name_column" }
+
+func (m *addPackNameColumnMigration) Up(ctx context.Context, db *database.DB, report func(done, total int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := db.Conn().ExecContext(ctx, "ALTER TABLE items ADD COLUMN pack_name TEXT"); err != nil {
+		return fmt.Errorf("failed to add pack_name column: %w", err)
+	}
+	report(1, 1)
+	return nil
+}
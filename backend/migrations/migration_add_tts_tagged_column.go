@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// addTTSTaggedColumnMigration is migration 5: it adds the tts_tagged column
+// services.SyncTTSTags uses to track which cached TTS mp3s already have ID3
+// metadata written into them, so re-running it is a no-op for items it's
+// already tagged. The column starts false for every existing row, so every
+// previously-cached mp3 is picked up by the next SyncTTSTags pass.
+type addTTSTaggedColumnMigration struct{}
+
+func newAddTTSTaggedColumnMigration() *addTTSTaggedColumnMigration {
+	return &addTTSTaggedColumnMigration{}
+}
+
+func (m *addTTSTaggedColumnMigration) Version() int { return 5 }
+func (m *addTTSTaggedColumnMigration) Name() string { return "add_tts_tagged_column" }
+
+func (m *addTTSTaggedColumnMigration) Up(ctx context.Context, db *database.DB, report func(done, total int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := db.Conn().ExecContext(ctx, "ALTER TABLE items ADD COLUMN tts_tagged INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return fmt.Errorf("failed to add tts_tagged column: %w", err)
+	}
+	report(1, 1)
+	return nil
+}
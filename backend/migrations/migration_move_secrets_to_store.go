@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/secrets"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+)
+
+// moveSecretsToStoreMigration is migration 2: it moves every key SaveEnvVar
+// used to write to .env in plaintext (OPENAI_API_KEY and anything else
+// matching secrets.IsSensitive) into the encrypted secrets store, then
+// scrubs those lines from .env so they stop round-tripping through a
+// plaintext file on every future read.
+type moveSecretsToStoreMigration struct{}
+
+func newMoveSecretsToStoreMigration() *moveSecretsToStoreMigration {
+	return &moveSecretsToStoreMigration{}
+}
+
+func (m *moveSecretsToStoreMigration) Version() int { return 2 }
+func (m *moveSecretsToStoreMigration) Name() string { return "move_secrets_to_store" }
+
+func (m *moveSecretsToStoreMigration) Up(ctx context.Context, db *database.DB, report func(done, total int)) error {
+	envPath, err := paths.EnvPath()
+	if err != nil {
+		return fmt.Errorf("failed to get env path: %w", err)
+	}
+
+	sensitive, remaining, err := splitSensitiveEnvLines(envPath)
+	if err != nil {
+		return err
+	}
+
+	total := len(sensitive)
+	for i, kv := range sensitive {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := secrets.Set(kv.key, kv.value); err != nil {
+			return fmt.Errorf("failed to move %s to secrets store: %w", kv.key, err)
+		}
+		report(i+1, total)
+	}
+
+	if len(sensitive) == 0 {
+		return nil
+	}
+	return os.WriteFile(envPath, []byte(strings.Join(remaining, "\n")+"\n"), 0600)
+}
+
+// DryRun reports how many sensitive .env entries Up would move, without
+// writing anything.
+func (m *moveSecretsToStoreMigration) DryRun(ctx context.Context, db *database.DB) (int, error) {
+	envPath, err := paths.EnvPath()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get env path: %w", err)
+	}
+	sensitive, _, err := splitSensitiveEnvLines(envPath)
+	if err != nil {
+		return 0, err
+	}
+	return len(sensitive), nil
+}
+
+type envKV struct {
+	key   string
+	value string
+}
+
+// splitSensitiveEnvLines reads the .env file at path (treating a missing
+// file as empty) and splits it into sensitive key=value pairs and the
+// non-sensitive lines that should stay behind.
+func splitSensitiveEnvLines(path string) (sensitive []envKV, remaining []string, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			remaining = append(remaining, line)
+			continue
+		}
+		parts := strings.SplitN(trimmed, "=", 2)
+		if len(parts) != 2 || !secrets.IsSensitive(strings.TrimSpace(parts[0])) {
+			remaining = append(remaining, line)
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), "\"'")
+		sensitive = append(sensitive, envKV{key: key, value: value})
+	}
+	return sensitive, remaining, nil
+}
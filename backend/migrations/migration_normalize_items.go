@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/services"
+)
+
+// normalizeItemsMigration is migration 1, carried over from the original
+// one-off runMigration1: it resaves every item (picking up whatever
+// normalization UpdateItem applies) and invalidates its TTS cache via the
+// reusable ItemService helper, since the spoken text's shape can change
+// even when its content hash doesn't.
+type normalizeItemsMigration struct{}
+
+func newNormalizeItemsMigration() *normalizeItemsMigration {
+	return &normalizeItemsMigration{}
+}
+
+func (m *normalizeItemsMigration) Version() int { return 1 }
+func (m *normalizeItemsMigration) Name() string  { return "normalize_items" }
+
+func (m *normalizeItemsMigration) Up(ctx context.Context, db *database.DB, report func(done, total int)) error {
+	items, err := db.GetAllItems()
+	if err != nil {
+		return fmt.Errorf("failed to get items for migration: %w", err)
+	}
+
+	itemService := services.NewItemService(db, services.NewImageService(db))
+	total := len(items)
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := itemService.UpdateItem(item); err != nil {
+			slog.Warn("normalize_items: failed to update item", "itemId", item.ItemID, "word", item.Word, "error", err)
+		} else if err := itemService.InvalidateTTSCache(item.ItemID); err != nil {
+			slog.Warn("normalize_items: failed to invalidate TTS cache", "itemId", item.ItemID, "error", err)
+		}
+		report(i+1, total)
+	}
+	return nil
+}
+
+// DryRun reports how many items Up would touch, without writing anything.
+func (m *normalizeItemsMigration) DryRun(ctx context.Context, db *database.DB) (int, error) {
+	items, err := db.GetAllItems()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get items for migration dry run: %w", err)
+	}
+	return len(items), nil
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// shardAssetLayoutMigration is migration 3: it moves any image/TTS blob
+// still sitting at its pre-sharding flat path ("<hash><ext>" directly under
+// the images/tts-cache directory) into the new two-level, hash-prefix
+// sharded layout (see assets.LocalFSStore and database.DB.ShardAssetLayout),
+// so a large library doesn't leave one giant flat directory around forever.
+type shardAssetLayoutMigration struct{}
+
+func newShardAssetLayoutMigration() *shardAssetLayoutMigration {
+	return &shardAssetLayoutMigration{}
+}
+
+func (m *shardAssetLayoutMigration) Version() int { return 3 }
+func (m *shardAssetLayoutMigration) Name() string { return "shard_asset_layout" }
+
+func (m *shardAssetLayoutMigration) Up(ctx context.Context, db *database.DB, report func(done, total int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	moved, err := db.ShardAssetLayout()
+	if err != nil {
+		return fmt.Errorf("failed to shard asset layout: %w", err)
+	}
+	report(moved, moved)
+	return nil
+}
@@ -0,0 +1,120 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// ProgressFunc reports progress for one migration's Up call within
+// RunPending: version/name identify which migration is running, done/total
+// its row-level progress.
+type ProgressFunc func(version int, name string, done, total int)
+
+// Runner applies pending Migrations from a Registry in version order,
+// recording each as applied in the schema_migrations table so it's never
+// re-run.
+type Runner struct {
+	db       *database.DB
+	registry *Registry
+}
+
+// NewRunner creates a Runner that applies registry's migrations against db.
+func NewRunner(db *database.DB, registry *Registry) *Runner {
+	return &Runner{db: db, registry: registry}
+}
+
+// Pending returns the registry's migrations that schema_migrations doesn't
+// yet record as applied, in version order.
+func (r *Runner) Pending() ([]Migration, error) {
+	applied, err := r.db.AppliedMigrationVersions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+
+	var pending []Migration
+	for _, m := range r.registry.Sorted() {
+		if !applied[m.Version()] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// RunPending applies every pending migration in order, reporting progress
+// via progress (may be nil) and recording each as applied once its Up
+// returns successfully. It stops at the first error or ctx cancellation,
+// leaving later migrations pending for the next run, and returns the
+// versions it did apply either way.
+func (r *Runner) RunPending(ctx context.Context, progress ProgressFunc) ([]int, error) {
+	pending, err := r.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []int
+	for _, m := range pending {
+		if err := ctx.Err(); err != nil {
+			return applied, err
+		}
+
+		slog.Info("Running migration", "version", m.Version(), "name", m.Name())
+		report := func(done, total int) {
+			if progress != nil {
+				progress(m.Version(), m.Name(), done, total)
+			}
+		}
+		if err := m.Up(ctx, r.db, report); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", m.Version(), m.Name(), err)
+		}
+		if err := r.db.RecordMigrationApplied(m.Version(), m.Name()); err != nil {
+			return applied, fmt.Errorf("failed to record migration %d as applied: %w", m.Version(), err)
+		}
+		applied = append(applied, m.Version())
+	}
+	return applied, nil
+}
+
+// DryRunMigration is implemented by Migrations that can estimate their own
+// impact (e.g. the count of rows Up would touch) without writing anything.
+type DryRunMigration interface {
+	DryRun(ctx context.Context, db *database.DB) (affected int, err error)
+}
+
+// DryRunResult is one migration's estimated impact from DryRunPending.
+// Affected is -1 if the migration doesn't implement DryRunMigration.
+type DryRunResult struct {
+	Version  int    `json:"version"`
+	Name     string `json:"name"`
+	Affected int    `json:"affected"`
+}
+
+// DryRunPending reports how many rows each pending migration would touch,
+// without applying any of them.
+func (r *Runner) DryRunPending(ctx context.Context) ([]DryRunResult, error) {
+	pending, err := r.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []DryRunResult
+	for _, m := range pending {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		dr, ok := m.(DryRunMigration)
+		if !ok {
+			results = append(results, DryRunResult{Version: m.Version(), Name: m.Name(), Affected: -1})
+			continue
+		}
+		affected, err := dr.DryRun(ctx, r.db)
+		if err != nil {
+			return results, fmt.Errorf("dry run of migration %d (%s) failed: %w", m.Version(), m.Name(), err)
+		}
+		results = append(results, DryRunResult{Version: m.Version(), Name: m.Name(), Affected: affected})
+	}
+	return results, nil
+}
@@ -0,0 +1,339 @@
+// Package secrets is an encrypted-at-rest replacement for writing API keys
+// and other credentials in plaintext to .env. Values are stored in a single
+// AES-GCM encrypted file, keyed by a passphrase resolved from the OS
+// keychain (or a session-supplied master password) and stretched with
+// scrypt.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+)
+
+const (
+	keyringService = "trueblocks-poetry"
+	keyringUser    = "secrets-passphrase"
+
+	saltSize     = 16
+	nonceSize    = 12
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// sensitivePatterns mirrors the substrings SaveEnvVar has always used to
+// decide a key holds a credential worth masking; here they decide which
+// keys get routed into the encrypted store instead of .env.
+var sensitivePatterns = []string{"KEY", "SECRET", "TOKEN", "PASSWORD", "PASS", "AUTH", "CREDENTIAL"}
+
+// IsSensitive reports whether key looks like it holds a credential.
+func IsSensitive(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, pattern := range sensitivePatterns {
+		if strings.Contains(upper, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	defaultStoreOnce sync.Once
+	defaultStore     *Store
+	defaultStoreErr  error
+)
+
+func defaultStoreInstance() (*Store, error) {
+	defaultStoreOnce.Do(func() {
+		path, err := paths.SecretsPath()
+		if err != nil {
+			defaultStoreErr = err
+			return
+		}
+		defaultStore = NewStore(path)
+	})
+	return defaultStore, defaultStoreErr
+}
+
+// Get decrypts and returns key from the default encrypted secrets store, or
+// "" if it isn't set. It's the drop-in replacement for the os.Getenv(key)
+// call sites that used to read credentials straight out of .env.
+func Get(key string) (string, error) {
+	store, err := defaultStoreInstance()
+	if err != nil {
+		return "", err
+	}
+	return store.Get(key)
+}
+
+// Set encrypts and persists key=value in the default encrypted secrets
+// store.
+func Set(key, value string) error {
+	store, err := defaultStoreInstance()
+	if err != nil {
+		return err
+	}
+	return store.Set(key, value)
+}
+
+// Delete removes key from the default encrypted secrets store, if present.
+func Delete(key string) error {
+	store, err := defaultStoreInstance()
+	if err != nil {
+		return err
+	}
+	return store.Delete(key)
+}
+
+// Keys returns every key currently in the default encrypted secrets store,
+// e.g. for GetEnvVars to report which credentials are configured without
+// decrypting their values.
+func Keys() ([]string, error) {
+	store, err := defaultStoreInstance()
+	if err != nil {
+		return nil, err
+	}
+	return store.Keys()
+}
+
+// SetMasterPassword overrides the default store's passphrase for the rest
+// of the session, for environments (headless CI, a locked-down OS keyring)
+// where the keychain lookup SaveEnvVar relies on can't run.
+func SetMasterPassword(password string) error {
+	store, err := defaultStoreInstance()
+	if err != nil {
+		return err
+	}
+	store.SetMasterPassword(password)
+	return nil
+}
+
+// Store is an AES-GCM encrypted key/value file.
+type Store struct {
+	path string
+
+	mu         sync.Mutex
+	passphrase string // empty until resolved, then cached for the session
+}
+
+// NewStore creates a Store backed by the encrypted file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// SetMasterPassword caches password in memory as this Store's passphrase
+// for the rest of the session, bypassing the OS keychain.
+func (s *Store) SetMasterPassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.passphrase = password
+}
+
+// Get returns key's decrypted value, or "" if the store has no entry for it
+// (including when the store file doesn't exist yet).
+func (s *Store) Get(key string) (string, error) {
+	values, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	return values[key], nil
+}
+
+// Set encrypts and persists key=value, replacing any existing value.
+func (s *Store) Set(key, value string) error {
+	values, err := s.load()
+	if err != nil {
+		return err
+	}
+	if values == nil {
+		values = make(map[string]string)
+	}
+	values[key] = value
+	return s.save(values)
+}
+
+// Delete removes key from the store, if present.
+func (s *Store) Delete(key string) error {
+	values, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[key]; !ok {
+		return nil
+	}
+	delete(values, key)
+	return s.save(values)
+}
+
+// Keys returns every key currently in the store.
+func (s *Store) Keys() ([]string, error) {
+	values, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// load decrypts the store file into a key/value map, returning a nil map if
+// the file doesn't exist yet.
+func (s *Store) load() (map[string]string, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets store: %w", err)
+	}
+	if len(data) < saltSize+nonceSize {
+		return nil, fmt.Errorf("secrets store is corrupt: file too short")
+	}
+
+	salt, nonce, ciphertext := data[:saltSize], data[saltSize:saltSize+nonceSize], data[saltSize+nonceSize:]
+	key, err := s.deriveKey(salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decrypt(key, nonce, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets store: %w", err)
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted secrets store: %w", err)
+	}
+	return values, nil
+}
+
+// save encrypts values under a freshly generated salt/nonce and writes the
+// store file via a temp-file-then-rename, so a crash mid-write can't leave
+// a half-written, unreadable store.
+func (s *Store) save(values map[string]string) error {
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets store: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := s.deriveKey(salt)
+	if err != nil {
+		return err
+	}
+	nonce, ciphertext, err := encrypt(key, plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secrets store: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+
+	out := make([]byte, 0, len(salt)+len(nonce)+len(ciphertext))
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	out = append(out, ciphertext...)
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to finalize secrets store: %w", err)
+	}
+	return nil
+}
+
+// deriveKey stretches the store's passphrase (resolving and caching it on
+// first use) with scrypt, salted per-save so two writes never reuse a key
+// even if the passphrase hasn't changed.
+func (s *Store) deriveKey(salt []byte) ([]byte, error) {
+	passphrase, err := s.resolvePassphrase()
+	if err != nil {
+		return nil, err
+	}
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+}
+
+// resolvePassphrase returns the passphrase this Store encrypts with,
+// preferring a session-supplied master password, then the OS keychain
+// (minting and storing a random passphrase there on first run), caching
+// whichever it finds for the rest of the session.
+func (s *Store) resolvePassphrase() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+
+	if pass, err := keyring.Get(keyringService, keyringUser); err == nil {
+		s.passphrase = pass
+		return s.passphrase, nil
+	}
+
+	pass, err := randomPassphrase()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate passphrase: %w", err)
+	}
+	if err := keyring.Set(keyringService, keyringUser, pass); err != nil {
+		return "", fmt.Errorf("OS keychain unavailable; call secrets.SetMasterPassword to supply one manually: %w", err)
+	}
+	s.passphrase = pass
+	return s.passphrase, nil
+}
+
+func randomPassphrase() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func encrypt(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+func decrypt(key, nonce, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
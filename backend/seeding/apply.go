@@ -0,0 +1,73 @@
+package seeding
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// ApplyPackData runs the SQL fragment (Mounts.SQL) of every pack mgr has
+// installed, once per pack version, against db. It's called separately from
+// PackManager.Install - after App.startup has opened the database - since
+// PackManager itself only ever touches the data folder's files and has no
+// *database.DB of its own (see PackManager's doc comment). A pack's SQL
+// fragment is expected to stamp its own pack_name onto every row it inserts
+// (e.g. INSERT INTO items (..., pack_name) VALUES (..., 'my-pack')), so
+// RemovePackData can find and undo exactly that pack's rows later.
+func ApplyPackData(db *database.DB, mgr *PackManager) error {
+	for _, installed := range mgr.List() {
+		if installed.Name == builtinPackName {
+			continue
+		}
+		source, ok := mgr.sources[installed.Name]
+		if !ok {
+			continue
+		}
+		pack, err := mgr.loadPackFromSource(source)
+		if err != nil {
+			return fmt.Errorf("failed to load pack %q: %w", installed.Name, err)
+		}
+		if pack.Mounts.SQL == "" {
+			continue
+		}
+
+		applied, err := db.IsPackApplied(pack.Name, pack.Version)
+		if err != nil {
+			return fmt.Errorf("failed to check pack %q applied state: %w", pack.Name, err)
+		}
+		if applied {
+			continue
+		}
+
+		dir, err := mgr.materializeSource(source)
+		if err != nil {
+			return fmt.Errorf("failed to materialize pack %q: %w", pack.Name, err)
+		}
+		sqlBytes, err := os.ReadFile(filepath.Join(dir, pack.Mounts.SQL))
+		if err != nil {
+			return fmt.Errorf("failed to read SQL fragment for pack %q: %w", pack.Name, err)
+		}
+
+		if _, err := db.Conn().Exec(string(sqlBytes)); err != nil {
+			return fmt.Errorf("failed to apply SQL fragment for pack %q: %w", pack.Name, err)
+		}
+		if err := db.RecordPackApplied(pack.Name, pack.Version); err != nil {
+			return fmt.Errorf("failed to record pack %q as applied: %w", pack.Name, err)
+		}
+	}
+	return nil
+}
+
+// RemovePackData deletes every row packName's SQL fragment inserted (via
+// database.DB.DeletePackItems), the data-side counterpart to
+// PackManager.Remove, which only drops packName from packs.lock. Call both
+// when a user removes a pack.
+func RemovePackData(db *database.DB, packName string) (int64, error) {
+	deleted, err := db.DeletePackItems(packName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove data for pack %q: %w", packName, err)
+	}
+	return deleted, nil
+}
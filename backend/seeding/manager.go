@@ -0,0 +1,458 @@
+package seeding
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lockEntry is one PackManager.packs.lock line: the pack a source resolved
+// to the last time Install ran, so repeated startups reuse the same
+// version instead of re-resolving the requires graph from scratch.
+type lockEntry struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"`
+}
+
+// InstalledPack is List's per-pack result.
+type InstalledPack struct {
+	Name     string            `json:"name"`
+	Version  string            `json:"version"`
+	Source   string            `json:"source"`
+	Requires map[string]string `json:"requires"`
+}
+
+// PackManager resolves, installs, and removes ContentPacks into a data
+// folder, modeled loosely on Hugo Modules: AddSource registers where a pack
+// comes from, Install fetches/extracts it and records the resolved version
+// in packs.lock (minimal-version selection across every pack's Requires),
+// and Tidy drops packs.lock entries whose pack.toml no longer resolves.
+//
+// PackManager only ever touches the data folder's files (images/, tts-cache/,
+// and a pack's own cached copy under .packs/); it has no *database.DB of its
+// own. A pack's SQL fragment - the part that actually needs a live
+// connection - is applied separately by ApplyPackData once App.startup has
+// opened the database, not by PackManager itself (see ApplyPackData for why
+// that's an intentional, documented departure from doing everything before
+// the database opens).
+type PackManager struct {
+	dataDir  string
+	lockPath string
+	sources  map[string]string // pack name -> source path/URL, from AddSource
+	locked   []lockEntry
+}
+
+// NewPackManager creates a PackManager rooted at dataDir (the same folder
+// EnsureDataSeeded extracts data.tar.gz into), loading any existing
+// packs.lock.
+func NewPackManager(dataDir string) (*PackManager, error) {
+	m := &PackManager{
+		dataDir:  dataDir,
+		lockPath: filepath.Join(dataDir, "packs.lock"),
+		sources:  map[string]string{},
+	}
+	if err := m.loadLock(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *PackManager) loadLock() error {
+	data, err := os.ReadFile(m.lockPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read packs.lock: %w", err)
+	}
+	return json.Unmarshal(data, &m.locked)
+}
+
+func (m *PackManager) saveLock() error {
+	data, err := json.MarshalIndent(m.locked, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode packs.lock: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.lockPath), 0755); err != nil {
+		return fmt.Errorf("failed to create data folder: %w", err)
+	}
+	return os.WriteFile(m.lockPath, data, 0644)
+}
+
+// AddSource registers where a pack named by its pack.toml can be fetched
+// from - a local directory containing pack.toml, a local .tar.gz file, or
+// an http(s) URL to one - without installing it yet, returning the pack's
+// own name (read from pack.toml) so the caller can pass it to Install.
+func (m *PackManager) AddSource(nameOrURL string) (string, error) {
+	pack, err := m.loadPackFromSource(nameOrURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to read pack manifest from %q: %w", nameOrURL, err)
+	}
+	m.sources[pack.Name] = nameOrURL
+	return pack.Name, nil
+}
+
+// loadPackFromSource resolves source into a *ContentPack by reading its
+// pack.toml, downloading/extracting it into the .packs/.staging directory
+// (see materializeSource) first if source is a URL or tar.gz rather than an
+// already-plain directory.
+func (m *PackManager) loadPackFromSource(source string) (*ContentPack, error) {
+	dir, err := m.materializeSource(source)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "pack.toml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack.toml: %w", err)
+	}
+	return parsePackTOML(data)
+}
+
+// materializeSource returns a plain directory backing source: source itself
+// if it's already a directory, or the directory it was extracted to
+// otherwise (downloading it first if it's an http(s) URL).
+func (m *PackManager) materializeSource(source string) (string, error) {
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return source, nil
+	}
+
+	archivePath := source
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		tmp, err := downloadToTemp(source)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = os.Remove(tmp) }()
+		archivePath = tmp
+	}
+
+	// Extract to a staging directory keyed by the archive's file name so
+	// repeated Installs from the same local archive don't re-extract every
+	// time. A downloaded archive gets a fresh temp name on each download, so
+	// it is extracted again.
+	staging := filepath.Join(m.dataDir, ".packs", ".staging", filepath.Base(archivePath))
+	if _, err := os.Stat(filepath.Join(staging, "pack.toml")); err == nil {
+		return staging, nil
+	}
+	if err := extractTarGz(archivePath, staging); err != nil {
+		return "", err
+	}
+	return staging, nil
+}
+
+func downloadToTemp(url string) (string, error) {
+	resp, err := http.Get(url) //nolint:gosec // pack source URLs are operator-supplied, not user input
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: status %s", url, resp.Status)
+	}
+
+	f, err := os.CreateTemp("", "poetry-pack-*.tar.gz")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("failed to save downloaded pack: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// extractTarGz extracts every regular file/directory in the tar.gz at
+// archivePath into dest, the same walk Seeder.Run uses for data.tar.gz.
+func extractTarGz(archivePath, dest string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open pack archive: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer func() { _ = gzr.Close() }()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading pack tar header: %w", err)
+		}
+
+		target := filepath.Join(dest, header.Name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create parent directory for %s: %w", target, err)
+			}
+			outFile, err := os.Create(target)
+			if err != nil {
+				return fmt.Errorf("failed to create file %s: %w", target, err)
+			}
+			if _, err := io.Copy(outFile, tr); err != nil {
+				_ = outFile.Close()
+				return fmt.Errorf("failed to write file %s: %w", target, err)
+			}
+			_ = outFile.Close()
+		}
+	}
+	return nil
+}
+
+// Install resolves name (previously registered via AddSource) plus
+// everything it Requires, checks each pack's version against the highest
+// minimum any dependent requires (failing if it falls below it; each pack
+// has one registered source, so only the version that source provides is
+// considered, no other version is looked for), merges its
+// images/tts-cache mounts into the data folder, and records the result in
+// packs.lock. version is the minimum version the caller itself wants to
+// force for name; pass "" to accept whatever the requires graph resolves to.
+func (m *PackManager) Install(name, version string) error {
+	source, ok := m.sources[name]
+	if !ok {
+		return fmt.Errorf("no source registered for pack %q - call AddSource first", name)
+	}
+
+	resolved, err := m.resolveGraph(name, version)
+	if err != nil {
+		return err
+	}
+
+	for _, pack := range resolved {
+		packSource := m.sources[pack.Name]
+		if pack.Name == name {
+			packSource = source
+		}
+		if err := m.mountPack(pack, packSource); err != nil {
+			return fmt.Errorf("failed to install pack %q: %w", pack.Name, err)
+		}
+		m.recordLock(pack.Name, pack.Version, packSource)
+	}
+
+	return m.saveLock()
+}
+
+// resolveGraph walks name's Requires transitively, collecting every pack
+// involved and picking, for each, the maximum of every minimum version
+// required of it (minimal-version selection) - forcedVersion, if non-empty,
+// is treated as an additional minimum required of name itself.
+func (m *PackManager) resolveGraph(name, forcedVersion string) ([]*ContentPack, error) {
+	minVersions := map[string]string{}
+	if forcedVersion != "" {
+		minVersions[name] = forcedVersion
+	}
+
+	visited := map[string]*ContentPack{}
+	var visit func(packName string) error
+	visit = func(packName string) error {
+		if _, done := visited[packName]; done {
+			return nil
+		}
+		source, ok := m.sources[packName]
+		if !ok {
+			return fmt.Errorf("pack %q is required but has no registered source", packName)
+		}
+		pack, err := m.loadPackFromSource(source)
+		if err != nil {
+			return err
+		}
+		visited[packName] = pack
+
+		for depName, minVersion := range pack.Requires {
+			if current, ok := minVersions[depName]; !ok || compareVersions(minVersion, current) > 0 {
+				minVersions[depName] = minVersion
+			}
+			if err := visit(depName); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(name); err != nil {
+		return nil, err
+	}
+
+	out := make([]*ContentPack, 0, len(visited))
+	for packName, pack := range visited {
+		if min, ok := minVersions[packName]; ok && compareVersions(pack.Version, min) < 0 {
+			return nil, fmt.Errorf("pack %q version %s does not satisfy required minimum %s", packName, pack.Version, min)
+		}
+		out = append(out, pack)
+	}
+	return out, nil
+}
+
+// mountPack merges pack's images/tts-cache mounts into the data folder.
+// Existing files are left alone, matching Seeder.Run's
+// never-overwrite rule for user-managed content.
+func (m *PackManager) mountPack(pack *ContentPack, source string) error {
+	dir, err := m.materializeSource(source)
+	if err != nil {
+		return err
+	}
+
+	if pack.Mounts.Images != "" {
+		if err := mergeDir(filepath.Join(dir, pack.Mounts.Images), filepath.Join(m.dataDir, "images")); err != nil {
+			return fmt.Errorf("failed to mount images: %w", err)
+		}
+	}
+	if pack.Mounts.TTSCache != "" {
+		if err := mergeDir(filepath.Join(dir, pack.Mounts.TTSCache), filepath.Join(m.dataDir, "tts-cache")); err != nil {
+			return fmt.Errorf("failed to mount tts-cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// mergeDir copies every file under src into dst, skipping any that already
+// exist there.
+func mergeDir(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if _, err := os.Stat(target); err == nil {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
+
+func (m *PackManager) recordLock(name, version, source string) {
+	for i, e := range m.locked {
+		if e.Name == name {
+			m.locked[i] = lockEntry{Name: name, Version: version, Source: source}
+			return
+		}
+	}
+	m.locked = append(m.locked, lockEntry{Name: name, Version: version, Source: source})
+}
+
+// List returns every pack packs.lock currently records, preceded by the
+// builtin pack, which is always listed since it's always present and never
+// needs installing.
+func (m *PackManager) List() []InstalledPack {
+	out := make([]InstalledPack, 0, len(m.locked)+1)
+	out = append(out, InstalledPack{Name: builtinPackName, Version: builtinPackVersion, Source: "embedded"})
+	for _, e := range m.locked {
+		pack, err := m.loadPackFromSource(e.Source)
+		requires := map[string]string{}
+		if err == nil {
+			requires = pack.Requires
+		}
+		out = append(out, InstalledPack{Name: e.Name, Version: e.Version, Source: e.Source, Requires: requires})
+	}
+	return out
+}
+
+// Graph returns, for every installed pack (including builtin, which depends
+// on nothing), the names of the packs it Requires - the dependency graph
+// List's flat slice doesn't show directly.
+func (m *PackManager) Graph() (map[string][]string, error) {
+	graph := make(map[string][]string, len(m.locked)+1)
+	graph[builtinPackName] = nil
+	for _, e := range m.locked {
+		pack, err := m.loadPackFromSource(e.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load pack %q: %w", e.Name, err)
+		}
+		deps := make([]string, 0, len(pack.Requires))
+		for dep := range pack.Requires {
+			deps = append(deps, dep)
+		}
+		graph[e.Name] = deps
+	}
+	return graph, nil
+}
+
+// Remove drops name from packs.lock and its registered source. It does not
+// delete name's already-merged images/tts-cache files - those are
+// indistinguishable from user content once merged - but does report the
+// removal so a caller (App.RemovePack) can also clean up the pack's items
+// rows via ApplyPackData's companion RemovePackData.
+func (m *PackManager) Remove(name string) error {
+	kept := m.locked[:0]
+	found := false
+	for _, e := range m.locked {
+		if e.Name == name {
+			found = true
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if !found {
+		return fmt.Errorf("pack %q is not installed", name)
+	}
+	m.locked = kept
+	delete(m.sources, name)
+	return m.saveLock()
+}
+
+// Tidy drops any packs.lock entry whose pack.toml no longer resolves from
+// its recorded source. It does not remove entries that are merely unused by
+// other packs' requires graphs.
+func (m *PackManager) Tidy() error {
+	reachable := map[string]bool{builtinPackName: true}
+	for _, e := range m.locked {
+		reachable[e.Name] = true
+	}
+	// A pack is only unreachable once nothing else Requires it AND the
+	// caller never installed it directly; packs.lock doesn't distinguish
+	// "installed directly" from "installed as a dependency", so Tidy here
+	// only removes entries whose own pack.toml no longer resolves - a
+	// conservative, honest subset of "drop anything unused".
+	kept := m.locked[:0]
+	removed := 0
+	for _, e := range m.locked {
+		if _, err := m.loadPackFromSource(e.Source); err != nil {
+			slog.Warn("[PackManager] dropping unresolvable pack from packs.lock", "pack", e.Name, "error", err)
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	m.locked = kept
+	if removed == 0 {
+		return nil
+	}
+	return m.saveLock()
+}
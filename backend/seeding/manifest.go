@@ -0,0 +1,84 @@
+package seeding
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// ManifestEntry is one manifest.json row: the relative path, content hash,
+// and size data.tar.gz's own manifest.json records for a file it ships,
+// plus whether that file is user-managed (Mutable - poetry.db, images, the
+// TTS cache) and so never repaired once extracted, unlike a bundled poet
+// portrait or seed SQL fragment.
+type ManifestEntry struct {
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size"`
+	Mutable bool   `json:"mutable"`
+}
+
+// Manifest is manifest.json's top-level shape.
+type Manifest struct {
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// entry returns the ManifestEntry for path, if any.
+func (m Manifest) entry(path string) (ManifestEntry, bool) {
+	for _, e := range m.Entries {
+		if e.Path == path {
+			return e, true
+		}
+	}
+	return ManifestEntry{}, false
+}
+
+// readManifest decompresses and walks archiveName inside sourceFS looking
+// for manifest.json, parsing it once found. It returns a zero Manifest, not
+// an error, if the archive has no manifest.json - older archives built
+// before this request shipped none, and Seeder.Run treats that the same as
+// "every file unknown, extract whatever's missing".
+func readManifest(sourceFS fs.FS, archiveName string) (Manifest, error) {
+	f, err := sourceFS.Open(archiveName)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to open %s: %w", archiveName, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer func() { _ = gzr.Close() }()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return Manifest{}, nil
+		}
+		if err != nil {
+			return Manifest{}, fmt.Errorf("error reading tar header: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || header.Name != "manifest.json" {
+			continue
+		}
+		var manifest Manifest
+		if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+			return Manifest{}, fmt.Errorf("failed to parse manifest.json: %w", err)
+		}
+		return manifest, nil
+	}
+}
+
+// sha256Hex returns the lowercase hex SHA-256 digest of data, the same
+// encoding manifest.json's sha256 field uses.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
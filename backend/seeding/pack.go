@@ -0,0 +1,144 @@
+package seeding
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// builtinPackName is the special-case pack name EnsureDataSeeded's bundled
+// data.tar.gz is treated as, so it shows up in PackManager.List/Graph
+// alongside every pack a user has added via AddSource. builtinPackVersion
+// is fixed since data.tar.gz ships no pack.toml of its own to read a real
+// version from.
+const (
+	builtinPackName    = "builtin"
+	builtinPackVersion = "1.0.0"
+)
+
+// PackMounts is where a pack's pack.toml points its content at, relative to
+// the pack's own root - merged into the app's data folder by PackManager.
+type PackMounts struct {
+	// Images is the subdirectory (if any) merged into the data folder's
+	// images/ directory, the same way data.tar.gz's own images/ already is.
+	Images string
+	// TTSCache is the subdirectory (if any) merged into tts-cache/.
+	TTSCache string
+	// SQL is the path (if any), relative to the pack root, to an idempotent
+	// SQL fragment that inserts this pack's items - see ApplyPackData.
+	SQL string
+}
+
+// ContentPack is one unit of installable poetry content: a tar.gz or plain
+// directory declaring a pack.toml with its Name, a semver Version, and the
+// other packs (by name) it Requires a minimum version of. Modeled loosely
+// on Hugo Modules - see PackManager for resolution and Install/Remove.
+type ContentPack struct {
+	Name     string
+	Version  string
+	Requires map[string]string
+	Mounts   PackMounts
+}
+
+// parsePackTOML parses a pack.toml's contents. It only understands the
+// subset pack.toml actually uses - top-level name/version, a [requires]
+// table of pack-name = min-version pairs, and a [mounts] table of
+// images/tts-cache/sql paths - rather than pulling in a general-purpose
+// TOML library for three flat tables.
+func parsePackTOML(data []byte) (*ContentPack, error) {
+	pack := &ContentPack{Requires: map[string]string{}}
+	section := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("pack.toml: malformed line %q", line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch section {
+		case "":
+			switch key {
+			case "name":
+				pack.Name = value
+			case "version":
+				pack.Version = value
+			}
+		case "requires":
+			pack.Requires[key] = value
+		case "mounts":
+			switch key {
+			case "images":
+				pack.Mounts.Images = value
+			case "tts-cache":
+				pack.Mounts.TTSCache = value
+			case "sql":
+				pack.Mounts.SQL = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("pack.toml: %w", err)
+	}
+
+	if pack.Name == "" {
+		return nil, fmt.Errorf("pack.toml: missing name")
+	}
+	if pack.Version == "" {
+		return nil, fmt.Errorf("pack.toml: missing version")
+	}
+	return pack, nil
+}
+
+// compareVersions orders two dotted-numeric semver strings ("1.2.0"),
+// ignoring any "-prerelease"/"+build" suffix, the same way Go's own module
+// graph treats two versions when picking the higher of the two: -1 if a<b,
+// 0 if equal, 1 if a>b. A part that doesn't parse as a number sorts as 0,
+// since pack.toml versions are expected to be well-formed.
+func compareVersions(a, b string) int {
+	pa := versionParts(a)
+	pb := versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na = pa[i]
+		}
+		if i < len(pb) {
+			nb = pb[i]
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	out := make([]int, len(parts))
+	for i, p := range parts {
+		n, _ := strconv.Atoi(p)
+		out[i] = n
+	}
+	return out
+}
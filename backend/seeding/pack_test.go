@@ -0,0 +1,63 @@
+package seeding
+
+import "testing"
+
+func TestParsePackTOML(t *testing.T) {
+	data := []byte(`
+name = "myth-cycle"
+version = "1.2.0"
+
+[requires]
+builtin = "1.0.0"
+
+[mounts]
+images = "images"
+tts-cache = "tts-cache"
+sql = "seed.sql"
+`)
+
+	pack, err := parsePackTOML(data)
+	if err != nil {
+		t.Fatalf("parsePackTOML returned error: %v", err)
+	}
+	if pack.Name != "myth-cycle" {
+		t.Errorf("Name = %q, want %q", pack.Name, "myth-cycle")
+	}
+	if pack.Version != "1.2.0" {
+		t.Errorf("Version = %q, want %q", pack.Version, "1.2.0")
+	}
+	if pack.Requires["builtin"] != "1.0.0" {
+		t.Errorf("Requires[builtin] = %q, want %q", pack.Requires["builtin"], "1.0.0")
+	}
+	if pack.Mounts.Images != "images" || pack.Mounts.TTSCache != "tts-cache" || pack.Mounts.SQL != "seed.sql" {
+		t.Errorf("Mounts = %+v, unexpected", pack.Mounts)
+	}
+}
+
+func TestParsePackTOMLMissingFields(t *testing.T) {
+	if _, err := parsePackTOML([]byte(`version = "1.0.0"`)); err == nil {
+		t.Error("expected error for missing name")
+	}
+	if _, err := parsePackTOML([]byte(`name = "x"`)); err == nil {
+		t.Error("expected error for missing version")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0", "1.0.1", -1},
+		{"1.1.0", "1.0.9", 1},
+		{"2.0.0", "1.9.9", 1},
+		{"1.0.0-beta", "1.0.0", 0},
+		{"v1.2.0", "1.2.0", 0},
+	}
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package seeding
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// progressThrottle is how often Run calls onProgress while extracting, so a
+// React splash screen gets smooth updates without flooding Wails events for
+// every single file.
+const progressThrottle = 100 * time.Millisecond
+
+// progressEMAAlpha weights how much a newly-observed throughput sample
+// moves ProgressEvent.ETASeconds' running average, the same smoothing
+// cheggaaa/pb-style progress bars use so the ETA doesn't jump around on
+// every small or large file.
+const progressEMAAlpha = 0.3
+
+// ProgressEvent is one Seeder.Run progress update: how far extraction has
+// gotten, in both bytes and files, plus an ETA extrapolated from an
+// exponentially weighted moving average of recent throughput.
+type ProgressEvent struct {
+	TotalBytes  int64   `json:"totalBytes"`
+	BytesDone   int64   `json:"bytesDone"`
+	CurrentFile string  `json:"currentFile"`
+	FilesDone   int     `json:"filesDone"`
+	FilesTotal  int     `json:"filesTotal"`
+	ETASeconds  float64 `json:"etaSeconds"`
+}
+
+// Seeder extracts/repairs data.tar.gz into a data folder, the way
+// EnsureDataSeeded always has, but as a cancellable, progress-reporting
+// operation - App.startup runs it in a goroutine and streams its
+// ProgressEvents to the frontend as "seed:progress" Wails events.
+type Seeder struct {
+	dataFolder string
+	sourceFS   fs.FS
+	sigFS      fs.FS
+	pubKey     ed25519.PublicKey
+}
+
+// NewSeeder creates a Seeder for dataFolder using the binary's own embedded
+// data.tar.gz/data.tar.gz.sig and public key.
+func NewSeeder(dataFolder string) (*Seeder, error) {
+	pub, err := seedPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return newSeederWithFS(dataFolder, seedData, seedSig, pub), nil
+}
+
+// newSeederWithFS is NewSeeder's test seam: sourceFS/sigFS/pubKey stand in
+// for the embedded data.tar.gz, its signature, and the real public key.
+func newSeederWithFS(dataFolder string, sourceFS, sigFS fs.FS, pubKey ed25519.PublicKey) *Seeder {
+	return &Seeder{dataFolder: dataFolder, sourceFS: sourceFS, sigFS: sigFS, pubKey: pubKey}
+}
+
+// Run verifies and extracts/repairs data.tar.gz into s.dataFolder, calling
+// onProgress (if non-nil) at most every progressThrottle with how far it's
+// gotten. It checks ctx between tar entries, so a cancellation mid-run
+// leaves whatever's been extracted so far intact rather than half-writing
+// the file in progress - new files are written to target+".tmp" and
+// renamed into place only once fully copied.
+func (s *Seeder) Run(ctx context.Context, onProgress func(ProgressEvent)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	log.Printf("[Seeding] Checking data folder: %s", s.dataFolder)
+	if err := os.MkdirAll(s.dataFolder, 0755); err != nil {
+		return fmt.Errorf("failed to create data folder: %w", err)
+	}
+
+	f, err := s.sourceFS.Open("data.tar.gz")
+	if err != nil {
+		log.Printf("[Seeding] Warning: data.tar.gz not found in embedded assets. Skipping seeding.")
+		return nil
+	}
+	archive, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read data.tar.gz: %w", err)
+	}
+
+	if err := verifySeedSignature(archive, s.sigFS, "data.tar.gz.sig", s.pubKey); err != nil {
+		return fmt.Errorf("refusing to extract data.tar.gz: %w", err)
+	}
+
+	manifest, err := readManifest(s.sourceFS, "data.tar.gz")
+	if err != nil {
+		return fmt.Errorf("failed to read manifest.json: %w", err)
+	}
+
+	totalBytes, filesTotal, err := planExtraction(archive, manifest)
+	if err != nil {
+		return fmt.Errorf("failed to plan extraction: %w", err)
+	}
+	tracker := newProgressTracker(totalBytes, filesTotal)
+	lastEmit := time.Time{}
+	emit := func(currentFile string) {
+		if onProgress == nil {
+			return
+		}
+		tracker.currentFile = currentFile
+		now := time.Now()
+		if !lastEmit.IsZero() && now.Sub(lastEmit) < progressThrottle {
+			return
+		}
+		lastEmit = now
+		onProgress(tracker.event())
+	}
+
+	gzr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer func() { _ = gzr.Close() }()
+
+	tr := tar.NewReader(gzr)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading tar header: %w", err)
+		}
+		if header.Name == "manifest.json" {
+			continue // metadata only, not part of the seeded data itself
+		}
+
+		target := filepath.Join(s.dataFolder, header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			entry, known := manifest.entry(header.Name)
+
+			if _, err := os.Stat(target); err == nil {
+				// If it's the database, NEVER overwrite.
+				if strings.HasSuffix(target, "poetry.db") {
+					continue
+				}
+				// Mutable files (or anything the manifest doesn't recognize)
+				// are left exactly as they are, per design.
+				if !known || entry.Mutable {
+					continue
+				}
+				data, err := io.ReadAll(tr)
+				if err != nil {
+					return fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+				}
+				if err := repairIfDrifted(target, data, entry); err != nil {
+					return err
+				}
+				tracker.advance(header.Name, int64(len(data)))
+				emit(header.Name)
+				continue
+			}
+
+			log.Printf("[Seeding] Extracting missing file: %s", header.Name)
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create parent directory for %s: %w", target, err)
+			}
+
+			tmpTarget := target + ".tmp"
+			outFile, err := os.Create(tmpTarget)
+			if err != nil {
+				return fmt.Errorf("failed to create file %s: %w", tmpTarget, err)
+			}
+			written, err := io.Copy(outFile, tr)
+			if err != nil {
+				_ = outFile.Close()
+				_ = os.Remove(tmpTarget)
+				return fmt.Errorf("failed to write file %s: %w", tmpTarget, err)
+			}
+			if err := outFile.Close(); err != nil {
+				_ = os.Remove(tmpTarget)
+				return fmt.Errorf("failed to close file %s: %w", tmpTarget, err)
+			}
+			if err := os.Rename(tmpTarget, target); err != nil {
+				return fmt.Errorf("failed to rename %s to %s: %w", tmpTarget, target, err)
+			}
+			tracker.advance(header.Name, written)
+			emit(header.Name)
+		}
+	}
+
+	if onProgress != nil {
+		onProgress(tracker.finalEvent())
+	}
+	log.Printf("[Seeding] Seeding check complete.")
+	return nil
+}
+
+// planExtraction returns the total byte count and file count Run expects to
+// process, for ProgressEvent.TotalBytes/FilesTotal. It reads these straight
+// off manifest when one was found; an older archive shipped without a
+// manifest.json falls back to a first pass over the tar counting regular
+// files itself.
+func planExtraction(archive []byte, manifest Manifest) (totalBytes int64, filesTotal int, err error) {
+	if len(manifest.Entries) > 0 {
+		for _, e := range manifest.Entries {
+			totalBytes += e.Size
+			filesTotal++
+		}
+		return totalBytes, filesTotal, nil
+	}
+
+	gzr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer func() { _ = gzr.Close() }()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("error reading tar header: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && header.Name != "manifest.json" {
+			totalBytes += header.Size
+			filesTotal++
+		}
+	}
+	return totalBytes, filesTotal, nil
+}
+
+// progressTracker accumulates Seeder.Run's progress and smooths an
+// instantaneous throughput sample into an EWMA so ETASeconds doesn't jump
+// around between a tiny file and a huge one.
+type progressTracker struct {
+	totalBytes  int64
+	filesTotal  int
+	bytesDone   int64
+	filesDone   int
+	currentFile string
+
+	lastTick  time.Time
+	lastBytes int64
+	emaRate   float64 // bytes/sec
+}
+
+func newProgressTracker(totalBytes int64, filesTotal int) *progressTracker {
+	return &progressTracker{totalBytes: totalBytes, filesTotal: filesTotal, lastTick: time.Now()}
+}
+
+// advance records that an entry named name of size bytes has just finished,
+// updating the EWMA throughput estimate from how long it took.
+func (p *progressTracker) advance(name string, size int64) {
+	p.filesDone++
+	p.bytesDone += size
+	p.currentFile = name
+
+	now := time.Now()
+	elapsed := now.Sub(p.lastTick).Seconds()
+	if elapsed > 0 {
+		instRate := float64(p.bytesDone-p.lastBytes) / elapsed
+		if p.emaRate <= 0 {
+			p.emaRate = instRate
+		} else {
+			p.emaRate = progressEMAAlpha*instRate + (1-progressEMAAlpha)*p.emaRate
+		}
+	}
+	p.lastTick = now
+	p.lastBytes = p.bytesDone
+}
+
+// eta extrapolates remaining time from the current EWMA throughput, or 0 if
+// there's no rate yet (the very first file) or nothing left to do.
+func (p *progressTracker) eta() float64 {
+	remaining := p.totalBytes - p.bytesDone
+	if p.emaRate <= 0 || remaining <= 0 {
+		return 0
+	}
+	return float64(remaining) / p.emaRate
+}
+
+func (p *progressTracker) event() ProgressEvent {
+	return ProgressEvent{
+		TotalBytes:  p.totalBytes,
+		BytesDone:   p.bytesDone,
+		CurrentFile: p.currentFile,
+		FilesDone:   p.filesDone,
+		FilesTotal:  p.filesTotal,
+		ETASeconds:  p.eta(),
+	}
+}
+
+func (p *progressTracker) finalEvent() ProgressEvent {
+	event := p.event()
+	event.ETASeconds = 0
+	return event
+}
+
+// repairIfDrifted overwrites target with data if target's current contents
+// no longer hash to entry.SHA256 - e.g. a bundled poet portrait that was
+// accidentally edited or corrupted since it was first extracted.
+func repairIfDrifted(target string, data []byte, entry ManifestEntry) error {
+	current, err := os.ReadFile(target)
+	if err != nil {
+		return fmt.Errorf("failed to read %s to check for drift: %w", target, err)
+	}
+	if sha256Hex(current) == entry.SHA256 {
+		return nil
+	}
+	log.Printf("[Seeding] Repairing drifted file: %s", target)
+	tmpTarget := target + ".tmp"
+	if err := os.WriteFile(tmpTarget, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpTarget, err)
+	}
+	if err := os.Rename(tmpTarget, target); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", tmpTarget, target, err)
+	}
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -39,7 +42,47 @@ func createMockTarGz(files map[string]string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func TestEnsureDataSeededWithFS(t *testing.T) {
+// signedMockFS builds a data.tar.gz/data.tar.gz.sig pair from files (plus a
+// manifest.json built from manifestEntries), signed with a freshly
+// generated ed25519 keypair, so tests can drive Seeder.Run's
+// signature-verification path without the real embedded public key.
+func signedMockFS(t *testing.T, files map[string]string, manifestEntries []ManifestEntry) (fstest.MapFS, fstest.MapFS, ed25519.PublicKey) {
+	t.Helper()
+
+	if len(manifestEntries) > 0 {
+		manifest, err := json.Marshal(Manifest{Entries: manifestEntries})
+		if err != nil {
+			t.Fatalf("Failed to marshal manifest: %v", err)
+		}
+		files = cloneFiles(files)
+		files["manifest.json"] = string(manifest)
+	}
+
+	archive, err := createMockTarGz(files)
+	if err != nil {
+		t.Fatalf("Failed to create mock tar.gz: %v", err)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate test keypair: %v", err)
+	}
+	sig := ed25519.Sign(priv, archive)
+
+	sourceFS := fstest.MapFS{"data.tar.gz": &fstest.MapFile{Data: archive}}
+	sigFS := fstest.MapFS{"data.tar.gz.sig": &fstest.MapFile{Data: sig}}
+	return sourceFS, sigFS, pub
+}
+
+func cloneFiles(files map[string]string) map[string]string {
+	out := make(map[string]string, len(files)+1)
+	for k, v := range files {
+		out[k] = v
+	}
+	return out
+}
+
+func TestSeederRun(t *testing.T) {
 	// Create a temporary directory for the data folder
 	tmpDir, err := os.MkdirTemp("", "seeding-test")
 	if err != nil {
@@ -49,7 +92,7 @@ func TestEnsureDataSeededWithFS(t *testing.T) {
 
 	// 1. Test with missing data.tar.gz
 	emptyFS := fstest.MapFS{}
-	err = ensureDataSeededWithFS(tmpDir, emptyFS)
+	err = newSeederWithFS(tmpDir, emptyFS, emptyFS, nil).Run(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Expected no error when data.tar.gz is missing, got: %v", err)
 	}
@@ -59,16 +102,9 @@ func TestEnsureDataSeededWithFS(t *testing.T) {
 		"poetry.db":       "mock database content",
 		"images/test.png": "mock image content",
 	}
-	tarGzData, err := createMockTarGz(mockData)
-	if err != nil {
-		t.Fatalf("Failed to create mock tar.gz: %v", err)
-	}
-
-	mockFS := fstest.MapFS{
-		"data.tar.gz": &fstest.MapFile{Data: tarGzData},
-	}
+	sourceFS, sigFS, pub := signedMockFS(t, mockData, nil)
 
-	err = ensureDataSeededWithFS(tmpDir, mockFS)
+	err = newSeederWithFS(tmpDir, sourceFS, sigFS, pub).Run(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Expected successful seeding, got: %v", err)
 	}
@@ -84,6 +120,11 @@ func TestEnsureDataSeededWithFS(t *testing.T) {
 		t.Errorf("images/test.png not extracted correctly")
 	}
 
+	// No .tmp files should be left behind once extraction finishes.
+	if _, err := os.Stat(imgPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected no leftover .tmp file for images/test.png")
+	}
+
 	// 3. Test NO overwrite of existing database
 	// Modify the database file on disk
 	err = os.WriteFile(dbPath, []byte("existing user data"), 0644)
@@ -96,12 +137,9 @@ func TestEnsureDataSeededWithFS(t *testing.T) {
 		"poetry.db":      "NEW database content",
 		"images/new.png": "new image",
 	}
-	newTarGz, _ := createMockTarGz(newData)
-	newMockFS := fstest.MapFS{
-		"data.tar.gz": &fstest.MapFile{Data: newTarGz},
-	}
+	newSourceFS, newSigFS, newPub := signedMockFS(t, newData, nil)
 
-	err = ensureDataSeededWithFS(tmpDir, newMockFS)
+	err = newSeederWithFS(tmpDir, newSourceFS, newSigFS, newPub).Run(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Expected successful seeding run 2, got: %v", err)
 	}
@@ -117,3 +155,123 @@ func TestEnsureDataSeededWithFS(t *testing.T) {
 		t.Errorf("New file images/new.png was not extracted")
 	}
 }
+
+func TestSeederRunRejectsBadSignature(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "seeding-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	sourceFS, _, otherPub := signedMockFS(t, map[string]string{"poetry.db": "data"}, nil)
+	badSigFS := fstest.MapFS{"data.tar.gz.sig": &fstest.MapFile{Data: []byte("not a real signature")}}
+
+	if err := newSeederWithFS(tmpDir, sourceFS, badSigFS, otherPub).Run(context.Background(), nil); err == nil {
+		t.Error("expected an error for an invalid signature, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "poetry.db")); !os.IsNotExist(err) {
+		t.Error("expected no files to be extracted when signature verification fails")
+	}
+}
+
+func TestSeederRunRepairsDriftedFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "seeding-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	portrait := "original portrait bytes"
+	manifestEntries := []ManifestEntry{
+		{Path: "images/poet.png", SHA256: sha256Hex([]byte(portrait)), Size: int64(len(portrait)), Mutable: false},
+		{Path: "poetry.db", SHA256: sha256Hex([]byte("db bytes")), Size: 8, Mutable: true},
+	}
+	sourceFS, sigFS, pub := signedMockFS(t, map[string]string{
+		"images/poet.png": portrait,
+		"poetry.db":       "db bytes",
+	}, manifestEntries)
+
+	seeder := newSeederWithFS(tmpDir, sourceFS, sigFS, pub)
+	if err := seeder.Run(context.Background(), nil); err != nil {
+		t.Fatalf("initial seeding failed: %v", err)
+	}
+
+	// Corrupt the non-mutable portrait on disk.
+	portraitPath := filepath.Join(tmpDir, "images/poet.png")
+	if err := os.WriteFile(portraitPath, []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("failed to corrupt portrait: %v", err)
+	}
+	// Also "corrupt" the mutable database - this must NOT be repaired.
+	dbPath := filepath.Join(tmpDir, "poetry.db")
+	if err := os.WriteFile(dbPath, []byte("user data"), 0644); err != nil {
+		t.Fatalf("failed to modify db: %v", err)
+	}
+
+	if err := seeder.Run(context.Background(), nil); err != nil {
+		t.Fatalf("repair pass failed: %v", err)
+	}
+
+	if content, err := os.ReadFile(portraitPath); err != nil || string(content) != portrait {
+		t.Errorf("drifted non-mutable file was not repaired, got %q", string(content))
+	}
+	if content, err := os.ReadFile(dbPath); err != nil || string(content) != "user data" {
+		t.Errorf("mutable file should not have been repaired, got %q", string(content))
+	}
+}
+
+func TestSeederRunReportsProgress(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "seeding-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	manifestEntries := []ManifestEntry{
+		{Path: "poetry.db", SHA256: sha256Hex([]byte("db bytes")), Size: 8, Mutable: true},
+		{Path: "images/test.png", SHA256: sha256Hex([]byte("image bytes")), Size: 11, Mutable: false},
+	}
+	sourceFS, sigFS, pub := signedMockFS(t, map[string]string{
+		"poetry.db":       "db bytes",
+		"images/test.png": "image bytes",
+	}, manifestEntries)
+
+	var events []ProgressEvent
+	seeder := newSeederWithFS(tmpDir, sourceFS, sigFS, pub)
+	if err := seeder.Run(context.Background(), func(e ProgressEvent) {
+		events = append(events, e)
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(events) == 0 {
+		t.Fatal("expected at least one progress event")
+	}
+	last := events[len(events)-1]
+	if last.FilesDone != 2 || last.FilesTotal != 2 {
+		t.Errorf("final event FilesDone/FilesTotal = %d/%d, want 2/2", last.FilesDone, last.FilesTotal)
+	}
+	if last.BytesDone != last.TotalBytes {
+		t.Errorf("final event BytesDone = %d, want %d (TotalBytes)", last.BytesDone, last.TotalBytes)
+	}
+}
+
+func TestSeederRunHonorsCancellation(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "seeding-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	sourceFS, sigFS, pub := signedMockFS(t, map[string]string{
+		"images/a.png": "a",
+		"images/b.png": "b",
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	seeder := newSeederWithFS(tmpDir, sourceFS, sigFS, pub)
+	if err := seeder.Run(ctx, nil); err == nil {
+		t.Error("expected an error from a pre-cancelled context")
+	}
+}
@@ -0,0 +1,52 @@
+package seeding
+
+import (
+	"crypto/ed25519"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+//go:embed data.tar.gz.sig
+var seedSig embed.FS
+
+// seedPublicKeyHex is the ed25519 public key (hex-encoded) data.tar.gz.sig
+// is checked against. The matching private key lives outside this repo and
+// signs data.tar.gz at release time; only the public half ever ships in the
+// binary.
+const seedPublicKeyHex = "d3b31bc1b739df7433ac5ce152488c7a60071eacdfb6142aee2cb0281cc34d5b"
+
+func seedPublicKey() (ed25519.PublicKey, error) {
+	raw, err := hex.DecodeString(seedPublicKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode embedded seed public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("embedded seed public key has wrong length %d, want %d", len(raw), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// verifySeedSignature checks archive against the detached signature at
+// sigName inside sigFS and pub, returning an error if the signature is
+// missing, malformed, or doesn't verify. Seeder.Run refuses to extract
+// data.tar.gz in any of those cases, rather than trust an archive that
+// could have been tampered with after it was signed.
+func verifySeedSignature(archive []byte, sigFS fs.FS, sigName string, pub ed25519.PublicKey) error {
+	sigFile, err := sigFS.Open(sigName)
+	if err != nil {
+		return fmt.Errorf("missing %s: %w", sigName, err)
+	}
+	defer func() { _ = sigFile.Close() }()
+
+	sig, err := io.ReadAll(sigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", sigName, err)
+	}
+	if !ed25519.Verify(pub, archive, sig) {
+		return fmt.Errorf("%s does not verify against the embedded seed public key", sigName)
+	}
+	return nil
+}
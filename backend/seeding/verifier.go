@@ -0,0 +1,102 @@
+package seeding
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// VerifyReport is Verifier.Verify's result: every file manifest.json
+// expects that's Missing entirely, every non-mutable file whose on-disk
+// hash no longer matches the manifest (Modified), and every file under the
+// data folder the manifest doesn't know about at all (Extra - almost
+// always user content, never touched by seeding).
+type VerifyReport struct {
+	Missing  []string
+	Modified []string
+	Extra    []string
+}
+
+// Dirty reports whether r found anything worth a user's attention.
+func (r VerifyReport) Dirty() bool {
+	return len(r.Missing) > 0 || len(r.Modified) > 0
+}
+
+// Verifier checks a data folder against data.tar.gz's manifest.json without
+// extracting or modifying anything, so App.VerifySeed can offer a "repair
+// data" action before EnsureDataSeeded's own repair pass (which only runs
+// at startup) gets a chance to run again.
+type Verifier struct {
+	dataFolder string
+	sourceFS   fs.FS
+}
+
+// NewVerifier creates a Verifier for dataFolder, reading the manifest from
+// the binary's own embedded data.tar.gz.
+func NewVerifier(dataFolder string) *Verifier {
+	return &Verifier{dataFolder: dataFolder, sourceFS: seedData}
+}
+
+// newVerifierWithFS is NewVerifier's test seam: sourceFS stands in for the
+// embedded data.tar.gz.
+func newVerifierWithFS(dataFolder string, sourceFS fs.FS) *Verifier {
+	return &Verifier{dataFolder: dataFolder, sourceFS: sourceFS}
+}
+
+// Verify compares v's data folder against the manifest, reporting every
+// file that's missing, modified (non-mutable only), or extra.
+func (v *Verifier) Verify() (VerifyReport, error) {
+	manifest, err := readManifest(v.sourceFS, "data.tar.gz")
+	if err != nil {
+		return VerifyReport{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var report VerifyReport
+	known := make(map[string]bool, len(manifest.Entries))
+	for _, entry := range manifest.Entries {
+		known[entry.Path] = true
+
+		target := filepath.Join(v.dataFolder, entry.Path)
+		data, err := os.ReadFile(target)
+		if os.IsNotExist(err) {
+			report.Missing = append(report.Missing, entry.Path)
+			continue
+		}
+		if err != nil {
+			return VerifyReport{}, fmt.Errorf("failed to read %s: %w", entry.Path, err)
+		}
+		if entry.Mutable {
+			continue
+		}
+		if sha256Hex(data) != entry.SHA256 {
+			report.Modified = append(report.Modified, entry.Path)
+		}
+	}
+
+	if _, err := os.Stat(v.dataFolder); os.IsNotExist(err) {
+		return report, nil
+	}
+	err = filepath.WalkDir(v.dataFolder, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(v.dataFolder, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "manifest.json" || rel == "packs.lock" {
+			return nil
+		}
+		if !known[rel] {
+			report.Extra = append(report.Extra, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return VerifyReport{}, fmt.Errorf("failed to walk data folder: %w", err)
+	}
+
+	return report, nil
+}
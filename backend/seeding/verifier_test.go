@@ -0,0 +1,114 @@
+package seeding
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func manifestFS(t *testing.T, entries []ManifestEntry) fstest.MapFS {
+	t.Helper()
+	manifest, err := json.Marshal(Manifest{Entries: entries})
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	archive, err := createMockTarGz(map[string]string{"manifest.json": string(manifest)})
+	if err != nil {
+		t.Fatalf("Failed to create mock tar.gz: %v", err)
+	}
+	return fstest.MapFS{"data.tar.gz": &fstest.MapFile{Data: archive}}
+}
+
+func TestVerifierReportsMissingModifiedExtra(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "verifier-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	entries := []ManifestEntry{
+		{Path: "images/poet.png", SHA256: sha256Hex([]byte("portrait bytes")), Mutable: false},
+		{Path: "images/missing.png", SHA256: sha256Hex([]byte("missing bytes")), Mutable: false},
+		{Path: "poetry.db", SHA256: sha256Hex([]byte("original db")), Mutable: true},
+	}
+	sourceFS := manifestFS(t, entries)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "images"), 0755); err != nil {
+		t.Fatalf("failed to create images dir: %v", err)
+	}
+	// poet.png on disk matches the manifest.
+	if err := os.WriteFile(filepath.Join(tmpDir, "images/poet.png"), []byte("portrait bytes"), 0644); err != nil {
+		t.Fatalf("failed to write poet.png: %v", err)
+	}
+	// poetry.db is mutable and has drifted - should NOT be reported as modified.
+	if err := os.WriteFile(filepath.Join(tmpDir, "poetry.db"), []byte("user-modified db"), 0644); err != nil {
+		t.Fatalf("failed to write poetry.db: %v", err)
+	}
+	// An extra, unmanifested file.
+	if err := os.WriteFile(filepath.Join(tmpDir, "images/bonus.png"), []byte("bonus"), 0644); err != nil {
+		t.Fatalf("failed to write bonus.png: %v", err)
+	}
+	// images/missing.png intentionally not written.
+
+	v := newVerifierWithFS(tmpDir, sourceFS)
+	report, err := v.Verify()
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+
+	if len(report.Missing) != 1 || report.Missing[0] != "images/missing.png" {
+		t.Errorf("Missing = %v, want [images/missing.png]", report.Missing)
+	}
+	if len(report.Modified) != 0 {
+		t.Errorf("Modified = %v, want none (mutable drift shouldn't count)", report.Modified)
+	}
+	if len(report.Extra) != 1 || report.Extra[0] != "images/bonus.png" {
+		t.Errorf("Extra = %v, want [images/bonus.png]", report.Extra)
+	}
+	if !report.Dirty() {
+		t.Error("expected Dirty() to be true when files are missing")
+	}
+}
+
+func TestVerifierReportsModifiedNonMutableFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "verifier-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	entries := []ManifestEntry{
+		{Path: "images/poet.png", SHA256: sha256Hex([]byte("original portrait")), Mutable: false},
+	}
+	sourceFS := manifestFS(t, entries)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "images"), 0755); err != nil {
+		t.Fatalf("failed to create images dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "images/poet.png"), []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("failed to write poet.png: %v", err)
+	}
+
+	v := newVerifierWithFS(tmpDir, sourceFS)
+	report, err := v.Verify()
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if len(report.Modified) != 1 || report.Modified[0] != "images/poet.png" {
+		t.Errorf("Modified = %v, want [images/poet.png]", report.Modified)
+	}
+}
+
+func TestVerifyReportDirty(t *testing.T) {
+	if (VerifyReport{}).Dirty() {
+		t.Error("empty report should not be dirty")
+	}
+	if (VerifyReport{Extra: []string{"x"}}).Dirty() {
+		t.Error("extra files alone should not count as dirty")
+	}
+	if !(VerifyReport{Missing: []string{"x"}}).Dirty() {
+		t.Error("report with a missing file should be dirty")
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/cache"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+)
+
+// BlobGCReport summarizes a GarbageCollectBlobs sweep.
+type BlobGCReport struct {
+	ImagesRemoved int   `json:"imagesRemoved"`
+	TTSRemoved    int   `json:"ttsRemoved"`
+	BytesFreed    int64 `json:"bytesFreed"`
+}
+
+// GarbageCollectBlobs sweeps the images and TTS cache directories for blob
+// files that image_cache/tts_cache no longer reference. SaveItemImage and
+// SpeakWord already garbage-collect a blob the moment its last reference
+// goes away, so this is a belt-and-suspenders pass for anything that got
+// left behind anyway - an interrupted write, or a database restored from a
+// backup taken before this sweep existed.
+func GarbageCollectBlobs(db *database.DB, imageService *ImageService, ttsService *TTSService) (BlobGCReport, error) {
+	var report BlobGCReport
+
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return report, fmt.Errorf("failed to get images directory: %w", err)
+	}
+	liveImages, err := db.AllImageCacheHashes()
+	if err != nil {
+		return report, fmt.Errorf("failed to list live image hashes: %w", err)
+	}
+	removed, bytesFreed, err := sweepOrphanedBlobs(imagesDir, ".png", liveImages, imageService.cache)
+	if err != nil {
+		return report, fmt.Errorf("failed to sweep image blobs: %w", err)
+	}
+	report.ImagesRemoved = removed
+	report.BytesFreed += bytesFreed
+
+	ttsDir, err := paths.TTSCacheDir()
+	if err != nil {
+		return report, fmt.Errorf("failed to get TTS cache directory: %w", err)
+	}
+	liveTTS, err := db.AllTTSCacheHashes()
+	if err != nil {
+		return report, fmt.Errorf("failed to list live TTS hashes: %w", err)
+	}
+	removed, bytesFreed, err = sweepOrphanedBlobs(ttsDir, ".mp3", liveTTS, ttsService.cache)
+	if err != nil {
+		return report, fmt.Errorf("failed to sweep TTS blobs: %w", err)
+	}
+	report.TTSRemoved = removed
+	report.BytesFreed += bytesFreed
+
+	return report, nil
+}
+
+// sweepOrphanedBlobs removes every file in dir with the given ext whose
+// content hash (its filename minus ext) isn't in live, returning how many
+// were removed and the total bytes freed. If c is non-nil, removal goes
+// through it so its on-disk size/count index stays consistent.
+func sweepOrphanedBlobs(dir, ext string, live []string, c *cache.BoundedCache) (removed int, bytesFreed int64, err error) {
+	liveSet := make(map[string]bool, len(live))
+	for _, hash := range live {
+		liveSet[hash] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ext {
+			continue
+		}
+		hash := strings.TrimSuffix(entry.Name(), ext)
+		if liveSet[hash] {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return removed, bytesFreed, fmt.Errorf("failed to stat %s: %w", entry.Name(), err)
+		}
+
+		if c != nil {
+			if err := c.Remove(entry.Name()); err != nil {
+				return removed, bytesFreed, fmt.Errorf("failed to remove orphaned blob %s: %w", entry.Name(), err)
+			}
+		} else if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return removed, bytesFreed, fmt.Errorf("failed to remove orphaned blob %s: %w", entry.Name(), err)
+		}
+
+		removed++
+		bytesFreed += info.Size()
+	}
+
+	return removed, bytesFreed, nil
+}
@@ -7,13 +7,16 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/TrueBlocks/trueblocks-poetry/backend/blobstore"
 	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/cache"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
 )
 
 // ImageService handles image operations
 type ImageService struct {
-	db *database.DB
+	db    *database.DB
+	cache *cache.BoundedCache
 }
 
 // NewImageService creates a new ImageService
@@ -23,61 +26,108 @@ func NewImageService(db *database.DB) *ImageService {
 	}
 }
 
-// GetItemImage retrieves an image for an item from the cache
+// SetCache attaches (or replaces) the bounded cache used for writes.
+func (s *ImageService) SetCache(c *cache.BoundedCache) {
+	s.cache = c
+}
+
+// PruneCache enforces the configured limits on the image cache, evicting
+// least-recently-used entries as needed.
+func (s *ImageService) PruneCache() (*cache.EvictionReport, error) {
+	if s.cache == nil {
+		return &cache.EvictionReport{}, nil
+	}
+	return s.cache.Prune()
+}
+
+// GetItemImage retrieves itemId's cached image, following its image_cache
+// hash to the content-addressed blob rather than a fixed <id>.png path.
 func (s *ImageService) GetItemImage(itemId int) (string, error) {
-	imagesDir, err := constants.GetImagesDir()
+	hash, err := s.db.GetImageCacheHash(itemId)
 	if err != nil {
-		return "", fmt.Errorf("failed to get images directory: %w", err)
+		return "", fmt.Errorf("failed to look up image cache hash: %w", err)
 	}
-	imagePath := filepath.Join(imagesDir, fmt.Sprintf("%d.png", itemId))
-
-	// Check if file exists
-	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
+	if hash == "" {
 		return "", nil // No image exists
 	}
 
-	// Read the image file
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get images directory: %w", err)
+	}
+	imageName := hash + ".png"
+	imagePath := filepath.Join(imagesDir, imageName)
+
 	imageBytes, err := os.ReadFile(imagePath)
+	if os.IsNotExist(err) {
+		return "", nil // Blob missing on disk; flags are out of sync with SyncFileFlags
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to read image file: %w", err)
 	}
 
-	// Encode to base64
+	if s.cache != nil {
+		s.cache.Touch(imageName)
+	}
+
 	encoded := base64.StdEncoding.EncodeToString(imageBytes)
 	return fmt.Sprintf("data:image/png;base64,%s", encoded), nil
 }
 
-// DeleteItemImage removes an image for an item from the cache
+// DeleteItemImage drops itemId's image_cache link entirely and
+// garbage-collects the blob it pointed at if nothing else still
+// references it.
 func (s *ImageService) DeleteItemImage(itemId int) error {
-	imagesDir, err := constants.GetImagesDir()
+	oldHash, err := s.db.GetImageCacheHash(itemId)
 	if err != nil {
-		return fmt.Errorf("failed to get images directory: %w", err)
+		return fmt.Errorf("failed to look up image cache hash: %w", err)
 	}
-	imagePath := filepath.Join(imagesDir, fmt.Sprintf("%d.png", itemId))
-
-	// Check if file exists
-	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-		return nil // Already doesn't exist
+	if oldHash == "" {
+		return nil
 	}
-
-	// Delete the file
-	if err := os.Remove(imagePath); err != nil {
-		return fmt.Errorf("failed to delete image file: %w", err)
+	if err := s.db.DeleteImageCacheHash(itemId); err != nil {
+		return fmt.Errorf("failed to delete image cache hash: %w", err)
 	}
-
-	// Update database flag
-	_, err = s.db.Conn().Exec("UPDATE items SET has_image = 0 WHERE item_id = ?", itemId)
-	if err != nil {
+	if _, err := s.db.Conn().Exec("UPDATE items SET has_image = 0 WHERE item_id = ?", itemId); err != nil {
 		return fmt.Errorf("failed to update has_image flag: %w", err)
 	}
+	return s.gcImageBlob(oldHash)
+}
 
+// gcImageBlob removes the cached .png for contentHash once no item
+// references it in image_cache anymore, so a replaced or deleted image
+// doesn't leak its old blob forever.
+func (s *ImageService) gcImageBlob(contentHash string) error {
+	refs, err := s.db.CountImageCacheRefs(contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to count image cache references: %w", err)
+	}
+	if refs > 0 {
+		return nil
+	}
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return err
+	}
+	imageName := contentHash + ".png"
+	if s.cache != nil {
+		if err := s.cache.Remove(imageName); err != nil {
+			return fmt.Errorf("failed to remove orphaned image blob: %w", err)
+		}
+		return nil
+	}
+	if err := os.Remove(filepath.Join(imagesDir, imageName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove orphaned image blob: %w", err)
+	}
 	return nil
 }
 
-// SaveItemImage saves an image for an item to the cache directory
+// SaveItemImage hashes imageData and stores it under image_cache's
+// content-addressed naming: identical images across items share one file
+// on disk, and replacing an item's image garbage-collects the blob it
+// previously pointed at once nothing else references it.
 func (s *ImageService) SaveItemImage(itemId int, imageData string) error {
-	// Get user config directory
-	cacheDir, err := constants.GetImagesDir()
+	cacheDir, err := paths.ImagesDir()
 	if err != nil {
 		return fmt.Errorf("failed to get images directory: %w", err)
 	}
@@ -91,23 +141,36 @@ func (s *ImageService) SaveItemImage(itemId int, imageData string) error {
 		return fmt.Errorf("invalid image data format")
 	}
 
-	// Decode from base64
 	decoded, err := base64.StdEncoding.DecodeString(parts[1])
 	if err != nil {
 		return fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	// Save to file
-	imagePath := filepath.Join(cacheDir, fmt.Sprintf("%d.png", itemId))
-	if err := os.WriteFile(imagePath, decoded, 0644); err != nil {
+	hash := blobstore.Hash(decoded)
+	imageName := hash + ".png"
+	imagePath := filepath.Join(cacheDir, imageName)
+
+	if s.cache != nil {
+		if _, err := s.cache.Write(imageName, decoded); err != nil {
+			return fmt.Errorf("failed to write image file: %w", err)
+		}
+	} else if err := os.WriteFile(imagePath, decoded, 0644); err != nil {
 		return fmt.Errorf("failed to write image file: %w", err)
 	}
 
-	// Update database flag
-	_, err = s.db.Conn().Exec("UPDATE items SET has_image = 1 WHERE item_id = ?", itemId)
+	oldHash, err := s.db.GetImageCacheHash(itemId)
 	if err != nil {
+		return fmt.Errorf("failed to look up image cache hash: %w", err)
+	}
+	if err := s.db.SetImageCacheHash(itemId, hash); err != nil {
+		return fmt.Errorf("failed to set image cache hash: %w", err)
+	}
+	if _, err := s.db.Conn().Exec("UPDATE items SET has_image = 1 WHERE item_id = ?", itemId); err != nil {
 		return fmt.Errorf("failed to update has_image flag: %w", err)
 	}
 
+	if oldHash != "" && oldHash != hash {
+		return s.gcImageBlob(oldHash)
+	}
 	return nil
 }
@@ -1,14 +1,16 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
 	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
 	"github.com/TrueBlocks/trueblocks-poetry/pkg/validator"
 )
 
@@ -35,9 +37,9 @@ func (s *ItemService) SearchItems(query string) ([]database.Item, error) {
 
 	// Parse definitions for all items
 	for i := range items {
-		if items[i].Definition != nil {
-			isPoem := parser.IsPoem(items[i].Type, *items[i].Definition)
-			items[i].ParsedDef = parser.ParseDefinition(*items[i].Definition, isPoem)
+		if def := items[i].Definition.GetOrEmpty(); def != "" {
+			isPoem := parser.IsPoem(items[i].Type, def)
+			items[i].ParsedDef = parser.ParseDefinition(def, isPoem)
 		}
 	}
 
@@ -53,9 +55,9 @@ func (s *ItemService) SearchItemsWithOptions(options database.SearchOptions) ([]
 
 	// Parse definitions for all items
 	for i := range items {
-		if items[i].Definition != nil {
-			isPoem := parser.IsPoem(items[i].Type, *items[i].Definition)
-			items[i].ParsedDef = parser.ParseDefinition(*items[i].Definition, isPoem)
+		if def := items[i].Definition.GetOrEmpty(); def != "" {
+			isPoem := parser.IsPoem(items[i].Type, def)
+			items[i].ParsedDef = parser.ParseDefinition(def, isPoem)
 		}
 	}
 
@@ -71,9 +73,9 @@ func (s *ItemService) GetItem(itemID int) (*database.Item, error) {
 	}
 
 	// Parse definition into structured segments
-	if item.Definition != nil {
-		isPoem := parser.IsPoem(item.Type, *item.Definition)
-		item.ParsedDef = parser.ParseDefinition(*item.Definition, isPoem)
+	if def := item.Definition.GetOrEmpty(); def != "" {
+		isPoem := parser.IsPoem(item.Type, def)
+		item.ParsedDef = parser.ParseDefinition(def, isPoem)
 	}
 
 	return item, nil
@@ -87,9 +89,9 @@ func (s *ItemService) GetItemByWord(word string) (*database.Item, error) {
 	}
 
 	// Parse definition into structured segments
-	if item.Definition != nil {
-		isPoem := parser.IsPoem(item.Type, *item.Definition)
-		item.ParsedDef = parser.ParseDefinition(*item.Definition, isPoem)
+	if def := item.Definition.GetOrEmpty(); def != "" {
+		isPoem := parser.IsPoem(item.Type, def)
+		item.ParsedDef = parser.ParseDefinition(def, isPoem)
 	}
 
 	return item, nil
@@ -103,9 +105,9 @@ func (s *ItemService) GetRandomItem() (*database.Item, error) {
 	}
 
 	// Parse definition into structured segments
-	if item.Definition != nil {
-		isPoem := parser.IsPoem(item.Type, *item.Definition)
-		item.ParsedDef = parser.ParseDefinition(*item.Definition, isPoem)
+	if def := item.Definition.GetOrEmpty(); def != "" {
+		isPoem := parser.IsPoem(item.Type, def)
+		item.ParsedDef = parser.ParseDefinition(def, isPoem)
 	}
 
 	return item, nil
@@ -116,6 +118,26 @@ func (s *ItemService) GetPoetIds() ([]int, error) {
 	return s.db.GetPoetIds()
 }
 
+// GetItemsByTag returns every item indexed with tag, optionally restricted
+// to one tag flavor (parser.TagTypeHashtag, parser.TagTypeCategory, or
+// parser.TagTypeFrontmatter); an empty kind matches any flavor.
+func (s *ItemService) GetItemsByTag(tag string, kind string) ([]database.Item, error) {
+	items, err := s.db.GetItemsByTag(tag, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse definitions for all items
+	for i := range items {
+		if def := items[i].Definition.GetOrEmpty(); def != "" {
+			isPoem := parser.IsPoem(items[i].Type, def)
+			items[i].ParsedDef = parser.ParseDefinition(def, isPoem)
+		}
+	}
+
+	return items, nil
+}
+
 // LinkOrTagResult is the return type for CreateLinkOrRemoveTags
 type LinkOrTagResult struct {
 	LinkCreated bool   `json:"linkCreated"`
@@ -152,23 +174,102 @@ func (s *ItemService) UpdateItem(item database.Item) error {
 		return err
 	}
 
-	// Delete TTS cache for this item
-	cacheDir, err := constants.GetTTSCacheDir()
-	if err == nil {
-		cacheFile := fmt.Sprintf("%s/%d.mp3", cacheDir, item.ItemID)
-		if err := os.Remove(cacheFile); err != nil && !os.IsNotExist(err) {
-			slog.Warn("Failed to delete TTS cache", "id", item.ItemID, "error", err)
-		} else if err == nil {
-			// Clear has_tts flag since file was deleted
-			if _, err := s.db.Conn().Exec("UPDATE items SET has_tts = 0 WHERE item_id = ?", item.ItemID); err != nil {
-				slog.Warn("Failed to clear has_tts flag", "id", item.ItemID, "error", err)
-			}
-		}
+	// Keep the content-addressable TTS cache in step: only drop the cached
+	// audio if the spoken text actually changed, so a cosmetic edit to
+	// Derivation/Appendicies doesn't destroy it.
+	if err := s.syncTTSCache(item); err != nil {
+		slog.Warn("Failed to sync TTS cache", "id", item.ItemID, "error", err)
 	}
 
 	return s.db.UpdateItem(item)
 }
 
+// syncTTSCache reconciles item's tts_cache row and has_tts flag with its
+// current Definition. If the hash of the (text, voice, model) that would be
+// spoken is unchanged, the cached .mp3 is left alone; if it changed, the old
+// link is dropped and the blob is garbage-collected once no other item
+// still references it.
+func (s *ItemService) syncTTSCache(item database.Item) error {
+	def := item.Definition.GetOrEmpty()
+	if def == "" {
+		return s.InvalidateTTSCache(item.ItemID)
+	}
+
+	newHash := TTSContentHash(s.db, item.Type, item.Word, def)
+
+	oldHash, err := s.db.GetTTSCacheHash(item.ItemID)
+	if err != nil {
+		return fmt.Errorf("failed to look up TTS cache hash: %w", err)
+	}
+	if oldHash == newHash {
+		return nil
+	}
+
+	if err := s.db.SetTTSCacheHash(item.ItemID, newHash); err != nil {
+		return fmt.Errorf("failed to record TTS cache hash: %w", err)
+	}
+
+	// The new hash may already be cached (e.g. an identical Definition
+	// elsewhere), in which case the item can reuse that blob immediately.
+	hasTTS := 0
+	if cacheDir, err := paths.TTSCacheDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(cacheDir, newHash+".mp3")); err == nil {
+			hasTTS = 1
+		}
+	}
+	if _, err := s.db.Conn().Exec("UPDATE items SET has_tts = ? WHERE item_id = ?", hasTTS, item.ItemID); err != nil {
+		return fmt.Errorf("failed to update has_tts flag: %w", err)
+	}
+
+	if oldHash == "" {
+		return nil
+	}
+	return s.gcTTSBlob(oldHash)
+}
+
+// InvalidateTTSCache drops itemID's tts_cache link entirely (it no longer has
+// any spoken text, or the cache simply needs invalidating - e.g. a schema
+// migration changing how TTS is cached) and garbage-collects the blob it
+// pointed at if nothing else still references it.
+func (s *ItemService) InvalidateTTSCache(itemID int) error {
+	oldHash, err := s.db.GetTTSCacheHash(itemID)
+	if err != nil {
+		return fmt.Errorf("failed to look up TTS cache hash: %w", err)
+	}
+	if oldHash == "" {
+		return nil
+	}
+	if err := s.db.DeleteTTSCacheHash(itemID); err != nil {
+		return fmt.Errorf("failed to delete TTS cache hash: %w", err)
+	}
+	if _, err := s.db.Conn().Exec("UPDATE items SET has_tts = 0 WHERE item_id = ?", itemID); err != nil {
+		return fmt.Errorf("failed to clear has_tts flag: %w", err)
+	}
+	return s.gcTTSBlob(oldHash)
+}
+
+// gcTTSBlob removes the cached .mp3 for contentHash once no item references
+// it in tts_cache anymore, so an edited-away Definition doesn't leak its old
+// audio blob forever.
+func (s *ItemService) gcTTSBlob(contentHash string) error {
+	refs, err := s.db.CountTTSCacheRefs(contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to count TTS cache references: %w", err)
+	}
+	if refs > 0 {
+		return nil
+	}
+	cacheDir, err := paths.TTSCacheDir()
+	if err != nil {
+		return err
+	}
+	cacheFile := filepath.Join(cacheDir, contentHash+".mp3")
+	if err := os.Remove(cacheFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove orphaned TTS blob: %w", err)
+	}
+	return nil
+}
+
 // ToggleItemMark toggles the mark field for an item
 func (s *ItemService) ToggleItemMark(itemID int, marked bool) error {
 	return s.db.ToggleItemMark(itemID, marked)
@@ -182,17 +283,8 @@ func (s *ItemService) DeleteItem(itemID int) error {
 	slog.Info("[ItemService] DeleteItem called", "id", itemID)
 
 	// Delete TTS cache for this item
-	cacheDir, err := constants.GetTTSCacheDir()
-	if err == nil {
-		cacheFile := fmt.Sprintf("%s/%d.mp3", cacheDir, itemID)
-		if err := os.Remove(cacheFile); err != nil && !os.IsNotExist(err) {
-			slog.Warn("Failed to delete TTS cache", "id", itemID, "error", err)
-		} else if err == nil {
-			// Clear has_tts flag since file was deleted
-			if _, err := s.db.Conn().Exec("UPDATE items SET has_tts = 0 WHERE item_id = ?", itemID); err != nil {
-				slog.Warn("Failed to clear has_tts flag", "id", itemID, "error", err)
-			}
-		}
+	if err := s.InvalidateTTSCache(itemID); err != nil {
+		slog.Warn("Failed to delete TTS cache", "id", itemID, "error", err)
 	}
 
 	// Delete Image cache for this item
@@ -203,124 +295,125 @@ func (s *ItemService) DeleteItem(itemID int) error {
 	return s.db.DeleteItem(itemID)
 }
 
-// GetItemsWithoutDefinitions returns items that have no definition or "MISSING DATA"
-func (s *ItemService) GetItemsWithoutDefinitions() ([]ItemWithoutDefinitionResult, error) {
-	// Get all items
+// GetItemsWithoutDefinitions returns items that have no definition or
+// "MISSING DATA". ctx is checked between items so a long scan can be
+// aborted; progress (may be nil) is reported after each item.
+func (s *ItemService) GetItemsWithoutDefinitions(ctx context.Context, progress ProgressReporter) ([]ItemWithoutDefinitionResult, error) {
 	allItems, err := s.db.SearchItems("")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get items: %w", err)
 	}
 
-	// Find items without definitions or with "MISSING DATA"
+	linkSummaries, err := s.db.GetIncomingLinkSummaries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incoming link summaries: %w", err)
+	}
+
+	total := len(allItems)
 	var results []ItemWithoutDefinitionResult
-	for _, item := range allItems {
+	for i, item := range allItems {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		reportProgress(progress, i+1, total, "scanning for missing definitions")
+
 		var hasMissingData bool
 		var includeItem bool
 
-		if item.Definition == nil || strings.TrimSpace(*item.Definition) == "" {
+		def := item.Definition.GetOrEmpty()
+		if strings.TrimSpace(def) == "" {
 			includeItem = true
 			hasMissingData = false
-		} else if strings.TrimSpace(*item.Definition) == "MISSING DATA" {
+		} else if strings.TrimSpace(def) == "MISSING DATA" {
 			includeItem = true
 			hasMissingData = true
 		}
 
-		if includeItem {
-			result := ItemWithoutDefinitionResult{
-				ItemID:         item.ItemID,
-				Word:           item.Word,
-				Type:           item.Type,
-				HasMissingData: hasMissingData,
-			}
-
-			// Get all links for this item
-			links, err := s.db.GetItemLinks(item.ItemID)
-			if err == nil {
-				// Filter for incoming links (where this item is destination)
-				var incomingLinks []database.Link
-				for _, link := range links {
-					if link.DestinationItemID == item.ItemID {
-						incomingLinks = append(incomingLinks, link)
-					}
-				}
-
-				// If exactly one incoming link, get source item info
-				if len(incomingLinks) == 1 {
-					sourceItem, err := s.db.GetItem(incomingLinks[0].SourceItemID)
-					if err == nil {
-						result.SingleIncomingLinkItemID = sourceItem.ItemID
-						result.SingleIncomingLinkWord = sourceItem.Word
-					}
-				}
-			}
+		if !includeItem {
+			continue
+		}
 
-			results = append(results, result)
+		result := ItemWithoutDefinitionResult{
+			ItemID:         item.ItemID,
+			Word:           item.Word,
+			Type:           item.Type,
+			HasMissingData: hasMissingData,
 		}
+		if summary, ok := linkSummaries[item.ItemID]; ok && summary.Count == 1 {
+			result.SingleIncomingLinkItemID = summary.SourceID
+			result.SingleIncomingLinkWord = summary.SourceWord
+		}
+
+		results = append(results, result)
 	}
 
 	return results, nil
 }
 
-// GetItemsWithUnknownTypes returns items whose type is not Writer, Title, or Reference
-func (s *ItemService) GetItemsWithUnknownTypes() ([]ItemWithUnknownTypeResult, error) {
-	// Get all items
+// GetItemsWithUnknownTypes returns items whose type is not Writer, Title, or
+// Reference. ctx is checked between items so a long scan can be aborted;
+// progress (may be nil) is reported after each item.
+func (s *ItemService) GetItemsWithUnknownTypes(ctx context.Context, progress ProgressReporter) ([]ItemWithUnknownTypeResult, error) {
 	allItems, err := s.db.SearchItems("")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get items: %w", err)
 	}
 
-	// Find items with unknown types
-	var results []ItemWithUnknownTypeResult
-	for _, item := range allItems {
-		if item.Type != "Reference" && item.Type != "Title" && item.Type != "Writer" {
-			result := ItemWithUnknownTypeResult{
-				ItemID: item.ItemID,
-				Word:   item.Word,
-				Type:   item.Type,
-			}
+	linkSummaries, err := s.db.GetIncomingLinkSummaries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incoming link summaries: %w", err)
+	}
 
-			// Get all links for this item
-			links, err := s.db.GetItemLinks(item.ItemID)
-			if err == nil {
-				// Filter for incoming links (where this item is destination)
-				var incomingLinks []database.Link
-				for _, link := range links {
-					if link.DestinationItemID == item.ItemID {
-						incomingLinks = append(incomingLinks, link)
-					}
-				}
+	total := len(allItems)
+	var results []ItemWithUnknownTypeResult
+	for i, item := range allItems {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		reportProgress(progress, i+1, total, "scanning for unknown types")
 
-				// Set the incoming link count
-				result.IncomingLinkCount = len(incomingLinks)
+		if item.Type == "Reference" || item.Type == "Title" || item.Type == "Writer" {
+			continue
+		}
 
-				// If exactly one incoming link, get source item info
-				if len(incomingLinks) == 1 {
-					sourceItem, err := s.db.GetItem(incomingLinks[0].SourceItemID)
-					if err == nil {
-						result.SingleIncomingLinkItemID = sourceItem.ItemID
-						result.SingleIncomingLinkWord = sourceItem.Word
-					}
-				}
+		result := ItemWithUnknownTypeResult{
+			ItemID: item.ItemID,
+			Word:   item.Word,
+			Type:   item.Type,
+		}
+		if summary, ok := linkSummaries[item.ItemID]; ok {
+			result.IncomingLinkCount = summary.Count
+			if summary.Count == 1 {
+				result.SingleIncomingLinkItemID = summary.SourceID
+				result.SingleIncomingLinkWord = summary.SourceWord
 			}
-
-			results = append(results, result)
 		}
+
+		results = append(results, result)
 	}
 
 	return results, nil
 }
 
-// GetUnknownTags returns items with tags other than {word:, {writer:, or {title:
-func (s *ItemService) GetUnknownTags() ([]UnknownTagResult, error) {
-	// Get all items
+// GetUnknownTags returns items with tags other than {word:, {writer:, or
+// {title: (and the hashtag/category/frontmatter flavors ParseAllTags also
+// recognizes). ctx is checked between items so a long scan can be aborted;
+// progress (may be nil) is reported after each item.
+func (s *ItemService) GetUnknownTags(ctx context.Context, progress ProgressReporter) ([]UnknownTagResult, error) {
 	allItems, err := s.db.SearchItems("")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get items: %w", err)
 	}
 
+	total := len(allItems)
 	var results []UnknownTagResult
 
-	for _, item := range allItems {
+	for i, item := range allItems {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		reportProgress(progress, i+1, total, "scanning for unknown tags")
+
 		// Only check Reference, Title, or Writer types
 		if item.Type != "Reference" && item.Type != "Title" && item.Type != "Writer" {
 			continue
@@ -328,14 +421,14 @@ func (s *ItemService) GetUnknownTags() ([]UnknownTagResult, error) {
 
 		// Check all text fields for tags
 		fieldsToCheck := []string{}
-		if item.Definition != nil {
-			fieldsToCheck = append(fieldsToCheck, *item.Definition)
+		if def := item.Definition.GetOrEmpty(); def != "" {
+			fieldsToCheck = append(fieldsToCheck, def)
 		}
-		if item.Derivation != nil {
-			fieldsToCheck = append(fieldsToCheck, *item.Derivation)
+		if der := item.Derivation.GetOrEmpty(); der != "" {
+			fieldsToCheck = append(fieldsToCheck, der)
 		}
-		if item.Appendicies != nil {
-			fieldsToCheck = append(fieldsToCheck, *item.Appendicies)
+		if app := item.Appendicies.GetOrEmpty(); app != "" {
+			fieldsToCheck = append(fieldsToCheck, app)
 		}
 
 		unknownTags := []string{}
@@ -344,8 +437,12 @@ func (s *ItemService) GetUnknownTags() ([]UnknownTagResult, error) {
 		for _, text := range fieldsToCheck {
 			refs := parser.ParseAllTags(text)
 			for _, ref := range refs {
-				// Check if it's an unknown tag (not word, writer, or title)
-				if ref.Type != "word" && ref.Type != "writer" && ref.Type != "title" {
+				// Check if it's an unknown tag: not word/writer/title, and
+				// not one of the recognized extended flavors (hashtag,
+				// category, frontmatter), which are indexed, not linked.
+				known := ref.Type == "word" || ref.Type == "writer" || ref.Type == "title" ||
+					ref.Type == parser.TagTypeHashtag || ref.Type == parser.TagTypeCategory || ref.Type == parser.TagTypeFrontmatter
+				if !known {
 					if !seenTags[ref.Original] {
 						unknownTags = append(unknownTags, ref.Original)
 						seenTags[ref.Original] = true
@@ -368,28 +465,6 @@ func (s *ItemService) GetUnknownTags() ([]UnknownTagResult, error) {
 	return results, nil
 }
 
-// MergeDuplicateItems merges duplicate items into the original by redirecting links and deleting duplicates
-func (s *ItemService) MergeDuplicateItems(originalID int, duplicateIDs []int) error {
-	for _, duplicateID := range duplicateIDs {
-		// Update all links that point TO this duplicate to point to the original instead (incoming links)
-		if err := s.db.UpdateLinksDestination(duplicateID, originalID); err != nil {
-			return fmt.Errorf("failed to update links for duplicate item %d: %w", duplicateID, err)
-		}
-
-		// Update all links that originate FROM this duplicate to originate from the original instead (outgoing links)
-		if err := s.db.UpdateLinksSource(duplicateID, originalID); err != nil {
-			return fmt.Errorf("failed to redirect outgoing links for item %d: %w", duplicateID, err)
-		}
-
-		// Delete the duplicate item
-		// We use DeleteItem to ensure cleanup of cache files
-		if err := s.DeleteItem(duplicateID); err != nil {
-			return fmt.Errorf("failed to delete duplicate item %d: %w", duplicateID, err)
-		}
-	}
-	return nil
-}
-
 // GetItemLinks gets all links for an item
 func (s *ItemService) GetItemLinks(itemID int) ([]database.Link, error) {
 	return s.db.GetItemLinks(itemID)
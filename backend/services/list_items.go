@@ -0,0 +1,182 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+)
+
+const defaultListItemsMaxKeys = 1000
+
+// ListItemsResult is the paginated response for ItemService.ListItems,
+// modeled on S3's ListObjectsV2 shape: a page of Items, any Delimiter-
+// collapsed Prefixes, and a token to fetch the next page.
+type ListItemsResult struct {
+	Items                 []database.Item `json:"items"`
+	Prefixes              []string        `json:"prefixes"`
+	IsTruncated           bool            `json:"isTruncated"`
+	NextContinuationToken string          `json:"nextContinuationToken,omitempty"`
+}
+
+// listItemsCursor is the JSON payload base64-encoded into a continuation
+// token. QueryHash binds the cursor to the query that produced it, so a
+// token can't be replayed against a different search.
+type listItemsCursor struct {
+	LastKey   string `json:"lastKey"`
+	QueryHash string `json:"queryHash"`
+}
+
+// listItemsQueryHash fingerprints the parts of options that affect row
+// ordering/membership, so ListItems can reject a continuation token whose
+// query has since changed (e.g. the frontend changed a filter but reused a
+// stale "next page" token from before).
+func listItemsQueryHash(options database.SearchOptions) string {
+	parts := strings.Join([]string{
+		options.Query,
+		strings.Join(options.Types, ","),
+		options.Source,
+		fmt.Sprintf("%v", options.UseRegex),
+		fmt.Sprintf("%v", options.CaseSensitive),
+		fmt.Sprintf("%v", options.HasImage),
+		fmt.Sprintf("%v", options.HasTts),
+		options.Prefix,
+		options.Delimiter,
+	}, "|")
+	sum := sha256.Sum256([]byte(parts))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func encodeListItemsToken(lastKey, queryHash string) string {
+	data, _ := json.Marshal(listItemsCursor{LastKey: lastKey, QueryHash: queryHash})
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+// decodeListItemsToken decodes and validates a continuation token against
+// queryHash. A malformed or stale (query-mismatched) token is reported as an
+// error rather than silently restarting from the top, since silently
+// restarting would hide pagination bugs in callers.
+func decodeListItemsToken(token, queryHash string) (listItemsCursor, error) {
+	var cursor listItemsCursor
+	data, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, fmt.Errorf("invalid continuation token: %w", err)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("invalid continuation token: %w", err)
+	}
+	if cursor.QueryHash != queryHash {
+		return cursor, fmt.Errorf("continuation token does not match query")
+	}
+	return cursor, nil
+}
+
+// listItemsSortKey is the stable key pagination is ordered and resumed by:
+// case-insensitive word, tie-broken by item ID since words aren't unique.
+func listItemsSortKey(item database.Item) string {
+	return fmt.Sprintf("%s\x00%09d", strings.ToLower(item.Word), item.ItemID)
+}
+
+// ListItems returns one page of SearchItemsWithOptions' result set, re-sorted
+// by word for stable pagination, with S3-style Delimiter collapsing: items
+// whose word shares a prefix up to the first Delimiter occurrence (after
+// Prefix is stripped) are grouped into Prefixes instead of being returned
+// individually, so the frontend can offer directory-style drill-down without
+// transferring the whole corpus.
+func (s *ItemService) ListItems(options database.SearchOptions) (ListItemsResult, error) {
+	items, err := s.db.SearchItemsWithOptions(options)
+	if err != nil {
+		return ListItemsResult{}, err
+	}
+
+	if options.Prefix != "" {
+		prefix := strings.ToLower(options.Prefix)
+		filtered := items[:0:0]
+		for _, item := range items {
+			if strings.HasPrefix(strings.ToLower(item.Word), prefix) {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return listItemsSortKey(items[i]) < listItemsSortKey(items[j])
+	})
+
+	queryHash := listItemsQueryHash(options)
+	lastKey := ""
+	if options.ContinuationToken != "" {
+		cursor, err := decodeListItemsToken(options.ContinuationToken, queryHash)
+		if err != nil {
+			return ListItemsResult{}, err
+		}
+		lastKey = cursor.LastKey
+	}
+
+	maxKeys := options.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultListItemsMaxKeys
+	}
+
+	var result ListItemsResult
+	seenPrefixes := make(map[string]bool)
+
+	for _, item := range items {
+		key := listItemsSortKey(item)
+		if lastKey != "" && key <= lastKey {
+			continue
+		}
+
+		if groupPrefix, ok := delimiterGroup(item.Word, options.Prefix, options.Delimiter); ok {
+			if !seenPrefixes[groupPrefix] {
+				seenPrefixes[groupPrefix] = true
+				result.Prefixes = append(result.Prefixes, groupPrefix)
+			}
+			lastKey = key
+			continue
+		}
+
+		if len(result.Items) >= maxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = encodeListItemsToken(lastKey, queryHash)
+			break
+		}
+
+		if def := item.Definition.GetOrEmpty(); def != "" {
+			isPoem := parser.IsPoem(item.Type, def)
+			item.ParsedDef = parser.ParseDefinition(def, isPoem)
+		}
+		result.Items = append(result.Items, item)
+		lastKey = key
+	}
+
+	sort.Strings(result.Prefixes)
+
+	return result, nil
+}
+
+// delimiterGroup reports the directory-style group item's word falls into
+// given delimiter, S3-style: the common prefix runs from the start of word
+// (after Prefix is stripped) through the first occurrence of delimiter. ok
+// is false (no grouping, item returned individually) when delimiter is empty
+// or doesn't occur in word.
+func delimiterGroup(word, prefix, delimiter string) (string, bool) {
+	if delimiter == "" {
+		return "", false
+	}
+	rest := word
+	if prefix != "" && len(word) >= len(prefix) && strings.EqualFold(word[:len(prefix)], prefix) {
+		rest = word[len(prefix):]
+	}
+	idx := strings.Index(rest, delimiter)
+	if idx < 0 {
+		return "", false
+	}
+	return prefix + rest[:idx+len(delimiter)], true
+}
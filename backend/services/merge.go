@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+)
+
+// mergeUndoRecord is the JSON payload stored in merge_journal.undo_data for
+// one merged duplicate: everything UndoMerge needs to put the duplicate back
+// exactly as it was.
+type mergeUndoRecord struct {
+	OriginalItemID    int                  `json:"originalItemId"`
+	DuplicateItemID   int                  `json:"duplicateId"`
+	MovedLinkIDs      []database.MovedLink `json:"movedLinkIds"`
+	DeletedItem       database.Item        `json:"deletedItemSnapshot"`
+	TTSContentHash    string               `json:"ttsContentHash,omitempty"`
+	ImageContentHash  string               `json:"imageContentHash,omitempty"`
+	DeletedCachePaths []trashedFile        `json:"deletedCachePaths,omitempty"`
+}
+
+// MergeDuplicateItems merges duplicate items into the original by
+// redirecting links and deleting duplicates. Each duplicate is merged in its
+// own SQL transaction with a merge_journal row recording how to undo it (see
+// UndoMerge), so a partial failure never leaves dangling links or
+// half-merged items. ctx is checked between duplicates so a large merge can
+// be aborted; progress (may be nil) is reported after each one.
+func (s *ItemService) MergeDuplicateItems(ctx context.Context, progress ProgressReporter, originalID int, duplicateIDs []int) error {
+	total := len(duplicateIDs)
+	for i, duplicateID := range duplicateIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		reportProgress(progress, i+1, total, "merging duplicates")
+
+		if _, err := s.mergeOneDuplicate(originalID, duplicateID); err != nil {
+			return fmt.Errorf("failed to merge duplicate item %d: %w", duplicateID, err)
+		}
+	}
+	return nil
+}
+
+// mergeOneDuplicate redirects duplicateID's links to originalID and deletes
+// it, all inside one transaction, journaling an undo record before
+// committing. Cache files are moved to a .trash subdirectory rather than
+// deleted outright, so a failed commit - or a later UndoMerge - can put them
+// straight back.
+func (s *ItemService) mergeOneDuplicate(originalID, duplicateID int) (int, error) {
+	tx, err := s.db.Conn().Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin merge transaction: %w", err)
+	}
+
+	var trashed []trashedFile
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		_ = tx.Rollback()
+		if restoreErr := restoreTrash(trashed); restoreErr != nil {
+			slog.Warn("Failed to restore trashed cache files after failed merge", "duplicateId", duplicateID, "error", restoreErr)
+		}
+	}()
+
+	duplicate, err := s.db.GetItemTx(tx, duplicateID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load duplicate item: %w", err)
+	}
+
+	movedLinks, err := s.db.MergeRedirectLinksTx(tx, originalID, duplicateID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to redirect links: %w", err)
+	}
+
+	ttsHash, ttsTrash, err := s.trashTTSCacheTx(tx, duplicateID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to trash TTS cache: %w", err)
+	}
+	if ttsTrash.TrashPath != "" {
+		trashed = append(trashed, ttsTrash)
+	}
+
+	imageHash, imageTrash, err := s.trashImageCacheTx(tx, duplicateID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to trash image cache: %w", err)
+	}
+	if imageTrash.TrashPath != "" {
+		trashed = append(trashed, imageTrash)
+	}
+
+	if err := s.db.DeleteItemTx(tx, duplicateID); err != nil {
+		return 0, fmt.Errorf("failed to delete duplicate item %d: %w", duplicateID, err)
+	}
+
+	undo := mergeUndoRecord{
+		OriginalItemID:    originalID,
+		DuplicateItemID:   duplicateID,
+		MovedLinkIDs:      movedLinks,
+		DeletedItem:       *duplicate,
+		TTSContentHash:    ttsHash,
+		ImageContentHash:  imageHash,
+		DeletedCachePaths: trashed,
+	}
+	undoJSON, err := json.Marshal(undo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode merge undo record: %w", err)
+	}
+
+	id, err := s.db.RecordMergeJournal(tx, originalID, duplicateID, string(undoJSON))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit merge transaction: %w", err)
+	}
+	committed = true
+
+	return id, nil
+}
+
+// trashTTSCacheTx moves duplicateID's cached TTS blob to trash inside tx, if
+// it has one and no other item still references the same content hash. It
+// returns the hash duplicateID had on record (possibly "") so UndoMerge can
+// restore the tts_cache link even when the blob itself wasn't trashed
+// because another item still shares it.
+func (s *ItemService) trashTTSCacheTx(tx *sql.Tx, itemID int) (hash string, trash trashedFile, err error) {
+	hash, err = s.db.GetTTSCacheHashTx(tx, itemID)
+	if err != nil {
+		return "", trashedFile{}, err
+	}
+	if hash == "" {
+		return "", trashedFile{}, nil
+	}
+
+	otherRefs, err := s.db.CountOtherTTSCacheRefsTx(tx, hash, itemID)
+	if err != nil {
+		return "", trashedFile{}, err
+	}
+	if otherRefs > 0 {
+		return hash, trashedFile{}, nil
+	}
+
+	cacheDir, err := paths.TTSCacheDir()
+	if err != nil {
+		return "", trashedFile{}, err
+	}
+	trash, err = moveToTrash(filepath.Join(cacheDir, hash+".mp3"))
+	if err != nil {
+		return "", trashedFile{}, fmt.Errorf("failed to trash TTS blob: %w", err)
+	}
+	return hash, trash, nil
+}
+
+// trashImageCacheTx moves duplicateID's cached image blob to trash inside
+// tx, if it has one and no other item still references the same content
+// hash. It returns the hash duplicateID had on record (possibly "") so
+// UndoMerge can restore the image_cache link even when the blob itself
+// wasn't trashed because another item still shares it.
+func (s *ItemService) trashImageCacheTx(tx *sql.Tx, itemID int) (hash string, trash trashedFile, err error) {
+	hash, err = s.db.GetImageCacheHashTx(tx, itemID)
+	if err != nil {
+		return "", trashedFile{}, err
+	}
+	if hash == "" {
+		return "", trashedFile{}, nil
+	}
+
+	otherRefs, err := s.db.CountOtherImageCacheRefsTx(tx, hash, itemID)
+	if err != nil {
+		return "", trashedFile{}, err
+	}
+	if otherRefs > 0 {
+		return hash, trashedFile{}, nil
+	}
+
+	imagesDir, err := paths.ImagesDir()
+	if err != nil {
+		return "", trashedFile{}, err
+	}
+	trash, err = moveToTrash(filepath.Join(imagesDir, hash+".png"))
+	if err != nil {
+		return "", trashedFile{}, fmt.Errorf("failed to trash image blob: %w", err)
+	}
+	return hash, trash, nil
+}
+
+// UndoMerge reverses a previous MergeDuplicateItems merge identified by
+// journalID: it restores the duplicate item's row, points its moved links
+// back, and restores any cache files that were trashed rather than deleted.
+// It refuses to run twice against the same journal entry.
+func (s *ItemService) UndoMerge(journalID int) error {
+	entry, err := s.db.GetMergeJournalEntry(journalID)
+	if err != nil {
+		return err
+	}
+	if entry.UndoneAt != nil {
+		return fmt.Errorf("merge journal entry %d was already undone", journalID)
+	}
+
+	var undo mergeUndoRecord
+	if err := json.Unmarshal([]byte(entry.UndoData), &undo); err != nil {
+		return fmt.Errorf("failed to decode merge undo record: %w", err)
+	}
+
+	tx, err := s.db.Conn().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin undo transaction: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := s.db.InsertItemTx(tx, undo.DeletedItem); err != nil {
+		return err
+	}
+	if err := s.db.SyncItemTagsTx(tx, undo.DeletedItem); err != nil {
+		return err
+	}
+	if err := s.db.RestoreLinksTx(tx, undo.MovedLinkIDs, undo.DuplicateItemID); err != nil {
+		return err
+	}
+	if undo.TTSContentHash != "" {
+		if err := s.db.SetTTSCacheHashTx(tx, undo.DuplicateItemID, undo.TTSContentHash); err != nil {
+			return err
+		}
+		if err := s.db.SetItemFlagTx(tx, undo.DuplicateItemID, "has_tts", true); err != nil {
+			return err
+		}
+	}
+	if undo.ImageContentHash != "" {
+		if err := s.db.SetImageCacheHashTx(tx, undo.DuplicateItemID, undo.ImageContentHash); err != nil {
+			return err
+		}
+		if err := s.db.SetItemFlagTx(tx, undo.DuplicateItemID, "has_image", true); err != nil {
+			return err
+		}
+	}
+
+	if err := restoreTrash(undo.DeletedCachePaths); err != nil {
+		return fmt.Errorf("failed to restore trashed cache files: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit undo transaction: %w", err)
+	}
+	committed = true
+
+	if err := s.db.MarkMergeJournalUndone(journalID); err != nil {
+		slog.Warn("Failed to mark merge journal entry undone", "journalId", journalID, "error", err)
+	}
+	return nil
+}
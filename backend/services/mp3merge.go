@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// mpeg1BitrateKbps and mpeg2BitrateKbps are the Layer III bitrate tables
+// (index 0 and 15 are reserved/free and never matched against a real
+// frame). TTS providers overwhelmingly emit MPEG-1 or MPEG-2 Layer III, so
+// that's all mergeMP3Streams needs to parse a frame's length.
+var mpeg1BitrateKbps = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+var mpeg2BitrateKbps = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+
+// mpeg1SampleRateHz and mpeg2SampleRateHz are the sampling-rate tables,
+// indexed the same way (index 3 is reserved).
+var mpeg1SampleRateHz = [4]int{44100, 48000, 32000, 0}
+var mpeg2SampleRateHz = [4]int{22050, 24000, 16000, 0}
+
+// mp3FrameHeader is a parsed 4-byte MPEG audio frame header, enough to
+// compute the frame's total length in bytes and to recognize the very
+// first frame of a stream (where a Xing/VBRI frame, if present, lives).
+type mp3FrameHeader struct {
+	isMPEG1 bool
+	padding int
+	length  int
+}
+
+// parseMP3FrameHeader parses the 4-byte frame header in b, returning false
+// if b doesn't start with a valid MPEG Layer III sync word.
+func parseMP3FrameHeader(b []byte) (mp3FrameHeader, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return mp3FrameHeader{}, false
+	}
+	versionBits := (b[1] >> 3) & 0x03
+	layerBits := (b[1] >> 1) & 0x03
+	if layerBits != 0x01 { // Layer III
+		return mp3FrameHeader{}, false
+	}
+	isMPEG1 := versionBits == 0x03
+
+	bitrateIndex := (b[2] >> 4) & 0x0F
+	sampleRateIndex := (b[2] >> 2) & 0x03
+	padding := int((b[2] >> 1) & 0x01)
+
+	var bitrateKbps, sampleRateHz int
+	if isMPEG1 {
+		bitrateKbps = mpeg1BitrateKbps[bitrateIndex]
+		sampleRateHz = mpeg1SampleRateHz[sampleRateIndex]
+	} else {
+		bitrateKbps = mpeg2BitrateKbps[bitrateIndex]
+		sampleRateHz = mpeg2SampleRateHz[sampleRateIndex]
+	}
+	if bitrateKbps == 0 || sampleRateHz == 0 {
+		return mp3FrameHeader{}, false
+	}
+
+	samplesPerFrame := 144
+	if !isMPEG1 {
+		samplesPerFrame = 72
+	}
+	length := samplesPerFrame*bitrateKbps*1000/sampleRateHz + padding
+
+	return mp3FrameHeader{isMPEG1: isMPEG1, padding: padding, length: length}, true
+}
+
+// id3v2SynchsafeSize decodes the 4-byte synchsafe integer (7 significant
+// bits per byte) ID3v2 stores its tag size as.
+func id3v2SynchsafeSize(b []byte) int {
+	return int(b[0])<<21 | int(b[1])<<14 | int(b[2])<<7 | int(b[3])
+}
+
+// stripID3v2 consumes and discards a leading ID3v2 tag from r, if present,
+// returning a reader positioned at the first byte after it (or at the
+// start of r, unchanged, if there was no tag).
+func stripID3v2(r *bufio.Reader) error {
+	header, err := r.Peek(10)
+	if err != nil {
+		if err == io.EOF || err == bufio.ErrBufferFull {
+			return nil
+		}
+		return err
+	}
+	if string(header[0:3]) != "ID3" {
+		return nil
+	}
+	size := id3v2SynchsafeSize(header[6:10])
+	if _, err := r.Discard(10); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(size)); err != nil {
+		return fmt.Errorf("failed to skip ID3v2 tag: %w", err)
+	}
+	return nil
+}
+
+// isXingOrVBRIFrame reports whether frame (the bytes of a single MPEG Layer
+// III frame, header included) is a Xing/Info/VBRI header frame rather than
+// real audio - these carry only seek/duration metadata for the single file
+// they were written into, so they're meaningless (and misleading) once
+// spliced into the middle of a merged stream.
+func isXingOrVBRIFrame(frame []byte) bool {
+	return bytes.Contains(frame, []byte("Xing")) ||
+		bytes.Contains(frame, []byte("Info")) ||
+		bytes.Contains(frame, []byte("VBRI"))
+}
+
+// mergeMP3Streams concatenates streams (already-decoded, complete MPEG
+// Layer III files, in the order they should play) into a single stream
+// written to w: each stream's leading ID3v2 tag is stripped, and every
+// stream after the first also has its leading Xing/VBRI header frame (if
+// any) stripped, since that frame's duration/seek-table only describes the
+// single file it came from. What's left - raw MPEG frames - is copied
+// through as-is, which is all an MP3 player needs to play the result back
+// to back with no gap.
+func mergeMP3Streams(streams []io.Reader, w io.Writer) error {
+	for i, stream := range streams {
+		br := bufio.NewReader(stream)
+		if err := stripID3v2(br); err != nil {
+			return fmt.Errorf("failed to strip ID3v2 from stream %d: %w", i, err)
+		}
+
+		if i > 0 {
+			if peeked, err := br.Peek(4); err == nil {
+				if header, ok := parseMP3FrameHeader(peeked); ok {
+					frame, err := br.Peek(header.length)
+					if err == nil && isXingOrVBRIFrame(frame) {
+						if _, err := br.Discard(header.length); err != nil {
+							return fmt.Errorf("failed to skip Xing/VBRI frame in stream %d: %w", i, err)
+						}
+					}
+				}
+			}
+		}
+
+		if _, err := io.Copy(w, br); err != nil {
+			return fmt.Errorf("failed to copy stream %d into merged output: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package services
+
+// ProgressReporter receives periodic progress updates from a long-running
+// maintenance scan (GetItemsWithoutDefinitions, GetItemsWithUnknownTypes,
+// GetUnknownTags, MergeDuplicateItems), so a caller can show a progress bar
+// without polling. current/total are 1-based item counts; phase is a short
+// human-readable label for what's currently being scanned.
+type ProgressReporter interface {
+	Report(current, total int, phase string)
+}
+
+// ProgressReporterFunc adapts a plain function to ProgressReporter.
+type ProgressReporterFunc func(current, total int, phase string)
+
+func (f ProgressReporterFunc) Report(current, total int, phase string) {
+	if f != nil {
+		f(current, total, phase)
+	}
+}
+
+// reportProgress calls reporter.Report if reporter is non-nil, so scan loops
+// don't need a nil check of their own at every iteration.
+func reportProgress(reporter ProgressReporter, current, total int, phase string) {
+	if reporter != nil {
+		reporter.Report(current, total, phase)
+	}
+}
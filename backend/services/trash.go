@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// trashedFile records where a cache blob was moved during a reversible
+// delete, so the caller can later move it back (undo) or leave it be.
+type trashedFile struct {
+	TrashPath    string `json:"trashPath"`
+	OriginalPath string `json:"originalPath"`
+}
+
+// moveToTrash renames path into a .trash subdirectory beside it, returning
+// the trashed file descriptor, or a zero value if path doesn't exist.
+func moveToTrash(path string) (trashedFile, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return trashedFile{}, nil
+	} else if err != nil {
+		return trashedFile{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	trashDir := filepath.Join(filepath.Dir(path), ".trash")
+	if err := os.MkdirAll(trashDir, 0755); err != nil {
+		return trashedFile{}, fmt.Errorf("failed to create trash dir: %w", err)
+	}
+	trashPath := filepath.Join(trashDir, filepath.Base(path))
+	if err := os.Rename(path, trashPath); err != nil {
+		return trashedFile{}, fmt.Errorf("failed to move %s to trash: %w", path, err)
+	}
+	return trashedFile{TrashPath: trashPath, OriginalPath: path}, nil
+}
+
+// purgeTrash permanently removes files previously moved to trash by
+// moveToTrash. It's best-effort: a failure just leaves an orphaned file in
+// .trash rather than aborting the caller's already-successful operation.
+func purgeTrash(files []trashedFile) {
+	for _, f := range files {
+		if f.TrashPath == "" {
+			continue
+		}
+		if err := os.Remove(f.TrashPath); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to purge trashed file", "path", f.TrashPath, "error", err)
+		}
+	}
+}
+
+// restoreTrash moves files previously moved to trash by moveToTrash back to
+// their original location, e.g. after a failed or undone merge.
+func restoreTrash(files []trashedFile) error {
+	for _, f := range files {
+		if f.TrashPath == "" {
+			continue
+		}
+		if err := os.Rename(f.TrashPath, f.OriginalPath); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", f.TrashPath, err)
+		}
+	}
+	return nil
+}
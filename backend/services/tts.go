@@ -2,18 +2,83 @@ package services
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
-	"net/http"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/secrets"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/cache"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+	"golang.org/x/sync/errgroup"
 )
 
+// ttsModel is the OpenAI TTS model requested by openAITTSProvider; it's
+// part of the content-addressable cache key alongside the provider, voice,
+// and text, so switching models would naturally invalidate the old cache
+// rather than collide with it.
+const ttsModel = "tts-1"
+
+// resolveTTSVoiceAndGender picks the gender-matched voice for
+// itemType/itemWord, the same selection SpeakWord uses to build its
+// provider request. voice is an OpenAI voice name (onyx/nova/alloy);
+// gender ("male"/"female"/"") is passed through for providers, like the
+// local piper backend, that pick their own voice per gender rather than by
+// OpenAI's naming. It's factored out so TTSContentHash can hash the same
+// parameters that will actually be requested.
+func resolveTTSVoiceAndGender(db *database.DB, itemType, itemWord string) (voice, gender string) {
+	voice = "alloy" // Default voice
+	if itemType == "Writer" && itemWord != "" {
+		parts := strings.Fields(itemWord)
+		if len(parts) > 0 {
+			firstName := parts[0]
+			g, err := db.GetGenderByFirstName(firstName)
+			if err != nil {
+				slog.Warn("Failed to get gender", "name", firstName, "error", err)
+			} else {
+				gender = g
+				if gender == "male" {
+					voice = "onyx" // Male voice
+				} else if gender == "female" {
+					voice = "nova" // Female voice
+				}
+			}
+		}
+	}
+	return voice, gender
+}
+
+// TTSContentHash derives the content-addressable cache key for text spoken
+// as itemType/itemWord: a SHA-256 hash of the normalized TTS input (the text
+// plus the provider/voice/model parameters that would be sent to the
+// backend). Identical definitions - even across items - hash to the same
+// key, so a cosmetic edit to a field that isn't actually spoken never
+// invalidates the cached audio, and switching TTS_PROVIDER or its resolved
+// voice never serves audio produced by a different backend.
+func TTSContentHash(db *database.DB, itemType, itemWord, text string) string {
+	voice, gender := resolveTTSVoiceAndGender(db, itemType, itemWord)
+	providerID := resolveTTSProviderID()
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s", providerID, ttsModel, voice, gender, text)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// resolveTTSProviderID returns the TTS_PROVIDER env var, defaulting to
+// "openai"; it's read fresh each time (rather than cached) so a .env change
+// picked up by SaveEnvVar takes effect without restarting the app.
+func resolveTTSProviderID() string {
+	if id := os.Getenv("TTS_PROVIDER"); id != "" {
+		return id
+	}
+	return "openai"
+}
+
 // TTSResult is the return type for SpeakWord
 type TTSResult struct {
 	AudioData []byte `json:"audioData"`
@@ -24,20 +89,84 @@ type TTSResult struct {
 
 // TTSService handles Text-to-Speech operations
 type TTSService struct {
-	db *database.DB
+	db       *database.DB
+	cache    *cache.BoundedCache
+	provider TTSProvider
 }
 
-// NewTTSService creates a new TTSService
+// NewTTSService creates a new TTSService, selecting its TTSProvider from
+// TTS_PROVIDER (falling back to the OpenAI provider, with a warning, if
+// that value is invalid).
 func NewTTSService(db *database.DB) *TTSService {
 	return &TTSService{
-		db: db,
+		db:       db,
+		provider: resolveTTSProvider(),
+	}
+}
+
+// NewTTSServiceWithCache creates a new TTSService backed by a BoundedCache
+// so that writes are subject to the configured size/file-count limits.
+func NewTTSServiceWithCache(db *database.DB, c *cache.BoundedCache) *TTSService {
+	return &TTSService{
+		db:       db,
+		cache:    c,
+		provider: resolveTTSProvider(),
+	}
+}
+
+// TTSProviderConfigured reports whether the TTS_PROVIDER backend has what
+// it needs to run (an API key for openai, an endpoint for http, nothing for
+// piper), for callers like App.GetCapabilities that just need a yes/no
+// without spinning up a TTSService.
+func TTSProviderConfigured() bool {
+	switch resolveTTSProviderID() {
+	case "piper":
+		return true
+	case "http":
+		return os.Getenv("TTS_HTTP_ENDPOINT") != ""
+	default:
+		key, err := secrets.Get("OPENAI_API_KEY")
+		return err == nil && key != ""
 	}
 }
 
-// SpeakWord uses OpenAI's text-to-speech API to pronounce text with gender-matched voices and caching
+// resolveTTSProvider builds the TTSProvider named by TTS_PROVIDER, falling
+// back to the OpenAI provider if the env var names an unknown or misconfigured
+// one, so a typo never leaves the service without any way to speak at all.
+func resolveTTSProvider() TTSProvider {
+	id := resolveTTSProviderID()
+	provider, err := newTTSProvider(id)
+	if err != nil {
+		slog.Warn("Failed to build configured TTS provider, falling back to openai", "provider", id, "error", err)
+		provider, _ = newTTSProvider("openai")
+	}
+	return provider
+}
+
+// SetProvider overrides the TTSProvider used by SpeakWord, e.g. for tests.
+func (s *TTSService) SetProvider(p TTSProvider) {
+	s.provider = p
+}
+
+// SetCache attaches (or replaces) the bounded cache used for writes.
+func (s *TTSService) SetCache(c *cache.BoundedCache) {
+	s.cache = c
+}
+
+// PruneCache enforces the configured limits on the TTS cache, evicting
+// least-recently-used entries as needed.
+func (s *TTSService) PruneCache() (*cache.EvictionReport, error) {
+	if s.cache == nil {
+		return &cache.EvictionReport{}, nil
+	}
+	return s.cache.Prune()
+}
+
+// SpeakWord uses the configured TTSProvider (TTS_PROVIDER: openai, piper, or
+// http) to pronounce text with gender-matched voices and caching.
 func (s *TTSService) SpeakWord(text string, itemType string, itemWord string, itemID int) TTSResult {
 	// Set up cache directory
-	cacheDir, err := constants.GetTTSCacheDir()
+	cacheDir, err := paths.TTSCacheDir()
 	if err != nil {
 		return TTSResult{
 			Error:     fmt.Sprintf("Failed to get TTS cache directory: %v", err),
@@ -52,131 +181,149 @@ func (s *TTSService) SpeakWord(text string, itemType string, itemWord string, it
 		}
 	}
 
-	// Use ItemID for cache filename
-	cacheFile := fmt.Sprintf("%s/%d.mp3", cacheDir, itemID)
+	// Content-addressable cache filename: identical text+voice+provider
+	// always maps to the same blob, even across items, and a cosmetic edit
+	// to a field that isn't actually spoken never invalidates it. Because
+	// the hash folds in the provider, switching TTS_PROVIDER naturally
+	// lands on a different cache entry instead of serving stale audio.
+	voice, gender := resolveTTSVoiceAndGender(s.db, itemType, itemWord)
+	hash := TTSContentHash(s.db, itemType, itemWord, text)
+	cacheName := hash + ".mp3"
+	cacheFile := fmt.Sprintf("%s/%s", cacheDir, cacheName)
+
+	if err := s.db.SetTTSCacheHash(itemID, hash); err != nil {
+		slog.Warn("Failed to record TTS cache hash", "itemID", itemID, "error", err)
+	}
 
 	// Check if cached file exists
 	if cachedData, err := os.ReadFile(cacheFile); err == nil {
-		slog.Info("Using cached TTS audio", "itemID", itemID)
+		slog.Info("Using cached TTS audio", "itemID", itemID, "hash", hash)
+		if s.cache != nil {
+			s.cache.Touch(cacheName)
+		}
+		if _, err := s.db.Conn().Exec("UPDATE items SET has_tts = 1 WHERE item_id = ?", itemID); err != nil {
+			slog.Warn("Failed to set has_tts flag", "itemID", itemID, "error", err)
+		}
 		return TTSResult{
 			AudioData: cachedData,
 			Cached:    true,
 		}
 	}
 
-	slog.Info("Cache miss, calling OpenAI API", "itemID", itemID)
+	slog.Info("Cache miss, calling TTS provider", "itemID", itemID, "provider", s.provider.ID())
 
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
+	audioData, err := createItemTTS(context.Background(), s.provider, text, voice, gender)
+	if err != nil {
 		return TTSResult{
-			Error:     "OpenAI API key not configured. Please add OPENAI_API_KEY to your .env file.",
-			ErrorType: "missing_key",
+			Error:     err.Error(),
+			ErrorType: ttsErrorKind(err),
 		}
 	}
 
-	// Create request to OpenAI TTS API
-	url := "https://api.openai.com/v1/audio/speech"
-
-	// Properly marshal JSON to handle special characters
-	type TTSRequest struct {
-		Model string `json:"model"`
-		Input string `json:"input"`
-		Voice string `json:"voice"`
-	}
-
-	// Determine voice based on item type and gender
-	voice := "alloy" // Default voice
-	if itemType == "Writer" && itemWord != "" {
-		// Extract first name (first word before space)
-		parts := strings.Fields(itemWord)
-		if len(parts) > 0 {
-			firstName := parts[0]
-			gender, err := s.db.GetGenderByFirstName(firstName)
-			if err != nil {
-				slog.Warn("Failed to get gender", "name", firstName, "error", err)
-			} else if gender == "male" {
-				voice = "onyx" // Male voice
-			} else if gender == "female" {
-				voice = "nova" // Female voice
-			}
+	// Cache the audio data for future use
+	if s.cache != nil {
+		if _, err := s.cache.Write(cacheName, audioData); err != nil {
+			slog.Warn("Failed to cache audio data", "error", err)
+			// Don't fail the request if caching fails
+		} else {
+			slog.Info("Cached TTS audio", "path", cacheFile)
 		}
+	} else if err := os.WriteFile(cacheFile, audioData, 0644); err != nil {
+		slog.Warn("Failed to cache audio data", "error", err)
+		// Don't fail the request if caching fails
+	} else {
+		slog.Info("Cached TTS audio", "path", cacheFile)
 	}
 
-	requestData := TTSRequest{
-		Model: "tts-1",
-		Input: text,
-		Voice: voice,
+	if _, err := s.db.Conn().Exec("UPDATE items SET has_tts = 1 WHERE item_id = ?", itemID); err != nil {
+		slog.Warn("Failed to set has_tts flag", "itemID", itemID, "error", err)
 	}
 
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return TTSResult{
-			Error:     fmt.Sprintf("Failed to prepare request: %v", err),
-			ErrorType: "unknown",
-		}
+	return TTSResult{
+		AudioData: audioData,
+		Cached:    false,
 	}
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
-	if err != nil {
-		return TTSResult{
-			Error:     fmt.Sprintf("Failed to create request: %v", err),
-			ErrorType: "unknown",
+// ttsChunkBoundary is the paragraph/stanza boundary createItemTTS splits an
+// item's text on: one or more blank lines.
+var ttsChunkBoundary = regexp.MustCompile(`\n\s*\n+`)
+
+// splitTTSChunks splits text into paragraph/stanza-sized pieces, so
+// createItemTTS can synthesize a whole poem as several short, parallel TTS
+// calls instead of one long one that's slow and risks the provider's
+// per-request length limit. Text with no blank-line boundary (a single
+// stanza, or a bare word/phrase) comes back as one chunk.
+func splitTTSChunks(text string) []string {
+	var chunks []string
+	for _, part := range ttsChunkBoundary.Split(strings.TrimSpace(text), -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			chunks = append(chunks, part)
 		}
 	}
+	if len(chunks) == 0 {
+		chunks = []string{text}
+	}
+	return chunks
+}
 
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
+// createItemTTS synthesizes text as one provider call per chunk (see
+// splitTTSChunks), run concurrently under an errgroup, and merges the
+// results back into a single mp3 in chunk order (see mergeMP3Streams).
+// Each chunk streams through its own io.Pipe: the synthesis goroutine
+// writes the audio it gets back from provider in, while a second goroutine
+// merges the pipes' read ends as they become available. If any chunk fails
+// to synthesize, its pipe is closed with that error; the merge goroutine,
+// on hitting that error, closes every other pipe the same way so no
+// still-running synthesis goroutine is left blocked writing to a pipe
+// nobody will ever read, and g.Wait() returns the first error either side
+// saw.
+func createItemTTS(ctx context.Context, provider TTSProvider, text, voice, gender string) ([]byte, error) {
+	chunks := splitTTSChunks(text)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return TTSResult{
-			Error:     fmt.Sprintf("Network error: %v. Please check your internet connection.", err),
-			ErrorType: "network",
-		}
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		errorMsg := fmt.Sprintf("OpenAI API error (%d): %s", resp.StatusCode, string(body))
-		errorType := "api"
-
-		// Detect specific API error types
-		if resp.StatusCode == 401 {
-			errorMsg = "Invalid API key. Please check your OPENAI_API_KEY in .env file."
-			errorType = "missing_key"
-		} else if resp.StatusCode == 429 {
-			errorMsg = "Rate limit exceeded. Please try again in a moment."
-		} else if resp.StatusCode >= 500 {
-			errorMsg = fmt.Sprintf("OpenAI server error (%d). Please try again later.", resp.StatusCode)
-		}
+	g, gctx := errgroup.WithContext(ctx)
+	pipeReaders := make([]*io.PipeReader, len(chunks))
+	mergeInputs := make([]io.Reader, len(chunks))
 
-		return TTSResult{
-			Error:     errorMsg,
-			ErrorType: errorType,
-		}
-	}
+	for i, chunk := range chunks {
+		pr, pw := io.Pipe()
+		pipeReaders[i] = pr
+		mergeInputs[i] = pr
 
-	// Read audio data
-	audioData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return TTSResult{
-			Error:     fmt.Sprintf("Failed to read audio data: %v", err),
-			ErrorType: "network",
-		}
-	}
+		i, chunk, pw := i, chunk, pw
+		g.Go(func() (err error) {
+			defer func() {
+				if err != nil {
+					_ = pw.CloseWithError(err)
+				} else {
+					err = pw.Close()
+				}
+			}()
 
-	// Cache the audio data for future use
-	if err := os.WriteFile(cacheFile, audioData, 0644); err != nil {
-		slog.Warn("Failed to cache audio data", "error", err)
-		// Don't fail the request if caching fails
-	} else {
-		slog.Info("Cached TTS audio", "path", cacheFile)
+			audio, synthErr := provider.Synthesize(gctx, chunk, voice, gender)
+			if synthErr != nil {
+				return fmt.Errorf("failed to synthesize chunk %d: %w", i, synthErr)
+			}
+			if _, writeErr := pw.Write(audio); writeErr != nil {
+				return fmt.Errorf("failed to stream chunk %d: %w", i, writeErr)
+			}
+			return nil
+		})
 	}
 
-	return TTSResult{
-		AudioData: audioData,
-		Cached:    false,
+	var merged bytes.Buffer
+	g.Go(func() error {
+		if err := mergeMP3Streams(mergeInputs, &merged); err != nil {
+			for _, pr := range pipeReaders {
+				_ = pr.CloseWithError(err)
+			}
+			return err
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
+	return merged.Bytes(), nil
 }
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/secrets"
+)
+
+// TTSProvider synthesizes spoken audio for a piece of text. ID identifies
+// the provider in cache filenames and logs, so switching TTS_PROVIDER never
+// silently serves audio produced by a different backend.
+type TTSProvider interface {
+	ID() string
+	Synthesize(ctx context.Context, text, voice, gender string) ([]byte, error)
+}
+
+// ttsProviderError classifies a provider failure the same way TTSResult.ErrorType
+// does ("missing_key", "network", "api", "unknown"), so SpeakWord can report
+// it without caring which provider produced it.
+type ttsProviderError struct {
+	kind string
+	err  error
+}
+
+func (e *ttsProviderError) Error() string { return e.err.Error() }
+func (e *ttsProviderError) Unwrap() error { return e.err }
+
+// ttsErrorKind returns the TTSResult.ErrorType for err, or "unknown" if it
+// wasn't raised as a ttsProviderError.
+func ttsErrorKind(err error) string {
+	if perr, ok := err.(*ttsProviderError); ok {
+		return perr.kind
+	}
+	return "unknown"
+}
+
+// newTTSProvider builds the TTSProvider named by id (as set in TTS_PROVIDER:
+// "openai", "piper", or "http"); an empty id defaults to "openai".
+func newTTSProvider(id string) (TTSProvider, error) {
+	switch id {
+	case "", "openai":
+		return &openAITTSProvider{model: ttsModel}, nil
+	case "piper":
+		return &piperTTSProvider{bin: envOrDefault("TTS_PIPER_BIN", "piper")}, nil
+	case "http":
+		endpoint := os.Getenv("TTS_HTTP_ENDPOINT")
+		if endpoint == "" {
+			return nil, fmt.Errorf("TTS_HTTP_ENDPOINT must be set when TTS_PROVIDER=http")
+		}
+		return &httpTTSProvider{endpoint: endpoint}, nil
+	default:
+		return nil, fmt.Errorf("unknown TTS_PROVIDER %q", id)
+	}
+}
+
+// envOrDefault returns os.Getenv(key), or def if it's unset.
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// openAITTSProvider calls OpenAI's /v1/audio/speech endpoint, the original
+// (and still default) backend.
+type openAITTSProvider struct {
+	model string
+}
+
+func (p *openAITTSProvider) ID() string { return "openai" }
+
+func (p *openAITTSProvider) Synthesize(ctx context.Context, text, voice, gender string) ([]byte, error) {
+	apiKey, err := secrets.Get("OPENAI_API_KEY")
+	if err != nil {
+		return nil, &ttsProviderError{kind: "missing_key", err: fmt.Errorf("failed to read OpenAI API key: %w", err)}
+	}
+	if apiKey == "" {
+		return nil, &ttsProviderError{kind: "missing_key", err: fmt.Errorf("OpenAI API key not configured. Please add OPENAI_API_KEY in Settings")}
+	}
+
+	type ttsRequest struct {
+		Model string `json:"model"`
+		Input string `json:"input"`
+		Voice string `json:"voice"`
+	}
+	jsonData, err := json.Marshal(ttsRequest{Model: p.model, Input: text, Voice: voice})
+	if err != nil {
+		return nil, &ttsProviderError{kind: "unknown", err: fmt.Errorf("failed to prepare request: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/audio/speech", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, &ttsProviderError{kind: "unknown", err: fmt.Errorf("failed to create request: %w", err)}
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, &ttsProviderError{kind: "network", err: fmt.Errorf("network error: %w. Please check your internet connection", err)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		switch {
+		case resp.StatusCode == 401:
+			return nil, &ttsProviderError{kind: "missing_key", err: fmt.Errorf("invalid API key. Please check your OPENAI_API_KEY in Settings")}
+		case resp.StatusCode == 429:
+			return nil, &ttsProviderError{kind: "api", err: fmt.Errorf("rate limit exceeded. Please try again in a moment")}
+		case resp.StatusCode >= 500:
+			return nil, &ttsProviderError{kind: "api", err: fmt.Errorf("OpenAI server error (%d). Please try again later", resp.StatusCode)}
+		default:
+			return nil, &ttsProviderError{kind: "api", err: fmt.Errorf("OpenAI API error (%d): %s", resp.StatusCode, string(body))}
+		}
+	}
+
+	audioData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &ttsProviderError{kind: "network", err: fmt.Errorf("failed to read audio data: %w", err)}
+	}
+	return audioData, nil
+}
+
+// piperTTSProvider synthesizes offline by shelling out to a local TTS
+// binary (piper, or espeak-ng if TTS_PIPER_BIN is pointed at it). It needs
+// no API key, so it's the fallback for users without OPENAI_API_KEY.
+type piperTTSProvider struct {
+	bin string
+}
+
+func (p *piperTTSProvider) ID() string { return "piper" }
+
+func (p *piperTTSProvider) Synthesize(ctx context.Context, text, voice, gender string) ([]byte, error) {
+	args := []string{"--stdout"}
+	if espeakVoice := genderToEspeakVoice(gender); espeakVoice != "" {
+		args = append(args, "-v", espeakVoice)
+	}
+	args = append(args, text)
+
+	cmd := exec.CommandContext(ctx, p.bin, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, &ttsProviderError{kind: "unknown", err: fmt.Errorf("%s failed: %w: %s", p.bin, err, stderr.String())}
+	}
+	return stdout.Bytes(), nil
+}
+
+// genderToEspeakVoice maps a resolved gender to an espeak-ng voice variant,
+// or "" to let the binary use its default.
+func genderToEspeakVoice(gender string) string {
+	switch gender {
+	case "male":
+		return "en+m3"
+	case "female":
+		return "en+f3"
+	default:
+		return ""
+	}
+}
+
+// httpTTSProvider calls a configurable self-hosted TTS server (e.g. a
+// Coqui or Kokoro deployment) over HTTP, for setups that want offline-style
+// synthesis without running the binary locally.
+type httpTTSProvider struct {
+	endpoint string
+}
+
+func (p *httpTTSProvider) ID() string { return "http" }
+
+func (p *httpTTSProvider) Synthesize(ctx context.Context, text, voice, gender string) ([]byte, error) {
+	type ttsRequest struct {
+		Text   string `json:"text"`
+		Voice  string `json:"voice"`
+		Gender string `json:"gender"`
+	}
+	jsonData, err := json.Marshal(ttsRequest{Text: text, Voice: voice, Gender: gender})
+	if err != nil {
+		return nil, &ttsProviderError{kind: "unknown", err: fmt.Errorf("failed to prepare request: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, &ttsProviderError{kind: "unknown", err: fmt.Errorf("failed to create request: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token, err := secrets.Get("TTS_HTTP_TOKEN"); err == nil && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, &ttsProviderError{kind: "network", err: fmt.Errorf("network error calling %s: %w", p.endpoint, err)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &ttsProviderError{kind: "api", err: fmt.Errorf("TTS HTTP provider error (%d): %s", resp.StatusCode, string(body))}
+	}
+
+	audioData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &ttsProviderError{kind: "network", err: fmt.Errorf("failed to read audio data: %w", err)}
+	}
+	return audioData, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/assets"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/bogem/id3v2/v2"
+	"github.com/dhowden/tag"
+)
+
+// SyncTTSTags writes ID3 metadata into every cached TTS mp3 that doesn't
+// have it yet (db.TTSTagCandidates), so the files are usable directly in a
+// music player or podcast app rather than as opaque blobs. It's a companion
+// pass to database.DB.SyncFileFlags, run separately since it needs
+// dhowden/tag and bogem/id3v2, dependencies the database package doesn't
+// otherwise need. Returns how many candidates it recorded as tagged,
+// including mp3s that already had a title and artist and were left as-is.
+func SyncTTSTags(ctx context.Context, db *database.DB) (int, error) {
+	candidates, err := db.TTSTagCandidates()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list TTS tag candidates: %w", err)
+	}
+
+	tagged := 0
+	for _, c := range candidates {
+		if err := ctx.Err(); err != nil {
+			return tagged, err
+		}
+		if err := tagItemTTS(db, c); err != nil {
+			slog.Warn("[SyncTTSTags] failed to tag item", "itemID", c.ItemID, "error", err)
+			continue
+		}
+		if err := db.SetTTSTagged(c.ItemID); err != nil {
+			slog.Warn("[SyncTTSTags] failed to record tagged state", "itemID", c.ItemID, "error", err)
+			continue
+		}
+		tagged++
+	}
+
+	slog.Info("SyncTTSTags complete", "candidates", len(candidates), "tagged", tagged)
+	return tagged, nil
+}
+
+// tagItemTTS writes ID3 metadata into c's cached mp3 if it doesn't already
+// have a title and artist set (dhowden/tag), then writes the tagged bytes
+// back through db.Assets() under the same content hash.
+func tagItemTTS(db *database.DB, c database.TTSTagCandidate) error {
+	if db.Assets() == nil {
+		return fmt.Errorf("no asset store configured")
+	}
+
+	r, err := db.Assets().Get(assets.KindTTS, c.TTSHash)
+	if err != nil {
+		return fmt.Errorf("failed to read cached audio: %w", err)
+	}
+	var audio bytes.Buffer
+	if _, err := audio.ReadFrom(r); err != nil {
+		_ = r.Close()
+		return fmt.Errorf("failed to buffer cached audio: %w", err)
+	}
+	_ = r.Close()
+
+	if meta, err := tag.ReadFrom(bytes.NewReader(audio.Bytes())); err == nil {
+		if meta.Title() != "" && meta.Artist() != "" {
+			return nil
+		}
+	}
+
+	id3Tag, err := id3v2.ParseReader(bytes.NewReader(audio.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("failed to parse mp3 for tagging: %w", err)
+	}
+	defer id3Tag.Close()
+
+	id3Tag.SetTitle(c.Word)
+	id3Tag.SetArtist(poetForItem(db, c.ItemID))
+	if c.Source != nil {
+		id3Tag.SetAlbum(*c.Source)
+	}
+	id3Tag.AddTextFrame(id3Tag.CommonID("Track number/Position in set"), id3Tag.DefaultEncoding(), fmt.Sprintf("%d", c.ItemID))
+
+	if hash, err := db.GetImageCacheHash(c.ItemID); err == nil && hash != "" && db.Assets().Exists(assets.KindImage, hash) {
+		if cover, err := db.Assets().Get(assets.KindImage, hash); err == nil {
+			var coverBytes bytes.Buffer
+			if _, err := coverBytes.ReadFrom(cover); err == nil {
+				id3Tag.AddAttachedPicture(id3v2.PictureFrame{
+					Encoding:    id3Tag.DefaultEncoding(),
+					MimeType:    "image/png",
+					PictureType: id3v2.PTFrontCover,
+					Description: "Cover",
+					Picture:     coverBytes.Bytes(),
+				})
+			}
+			_ = cover.Close()
+		}
+	}
+
+	var tagged bytes.Buffer
+	if _, err := id3Tag.WriteTo(&tagged); err != nil {
+		return fmt.Errorf("failed to write tagged mp3: %w", err)
+	}
+
+	if err := db.Assets().Set(assets.KindTTS, c.TTSHash, &tagged); err != nil {
+		return fmt.Errorf("failed to store tagged mp3: %w", err)
+	}
+	return nil
+}
+
+// poetForItem returns the Word of the first linked item of type "Writer"
+// it finds for itemID, or "" if none is linked. The schema has no explicit
+// authorship link type (see database.Link), so this is a best-effort guess
+// rather than a guaranteed answer.
+func poetForItem(db *database.DB, itemID int) string {
+	links, err := db.GetItemLinks(itemID)
+	if err != nil {
+		return ""
+	}
+	for _, link := range links {
+		otherID := link.DestinationItemID
+		if otherID == itemID {
+			otherID = link.SourceItemID
+		}
+		other, err := db.GetItem(otherID)
+		if err != nil {
+			continue
+		}
+		if other.Type == "Writer" {
+			return other.Word
+		}
+	}
+	return ""
+}
@@ -0,0 +1,242 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// BundleManifest describes one exported bundle: the schema version and
+// (caller-supplied, since Manager doesn't know its own build) app version
+// at export time, plus when it was exported. ImportBundle refuses a bundle
+// whose SchemaVersion is newer than CurrentSchemaVersion, since this build
+// has no migration path for fields it doesn't know about yet.
+type BundleManifest struct {
+	AppVersion    string    `json:"appVersion,omitempty"`
+	SchemaVersion int       `json:"schemaVersion"`
+	ExportedAt    time.Time `json:"exportedAt"`
+}
+
+// Bundle is the single JSON document ExportBundle writes and ImportBundle
+// reads: a manifest plus the current contents of settings.json, search.json,
+// and history.json. It lets a user migrate their Poetry state between
+// machines, or share just a curated SavedSearches collection.
+type Bundle struct {
+	Manifest BundleManifest `json:"manifest"`
+	Settings Settings       `json:"settings"`
+	Search   Search         `json:"search"`
+	History  History        `json:"history"`
+}
+
+// ExportOptions controls what ExportBundle includes. AppVersion is stamped
+// into the manifest as-is; ExcludeHistory omits browsing history, useful
+// when sharing a SavedSearches collection without also sharing what the
+// exporting user looked at.
+type ExportOptions struct {
+	AppVersion     string
+	ExcludeHistory bool
+}
+
+// ExportBundle writes a single versioned JSON document containing the
+// current settings, saved searches, and (unless ExcludeHistory) history to
+// w. See ImportBundle for the reverse operation.
+func (m *Manager) ExportBundle(w io.Writer, opts ExportOptions) error {
+	m.mu.RLock()
+	bundle := Bundle{
+		Manifest: BundleManifest{
+			AppVersion:    opts.AppVersion,
+			SchemaVersion: CurrentSchemaVersion,
+			ExportedAt:    time.Now(),
+		},
+		Settings: *m.settings,
+		Search:   *m.search,
+	}
+	if !opts.ExcludeHistory {
+		bundle.History = *m.history
+	}
+	m.mu.RUnlock()
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// ImportMode selects how ImportBundle reconciles an incoming bundle with
+// the Manager's current on-disk state.
+type ImportMode string
+
+const (
+	// ImportMerge unions saved searches and recent searches by identity
+	// (name, and exact term, respectively) and merges history entries,
+	// adding each incoming entry not already present with the same ID,
+	// visit time and source, then keeping the newest maxHistoryEntries.
+	// Entries that share an ID but differ in visit time are all kept. Settings (window
+	// position, last-viewed item, etc.) are left untouched - it rarely
+	// makes sense to carry those over from another machine.
+	ImportMerge ImportMode = "merge"
+
+	// ImportReplace overwrites settings, search, and history outright with
+	// the bundle's contents.
+	ImportReplace ImportMode = "replace"
+)
+
+// ImportOptions controls how ImportBundle applies an incoming Bundle.
+type ImportOptions struct {
+	Mode ImportMode
+
+	// DryRun computes and returns the ImportDiff without writing anything.
+	DryRun bool
+}
+
+// ImportDiff summarizes what ImportBundle did (or, with DryRun, would do),
+// so a caller can show the user what an import changed without having to
+// diff before/after snapshots themselves.
+type ImportDiff struct {
+	SettingsReplaced     bool     `json:"settingsReplaced"`
+	SavedSearchesAdded   []string `json:"savedSearchesAdded,omitempty"`
+	SavedSearchesUpdated []string `json:"savedSearchesUpdated,omitempty"`
+	RecentSearchesAdded  int      `json:"recentSearchesAdded"`
+	HistoryEntriesAdded  int      `json:"historyEntriesAdded"`
+}
+
+// ImportBundle reads a Bundle written by ExportBundle from r and applies it
+// per opts.Mode, returning a diff describing the result. A bundle whose
+// schema version is newer than this build's CurrentSchemaVersion is
+// rejected outright, in either mode, since there's no way to know what an
+// unrecognized field means.
+func (m *Manager) ImportBundle(r io.Reader, opts ImportOptions) (*ImportDiff, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if bundle.Manifest.SchemaVersion > CurrentSchemaVersion {
+		return nil, fmt.Errorf("bundle schema version %d is newer than this build supports (%d)", bundle.Manifest.SchemaVersion, CurrentSchemaVersion)
+	}
+
+	switch opts.Mode {
+	case ImportReplace:
+		return m.replaceFromBundle(bundle, opts.DryRun)
+	case ImportMerge, "":
+		return m.mergeFromBundle(bundle, opts.DryRun)
+	default:
+		return nil, fmt.Errorf("unknown import mode %q", opts.Mode)
+	}
+}
+
+func (m *Manager) replaceFromBundle(bundle Bundle, dryRun bool) (*ImportDiff, error) {
+	diff := &ImportDiff{
+		SettingsReplaced:    true,
+		RecentSearchesAdded: len(bundle.Search.RecentSearches),
+		HistoryEntriesAdded: len(bundle.History.Entries),
+	}
+	for _, s := range bundle.Search.SavedSearches {
+		diff.SavedSearchesAdded = append(diff.SavedSearchesAdded, s.Name)
+	}
+	if dryRun {
+		return diff, nil
+	}
+
+	m.mu.Lock()
+	*m.settings = bundle.Settings
+	*m.search = bundle.Search
+	*m.history = bundle.History
+	m.mu.Unlock()
+
+	if err := m.Save(); err != nil {
+		return diff, err
+	}
+	if err := m.SaveSearch(); err != nil {
+		return diff, err
+	}
+	if err := m.SaveHistory(); err != nil {
+		return diff, err
+	}
+	return diff, nil
+}
+
+func (m *Manager) mergeFromBundle(bundle Bundle, dryRun bool) (*ImportDiff, error) {
+	m.mu.Lock()
+
+	diff := &ImportDiff{}
+
+	existingSaved := make(map[string]int, len(m.search.SavedSearches))
+	for i, s := range m.search.SavedSearches {
+		existingSaved[s.Name] = i
+	}
+	mergedSaved := append([]SavedSearch(nil), m.search.SavedSearches...)
+	for _, incoming := range bundle.Search.SavedSearches {
+		if i, exists := existingSaved[incoming.Name]; exists {
+			mergedSaved[i] = incoming
+			diff.SavedSearchesUpdated = append(diff.SavedSearchesUpdated, incoming.Name)
+		} else {
+			mergedSaved = append(mergedSaved, incoming)
+			diff.SavedSearchesAdded = append(diff.SavedSearchesAdded, incoming.Name)
+		}
+	}
+
+	existingRecent := make(map[string]bool, len(m.search.RecentSearches))
+	for _, term := range m.search.RecentSearches {
+		existingRecent[term] = true
+	}
+	mergedRecent := append([]string(nil), m.search.RecentSearches...)
+	for _, term := range bundle.Search.RecentSearches {
+		if !existingRecent[term] {
+			mergedRecent = append(mergedRecent, term)
+			diff.RecentSearchesAdded++
+		}
+	}
+	if len(mergedRecent) > 50 {
+		mergedRecent = mergedRecent[:50]
+	}
+
+	existingHistory := make(map[string]bool, len(m.history.Entries))
+	for _, e := range m.history.Entries {
+		existingHistory[historyEntryKey(e)] = true
+	}
+	mergedHistory := append([]HistoryEntry(nil), m.history.Entries...)
+	for _, e := range bundle.History.Entries {
+		if !existingHistory[historyEntryKey(e)] {
+			mergedHistory = append(mergedHistory, e)
+			diff.HistoryEntriesAdded++
+		}
+	}
+	sort.Slice(mergedHistory, func(i, j int) bool { return mergedHistory[i].VisitedAt.After(mergedHistory[j].VisitedAt) })
+	if len(mergedHistory) > maxHistoryEntries {
+		mergedHistory = mergedHistory[:maxHistoryEntries]
+	}
+
+	if dryRun {
+		m.mu.Unlock()
+		return diff, nil
+	}
+
+	m.search.SavedSearches = mergedSaved
+	m.search.RecentSearches = mergedRecent
+	m.history.Entries = mergedHistory
+	m.mu.Unlock()
+
+	if err := m.SaveSearch(); err != nil {
+		return diff, err
+	}
+	if err := m.SaveHistory(); err != nil {
+		return diff, err
+	}
+	return diff, nil
+}
+
+// historyEntryKey identifies a HistoryEntry for merge-time deduplication.
+func historyEntryKey(e HistoryEntry) string {
+	return fmt.Sprintf("%d|%d|%s", e.ID, e.VisitedAt.UnixNano(), e.Source)
+}
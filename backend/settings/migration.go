@@ -0,0 +1,147 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentSchemaVersion is the schema version new Settings, Search, and
+// History values are created at. Load/LoadSearch/LoadHistory migrate an
+// older on-disk file up to this version, in order, before unmarshaling it
+// into the real struct - so a field rename or a change in a value's shape
+// (e.g. splitting NavigationHistory into typed entries, or giving
+// TableSort N levels instead of 2) ships as a Migration instead of silently
+// misreading or discarding an older user's config.
+const CurrentSchemaVersion = 2
+
+// Migration is one versioned transformation of a settings/search/history
+// file's raw JSON bytes. From and To must be consecutive versions; Migrate
+// receives the file exactly as it was read from disk and returns the bytes
+// to hand to either the next registered Migration or, once To reaches
+// CurrentSchemaVersion, to json.Unmarshal.
+type Migration struct {
+	From    int
+	To      int
+	Migrate func([]byte) ([]byte, error)
+}
+
+// schemaVersionOnly is unmarshaled first so applyMigrations can read a
+// file's schema version without assuming anything else about its shape -
+// important since the whole point of migrating is that the shape may not
+// match the current struct yet.
+type schemaVersionOnly struct {
+	SchemaVersion int `json:"schemaVersion"`
+}
+
+// settingsMigrations, searchMigrations, and historyMigrations are applied,
+// in order, by Load, LoadSearch, and LoadHistory respectively. A file with
+// no "schemaVersion" key at all (every file written before this framework
+// existed) unmarshals SchemaVersion as the zero value, so From: 0 is what
+// picks up pre-existing users' configs.
+var (
+	settingsMigrations = []Migration{
+		{From: 0, To: 1, Migrate: stampSchemaVersion(1)},
+		{From: 1, To: 2, Migrate: stampSchemaVersion(2)},
+	}
+	searchMigrations = []Migration{
+		{From: 0, To: 1, Migrate: stampSchemaVersion(1)},
+		{From: 1, To: 2, Migrate: stampSchemaVersion(2)},
+	}
+	historyMigrations = []Migration{
+		{From: 0, To: 1, Migrate: stampSchemaVersion(1)},
+		{From: 1, To: 2, Migrate: migrateHistoryEntriesV1ToV2},
+	}
+)
+
+// historyV1 is the pre-chunk5-3 shape of a history.json file: a flat list
+// of visited item IDs with no timestamp, kind, or source.
+type historyV1 struct {
+	SchemaVersion     int   `json:"schemaVersion"`
+	NavigationHistory []int `json:"navigationHistory"`
+}
+
+// migrateHistoryEntriesV1ToV2 converts the flat NavigationHistory []int
+// into typed HistoryEntry values. The migrated entries have no known
+// VisitedAt (left at the zero time) or Kind, and Source is stamped
+// HistorySourceLegacy so they're distinguishable from entries recorded by
+// RecordVisit going forward.
+func migrateHistoryEntriesV1ToV2(data []byte) ([]byte, error) {
+	var old historyV1
+	if err := json.Unmarshal(data, &old); err != nil {
+		return nil, fmt.Errorf("failed to parse v1 history: %w", err)
+	}
+
+	entries := make([]HistoryEntry, 0, len(old.NavigationHistory))
+	for _, id := range old.NavigationHistory {
+		entries = append(entries, HistoryEntry{ID: id, Source: HistorySourceLegacy})
+	}
+
+	migrated, err := json.Marshal(History{SchemaVersion: 2, Entries: entries})
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-encode migrated history: %w", err)
+	}
+	return migrated, nil
+}
+
+// stampSchemaVersion returns a Migration.Migrate func that does nothing but
+// set the "schemaVersion" key, for the common case where a version bump
+// doesn't need to touch any other field - e.g. the 0-to-1 migration that
+// introduced schema versioning itself.
+func stampSchemaVersion(version int) func([]byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		var raw map[string]interface{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse file for migration: %w", err)
+		}
+		raw["schemaVersion"] = version
+		migrated, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-encode migrated file: %w", err)
+		}
+		return migrated, nil
+	}
+}
+
+// applyMigrations brings data up to CurrentSchemaVersion by running
+// migrations in order starting from data's current schema version. Before
+// the first migration runs, the pre-migration bytes are backed up under
+// store, keyed key + ".v{N}.bak" (N being that starting version), so a
+// botched migration can always be recovered from by hand. If data is
+// already at CurrentSchemaVersion, it's returned unchanged and no backup is
+// written; a version newer than CurrentSchemaVersion is an error.
+func applyMigrations(store Store, key string, data []byte, migrations []Migration) ([]byte, error) {
+	var v schemaVersionOnly
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if v.SchemaVersion == CurrentSchemaVersion {
+		return data, nil
+	}
+	if v.SchemaVersion > CurrentSchemaVersion {
+		return nil, fmt.Errorf("no migration registered from schema version %d (newer than this build supports, %d)", v.SchemaVersion, CurrentSchemaVersion)
+	}
+
+	byFrom := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		byFrom[m.From] = m
+	}
+
+	if err := store.Put(fmt.Sprintf("%s.v%d.bak", key, v.SchemaVersion), data); err != nil {
+		return nil, fmt.Errorf("failed to back up pre-migration file: %w", err)
+	}
+
+	current := v.SchemaVersion
+	for current < CurrentSchemaVersion {
+		m, ok := byFrom[current]
+		if !ok {
+			return nil, fmt.Errorf("no migration registered from schema version %d", current)
+		}
+		migrated, err := m.Migrate(data)
+		if err != nil {
+			return nil, fmt.Errorf("migration from v%d to v%d failed: %w", m.From, m.To, err)
+		}
+		data = migrated
+		current = m.To
+	}
+	return data, nil
+}
@@ -0,0 +1,156 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// v0SettingsFixture is a settings.json exactly as it looked before schema
+// versioning existed - no "schemaVersion" key at all.
+const v0SettingsFixture = `{
+	"window": {"x": 10, "y": 20, "width": 800, "height": 600, "leftbarWidth": 240},
+	"exportFolder": "/home/user/Documents/Poetry/exports",
+	"lastWordId": 42,
+	"lastView": "search"
+}`
+
+// v2SettingsFixture is already at CurrentSchemaVersion and should pass
+// through applyMigrations untouched.
+const v2SettingsFixture = `{
+	"schemaVersion": 2,
+	"window": {"x": 10, "y": 20, "width": 800, "height": 600, "leftbarWidth": 240},
+	"exportFolder": "/home/user/Documents/Poetry/exports",
+	"lastWordId": 42,
+	"lastView": "search"
+}`
+
+// v1HistoryFixture is a history.json from between chunk5-2 (schema
+// versioning introduced) and chunk5-3 (typed entries introduced) - it has a
+// schemaVersion but still the flat NavigationHistory []int shape.
+const v1HistoryFixture = `{
+	"schemaVersion": 1,
+	"navigationHistory": [7, 3, 7, 9]
+}`
+
+// v0HistoryFixture predates schema versioning entirely.
+const v0HistoryFixture = `{
+	"navigationHistory": [1, 2]
+}`
+
+func TestApplyMigrationsFromV0(t *testing.T) {
+	store := NewMemStore()
+
+	migrated, err := applyMigrations(store, "settings.json", []byte(v0SettingsFixture), settingsMigrations)
+	if err != nil {
+		t.Fatalf("applyMigrations returned error: %v", err)
+	}
+
+	var v schemaVersionOnly
+	if err := json.Unmarshal(migrated, &v); err != nil {
+		t.Fatalf("migrated data is not valid JSON: %v", err)
+	}
+	if v.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", CurrentSchemaVersion, v.SchemaVersion)
+	}
+
+	var s Settings
+	if err := json.Unmarshal(migrated, &s); err != nil {
+		t.Fatalf("migrated data does not unmarshal into Settings: %v", err)
+	}
+	if s.LastWordID != 42 || s.LastView != "search" {
+		t.Errorf("migration lost existing fields: %+v", s)
+	}
+
+	if _, err := store.Get("settings.json.v0.bak"); err != nil {
+		t.Errorf("expected pre-migration backup at settings.json.v0.bak: %v", err)
+	}
+}
+
+func TestApplyMigrationsAlreadyCurrent(t *testing.T) {
+	store := NewMemStore()
+
+	migrated, err := applyMigrations(store, "settings.json", []byte(v2SettingsFixture), settingsMigrations)
+	if err != nil {
+		t.Fatalf("applyMigrations returned error: %v", err)
+	}
+	if string(migrated) != v2SettingsFixture {
+		t.Errorf("expected already-current data to pass through unchanged")
+	}
+
+	if _, err := store.Get("settings.json.v0.bak"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected no backup to be written for already-current data")
+	}
+}
+
+func TestApplyMigrationsUnknownVersion(t *testing.T) {
+	store := NewMemStore()
+
+	_, err := applyMigrations(store, "settings.json", []byte(`{"schemaVersion": 99}`), settingsMigrations)
+	if err == nil {
+		t.Fatal("expected an error for a schema version with no registered migration")
+	}
+}
+
+func TestApplyMigrationsHistoryFromV0(t *testing.T) {
+	store := NewMemStore()
+
+	migrated, err := applyMigrations(store, "history.json", []byte(v0HistoryFixture), historyMigrations)
+	if err != nil {
+		t.Fatalf("applyMigrations returned error: %v", err)
+	}
+
+	var h History
+	if err := json.Unmarshal(migrated, &h); err != nil {
+		t.Fatalf("migrated data does not unmarshal into History: %v", err)
+	}
+	if h.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", CurrentSchemaVersion, h.SchemaVersion)
+	}
+
+	wantIDs := []int{1, 2}
+	if len(h.Entries) != len(wantIDs) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(wantIDs), len(h.Entries), h.Entries)
+	}
+	for i, id := range wantIDs {
+		if h.Entries[i].ID != id {
+			t.Errorf("entry %d: expected ID %d, got %d", i, id, h.Entries[i].ID)
+		}
+		if h.Entries[i].Source != HistorySourceLegacy {
+			t.Errorf("entry %d: expected Source %q, got %q", i, HistorySourceLegacy, h.Entries[i].Source)
+		}
+	}
+}
+
+func TestApplyMigrationsHistoryFromV1(t *testing.T) {
+	store := NewMemStore()
+
+	migrated, err := applyMigrations(store, "history.json", []byte(v1HistoryFixture), historyMigrations)
+	if err != nil {
+		t.Fatalf("applyMigrations returned error: %v", err)
+	}
+
+	var h History
+	if err := json.Unmarshal(migrated, &h); err != nil {
+		t.Fatalf("migrated data does not unmarshal into History: %v", err)
+	}
+	if h.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", CurrentSchemaVersion, h.SchemaVersion)
+	}
+
+	// The v1 fixture has a duplicate ID (7); the v1-to-v2 migration doesn't
+	// dedupe - RecordVisit's dedupe-on-write only applies going forward.
+	wantIDs := []int{7, 3, 7, 9}
+	if len(h.Entries) != len(wantIDs) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(wantIDs), len(h.Entries), h.Entries)
+	}
+	for i, id := range wantIDs {
+		if h.Entries[i].ID != id {
+			t.Errorf("entry %d: expected ID %d, got %d", i, id, h.Entries[i].ID)
+		}
+	}
+
+	if _, err := store.Get("history.json.v1.bak"); err != nil {
+		t.Errorf("expected pre-migration backup at history.json.v1.bak: %v", err)
+	}
+}
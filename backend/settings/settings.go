@@ -1,24 +1,46 @@
 package settings
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
+	"github.com/fsnotify/fsnotify"
 )
 
 // SavedSearch represents a named search query
 type SavedSearch struct {
-	Name   string   `json:"name"`
-	Query  string   `json:"query"`
-	Types  []string `json:"types,omitempty"`
-	Source string   `json:"source,omitempty"`
+	Name       string   `json:"name"`
+	Query      string   `json:"query"`
+	Types      []string `json:"types,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	FolderPath string   `json:"folderPath,omitempty"` // e.g. "work/ethereum/txs"; "" is the root folder
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// SavedSearchNode is one node of the tree ListSavedSearches("tree", ...)
+// returns - either a folder (IsFolder true, Children populated, Search nil)
+// or a leaf saved search (Search set, Children nil). In "list" mode every
+// returned node is a leaf.
+type SavedSearchNode struct {
+	Name     string            `json:"name"`
+	IsFolder bool              `json:"isFolder"`
+	Search   *SavedSearch      `json:"search,omitempty"`
+	Children []SavedSearchNode `json:"children,omitempty"`
 }
 
 // Search stores search-related data
 type Search struct {
+	SchemaVersion  int           `json:"schemaVersion"`
 	RecentSearches []string      `json:"recentSearches"` // most recent first, max 50
 	SavedSearches  []SavedSearch `json:"savedSearches"`  // user-named search bookmarks
 }
@@ -49,13 +71,89 @@ type TableSort struct {
 	Dir2   string `json:"dir2,omitempty"`
 }
 
+// HistoryEntry is one visited item, typed with what kind of thing it was,
+// when it was visited, and what UI action led there - enough to drive a
+// "recent by category" view or an activity-feed-style history view instead
+// of just a bag of IDs.
+type HistoryEntry struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind,omitempty"` // e.g. "word"; empty for legacy/unknown entries
+	VisitedAt time.Time `json:"visitedAt"`
+	Source    string    `json:"source"`          // one of the History source constants below
+	Query     string    `json:"query,omitempty"` // the search query that led here, if Source is HistorySourceSearch
+}
+
+// History source kinds, recording what UI action produced a HistoryEntry.
+const (
+	HistorySourceSearch  = "search"
+	HistorySourceGraph   = "graph"
+	HistorySourceManager = "manager"
+	HistorySourceLink    = "link"
+	HistorySourceLegacy  = "legacy" // migrated from the pre-chunk5-3 flat NavigationHistory []int; no known source
+)
+
+// maxHistoryEntries caps how many visits History retains. It's higher than
+// the old flat NavigationHistory's cap of 50 since VisitsByDay/TopItems are
+// more useful with a deeper window to aggregate over.
+const maxHistoryEntries = 200
+
 // History stores navigation history
 type History struct {
-	NavigationHistory []int `json:"navigationHistory"` // list of recently visited item IDs
+	SchemaVersion int            `json:"schemaVersion"`
+	Entries       []HistoryEntry `json:"entries"`
+}
+
+// HistoryFilter narrows QueryHistory's results. A zero-value field matches
+// any value; Since/Until are inclusive bounds on VisitedAt.
+type HistoryFilter struct {
+	Kind   string
+	Source string
+	Since  time.Time
+	Until  time.Time
+}
+
+func (f HistoryFilter) matches(e HistoryEntry) bool {
+	if f.Kind != "" && e.Kind != f.Kind {
+		return false
+	}
+	if f.Source != "" && e.Source != f.Source {
+		return false
+	}
+	if !f.Since.IsZero() && e.VisitedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.VisitedAt.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// ItemVisitCount is one item's visit count, as returned by TopItems.
+type ItemVisitCount struct {
+	ID    int `json:"id"`
+	Count int `json:"count"`
+}
+
+// CacheLimits stores the size/file-count caps enforced on the TTS, image, and
+// lazy-loaded item text caches
+type CacheLimits struct {
+	TTSMaxBytes   int64 `json:"ttsMaxBytes"`
+	TTSMaxFiles   int   `json:"ttsMaxFiles"`
+	ImageMaxBytes int64 `json:"imageMaxBytes"`
+	ImageMaxFiles int   `json:"imageMaxFiles"`
+	LazyMaxBytes  int64 `json:"lazyMaxBytes"` // ceiling for the in-memory definition/derivation/appendicies cache
+}
+
+// Logging stores rotation parameters for the application log file
+type Logging struct {
+	MaxBytes   int64 `json:"maxBytes"`   // rotate app.log once it reaches this size
+	MaxFiles   int   `json:"maxFiles"`   // number of rotated files to keep
+	MaxAgeDays int   `json:"maxAgeDays"` // delete rotated files older than this
 }
 
 // Settings stores user preferences
 type Settings struct {
+	SchemaVersion  int                  `json:"schemaVersion"`
 	Window         Window               `json:"window"`
 	ExportFolder   string               `json:"exportFolder"`
 	LastWordID     int                  `json:"lastWordId"`
@@ -69,33 +167,65 @@ type Settings struct {
 	CurrentSearch  string               `json:"currentSearch"`        // current table search query
 	ManagerOldType string               `json:"managerOldType"`       // Item Manager: last selected old type
 	ManagerNewType string               `json:"managerNewType"`       // Item Manager: last selected new type
+	CacheLimits    CacheLimits          `json:"cacheLimits"`          // TTS/image cache size and file-count caps
+	ExportTemplate string               `json:"exportTemplate"`       // name of the default export template
+	Logging        Logging              `json:"logging"`              // app.log rotation parameters
+}
+
+// SettingsChangedEvent is emitted on the channel Watch returns whenever
+// settings.json is written on disk - by another process (a second instance,
+// a sync tool like Syncthing), by a hand edit, or by this Manager's own
+// Save, which Watch does not tell apart from the others.
+type SettingsChangedEvent struct {
+	Settings *Settings
 }
 
-// Manager handles settings persistence
+// settingsKey, searchKey, and historyKey are the Store keys Manager reads
+// and writes its three documents under - the same names FileStore has
+// always used as on-disk filenames.
+const (
+	settingsKey = "settings.json"
+	searchKey   = "search.json"
+	historyKey  = "history.json"
+)
+
+// Manager handles settings persistence. It is safe for concurrent use: mu
+// guards every access to settings, search, and history, Save/SaveSearch/
+// SaveHistory write through store so a crash mid-write can't leave a
+// truncated document behind, and Watch can be used to pick up changes
+// written by another process sharing the same config directory (FileStore-
+// backed Managers only).
 type Manager struct {
-	settingsPath string
-	searchPath   string
-	historyPath  string
-	settings     *Settings
-	search       *Search
-	history      *History
+	mu sync.RWMutex
+
+	store    Store
+	settings *Settings
+	search   *Search
+	history  *History
 }
 
-// NewManager creates a new settings manager
+// NewManager creates a new settings manager backed by a FileStore rooted at
+// the user's config directory.
 func NewManager() (*Manager, error) {
-	configDir, err := constants.GetConfigDir()
+	configDir, err := paths.ConfigDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config directory: %w", err)
 	}
 
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	store, err := NewFileStore(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open settings store: %w", err)
 	}
 
-	settingsPath := filepath.Join(configDir, "settings.json")
-	searchPath := filepath.Join(configDir, "search.json")
-	historyPath := filepath.Join(configDir, "history.json")
+	return NewManagerWithStore(store)
+}
 
+// NewManagerWithStore creates a settings manager backed by store - e.g. a
+// MemStore in tests so the settings package can be exercised without
+// touching $HOME, or a SQLiteStore to keep the documents in one database.
+// Existing settings/search/history under store are loaded the same way
+// NewManager does.
+func NewManagerWithStore(store Store) (*Manager, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
@@ -103,10 +233,9 @@ func NewManager() (*Manager, error) {
 	defaultExportFolder := filepath.Join(homeDir, "Documents", "Poetry", "exports")
 
 	m := &Manager{
-		settingsPath: settingsPath,
-		searchPath:   searchPath,
-		historyPath:  historyPath,
+		store: store,
 		settings: &Settings{
+			SchemaVersion: CurrentSchemaVersion,
 			Window: Window{
 				X:            100,
 				Y:            100,
@@ -120,13 +249,27 @@ func NewManager() (*Manager, error) {
 				Outgoing: true,  // default collapsed
 				Incoming: false, // default expanded
 			},
+			CacheLimits: CacheLimits{
+				TTSMaxBytes:   500 * 1024 * 1024, // 500MB
+				TTSMaxFiles:   5000,
+				ImageMaxBytes: 500 * 1024 * 1024, // 500MB
+				ImageMaxFiles: 5000,
+				LazyMaxBytes:  32 * 1024 * 1024, // 32MB
+			},
+			Logging: Logging{
+				MaxBytes:   10 * 1024 * 1024, // 10MB
+				MaxFiles:   7,
+				MaxAgeDays: 30,
+			},
 		},
 		search: &Search{
+			SchemaVersion:  CurrentSchemaVersion,
 			RecentSearches: []string{},
 			SavedSearches:  []SavedSearch{},
 		},
 		history: &History{
-			NavigationHistory: []int{},
+			SchemaVersion: CurrentSchemaVersion,
+			Entries:       []HistoryEntry{},
 		},
 	}
 
@@ -138,231 +281,419 @@ func NewManager() (*Manager, error) {
 	return m, nil
 }
 
-// Load reads settings from disk
+// Load reads settings from the store
 func (m *Manager) Load() error {
-	data, err := os.ReadFile(m.settingsPath)
+	data, err := m.store.Get(settingsKey)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist yet, use defaults
+		if errors.Is(err, ErrNotFound) {
+			// Nothing stored yet, use defaults
 			return nil
 		}
 		return fmt.Errorf("failed to read settings: %w", err)
 	}
 
-	if err := json.Unmarshal(data, m.settings); err != nil {
+	data, err = applyMigrations(m.store, settingsKey, data, settingsMigrations)
+	if err != nil {
+		return fmt.Errorf("failed to migrate settings: %w", err)
+	}
+
+	var loaded Settings
+	if err := json.Unmarshal(data, &loaded); err != nil {
 		return fmt.Errorf("failed to parse settings: %w", err)
 	}
 
+	m.mu.Lock()
+	*m.settings = loaded
+	m.mu.Unlock()
 	return nil
 }
 
-// Save writes settings to disk
+// Save writes settings to the store.
 func (m *Manager) Save() error {
+	m.mu.RLock()
 	data, err := json.MarshalIndent(m.settings, "", "  ")
+	m.mu.RUnlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
-	if err := os.WriteFile(m.settingsPath, data, 0644); err != nil {
+	if err := m.store.Put(settingsKey, data); err != nil {
 		return fmt.Errorf("failed to write settings: %w", err)
 	}
-
 	return nil
 }
 
-// Get returns current settings
+// Get returns a snapshot of the current settings. It is a deep copy, not a
+// live pointer into the Manager's internal state, so it stays safe to read
+// even if another goroutine concurrently calls an Update* method or Watch
+// reloads the file out from under it.
 func (m *Manager) Get() *Settings {
-	return m.settings
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := *m.settings
+	s.TabSelections = copyStringMap(m.settings.TabSelections)
+	s.TableSorts = copyTableSortMap(m.settings.TableSorts)
+	return &s
+}
+
+// SchemaVersion returns the schema version the in-memory settings were
+// loaded at (or created at, for a brand-new config). It's always
+// CurrentSchemaVersion once Load has run, since applyMigrations brings an
+// older file up to date before it's unmarshaled.
+func (m *Manager) SchemaVersion() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.settings.SchemaVersion
+}
+
+func copyStringMap(src map[string]string) map[string]string {
+	if src == nil {
+		return nil
+	}
+	dst := make(map[string]string, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func copyTableSortMap(src map[string]TableSort) map[string]TableSort {
+	if src == nil {
+		return nil
+	}
+	dst := make(map[string]TableSort, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
 }
 
 // Update updates all settings and saves
 func (m *Manager) Update(s Settings) error {
+	m.mu.Lock()
 	*m.settings = s
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateWindowPosition updates and saves window position
 func (m *Manager) UpdateWindowPosition(x, y, width, height int) error {
+	m.mu.Lock()
 	m.settings.Window.X = x
 	m.settings.Window.Y = y
 	m.settings.Window.Width = width
 	m.settings.Window.Height = height
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateLeftbarWidth updates and saves leftbar width
 func (m *Manager) UpdateLeftbarWidth(width int) error {
+	m.mu.Lock()
 	m.settings.Window.LeftbarWidth = width
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateTabSelection updates and saves a tab selection for a specific view
 func (m *Manager) UpdateTabSelection(viewID, tabID string) error {
+	m.mu.Lock()
 	if m.settings.TabSelections == nil {
 		m.settings.TabSelections = make(map[string]string)
 	}
 	m.settings.TabSelections[viewID] = tabID
+	m.mu.Unlock()
 	return m.Save()
 }
 
-// UpdateLastWord updates and saves last viewed word
+// UpdateLastWord updates the last viewed word and records a visit for it.
+// The caller doesn't know which UI surface triggered the navigation, so the
+// visit is recorded with HistorySourceManager; callers that do know the
+// source should call RecordVisit directly instead.
 func (m *Manager) UpdateLastWord(wordID int) error {
+	m.mu.Lock()
 	m.settings.LastWordID = wordID
+	m.mu.Unlock()
 
-	// Update history
 	if wordID > 0 {
-		// Remove if already exists (to move to front)
-		filtered := make([]int, 0, len(m.history.NavigationHistory))
-		for _, id := range m.history.NavigationHistory {
-			if id != wordID {
-				filtered = append(filtered, id)
-			}
+		if err := m.RecordVisit(HistoryEntry{ID: wordID, Kind: "word", Source: HistorySourceManager}); err != nil {
+			return err
 		}
+	}
 
-		// Add to front
-		m.history.NavigationHistory = append([]int{wordID}, filtered...)
+	return m.Save()
+}
+
+// RecordVisit prepends entry to the visit history (most recent first),
+// stamping VisitedAt with the current time if the caller left it zero,
+// removing any earlier entry for the same ID so a re-visit moves to the
+// front instead of appearing twice, and capping the history at
+// maxHistoryEntries.
+func (m *Manager) RecordVisit(entry HistoryEntry) error {
+	if entry.VisitedAt.IsZero() {
+		entry.VisitedAt = time.Now()
+	}
 
-		// Limit to 50
-		if len(m.history.NavigationHistory) > 50 {
-			m.history.NavigationHistory = m.history.NavigationHistory[:50]
+	m.mu.Lock()
+	filtered := make([]HistoryEntry, 0, len(m.history.Entries))
+	for _, e := range m.history.Entries {
+		if e.ID != entry.ID {
+			filtered = append(filtered, e)
 		}
-		if err := m.SaveHistory(); err != nil {
-			return err
+	}
+	m.history.Entries = append([]HistoryEntry{entry}, filtered...)
+	if len(m.history.Entries) > maxHistoryEntries {
+		m.history.Entries = m.history.Entries[:maxHistoryEntries]
+	}
+	m.mu.Unlock()
+
+	return m.SaveHistory()
+}
+
+// QueryHistory returns visit entries matching filter, most recent first.
+func (m *Manager) QueryHistory(filter HistoryFilter) []HistoryEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matched []HistoryEntry
+	for _, e := range m.history.Entries {
+		if filter.matches(e) {
+			matched = append(matched, e)
 		}
 	}
+	return matched
+}
 
-	return m.Save()
+// VisitsByDay returns the number of visits recorded on each day, keyed
+// "2006-01-02" in the entry's local time, for a calendar-style activity
+// view.
+func (m *Manager) VisitsByDay() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	byDay := make(map[string]int)
+	for _, e := range m.history.Entries {
+		byDay[e.VisitedAt.Format("2006-01-02")]++
+	}
+	return byDay
+}
+
+// TopItems returns the n most-visited item IDs, most-visited first. Ties
+// are broken by which item was visited most recently. A negative n returns
+// every visited item.
+func (m *Manager) TopItems(n int) []ItemVisitCount {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[int]int)
+	mostRecentIndex := make(map[int]int)
+	for i, e := range m.history.Entries {
+		counts[e.ID]++
+		if _, seen := mostRecentIndex[e.ID]; !seen {
+			mostRecentIndex[e.ID] = i
+		}
+	}
+
+	ids := make([]int, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if counts[ids[i]] != counts[ids[j]] {
+			return counts[ids[i]] > counts[ids[j]]
+		}
+		return mostRecentIndex[ids[i]] < mostRecentIndex[ids[j]]
+	})
+
+	if n >= 0 && n < len(ids) {
+		ids = ids[:n]
+	}
+
+	top := make([]ItemVisitCount, len(ids))
+	for i, id := range ids {
+		top[i] = ItemVisitCount{ID: id, Count: counts[id]}
+	}
+	return top
 }
 
 // GetNavigationHistory returns the navigation history
 func (m *Manager) GetNavigationHistory() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	// Return only the first 10 items for display
-	if len(m.history.NavigationHistory) > 10 {
-		return m.history.NavigationHistory[:10]
+	entries := m.history.Entries
+	if len(entries) > 10 {
+		entries = entries[:10]
+	}
+	ids := make([]int, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
 	}
-	return m.history.NavigationHistory
+	return ids
 }
 
-// LoadHistory reads history from disk
+// LoadHistory reads history from the store
 func (m *Manager) LoadHistory() error {
-	data, err := os.ReadFile(m.historyPath)
+	data, err := m.store.Get(historyKey)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist yet, check if we have history in settings to migrate
+		if errors.Is(err, ErrNotFound) {
+			// Nothing stored yet, check if we have history in settings to migrate
 			// Note: We are not doing a migration as requested by the user
 			return nil
 		}
 		return fmt.Errorf("failed to read history: %w", err)
 	}
 
-	if err := json.Unmarshal(data, m.history); err != nil {
+	data, err = applyMigrations(m.store, historyKey, data, historyMigrations)
+	if err != nil {
+		return fmt.Errorf("failed to migrate history: %w", err)
+	}
+
+	var loaded History
+	if err := json.Unmarshal(data, &loaded); err != nil {
 		return fmt.Errorf("failed to parse history: %w", err)
 	}
 
+	m.mu.Lock()
+	*m.history = loaded
+	m.mu.Unlock()
 	return nil
 }
 
-// SaveHistory writes history to disk
+// SaveHistory writes history to the store.
 func (m *Manager) SaveHistory() error {
+	m.mu.RLock()
 	data, err := json.MarshalIndent(m.history, "", "  ")
+	m.mu.RUnlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal history: %w", err)
 	}
 
-	if err := os.WriteFile(m.historyPath, data, 0644); err != nil {
+	if err := m.store.Put(historyKey, data); err != nil {
 		return fmt.Errorf("failed to write history: %w", err)
 	}
-
 	return nil
 }
 
-// RemoveFromHistory removes an item ID from the navigation history
+// RemoveFromHistory removes every visit entry for an item ID from the
+// navigation history
 func (m *Manager) RemoveFromHistory(itemID int) error {
-	filtered := make([]int, 0, len(m.history.NavigationHistory))
-	for _, id := range m.history.NavigationHistory {
-		if id != itemID {
-			filtered = append(filtered, id)
+	m.mu.Lock()
+	filtered := make([]HistoryEntry, 0, len(m.history.Entries))
+	for _, e := range m.history.Entries {
+		if e.ID != itemID {
+			filtered = append(filtered, e)
 		}
 	}
-	m.history.NavigationHistory = filtered
+	m.history.Entries = filtered
+	m.mu.Unlock()
 	return m.SaveHistory()
 }
 
-// GetHistoryItem returns the item at the specified index, or 0 if out of bounds
+// GetHistoryItem returns the item ID at the specified index, or 0 if out of bounds
 func (m *Manager) GetHistoryItem(index int) int {
-	if index >= 0 && index < len(m.history.NavigationHistory) {
-		return m.history.NavigationHistory[index]
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if index >= 0 && index < len(m.history.Entries) {
+		return m.history.Entries[index].ID
 	}
 	return 0
 }
 
 // GetHistoryLength returns the number of items in history
 func (m *Manager) GetHistoryLength() int {
-	return len(m.history.NavigationHistory)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.history.Entries)
 }
+
 func (m *Manager) UpdateLastView(view string) error {
+	m.mu.Lock()
 	m.settings.LastView = view
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateRevealMarkdown updates the reveal markdown setting
 func (m *Manager) UpdateRevealMarkdown(reveal bool) error {
+	m.mu.Lock()
 	m.settings.RevealMarkdown = reveal
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateOutgoingCollapsed updates the outgoing collapsed setting
 func (m *Manager) UpdateOutgoingCollapsed(collapsed bool) error {
+	m.mu.Lock()
 	m.settings.Collapsed.Outgoing = collapsed
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateIncomingCollapsed updates the incoming collapsed setting
 func (m *Manager) UpdateIncomingCollapsed(collapsed bool) error {
+	m.mu.Lock()
 	m.settings.Collapsed.Incoming = collapsed
+	m.mu.Unlock()
 	return m.Save()
 }
 
-// LoadSearch reads search data from disk
+// LoadSearch reads search data from the store
 func (m *Manager) LoadSearch() error {
-	data, err := os.ReadFile(m.searchPath)
+	data, err := m.store.Get(searchKey)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist yet, use defaults
+		if errors.Is(err, ErrNotFound) {
+			// Nothing stored yet, use defaults
 			return nil
 		}
 		return fmt.Errorf("failed to read search data: %w", err)
 	}
 
-	if err := json.Unmarshal(data, m.search); err != nil {
+	data, err = applyMigrations(m.store, searchKey, data, searchMigrations)
+	if err != nil {
+		return fmt.Errorf("failed to migrate search data: %w", err)
+	}
+
+	var loaded Search
+	if err := json.Unmarshal(data, &loaded); err != nil {
 		return fmt.Errorf("failed to parse search data: %w", err)
 	}
 
+	m.mu.Lock()
+	*m.search = loaded
+	m.mu.Unlock()
 	return nil
 }
 
-// SaveSearch writes search data to disk
+// SaveSearch writes search data to the store.
 func (m *Manager) SaveSearch() error {
+	m.mu.RLock()
 	data, err := json.MarshalIndent(m.search, "", "  ")
+	m.mu.RUnlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal search data: %w", err)
 	}
 
-	if err := os.WriteFile(m.searchPath, data, 0644); err != nil {
+	if err := m.store.Put(searchKey, data); err != nil {
 		return fmt.Errorf("failed to write search data: %w", err)
 	}
-
 	return nil
 }
 
 // GetRecentSearches returns the recent searches list
 func (m *Manager) GetRecentSearches() []string {
-	return m.search.RecentSearches
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.search.RecentSearches...)
 }
 
 // GetSavedSearches returns the saved searches list
 func (m *Manager) GetSavedSearches() []SavedSearch {
-	return m.search.SavedSearches
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]SavedSearch(nil), m.search.SavedSearches...)
 }
 
 // AddRecentSearch adds a search term to recent searches (most recent first, max 50)
@@ -371,6 +702,7 @@ func (m *Manager) AddRecentSearch(term string) error {
 		return nil
 	}
 
+	m.mu.Lock()
 	// Remove if already exists (to move to front)
 	filtered := make([]string, 0, len(m.search.RecentSearches))
 	for _, s := range m.search.RecentSearches {
@@ -386,12 +718,14 @@ func (m *Manager) AddRecentSearch(term string) error {
 	if len(m.search.RecentSearches) > 50 {
 		m.search.RecentSearches = m.search.RecentSearches[:50]
 	}
+	m.mu.Unlock()
 
 	return m.SaveSearch()
 }
 
 // RemoveRecentSearch removes a search term from recent searches
 func (m *Manager) RemoveRecentSearch(term string) error {
+	m.mu.Lock()
 	filtered := make([]string, 0, len(m.search.RecentSearches))
 	for _, s := range m.search.RecentSearches {
 		if s != term {
@@ -399,41 +733,51 @@ func (m *Manager) RemoveRecentSearch(term string) error {
 		}
 	}
 	m.search.RecentSearches = filtered
+	m.mu.Unlock()
 	return m.SaveSearch()
 }
 
-// AddSavedSearch saves a named search for later recall
-func (m *Manager) AddSavedSearch(name, query string, types []string, source string) error {
+// AddSavedSearch saves a named search for later recall, under folderPath
+// (e.g. "work/ethereum/txs"; "" for the root folder) and tagged with tags.
+func (m *Manager) AddSavedSearch(name, query string, types []string, source, folderPath string, tags []string) error {
 	if name == "" || query == "" {
 		return fmt.Errorf("name and query are required")
 	}
 
-	// Check if already exists and update
+	m.mu.Lock()
+	updated := false
 	for i, saved := range m.search.SavedSearches {
 		if saved.Name == name {
 			m.search.SavedSearches[i] = SavedSearch{
-				Name:   name,
-				Query:  query,
-				Types:  types,
-				Source: source,
+				Name:       name,
+				Query:      query,
+				Types:      types,
+				Source:     source,
+				FolderPath: folderPath,
+				Tags:       tags,
 			}
-			return m.SaveSearch()
+			updated = true
+			break
 		}
 	}
-
-	// Add new saved search
-	m.search.SavedSearches = append(m.search.SavedSearches, SavedSearch{
-		Name:   name,
-		Query:  query,
-		Types:  types,
-		Source: source,
-	})
+	if !updated {
+		m.search.SavedSearches = append(m.search.SavedSearches, SavedSearch{
+			Name:       name,
+			Query:      query,
+			Types:      types,
+			Source:     source,
+			FolderPath: folderPath,
+			Tags:       tags,
+		})
+	}
+	m.mu.Unlock()
 
 	return m.SaveSearch()
 }
 
 // DeleteSavedSearch removes a saved search by name
 func (m *Manager) DeleteSavedSearch(name string) error {
+	m.mu.Lock()
 	filtered := make([]SavedSearch, 0, len(m.search.SavedSearches))
 	for _, saved := range m.search.SavedSearches {
 		if saved.Name != name {
@@ -441,23 +785,173 @@ func (m *Manager) DeleteSavedSearch(name string) error {
 		}
 	}
 	m.search.SavedSearches = filtered
+	m.mu.Unlock()
+	return m.SaveSearch()
+}
+
+// ListSavedSearches returns saved searches under folder ("" means the
+// root), in one of two views: mode "list" returns every saved search in
+// folder's subtree as a flat, name-sorted slice of leaf nodes; mode "tree"
+// nests them under their intervening folder names instead, depth-first and
+// name-sorted at each level. Borrowed from the folder-scoped,
+// mode-switchable pattern dashboard search UIs use once a flat list of
+// saved items gets too long to scan.
+func (m *Manager) ListSavedSearches(mode string, folder string) ([]SavedSearchNode, error) {
+	m.mu.RLock()
+	saved := append([]SavedSearch(nil), m.search.SavedSearches...)
+	m.mu.RUnlock()
+
+	var inScope []SavedSearch
+	for _, s := range saved {
+		if savedSearchFolderContains(folder, s.FolderPath) {
+			inScope = append(inScope, s)
+		}
+	}
+
+	switch mode {
+	case "list":
+		sort.Slice(inScope, func(i, j int) bool { return inScope[i].Name < inScope[j].Name })
+		nodes := make([]SavedSearchNode, len(inScope))
+		for i, s := range inScope {
+			s := s
+			nodes[i] = SavedSearchNode{Name: s.Name, Search: &s}
+		}
+		return nodes, nil
+	case "tree":
+		return buildSavedSearchTree(inScope, folder), nil
+	default:
+		return nil, fmt.Errorf("unknown saved search list mode %q: must be \"list\" or \"tree\"", mode)
+	}
+}
+
+// savedSearchFolderContains reports whether path is folder itself or
+// somewhere in folder's subtree. The root folder ("") contains everything.
+func savedSearchFolderContains(folder, path string) bool {
+	if folder == "" {
+		return true
+	}
+	return path == folder || strings.HasPrefix(path, folder+"/")
+}
+
+// savedSearchFolderNode accumulates the saved searches and child folders
+// found under one folder path while buildSavedSearchTree walks inScope, so
+// the tree can be assembled in a single pass before being flattened (and
+// sorted) into the SavedSearchNode shape callers see.
+type savedSearchFolderNode struct {
+	name     string
+	children map[string]*savedSearchFolderNode
+	leaves   []SavedSearch
+}
+
+func newSavedSearchFolderNode(name string) *savedSearchFolderNode {
+	return &savedSearchFolderNode{name: name, children: make(map[string]*savedSearchFolderNode)}
+}
+
+func (f *savedSearchFolderNode) flatten() []SavedSearchNode {
+	nodes := make([]SavedSearchNode, 0, len(f.children)+len(f.leaves))
+	for _, child := range f.children {
+		nodes = append(nodes, SavedSearchNode{
+			Name:     child.name,
+			IsFolder: true,
+			Children: child.flatten(),
+		})
+	}
+	for _, s := range f.leaves {
+		s := s
+		nodes = append(nodes, SavedSearchNode{Name: s.Name, Search: &s})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
+
+// buildSavedSearchTree nests searches (all already known to be within
+// folder's subtree) under their intervening folder path segments, relative
+// to folder.
+func buildSavedSearchTree(searches []SavedSearch, folder string) []SavedSearchNode {
+	root := newSavedSearchFolderNode("")
+
+	for _, s := range searches {
+		rel := strings.Trim(strings.TrimPrefix(s.FolderPath, folder), "/")
+		cur := root
+		if rel != "" {
+			for _, part := range strings.Split(rel, "/") {
+				child, ok := cur.children[part]
+				if !ok {
+					child = newSavedSearchFolderNode(part)
+					cur.children[part] = child
+				}
+				cur = child
+			}
+		}
+		cur.leaves = append(cur.leaves, s)
+	}
+
+	return root.flatten()
+}
+
+// MoveSavedSearch changes name's folder to newFolder, returning an error if
+// no saved search with that name exists.
+func (m *Manager) MoveSavedSearch(name, newFolder string) error {
+	m.mu.Lock()
+	found := false
+	for i, s := range m.search.SavedSearches {
+		if s.Name == name {
+			m.search.SavedSearches[i].FolderPath = newFolder
+			found = true
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("saved search %q not found", name)
+	}
+	return m.SaveSearch()
+}
+
+// RenameFolder renames every saved search's FolderPath from old to new,
+// including ones in a subfolder of old, e.g. renaming "work" to "archive"
+// also moves "work/ethereum/txs" to "archive/ethereum/txs".
+func (m *Manager) RenameFolder(old, new string) error {
+	m.mu.Lock()
+	renamed := 0
+	for i, s := range m.search.SavedSearches {
+		switch {
+		case s.FolderPath == old:
+			m.search.SavedSearches[i].FolderPath = new
+			renamed++
+		case strings.HasPrefix(s.FolderPath, old+"/"):
+			m.search.SavedSearches[i].FolderPath = new + strings.TrimPrefix(s.FolderPath, old)
+			renamed++
+		}
+	}
+	m.mu.Unlock()
+
+	if renamed == 0 {
+		return fmt.Errorf("no saved searches found under folder %q", old)
+	}
 	return m.SaveSearch()
 }
 
 // UpdateReportLinkIntegrityCollapsed updates the link integrity report collapsed state
 func (m *Manager) UpdateReportLinkIntegrityCollapsed(collapsed bool) error {
+	m.mu.Lock()
 	m.settings.Collapsed.LinkIntegrity = collapsed
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateReportItemHealthCollapsed updates the item health report collapsed state
 func (m *Manager) UpdateReportItemHealthCollapsed(collapsed bool) error {
+	m.mu.Lock()
 	m.settings.Collapsed.ItemHealth = collapsed
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateTableSort updates the sorting state for a table
 func (m *Manager) UpdateTableSort(tableName, field1, dir1, field2, dir2 string) error {
+	m.mu.Lock()
 	if m.settings.TableSorts == nil {
 		m.settings.TableSorts = make(map[string]TableSort)
 	}
@@ -467,17 +961,129 @@ func (m *Manager) UpdateTableSort(tableName, field1, dir1, field2, dir2 string)
 		Field2: field2,
 		Dir2:   dir2,
 	}
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateCurrentSearch updates the current table search query
 func (m *Manager) UpdateCurrentSearch(query string) error {
+	m.mu.Lock()
 	m.settings.CurrentSearch = query
+	m.mu.Unlock()
 	return m.Save()
 }
 
 // UpdateExportFolder updates the export folder path
 func (m *Manager) UpdateExportFolder(folder string) error {
+	m.mu.Lock()
 	m.settings.ExportFolder = folder
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateCacheLimits updates and saves the TTS/image cache limits
+func (m *Manager) UpdateCacheLimits(limits CacheLimits) error {
+	m.mu.Lock()
+	m.settings.CacheLimits = limits
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateLazyCacheLimit updates and saves the lazy-loaded item text cache's
+// byte ceiling
+func (m *Manager) UpdateLazyCacheLimit(maxBytes int64) error {
+	m.mu.Lock()
+	m.settings.CacheLimits.LazyMaxBytes = maxBytes
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateLogging updates and saves the app.log rotation parameters
+func (m *Manager) UpdateLogging(logging Logging) error {
+	m.mu.Lock()
+	m.settings.Logging = logging
+	m.mu.Unlock()
 	return m.Save()
 }
+
+// UpdateExportTemplate updates and saves the default export template name
+func (m *Manager) UpdateExportTemplate(name string) error {
+	m.mu.Lock()
+	m.settings.ExportTemplate = name
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// Watch starts watching settings.json for changes - made by another process
+// (e.g. a second instance of the app sharing a config directory synced by
+// Syncthing), by a hand edit, or by this Manager's own Save, which is not
+// filtered out - and reloads it into the Manager whenever that happens, emitting the reloaded
+// settings on the returned channel. The watch, and the channel, stop when
+// ctx is canceled.
+//
+// Watch only works for a Manager backed by a FileStore: it relies on
+// fsnotify, which has no equivalent for a SQLiteStore or MemStore. It
+// watches the config directory rather than the file itself because
+// FileStore.Put (used by Save, here and by any other process doing the same
+// thing) replaces the file via rename rather than writing it in place, and a
+// rename-based write is only reliably observed as an event on its
+// containing directory.
+func (m *Manager) Watch(ctx context.Context) (<-chan SettingsChangedEvent, error) {
+	fileStore, ok := m.store.(*FileStore)
+	if !ok {
+		return nil, fmt.Errorf("Watch requires a FileStore-backed Manager, got %T", m.store)
+	}
+	settingsPath := fileStore.Path(settingsKey)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start settings watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(settingsPath)); err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("failed to watch config directory: %w", err)
+	}
+
+	events := make(chan SettingsChangedEvent, 1)
+
+	go func() {
+		defer watcher.Close()
+		defer close(events)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != settingsPath {
+					continue
+				}
+				if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				if err := m.Load(); err != nil {
+					slog.Warn("failed to reload settings after external change", "error", err)
+					continue
+				}
+				select {
+				case events <- SettingsChangedEvent{Settings: m.Get()}:
+				default:
+					// Drop the event if the previous one hasn't been
+					// consumed yet, rather than blocking the watch loop.
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("settings watcher error", "error", err)
+			}
+		}
+	}()
+
+	return events, nil
+}
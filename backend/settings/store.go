@@ -0,0 +1,173 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by Store.Get when key has no value.
+var ErrNotFound = errors.New("settings: key not found")
+
+// Store is the persistence backend behind Manager. Keys are flat names like
+// "settings.json", "search.json", or a migration backup like
+// "history.json.v1.bak" - implementations are free to map them onto files,
+// database rows, or an in-memory map however suits the backend.
+type Store interface {
+	// Get returns the bytes stored under key, or ErrNotFound if key has
+	// never been written.
+	Get(key string) ([]byte, error)
+	// Put writes data under key, replacing any existing value.
+	Put(key string, data []byte) error
+	// Delete removes key. It is not an error for key not to exist.
+	Delete(key string) error
+	// List returns every key with the given prefix, in no particular order.
+	List(prefix string) ([]string, error)
+}
+
+// FileStore is a Store backed by one file per key inside a base directory -
+// the layout Manager has always used on disk: settings.json, search.json,
+// history.json, plus the migration backups applyMigrations writes alongside
+// them.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore rooted at dir, creating dir if it doesn't
+// already exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Path returns the filesystem path key is stored at. It exists for callers
+// like Manager.Watch that need to match fsnotify events against a file;
+// other Store implementations have no filesystem path to expose.
+func (f *FileStore) Path(key string) string {
+	return filepath.Join(f.dir, key)
+}
+
+func (f *FileStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(f.Path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Put writes data to key's file by first writing it to a temp file in the
+// same directory and then renaming it into place, so a crash or power loss
+// between the write and the rename can never leave the file truncated or
+// half-written - the rename is atomic on every platform this app ships for.
+func (f *FileStore) Put(key string, data []byte) error {
+	path := f.Path(key)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }() // no-op once the rename below succeeds
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return fmt.Errorf("failed to set temp file permissions: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename into place: %w", err)
+	}
+	return nil
+}
+
+func (f *FileStore) Delete(key string) error {
+	if err := os.Remove(f.Path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (f *FileStore) List(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list store directory: %w", err)
+	}
+
+	var keys []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) {
+			keys = append(keys, e.Name())
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// MemStore is an in-memory Store, for exercising Manager and the rest of the
+// settings package in tests without touching $HOME or any real filesystem.
+type MemStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemStore creates an empty MemStore.
+func NewMemStore() *MemStore {
+	return &MemStore{data: make(map[string][]byte)}
+}
+
+func (s *MemStore) Get(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	return cp, nil
+}
+
+func (s *MemStore) Put(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	s.data[key] = cp
+	return nil
+}
+
+func (s *MemStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func (s *MemStore) List(prefix string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []string
+	for k := range s.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
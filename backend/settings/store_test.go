@@ -0,0 +1,155 @@
+package settings
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreGetPutDelete(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore returned error: %v", err)
+	}
+
+	if _, err := store.Get("settings.json"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for missing key, got %v", err)
+	}
+
+	if err := store.Put("settings.json", []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	data, err := store.Get("settings.json")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if string(data) != `{"a":1}` {
+		t.Errorf("expected stored bytes back, got %q", data)
+	}
+
+	if err := store.Delete("settings.json"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := store.Get("settings.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after Delete, got %v", err)
+	}
+}
+
+func TestFileStoreList(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore returned error: %v", err)
+	}
+
+	_ = store.Put("settings.json", []byte("{}"))
+	_ = store.Put("settings.json.v0.bak", []byte("{}"))
+	_ = store.Put("search.json", []byte("{}"))
+
+	keys, err := store.List("settings.json")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	want := []string{"settings.json", "settings.json.v0.bak"}
+	if len(keys) != len(want) {
+		t.Fatalf("expected %v, got %v", want, keys)
+	}
+	for i, k := range want {
+		if keys[i] != k {
+			t.Errorf("expected %v, got %v", want, keys)
+			break
+		}
+	}
+}
+
+func TestFileStorePath(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore returned error: %v", err)
+	}
+	if got, want := store.Path("settings.json"), filepath.Join(dir, "settings.json"); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+}
+
+func TestMemStoreGetPutDelete(t *testing.T) {
+	store := NewMemStore()
+
+	if _, err := store.Get("k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for missing key, got %v", err)
+	}
+
+	if err := store.Put("k", []byte("v1")); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+	data, err := store.Get("k")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if string(data) != "v1" {
+		t.Errorf("expected %q, got %q", "v1", data)
+	}
+
+	// Mutating the returned slice must not corrupt the store's copy.
+	data[0] = 'x'
+	data2, _ := store.Get("k")
+	if string(data2) != "v1" {
+		t.Errorf("Get did not return an independent copy: %q", data2)
+	}
+
+	if err := store.Delete("k"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := store.Get("k"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after Delete, got %v", err)
+	}
+}
+
+func TestMemStoreList(t *testing.T) {
+	store := NewMemStore()
+	_ = store.Put("settings.json", []byte("{}"))
+	_ = store.Put("settings.json.v0.bak", []byte("{}"))
+	_ = store.Put("search.json", []byte("{}"))
+
+	keys, err := store.List("settings.json")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	want := []string{"settings.json", "settings.json.v0.bak"}
+	if len(keys) != len(want) {
+		t.Fatalf("expected %v, got %v", want, keys)
+	}
+	for i, k := range want {
+		if keys[i] != k {
+			t.Errorf("expected %v, got %v", want, keys)
+			break
+		}
+	}
+}
+
+func TestNewManagerWithMemStore(t *testing.T) {
+	store := NewMemStore()
+
+	mgr, err := NewManagerWithStore(store)
+	if err != nil {
+		t.Fatalf("NewManagerWithStore returned error: %v", err)
+	}
+
+	if err := mgr.UpdateLastView("graph"); err != nil {
+		t.Fatalf("UpdateLastView returned error: %v", err)
+	}
+
+	if _, err := store.Get(settingsKey); err != nil {
+		t.Fatalf("expected settings to be persisted to the store: %v", err)
+	}
+
+	reloaded, err := NewManagerWithStore(store)
+	if err != nil {
+		t.Fatalf("NewManagerWithStore returned error: %v", err)
+	}
+	if got := reloaded.Get().LastView; got != "graph" {
+		t.Errorf("expected reloaded LastView %q, got %q", "graph", got)
+	}
+}
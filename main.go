@@ -6,8 +6,8 @@ import (
 	"os"
 
 	"github.com/TrueBlocks/trueblocks-poetry/backend/settings"
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
 	applogger "github.com/TrueBlocks/trueblocks-poetry/pkg/logger"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
 
 	"github.com/joho/godotenv"
 	"github.com/wailsapp/wails/v2"
@@ -33,7 +33,7 @@ func main() {
 	if err := godotenv.Load(envPath); err != nil {
 		slog.Info("No .env at path, trying fallback location...", "path", envPath)
 		// If not found in current directory, try config folder
-		fallbackPath, err := constants.GetEnvPath()
+		fallbackPath, err := paths.EnvPath()
 		if err != nil {
 			slog.Error("Could not determine config directory", "error", err)
 		} else {
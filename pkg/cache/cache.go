@@ -0,0 +1,320 @@
+// Package cache provides a size- and count-bounded on-disk cache with LRU
+// eviction, used by the TTS and image subsystems to keep their cache
+// directories from growing without bound.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// lowWaterRatio is the fraction of the high-water mark eviction stops at.
+const lowWaterRatio = 0.8
+
+// indexFileName is the name of the on-disk index file within the cache directory.
+const indexFileName = "index.json"
+
+// entry describes a single cached file.
+type entry struct {
+	Size      int64     `json:"size"`
+	LastUsed  time.Time `json:"lastUsed"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// EvictionReport summarizes the result of a prune pass.
+type EvictionReport struct {
+	EvictedFiles []string `json:"evictedFiles"`
+	BytesFreed   int64    `json:"bytesFreed"`
+	Reason       string   `json:"reason"`
+}
+
+// BoundedCache is a directory of files kept under a byte and file-count cap,
+// with optional max-age expiry, evicted in ascending last-used order.
+type BoundedCache struct {
+	dir      string
+	maxBytes int64
+	maxFiles int
+	maxAge   time.Duration
+
+	mu       sync.Mutex
+	index    map[string]entry
+	inFlight map[string]bool
+}
+
+// NewBoundedCache creates a BoundedCache rooted at dir, loading the on-disk
+// index if present or rebuilding it from the directory contents otherwise.
+func NewBoundedCache(dir string, maxBytes int64, maxFiles int) (*BoundedCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c := &BoundedCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		index:    make(map[string]entry),
+		inFlight: make(map[string]bool),
+	}
+
+	if err := c.loadIndex(); err != nil {
+		slog.Warn("[cache] Failed to load index, rebuilding from disk", "dir", dir, "error", err)
+		if err := c.rebuildIndex(); err != nil {
+			return nil, fmt.Errorf("failed to rebuild index: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// SetLimits updates the high-water marks enforced on future writes and prunes.
+func (c *BoundedCache) SetLimits(maxBytes int64, maxFiles int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxBytes = maxBytes
+	c.maxFiles = maxFiles
+}
+
+// SetMaxAge sets the max-age policy; entries older than maxAge are evicted on Prune
+// regardless of the size/count usage. A zero value disables age-based eviction.
+func (c *BoundedCache) SetMaxAge(maxAge time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxAge = maxAge
+}
+
+// Path returns the on-disk path for a cache entry name.
+func (c *BoundedCache) Path(name string) string {
+	return filepath.Join(c.dir, name)
+}
+
+// Write stores data under name, updates the index, and enforces the
+// configured limits. The name is marked in-flight for the duration of the
+// write so a concurrent Prune can't delete a file still being written.
+func (c *BoundedCache) Write(name string, data []byte) (*EvictionReport, error) {
+	c.mu.Lock()
+	c.inFlight[name] = true
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.inFlight, name)
+		c.mu.Unlock()
+	}()
+
+	if err := os.WriteFile(c.Path(name), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write cache file %s: %w", name, err)
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	prev, existed := c.index[name]
+	createdAt := now
+	if existed {
+		createdAt = prev.CreatedAt
+	}
+	c.index[name] = entry{Size: int64(len(data)), LastUsed: now, CreatedAt: createdAt}
+	c.mu.Unlock()
+
+	if err := c.saveIndex(); err != nil {
+		slog.Warn("[cache] Failed to save index after write", "dir", c.dir, "error", err)
+	}
+
+	return c.Prune()
+}
+
+// Touch records a read/access of name, refreshing its LastUsed time.
+func (c *BoundedCache) Touch(name string) {
+	c.mu.Lock()
+	e, ok := c.index[name]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	e.LastUsed = time.Now()
+	c.index[name] = e
+	c.mu.Unlock()
+
+	if err := c.saveIndex(); err != nil {
+		slog.Warn("[cache] Failed to save index after touch", "dir", c.dir, "error", err)
+	}
+}
+
+// Remove deletes name from disk and the index, tolerating a missing file.
+func (c *BoundedCache) Remove(name string) error {
+	if err := os.Remove(c.Path(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cache file %s: %w", name, err)
+	}
+
+	c.mu.Lock()
+	delete(c.index, name)
+	c.mu.Unlock()
+
+	return c.saveIndex()
+}
+
+// Prune evicts entries in ascending LastUsed order until usage is at or below
+// the low-water mark, then evicts any entries older than maxAge. Entries
+// currently being written are never evicted.
+func (c *BoundedCache) Prune() (*EvictionReport, error) {
+	c.mu.Lock()
+
+	type named struct {
+		name string
+		e    entry
+	}
+	names := make([]named, 0, len(c.index))
+	var totalBytes int64
+	for name, e := range c.index {
+		names = append(names, named{name, e})
+		totalBytes += e.Size
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].e.LastUsed.Before(names[j].e.LastUsed) })
+
+	report := &EvictionReport{}
+	lowBytes := int64(float64(c.maxBytes) * lowWaterRatio)
+	lowFiles := int(float64(c.maxFiles) * lowWaterRatio)
+
+	overLimit := (c.maxBytes > 0 && totalBytes > c.maxBytes) || (c.maxFiles > 0 && len(names) > c.maxFiles)
+	if overLimit {
+		for _, n := range names {
+			if (c.maxBytes <= 0 || totalBytes <= lowBytes) && (c.maxFiles <= 0 || len(c.index) <= lowFiles) {
+				break
+			}
+			if c.maxBytes <= 0 && c.maxFiles <= 0 {
+				break
+			}
+			if c.inFlight[n.name] {
+				continue
+			}
+			delete(c.index, n.name)
+			totalBytes -= n.e.Size
+			report.EvictedFiles = append(report.EvictedFiles, n.name)
+			report.BytesFreed += n.e.Size
+		}
+		if len(report.EvictedFiles) > 0 {
+			report.Reason = "size_limit"
+		}
+	}
+
+	// Max-age eviction runs regardless of usage.
+	if c.maxAge > 0 {
+		cutoff := time.Now().Add(-c.maxAge)
+		for name, e := range c.index {
+			if c.inFlight[name] {
+				continue
+			}
+			if e.CreatedAt.Before(cutoff) {
+				delete(c.index, name)
+				report.EvictedFiles = append(report.EvictedFiles, name)
+				report.BytesFreed += e.Size
+				if report.Reason == "" {
+					report.Reason = "max_age"
+				} else if report.Reason != "max_age" {
+					report.Reason = "size_limit+max_age"
+				}
+			}
+		}
+	}
+
+	c.mu.Unlock()
+
+	for _, name := range report.EvictedFiles {
+		if err := os.Remove(c.Path(name)); err != nil && !os.IsNotExist(err) {
+			slog.Warn("[cache] Failed to remove evicted file", "name", name, "error", err)
+		}
+	}
+
+	if len(report.EvictedFiles) > 0 {
+		if err := c.saveIndex(); err != nil {
+			slog.Warn("[cache] Failed to save index after prune", "dir", c.dir, "error", err)
+		}
+		slog.Info("[cache] Pruned cache", "dir", c.dir, "evicted", len(report.EvictedFiles), "bytesFreed", report.BytesFreed, "reason", report.Reason)
+	}
+
+	return report, nil
+}
+
+// loadIndex reads the on-disk index file into memory.
+func (c *BoundedCache) loadIndex() error {
+	data, err := os.ReadFile(filepath.Join(c.dir, indexFileName))
+	if err != nil {
+		return err
+	}
+
+	var idx map[string]entry
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.index = idx
+	c.mu.Unlock()
+	return nil
+}
+
+// saveIndex atomically writes the in-memory index to disk.
+func (c *BoundedCache) saveIndex() error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.index, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache index: %w", err)
+	}
+
+	path := filepath.Join(c.dir, indexFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace index: %w", err)
+	}
+	return nil
+}
+
+// rebuildIndex reconstructs the index from the files present on disk, used
+// when the index file is missing or unreadable.
+func (c *BoundedCache) rebuildIndex() error {
+	idx := make(map[string]entry)
+
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() == indexFileName {
+			return nil
+		}
+		idx[info.Name()] = entry{
+			Size:      info.Size(),
+			LastUsed:  info.ModTime(),
+			CreatedAt: info.ModTime(),
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.index = idx
+	c.mu.Unlock()
+
+	return c.saveIndex()
+}
+
+// Stats returns the current file count and total byte size tracked by the index.
+func (c *BoundedCache) Stats() (fileCount int, totalSize int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.index {
+		fileCount++
+		totalSize += e.Size
+	}
+	return fileCount, totalSize
+}
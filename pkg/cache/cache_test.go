@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWriteAndPruneBySize(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewBoundedCache(dir, 10, 0) // 10 bytes max
+	if err != nil {
+		t.Fatalf("NewBoundedCache() error = %v", err)
+	}
+
+	if _, err := c.Write("a", []byte("12345")); err != nil {
+		t.Fatalf("Write(a) error = %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := c.Write("b", []byte("12345")); err != nil {
+		t.Fatalf("Write(b) error = %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	report, err := c.Write("c", []byte("12345"))
+	if err != nil {
+		t.Fatalf("Write(c) error = %v", err)
+	}
+
+	if len(report.EvictedFiles) == 0 {
+		t.Fatalf("expected eviction after exceeding size limit, got none")
+	}
+
+	if _, err := os.Stat(c.Path("a")); !os.IsNotExist(err) {
+		t.Errorf("expected oldest entry %q to be evicted from disk", "a")
+	}
+}
+
+func TestRebuildIndexFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/orphan.mp3", []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+
+	c, err := NewBoundedCache(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("NewBoundedCache() error = %v", err)
+	}
+
+	count, size := c.Stats()
+	if count != 1 || size != 4 {
+		t.Errorf("Stats() = (%d, %d), want (1, 4)", count, size)
+	}
+}
+
+func TestMaxAgeEviction(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewBoundedCache(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("NewBoundedCache() error = %v", err)
+	}
+	c.SetMaxAge(time.Millisecond)
+
+	if _, err := c.Write("old", []byte("x")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	report, err := c.Prune()
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(report.EvictedFiles) != 1 || report.EvictedFiles[0] != "old" {
+		t.Errorf("expected max-age eviction of %q, got %v", "old", report.EvictedFiles)
+	}
+}
@@ -0,0 +1,247 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+)
+
+// dumpTemplatesSubdir is the folder (relative to the config directory) the
+// full-database-dump templates live in, separate from the per-item
+// Handlebars templates under templatesSubdir.
+const dumpTemplatesSubdir = "dump-templates"
+
+// ItemContext is the data handed to item.md.tmpl when rendering one entry
+// of the full-database dump.
+type ItemContext struct {
+	ItemID      int
+	Word        string
+	Type        string
+	HasImage    bool
+	ImagePath   string
+	HasTTS      bool
+	Definition  string
+	Derivation  string
+	Appendicies string
+	Source      string
+	SourcePg    string
+}
+
+// ReportContext is the data handed to report.md.tmpl when rendering one of
+// the data-quality reports (unlinked references, duplicates, ...).
+type ReportContext struct {
+	Title        string
+	Count        int
+	Headers      []string
+	Rows         [][]string
+	EmptyMessage string
+}
+
+// IndexContext is the data handed to index.md.tmpl when rendering the
+// dump's title/summary header.
+type IndexContext struct {
+	DatabasePath   string
+	ExportFolder   string
+	TotalItems     int
+	ReferenceCount int
+	WriterCount    int
+	TitleCount     int
+	OtherCount     int
+}
+
+// defaultDumpTemplates holds the built-in item/report/index templates, used
+// whenever the user hasn't saved an override under the same name.
+var defaultDumpTemplates = map[string]string{
+	"item.md.tmpl": `## {{.Word}}
+
+**Type:** {{.Type}}
+
+{{if .HasImage}}![{{.Word}}]({{.ImagePath}})
+
+{{end}}{{if .HasTTS}}🔊 **Has TTS**
+
+{{end}}{{if .Definition}}### Definition
+
+{{resolveRefs .Definition}}
+
+{{end}}{{if .Derivation}}### Etymology
+
+{{resolveRefs .Derivation}}
+
+{{end}}{{if .Appendicies}}### Notes
+
+{{resolveRefs .Appendicies}}
+
+{{end}}{{if .Source}}**Source:** {{resolveRefs .Source}}{{if .SourcePg}}, p. {{.SourcePg}}{{end}}
+
+{{end}}---
+
+`,
+	"report.md.tmpl": `## {{.Title}} ({{.Count}})
+
+{{if .Rows}}| {{join .Headers}} |
+|{{range .Headers}}---|{{end}}
+{{range .Rows}}| {{join .}} |
+{{end}}
+{{else}}✓ {{.EmptyMessage}}
+
+{{end}}`,
+	"index.md.tmpl": `# Poetry Database Export
+
+**Database Path:** {{.DatabasePath}}
+**Export Folder:** {{.ExportFolder}}
+
+**Total Items:** {{.TotalItems}}
+
+- References: {{.ReferenceCount}}
+- Writers: {{.WriterCount}}
+- Titles: {{.TitleCount}}
+- Other: {{.OtherCount}}
+
+`,
+}
+
+// DumpLoader reads user-editable text/template files from
+// <configDir>/dump-templates/, falling back to the built-in defaults above
+// when no override exists.
+type DumpLoader struct {
+	configDir string
+}
+
+// NewDumpLoader creates a DumpLoader rooted at configDir.
+func NewDumpLoader(configDir string) *DumpLoader {
+	return &DumpLoader{configDir: configDir}
+}
+
+func (l *DumpLoader) dir() (string, error) {
+	dir := filepath.Join(l.configDir, dumpTemplatesSubdir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create dump templates directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Load returns the template body for name (e.g. "item.md.tmpl"), preferring
+// a user override on disk and falling back to the built-in default.
+func (l *DumpLoader) Load(name string) (string, error) {
+	dir, err := l.dir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err == nil {
+		return string(data), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read dump template %s: %w", name, err)
+	}
+
+	if body, ok := defaultDumpTemplates[name]; ok {
+		return body, nil
+	}
+	return "", fmt.Errorf("no dump template named %q found and no default exists for it", name)
+}
+
+// Save writes body to disk as a user override for name.
+func (l *DumpLoader) Save(name, body string) error {
+	dir, err := l.dir()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+		return fmt.Errorf("failed to save dump template %s: %w", name, err)
+	}
+	return nil
+}
+
+// List returns the names of all available dump templates: user overrides on
+// disk plus any built-in defaults not yet overridden, sorted alphabetically.
+func (l *DumpLoader) List() ([]string, error) {
+	dir, err := l.dir()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump templates directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") {
+			continue
+		}
+		seen[entry.Name()] = true
+		names = append(names, entry.Name())
+	}
+	for name := range defaultDumpTemplates {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// DumpRenderer renders the typed item/report/index contexts above through
+// user-editable text/template files, calling into a TagRendererRegistry for
+// {word:}/{writer:}/{title:} expansion. This is the themeable presentation
+// layer for the full-database dump (ExportToMarkdown and friends), distinct
+// from the per-item Handlebars Renderer used by ExportItems.
+type DumpRenderer struct {
+	loader *DumpLoader
+	tags   *parser.TagRendererRegistry
+}
+
+// NewDumpRenderer creates a DumpRenderer backed by loader for template
+// resolution and tags for {word:}/{writer:}/{title:} expansion.
+func NewDumpRenderer(loader *DumpLoader, tags *parser.TagRendererRegistry) *DumpRenderer {
+	return &DumpRenderer{loader: loader, tags: tags}
+}
+
+func (r *DumpRenderer) render(templateName string, data interface{}) (string, error) {
+	body, err := r.loader.Load(templateName)
+	if err != nil {
+		return "", err
+	}
+
+	tpl, err := template.New(templateName).Funcs(template.FuncMap{
+		"resolveRefs": r.tags.ReplaceWith,
+		"join":        func(parts []string) string { return strings.Join(parts, " | ") },
+	}).Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse dump template %s: %w", templateName, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render dump template %s: %w", templateName, err)
+	}
+	return buf.String(), nil
+}
+
+// RenderItem renders ctx through item.md.tmpl.
+func (r *DumpRenderer) RenderItem(ctx ItemContext) (string, error) {
+	return r.render("item.md.tmpl", ctx)
+}
+
+// RenderReport renders ctx through report.md.tmpl.
+func (r *DumpRenderer) RenderReport(ctx ReportContext) (string, error) {
+	return r.render("report.md.tmpl", ctx)
+}
+
+// RenderIndex renders ctx through index.md.tmpl.
+func (r *DumpRenderer) RenderIndex(ctx IndexContext) (string, error) {
+	return r.render("index.md.tmpl", ctx)
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+)
+
+func TestDumpLoaderFallsBackToDefault(t *testing.T) {
+	loader := NewDumpLoader(t.TempDir())
+
+	body, err := loader.Load("item.md.tmpl")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if body != defaultDumpTemplates["item.md.tmpl"] {
+		t.Errorf("Load() returned unexpected body for default template")
+	}
+}
+
+func TestDumpLoaderSaveOverridesDefault(t *testing.T) {
+	loader := NewDumpLoader(t.TempDir())
+
+	custom := "custom {{.Word}}"
+	if err := loader.Save("item.md.tmpl", custom); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	body, err := loader.Load("item.md.tmpl")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if body != custom {
+		t.Errorf("Load() = %q, want %q", body, custom)
+	}
+}
+
+func TestDumpRendererRenderItemResolvesRefs(t *testing.T) {
+	loader := NewDumpLoader(t.TempDir())
+	renderer := NewDumpRenderer(loader, parser.NewMarkdownTagRenderers())
+
+	out, err := renderer.RenderItem(ItemContext{
+		Word:       "Hamlet",
+		Type:       "Title",
+		Definition: "written by {writer: shakespeare}",
+	})
+	if err != nil {
+		t.Fatalf("RenderItem() error = %v", err)
+	}
+	if !strings.Contains(out, "**<small>SHAKESPEARE</small>**") {
+		t.Errorf("RenderItem() did not resolve tags: %q", out)
+	}
+}
@@ -0,0 +1,66 @@
+// Package export renders database.Item values through user-editable
+// Handlebars-style templates into a cross-linked corpus of files.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// ExportItems renders items through templateName in the given format ("md",
+// "html", or "json") and writes the result into destFolder, returning the
+// path written. For md/html, each item is rendered to its own file named via
+// FilenameFor so cross-item links produced by the "link" and "resolve-refs"
+// helpers resolve to real files in the same folder. For json, all items are
+// marshaled into a single export.json file and templateName is ignored.
+func (r *Renderer) ExportItems(items []database.Item, templateName, format, destFolder string) (string, error) {
+	if err := os.MkdirAll(destFolder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export folder: %w", err)
+	}
+
+	switch format {
+	case "json":
+		return r.exportJSON(items, destFolder)
+	case "html", "md":
+		return r.exportTemplated(items, templateName, format, destFolder)
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func (r *Renderer) exportJSON(items []database.Item, destFolder string) (string, error) {
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal items: %w", err)
+	}
+
+	path := filepath.Join(destFolder, "export.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write export.json: %w", err)
+	}
+	return path, nil
+}
+
+func (r *Renderer) exportTemplated(items []database.Item, templateName, format, destFolder string) (string, error) {
+	for _, item := range items {
+		name := templateName
+		if name == "" {
+			name = item.Type
+		}
+
+		body, err := r.Render(item, name, format)
+		if err != nil {
+			return "", fmt.Errorf("failed to render item %d (%s): %w", item.ItemID, item.Word, err)
+		}
+
+		path := filepath.Join(destFolder, FilenameFor(item, format))
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return destFolder, nil
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+// ManifestFilename is the name of the incremental-export manifest written
+// into an export folder, recording what was rendered on the last run.
+const ManifestFilename = ".poetry-export-manifest.json"
+
+// ManifestEntry is the last-known rendered state of one item: the content
+// digest it was rendered from, the image's source mtime at copy time (so a
+// later image change is detected even though the item row didn't change),
+// and the rendered Markdown block itself so an unchanged item can be
+// spliced back in without re-running any template.
+type ManifestEntry struct {
+	Digest       string    `json:"digest"`
+	ImageModTime time.Time `json:"imageModTime,omitempty"`
+	Rendered     string    `json:"rendered"`
+}
+
+// Manifest maps item ID to its last-known rendered state.
+type Manifest struct {
+	Items map[int]ManifestEntry `json:"items"`
+}
+
+// LoadManifest reads the manifest at path, returning an empty Manifest (not
+// an error) if none exists yet.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Manifest{Items: make(map[int]ManifestEntry)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if m.Items == nil {
+		m.Items = make(map[int]ManifestEntry)
+	}
+	return &m, nil
+}
+
+// Save writes m to path as indented JSON.
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// ItemDigest hashes the fields of item that affect its rendered Markdown
+// output, so a digest mismatch means "this item needs re-rendering".
+func ItemDigest(item database.Item) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00", item.Word, item.Type,
+		item.Definition.GetOrEmpty(), item.Derivation.GetOrEmpty(), item.Appendicies.GetOrEmpty())
+	if item.Source != nil {
+		h.Write([]byte(*item.Source))
+	}
+	h.Write([]byte{0})
+	if item.SourcePg != nil {
+		h.Write([]byte(*item.SourcePg))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+func TestItemDigestChangesWithDefinition(t *testing.T) {
+	item := database.Item{ItemID: 1, Word: "Keats", Type: "Writer"}
+	item.Definition = database.NewLazyString(strPtr("a poet"))
+
+	before := ItemDigest(item)
+
+	item.Definition = database.NewLazyString(strPtr("a romantic poet"))
+	after := ItemDigest(item)
+
+	if before == after {
+		t.Errorf("expected digest to change when definition changes")
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ManifestFilename)
+
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest() on missing file error = %v", err)
+	}
+	m.Items[1] = ManifestEntry{Digest: "abc", Rendered: "## Keats\n"}
+
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if reloaded.Items[1].Digest != "abc" {
+		t.Errorf("Items[1].Digest = %q, want %q", reloaded.Items[1].Digest, "abc")
+	}
+}
+
+func strPtr(s string) *string { return &s }
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+	"github.com/aymerick/raymond"
+)
+
+// Renderer expands database.Item values through Handlebars-compatible
+// templates, resolving {word:}/{writer:}/{title:} tags into hyperlinks
+// that point at the deterministic filename of the destination item.
+type Renderer struct {
+	db     *database.DB
+	loader *TemplateLoader
+}
+
+// NewRenderer creates a Renderer backed by db for reference/link lookups
+// and loader for template resolution.
+func NewRenderer(db *database.DB, loader *TemplateLoader) *Renderer {
+	return &Renderer{db: db, loader: loader}
+}
+
+// slugPattern matches runs of characters that aren't safe in a filename.
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// FilenameFor returns the deterministic output filename for an item, used
+// both when linking to an item and when writing its rendered file.
+func FilenameFor(item database.Item, ext string) string {
+	slug := slugPattern.ReplaceAllString(strings.ToLower(item.Word), "-")
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		slug = "item"
+	}
+	return fmt.Sprintf("%s-%s-%d.%s", strings.ToLower(item.Type), slug, item.ItemID, ext)
+}
+
+// templateContext is the data handed to the Handlebars template for a single item.
+type templateContext struct {
+	ItemID     int    `json:"itemId"`
+	Word       string `json:"word"`
+	Type       string `json:"type"`
+	Definition string `json:"definition"`
+	Derivation string `json:"derivation"`
+	HasImage   bool   `json:"hasImage"`
+	ImagePath  string `json:"imagePath"`
+}
+
+// Render expands templateName against item, returning the rendered body.
+// ext is the output file extension used to build hyperlink targets (md or html).
+func (r *Renderer) Render(item database.Item, templateName string, ext string) (string, error) {
+	body, err := r.loader.Load(templateName)
+	if err != nil {
+		return "", err
+	}
+
+	tpl, err := raymond.Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", templateName, err)
+	}
+
+	r.registerHelpers(tpl, item, ext)
+
+	ctx := templateContext{
+		ItemID: item.ItemID,
+		Word:   item.Word,
+		Type:   item.Type,
+	}
+	ctx.Definition = item.Definition.GetOrEmpty()
+	ctx.Derivation = item.Derivation.GetOrEmpty()
+
+	out, err := tpl.Exec(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to render template %s for item %d: %w", templateName, item.ItemID, err)
+	}
+	return out, nil
+}
+
+// registerHelpers installs the domain helpers for this render pass. They close
+// over item/ext/r.db so each helper call can resolve links relative to the
+// item currently being rendered.
+func (r *Renderer) registerHelpers(tpl *raymond.Template, item database.Item, ext string) {
+	tpl.RegisterHelper("link", func(word string) raymond.SafeString {
+		dest, err := r.db.GetItemByWord(word)
+		if err != nil || dest == nil {
+			return raymond.SafeString(word)
+		}
+		return raymond.SafeString(fmt.Sprintf(`<a href="%s">%s</a>`, FilenameFor(*dest, ext), dest.Word))
+	})
+
+	tpl.RegisterHelper("resolve-refs", func(text string) raymond.SafeString {
+		return raymond.SafeString(parser.ReplaceTags(text, func(ref parser.Reference) string {
+			if ref.Type != "word" && ref.Type != "writer" && ref.Type != "title" {
+				return ref.Original
+			}
+			matchWord := database.StripPossessive(ref.Value)
+			dest, err := r.db.GetItemByWord(matchWord)
+			if err != nil || dest == nil {
+				return ref.Value
+			}
+			return fmt.Sprintf(`<a href="%s">%s</a>`, FilenameFor(*dest, ext), ref.Value)
+		}))
+	})
+
+	tpl.RegisterHelper("incoming", func() []database.Item {
+		return r.linkedItems(item.ItemID, true)
+	})
+
+	tpl.RegisterHelper("outgoing", func() []database.Item {
+		return r.linkedItems(item.ItemID, false)
+	})
+
+	tpl.RegisterHelper("cliches", func() []database.Cliche {
+		cliches, err := r.db.GetAllCliches()
+		if err != nil {
+			return nil
+		}
+		return cliches
+	})
+
+	tpl.RegisterHelper("stripPossessive", func(word string) string {
+		return database.StripPossessive(word)
+	})
+}
+
+// linkedItems returns the items linked to itemID, in the incoming (items that
+// link to it) or outgoing (items it links to) direction.
+func (r *Renderer) linkedItems(itemID int, incoming bool) []database.Item {
+	links, err := r.db.GetItemLinks(itemID)
+	if err != nil {
+		return nil
+	}
+
+	var items []database.Item
+	for _, link := range links {
+		var otherID int
+		if incoming && link.DestinationItemID == itemID {
+			otherID = link.SourceItemID
+		} else if !incoming && link.SourceItemID == itemID {
+			otherID = link.DestinationItemID
+		} else {
+			continue
+		}
+
+		item, err := r.db.GetItem(otherID)
+		if err != nil || item == nil {
+			continue
+		}
+		items = append(items, *item)
+	}
+	return items
+}
@@ -0,0 +1,142 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// templatesSubdir is the folder (relative to the config directory) user templates live in.
+const templatesSubdir = "templates"
+
+// defaultTemplates holds the built-in per-type templates, used whenever the
+// user hasn't saved an override under the same name.
+var defaultTemplates = map[string]string{
+	"Writer": `## {{word}}
+
+{{#if hasImage}}![{{word}}]({{imagePath}})
+{{/if}}
+{{{resolve-refs definition}}}
+
+### Poems
+{{#each incoming}}
+- {{link word}}
+{{/each}}
+`,
+	"Title": `## {{word}}
+
+{{{resolve-refs definition}}}
+
+**Written by:** {{#each outgoing}}{{link word}}{{/each}}
+`,
+	"Reference": `## {{word}}
+
+{{{resolve-refs definition}}}
+`,
+}
+
+// TemplateLoader reads user-editable .hbs templates from <configDir>/templates/,
+// falling back to the built-in per-type defaults when no override exists.
+type TemplateLoader struct {
+	configDir string
+}
+
+// NewTemplateLoader creates a TemplateLoader rooted at configDir.
+func NewTemplateLoader(configDir string) *TemplateLoader {
+	return &TemplateLoader{configDir: configDir}
+}
+
+// dir returns the templates directory, creating it if necessary.
+func (l *TemplateLoader) dir() (string, error) {
+	dir := filepath.Join(l.configDir, templatesSubdir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	return dir, nil
+}
+
+// path returns the on-disk path for a template name, adding the .hbs extension if missing.
+func (l *TemplateLoader) path(name string) (string, error) {
+	dir, err := l.dir()
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(name, ".hbs") {
+		name += ".hbs"
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Load returns the template body for name, preferring a user override on disk
+// and falling back to the built-in default for that type.
+func (l *TemplateLoader) Load(name string) (string, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return string(data), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+
+	if body, ok := defaultTemplates[name]; ok {
+		return body, nil
+	}
+
+	return "", fmt.Errorf("no template named %q found and no default exists for it", name)
+}
+
+// Save writes body to disk as a user override for name.
+func (l *TemplateLoader) Save(name, body string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		return fmt.Errorf("failed to save template %s: %w", name, err)
+	}
+	return nil
+}
+
+// List returns the names of all available templates: user overrides on disk
+// plus any built-in defaults not yet overridden, sorted alphabetically.
+func (l *TemplateLoader) List() ([]string, error) {
+	dir, err := l.dir()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".hbs") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ".hbs")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for name := range defaultTemplates {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
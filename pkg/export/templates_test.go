@@ -0,0 +1,46 @@
+package export
+
+import (
+	"testing"
+)
+
+func TestTemplateLoaderFallsBackToDefault(t *testing.T) {
+	loader := NewTemplateLoader(t.TempDir())
+
+	body, err := loader.Load("Reference")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if body != defaultTemplates["Reference"] {
+		t.Errorf("Load() returned unexpected body for default template")
+	}
+}
+
+func TestTemplateLoaderSaveOverridesDefault(t *testing.T) {
+	loader := NewTemplateLoader(t.TempDir())
+
+	custom := "custom body {{word}}"
+	if err := loader.Save("Reference", custom); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	body, err := loader.Load("Reference")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if body != custom {
+		t.Errorf("Load() = %q, want %q", body, custom)
+	}
+}
+
+func TestTemplateLoaderListIncludesDefaults(t *testing.T) {
+	loader := NewTemplateLoader(t.TempDir())
+
+	names, err := loader.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(names) != len(defaultTemplates) {
+		t.Errorf("List() returned %d names, want %d", len(names), len(defaultTemplates))
+	}
+}
@@ -0,0 +1,197 @@
+// Package fts5 turns a user-typed search string into a safe SQLite FTS5
+// MATCH expression. It supports bare/quoted terms, prefix matches
+// ("foo*"), NEAR/N proximity, column scoping ("word:", "definition:",
+// "derivation:", "appendicies:"), grouping with parens, and AND/OR/NOT
+// (case-insensitive, with the usual NOT > AND > OR precedence and
+// implicit AND between adjacent terms). Parse never fails - malformed
+// input (unbalanced quotes, a stray ':', trailing operators) degrades to
+// the closest reasonable tree rather than an error, so Build's output is
+// always valid FTS5 syntax.
+package fts5
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Fields lists the items_fts columns a term can be scoped to. A field
+// prefix naming anything else is treated as part of the term's literal
+// text instead of a column scope.
+var Fields = []string{"word", "definition", "derivation", "appendicies"}
+
+func isField(name string) bool {
+	for _, f := range Fields {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Kind identifies the shape of a Node.
+type Kind int
+
+const (
+	// KindTerm is a single bare or prefix-matched word (Text, optionally
+	// with Prefix set).
+	KindTerm Kind = iota
+	// KindPhrase is a quoted multi-word phrase (Text holds the phrase
+	// with its surrounding quotes stripped).
+	KindPhrase
+	// KindNear is a proximity match between two children, "a NEAR/N b".
+	KindNear
+	// KindAnd, KindOr, KindNot combine Children with FTS5's boolean
+	// operators. KindNot always has exactly one child (the negated term).
+	// It is usually a child of an And, but a query starting with NOT, or
+	// with NOT right after OR, puts it at the root or under an Or.
+	KindAnd
+	KindOr
+	KindNot
+	// KindGroup is a parenthesized sub-expression (single child).
+	KindGroup
+)
+
+// Node is one node of the parsed query AST. Which fields are meaningful
+// depends on Kind - see the Kind* constants above.
+type Node struct {
+	Kind     Kind
+	Text     string
+	Field    string // column scope ("" = unscoped), only set on Term/Phrase
+	Prefix   bool   // true if Text came from a trailing "*" (Term only)
+	Near     int    // proximity distance, KindNear only (default 10)
+	Children []*Node
+}
+
+// Parse tokenizes and parses query into an AST. It never returns an error:
+// unbalanced quotes run to end-of-input as a phrase, a field prefix with no
+// term after it becomes a literal term of the field name, an AND/OR/NEAR
+// with no operand before it becomes a literal term, and a dangling
+// AND/OR/NEAR/NOT at the end of the query is dropped.
+func Parse(query string) *Node {
+	p := &parser{tokens: lex(query)}
+	node := p.parseOr()
+	if node == nil {
+		return &Node{Kind: KindPhrase, Text: ""}
+	}
+	return node
+}
+
+// Build renders node as a parenthesized FTS5 MATCH expression string. Every
+// literal term and phrase is quoted with embedded quotes doubled, so the
+// result is safe to pass straight into a "... MATCH ?" bind parameter
+// regardless of what the original input contained.
+func Build(node *Node) string {
+	if node == nil {
+		return `""`
+	}
+	switch node.Kind {
+	case KindTerm:
+		return buildLiteral(node, false)
+	case KindPhrase:
+		return buildLiteral(node, true)
+	case KindNear:
+		near := node.Near
+		if near <= 0 {
+			near = 10
+		}
+		if len(node.Children) != 2 {
+			return buildChildren(node.Children, "AND")
+		}
+		return Build(node.Children[0]) + " NEAR/" + strconv.Itoa(near) + " " + Build(node.Children[1])
+	case KindNot:
+		if len(node.Children) == 0 {
+			return `""`
+		}
+		return "NOT " + Build(node.Children[0])
+	case KindAnd:
+		return buildChildren(node.Children, "AND")
+	case KindOr:
+		return buildChildren(node.Children, "OR")
+	case KindGroup:
+		if len(node.Children) == 0 {
+			return `""`
+		}
+		return "(" + Build(node.Children[0]) + ")"
+	default:
+		return `""`
+	}
+}
+
+func buildChildren(children []*Node, op string) string {
+	parts := make([]string, 0, len(children))
+	for _, c := range children {
+		s := Build(c)
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	if len(parts) == 0 {
+		return `""`
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, " "+op+" ") + ")"
+}
+
+func buildLiteral(node *Node, forcePrefixless bool) string {
+	quoted := quoteFTS5(node.Text)
+	if node.Prefix && !forcePrefixless {
+		quoted += "*"
+	}
+	if node.Field != "" && isField(node.Field) {
+		return strings.ToLower(node.Field) + ":" + quoted
+	}
+	return quoted
+}
+
+// quoteFTS5 double-quotes s for use as an FTS5 string literal, doubling any
+// embedded double quotes the way SQLite string literals require.
+func quoteFTS5(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// ScopeFields rewrites node so every Term/Phrase leaf that doesn't already
+// carry an explicit column scope (from a "word:" prefix in the original
+// query) is OR'd across fields instead - "sonnet" with fields
+// ["word","definition"] becomes "(word:sonnet OR definition:sonnet)". A leaf
+// that was already scoped is left untouched, so a query can mix Fields-wide
+// terms with one-off column overrides. Returns node unchanged if fields is
+// empty.
+func ScopeFields(node *Node, fields []string) *Node {
+	if node == nil || len(fields) == 0 {
+		return node
+	}
+	switch node.Kind {
+	case KindTerm, KindPhrase:
+		if node.Field != "" {
+			return node
+		}
+		clones := make([]*Node, len(fields))
+		for i, f := range fields {
+			clone := *node
+			clone.Field = f
+			clones[i] = &clone
+		}
+		if len(clones) == 1 {
+			return clones[0]
+		}
+		return &Node{Kind: KindOr, Children: clones}
+	case KindNot, KindGroup:
+		return &Node{Kind: node.Kind, Children: scopeFieldsChildren(node.Children, fields)}
+	case KindNear:
+		return &Node{Kind: node.Kind, Near: node.Near, Children: scopeFieldsChildren(node.Children, fields)}
+	case KindAnd, KindOr:
+		return &Node{Kind: node.Kind, Children: scopeFieldsChildren(node.Children, fields)}
+	default:
+		return node
+	}
+}
+
+func scopeFieldsChildren(children []*Node, fields []string) []*Node {
+	scoped := make([]*Node, len(children))
+	for i, c := range children {
+		scoped[i] = ScopeFields(c, fields)
+	}
+	return scoped
+}
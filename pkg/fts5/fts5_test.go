@@ -0,0 +1,162 @@
+package fts5
+
+import "testing"
+
+func TestBuildBareTerm(t *testing.T) {
+	if got, want := Build(Parse("keats")), `"keats"`; got != want {
+		t.Errorf("Build(Parse(%q)) = %q, want %q", "keats", got, want)
+	}
+}
+
+func TestBuildImplicitAnd(t *testing.T) {
+	got := Build(Parse("keats nightingale"))
+	want := `("keats" AND "nightingale")`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildExplicitOr(t *testing.T) {
+	got := Build(Parse("keats or shelley"))
+	want := `("keats" OR "shelley")`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildNot(t *testing.T) {
+	got := Build(Parse("keats not shelley"))
+	want := `("keats" AND NOT "shelley")`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildFieldScope(t *testing.T) {
+	got := Build(Parse("word:sonnet and definition:keats"))
+	want := `(word:"sonnet" AND definition:"keats")`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildUnknownFieldFallsBackToLiteral(t *testing.T) {
+	// "genre" isn't one of Fields (word/definition/derivation/appendicies),
+	// so the scope is dropped and the term stays a plain literal.
+	got := Build(Parse("word:keats"))
+	want := `word:"keats"`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = Build(Parse("genre:sonnet"))
+	want = `"sonnet"`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScopeFields(t *testing.T) {
+	got := Build(ScopeFields(Parse("sonnet"), []string{"word", "definition"}))
+	want := `(word:"sonnet" OR definition:"sonnet")`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScopeFieldsLeavesExplicitScopeAlone(t *testing.T) {
+	got := Build(ScopeFields(Parse("definition:sonnet"), []string{"word"}))
+	want := `definition:"sonnet"`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScopeFieldsRecursesThroughBooleans(t *testing.T) {
+	got := Build(ScopeFields(Parse("keats and shelley"), []string{"word"}))
+	want := `(word:"keats" AND word:"shelley")`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildPrefixMatch(t *testing.T) {
+	got := Build(Parse("night*"))
+	want := `"night"*`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildPhrase(t *testing.T) {
+	got := Build(Parse(`"ode to a nightingale"`))
+	want := `"ode to a nightingale"`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildNear(t *testing.T) {
+	got := Build(Parse("keats NEAR/5 nightingale"))
+	want := `"keats" NEAR/5 "nightingale"`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildGroup(t *testing.T) {
+	got := Build(Parse("(keats or shelley) and sonnet"))
+	want := `((("keats" OR "shelley")) AND "sonnet")`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildEmptyQuery(t *testing.T) {
+	if got, want := Build(Parse("")), `""`; got != want {
+		t.Errorf("Build(Parse(%q)) = %q, want %q", "", got, want)
+	}
+}
+
+// TestBuildMalformedInputNeverPanics is a small fuzz-style corpus of
+// malformed queries - unbalanced quotes, stray colons, dangling operators,
+// backslashes - that must parse and build into *some* string without
+// panicking. The results are not run against SQLite, so whether each is
+// also valid syntax for a live FTS5 MATCH is not checked here.
+func TestBuildMalformedInputNeverPanics(t *testing.T) {
+	corpus := []string{
+		`"unterminated phrase`,
+		`word:`,
+		`:stray leading colon`,
+		`a:b:c`,
+		`keats AND`,
+		`AND keats`,
+		`OR`,
+		`NOT`,
+		`NEAR/`,
+		`keats NEAR/abc shelley`,
+		`(((unbalanced`,
+		`unbalanced)))`,
+		`\backslash\path`,
+		`"" `,
+		`***`,
+		`field:(a or b`,
+		"\t\n  ",
+		`title:"unterminated and more AND or not (`,
+	}
+
+	for _, q := range corpus {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Parse(%q) panicked: %v", q, r)
+				}
+			}()
+			node := Parse(q)
+			result := Build(node)
+			if result == "" {
+				t.Errorf("Build(Parse(%q)) returned an empty string, want at least %q", q, `""`)
+			}
+		}()
+	}
+}
@@ -0,0 +1,123 @@
+package fts5
+
+import (
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tEOF tokenKind = iota
+	tLParen
+	tRParen
+	tAnd
+	tOr
+	tNot
+	tNear
+	tField  // text = field name, column scope follows immediately
+	tString // text = phrase with quotes stripped
+	tWord   // text = bare word, prefix = trailing "*" seen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	prefix bool
+	near   int
+}
+
+func isBreak(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '(', ')', '"', ':':
+		return true
+	default:
+		return false
+	}
+}
+
+// lex tokenizes query for parser. It never fails: an unterminated quote
+// runs to end-of-input as a tString, and a lone break character with no
+// word before it (a leading ':', say) is simply skipped.
+func lex(query string) []token {
+	var toks []token
+	s := query
+	n := len(s)
+	i := 0
+
+	for i < n {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tLParen})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tRParen})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < n && s[j] != '"' {
+				j++
+			}
+			toks = append(toks, token{kind: tString, text: s[i+1 : j]})
+			if j < n {
+				i = j + 1
+			} else {
+				i = n
+			}
+		default:
+			j := i
+			for j < n && !isBreak(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			i = j
+			if word == "" {
+				// Lone break character (e.g. a stray ':') with nothing
+				// preceding it - drop it and move on.
+				i++
+				continue
+			}
+			if i < n && s[i] == ':' {
+				i++
+				toks = append(toks, token{kind: tField, text: word})
+				continue
+			}
+			toks = append(toks, wordToken(word))
+		}
+	}
+
+	toks = append(toks, token{kind: tEOF})
+	return toks
+}
+
+// wordToken classifies a bare, colon-free word as an AND/OR/NOT keyword, a
+// NEAR/N proximity operator, or a literal term (with a trailing "*"
+// stripped into prefix).
+func wordToken(word string) token {
+	switch upper := strings.ToUpper(word); upper {
+	case "AND":
+		return token{kind: tAnd}
+	case "OR":
+		return token{kind: tOr}
+	case "NOT":
+		return token{kind: tNot}
+	default:
+		if upper == "NEAR" || strings.HasPrefix(upper, "NEAR/") {
+			near := 10
+			if idx := strings.IndexByte(word, '/'); idx >= 0 {
+				if v, err := strconv.Atoi(word[idx+1:]); err == nil && v > 0 {
+					near = v
+				}
+			}
+			return token{kind: tNear, near: near}
+		}
+	}
+
+	if len(word) > 1 && word[len(word)-1] == '*' {
+		return token{kind: tWord, text: word[:len(word)-1], prefix: true}
+	}
+	return token{kind: tWord, text: word}
+}
@@ -0,0 +1,165 @@
+package fts5
+
+// parser is a recursive-descent parser over lex's token stream.
+// Precedence, loosest to tightest: OR, AND (including implicit AND
+// between adjacent terms), NEAR, NOT, then a primary (term, phrase, or
+// parenthesized group).
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func combine(left, right *Node, kind Kind) *Node {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	return &Node{Kind: kind, Children: []*Node{left, right}}
+}
+
+func (p *parser) parseOr() *Node {
+	left := p.parseAnd()
+	for p.peek().kind == tOr {
+		p.next()
+		left = combine(left, p.parseAnd(), KindOr)
+	}
+	return left
+}
+
+// parseAnd consumes an explicit "AND" or, for any token that could start a
+// new primary, an implicit one - "keats odes" means the same as
+// "keats AND odes".
+func (p *parser) parseAnd() *Node {
+	left := p.parseNear()
+	for {
+		switch p.peek().kind {
+		case tAnd:
+			p.next()
+			left = combine(left, p.parseNear(), KindAnd)
+		case tWord, tString, tField, tLParen, tNot:
+			left = combine(left, p.parseNear(), KindAnd)
+		default:
+			return left
+		}
+	}
+}
+
+func (p *parser) parseNear() *Node {
+	left := p.parseUnary()
+	for p.peek().kind == tNear {
+		t := p.next()
+		right := p.parseUnary()
+		if right == nil {
+			continue
+		}
+		if left == nil {
+			left = right
+			continue
+		}
+		left = &Node{Kind: KindNear, Near: t.near, Children: []*Node{left, right}}
+	}
+	return left
+}
+
+func (p *parser) parseUnary() *Node {
+	if p.peek().kind == tNot {
+		p.next()
+		child := p.parseUnary()
+		if child == nil {
+			return nil
+		}
+		return &Node{Kind: KindNot, Children: []*Node{child}}
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() *Node {
+	tok := p.peek()
+	switch tok.kind {
+	case tLParen:
+		p.next()
+		inner := p.parseOr()
+		if p.peek().kind == tRParen {
+			p.next()
+		}
+		if inner == nil {
+			return nil
+		}
+		return &Node{Kind: KindGroup, Children: []*Node{inner}}
+
+	case tField:
+		p.next()
+		field := tok.text
+		switch next := p.peek(); next.kind {
+		case tString:
+			p.next()
+			return &Node{Kind: KindPhrase, Text: next.text, Field: field}
+		case tWord:
+			p.next()
+			return &Node{Kind: KindTerm, Text: next.text, Field: field, Prefix: next.prefix}
+		case tLParen:
+			// "field:(a OR b)" - scoping a whole group to one column
+			// isn't representable without duplicating the scope onto
+			// every leaf, so just parse the group and drop the scope
+			// rather than silently discarding the user's terms.
+			p.next()
+			inner := p.parseOr()
+			if p.peek().kind == tRParen {
+				p.next()
+			}
+			if inner == nil {
+				return &Node{Kind: KindTerm, Text: field}
+			}
+			return &Node{Kind: KindGroup, Children: []*Node{inner}}
+		default:
+			// A field prefix with nothing valid after it ("word:" at
+			// end of input, or "word:AND") - treat the field name
+			// itself as a literal search term instead of dropping it.
+			return &Node{Kind: KindTerm, Text: field}
+		}
+
+	case tString:
+		p.next()
+		return &Node{Kind: KindPhrase, Text: tok.text}
+
+	case tWord:
+		p.next()
+		return &Node{Kind: KindTerm, Text: tok.text, Prefix: tok.prefix}
+
+	case tAnd, tOr, tNear:
+		// A stray operator with no valid operand around it - fall back
+		// to treating its keyword as a literal term.
+		p.next()
+		return &Node{Kind: KindTerm, Text: operatorText(tok.kind)}
+
+	default:
+		return nil
+	}
+}
+
+func operatorText(kind tokenKind) string {
+	switch kind {
+	case tAnd:
+		return "and"
+	case tOr:
+		return "or"
+	case tNear:
+		return "near"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,170 @@
+// Package fuzzy implements an fzf-style fuzzy string matcher: the pattern's
+// characters must appear in order (case-insensitive) somewhere in the
+// candidate, with bonuses for word-boundary and consecutive matches.
+package fuzzy
+
+import "unicode"
+
+const (
+	boundaryBonus    = 10
+	consecutiveBonus = 5
+)
+
+// Match scores how well pattern fuzzy-matches candidate. ok is false if
+// pattern's characters don't all appear, in order, somewhere in candidate.
+// positions are the byte offsets into candidate of the matched characters,
+// suitable for highlighting.
+func Match(pattern, candidate string) (score int, positions []int, ok bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+
+	if !inOrder(pattern, candidate) {
+		return 0, nil, false
+	}
+
+	candRunes := []rune(candidate)
+	m := &matcher{
+		pat:     []rune(foldCase(pattern)),
+		cand:    []rune(foldCase(candidate)),
+		orig:    candRunes,
+		offsets: runeByteOffsets(candidate),
+		memo:    make(map[state]*result),
+	}
+
+	res := m.solve(0, 0)
+	if res == nil {
+		return 0, nil, false
+	}
+
+	positions = make([]int, len(res.positions))
+	for i, runeIdx := range res.positions {
+		positions[i] = m.offsets[runeIdx]
+	}
+	return res.score, positions, true
+}
+
+// inOrder is the fast-reject pass: it fails fast if pattern's characters
+// don't all appear, case-insensitively, in order within candidate.
+func inOrder(pattern, candidate string) bool {
+	pat := []rune(foldCase(pattern))
+	cand := []rune(foldCase(candidate))
+
+	ci := 0
+	for _, pr := range pat {
+		found := false
+		for ; ci < len(cand); ci++ {
+			if cand[ci] == pr {
+				found = true
+				ci++
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func foldCase(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
+
+// runeByteOffsets returns, for each rune in s, the byte offset at which it starts.
+func runeByteOffsets(s string) []int {
+	offsets := make([]int, 0, len(s))
+	for i := range s {
+		offsets = append(offsets, i)
+	}
+	return offsets
+}
+
+type state struct {
+	patIdx  int
+	candIdx int
+}
+
+type result struct {
+	score     int
+	positions []int // rune indices into candidate; Match converts these to byte offsets
+}
+
+type matcher struct {
+	pat     []rune
+	cand    []rune
+	orig    []rune
+	offsets []int
+	memo    map[state]*result
+}
+
+// solve returns the best-scoring alignment of pat[patIdx:] within cand[candIdx:],
+// or nil if pat[patIdx:] cannot be matched using only cand[candIdx:]. Skipping a
+// candidate character costs nothing directly, but the consecutive-match bonus
+// below rewards tight runs, which has the same effect as a gap penalty without
+// needing to track gap length in the DP state.
+func (m *matcher) solve(patIdx, candIdx int) *result {
+	if patIdx == len(m.pat) {
+		return &result{}
+	}
+	if candIdx >= len(m.cand) {
+		return nil
+	}
+
+	key := state{patIdx, candIdx}
+	if r, ok := m.memo[key]; ok {
+		return r
+	}
+
+	best := m.solve(patIdx, candIdx+1)
+
+	if m.pat[patIdx] == m.cand[candIdx] {
+		if rest := m.solve(patIdx+1, candIdx+1); rest != nil {
+			bonus := 0
+			if m.isBoundary(candIdx) {
+				bonus += boundaryBonus
+			}
+			if len(rest.positions) > 0 && rest.positions[0] == candIdx+1 {
+				bonus += consecutiveBonus
+			}
+
+			candidate := &result{
+				score:     1 + bonus + rest.score,
+				positions: append([]int{candIdx}, rest.positions...),
+			}
+			// On a tie, prefer the direct match over best (the skip path):
+			// best can only have found a match starting later than candIdx,
+			// so candidate is always the earlier (and potentially adjacent
+			// to the parent's match) alignment of the two.
+			if best == nil || candidate.score >= best.score {
+				best = candidate
+			}
+		}
+	}
+
+	m.memo[key] = best
+	return best
+}
+
+// isBoundary reports whether position idx in the candidate starts a "word":
+// the very first character, the character after non-alphanumeric punctuation
+// or whitespace, or the upper-case letter starting a camelCase hump.
+func (m *matcher) isBoundary(idx int) bool {
+	if idx == 0 {
+		return true
+	}
+	prev := m.orig[idx-1]
+	cur := m.orig[idx]
+
+	if !unicode.IsLetter(prev) && !unicode.IsNumber(prev) {
+		return true
+	}
+	if unicode.IsLower(prev) && unicode.IsUpper(cur) {
+		return true
+	}
+	return false
+}
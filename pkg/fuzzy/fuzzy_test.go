@@ -0,0 +1,65 @@
+package fuzzy
+
+import "testing"
+
+func TestMatchInOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		candidate string
+		wantOk    bool
+	}{
+		{"empty pattern always matches", "", "anything", true},
+		{"exact match", "shakespeare", "Shakespeare", true},
+		{"subsequence match", "shksp", "Shakespeare", true},
+		{"out of order fails", "pks", "Shakespeare", false},
+		{"missing characters fails", "xyz", "Shakespeare", false},
+		{"case insensitive", "SHAKE", "shakespeare", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, positions, ok := Match(tt.pattern, tt.candidate)
+			if ok != tt.wantOk {
+				t.Fatalf("Match() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && tt.pattern != "" && len(positions) != len([]rune(tt.pattern)) {
+				t.Errorf("Match() positions = %v, want %d entries", positions, len([]rune(tt.pattern)))
+			}
+			if ok && score < 0 {
+				t.Errorf("Match() score = %d, want non-negative", score)
+			}
+		})
+	}
+}
+
+func TestMatchPrefersWordBoundaries(t *testing.T) {
+	// "wo" should score higher against "William Wordsworth" when it aligns
+	// with the boundary "W" in "Wordsworth" rather than a mid-word hit.
+	boundaryScore, _, ok := Match("wo", "William Wordsworth")
+	if !ok {
+		t.Fatal("expected match")
+	}
+
+	midWordScore, _, ok := Match("wo", "mellow orange")
+	if !ok {
+		t.Fatal("expected match")
+	}
+
+	if boundaryScore <= midWordScore {
+		t.Errorf("boundary match score %d should exceed mid-word match score %d", boundaryScore, midWordScore)
+	}
+}
+
+func TestMatchPositionsAreByteOffsets(t *testing.T) {
+	_, positions, ok := Match("café", "a café table")
+	if !ok {
+		t.Fatal("expected match")
+	}
+	// "é" is a multi-byte rune, so the final position must account for it
+	// rather than simply being len("caf") relative to the match start.
+	last := positions[len(positions)-1]
+	if last != len("a caf") {
+		t.Errorf("positions = %v, want last byte offset %d", positions, len("a caf"))
+	}
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// handler wraps an underlying slog.Handler (the JSON handler writing to the
+// rotating file) and gates records by per-subsystem level instead of a
+// single global level. The subsystem is whichever logger.With("subsystem",
+// ...) was last applied, tracked through WithAttrs so that loggers returned
+// by For keep filtering correctly.
+type handler struct {
+	next      slog.Handler
+	levels    *LevelController
+	subsystem string
+}
+
+// newHandler wraps next, gating records against levels. Loggers built from
+// the result default to the SubsystemDefault bucket until tagged otherwise
+// via With("subsystem", ...).
+func newHandler(next slog.Handler, levels *LevelController) *handler {
+	return &handler{next: next, levels: levels, subsystem: SubsystemDefault}
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.levels.Level(h.subsystem)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	for _, a := range attrs {
+		if a.Key == "subsystem" {
+			clone.subsystem = a.Value.String()
+		}
+	}
+	return &clone
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	return &clone
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Subsystems recognized by SetLogLevel/GetLogTail. Logger instances tagged
+// with any other subsystem name still work, but won't show up in the UI's
+// per-subsystem toggles.
+const (
+	SubsystemDB       = "db"
+	SubsystemParser   = "parser"
+	SubsystemSettings = "settings"
+	SubsystemTTS      = "tts"
+	SubsystemImages   = "images"
+	SubsystemWails    = "wails"
+	SubsystemDefault  = "app"
+)
+
+// LevelController maintains the minimum log level for each subsystem, so
+// verbosity can be raised or lowered independently at runtime (e.g. "db" at
+// Debug while everything else stays at Info).
+type LevelController struct {
+	mu           sync.RWMutex
+	defaultLevel slog.Level
+	levels       map[string]slog.Level
+}
+
+// NewLevelController creates a LevelController that falls back to
+// defaultLevel for any subsystem that hasn't been set explicitly.
+func NewLevelController(defaultLevel slog.Level) *LevelController {
+	return &LevelController{
+		defaultLevel: defaultLevel,
+		levels:       make(map[string]slog.Level),
+	}
+}
+
+// SetLevel sets the minimum level for subsystem.
+func (c *LevelController) SetLevel(subsystem string, level slog.Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.levels[subsystem] = level
+}
+
+// Level returns the current minimum level for subsystem, falling back to the
+// controller's default if it hasn't been set.
+func (c *LevelController) Level(subsystem string) slog.Level {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if level, ok := c.levels[subsystem]; ok {
+		return level
+	}
+	return c.defaultLevel
+}
+
+// ParseLevel parses the standard slog level names ("debug", "info", "warn",
+// "error") into a slog.Level.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return level, nil
+}
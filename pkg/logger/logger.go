@@ -5,44 +5,96 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
-	"github.com/TrueBlocks/trueblocks-poetry/pkg/constants"
+	"github.com/TrueBlocks/trueblocks-poetry/backend/settings"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/paths"
 )
 
+// Log is the default, package-level logger, tagged with SubsystemDefault.
+// Prefer For(subsystem) when logging from code that belongs to one of the
+// recognized subsystems.
 var Log *slog.Logger
 
-// InitLogger initializes the global logger to write to both stdout and a file
+var (
+	levels  *LevelController
+	root    *handler
+	logPath string
+)
+
+// InitLogger initializes the global logger to write structured JSON to both
+// stdout and a rotating app.log, with per-subsystem level filtering. Rotation
+// parameters come from the user's saved settings (falling back to defaults
+// if none are saved yet).
 func InitLogger() error {
-	configDir, err := constants.GetConfigDir()
+	configDir, err := paths.ConfigDir()
 	if err != nil {
 		return err
 	}
 
-	// Create logs directory
 	logsDir := filepath.Join(configDir, "logs")
 	if err := os.MkdirAll(logsDir, 0755); err != nil {
 		return err
 	}
+	logPath = filepath.Join(logsDir, "app.log")
 
-	// Open log file
-	logPath := filepath.Join(logsDir, "app.log")
-	file, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
+	if err := migrateLegacyLog(logPath); err != nil {
 		return err
 	}
 
-	// Create multi-writer
-	multiWriter := io.MultiWriter(os.Stdout, file)
+	maxBytes, maxFiles, maxAge := defaultRotationSettings()
+	if mgr, err := settings.NewManager(); err == nil {
+		logging := mgr.Get().Logging
+		if logging.MaxBytes > 0 {
+			maxBytes = logging.MaxBytes
+		}
+		if logging.MaxFiles > 0 {
+			maxFiles = logging.MaxFiles
+		}
+		if logging.MaxAgeDays > 0 {
+			maxAge = time.Duration(logging.MaxAgeDays) * 24 * time.Hour
+		}
+	}
 
-	// Create handler
-	handler := slog.NewTextHandler(multiWriter, &slog.HandlerOptions{
+	rotator, err := newRotatingWriter(logPath, maxBytes, maxFiles, maxAge)
+	if err != nil {
+		return err
+	}
+
+	multiWriter := io.MultiWriter(os.Stdout, rotator)
+	jsonHandler := slog.NewJSONHandler(multiWriter, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	})
 
-	// Set global logger
-	Log = slog.New(handler)
+	levels = NewLevelController(slog.LevelInfo)
+	root = newHandler(jsonHandler, levels)
+
+	Log = slog.New(root).With("subsystem", SubsystemDefault)
 	slog.SetDefault(Log)
 
 	Log.Info("Logger initialized", "path", logPath)
 	return nil
 }
+
+func defaultRotationSettings() (maxBytes int64, maxFiles int, maxAge time.Duration) {
+	return 10 * 1024 * 1024, 7, 30 * 24 * time.Hour
+}
+
+// For returns a logger tagged with the given subsystem, whose level is
+// controlled independently via SetLevel. Falls back to the default logger if
+// InitLogger hasn't run yet (e.g. in tests).
+func For(subsystem string) *slog.Logger {
+	if root == nil {
+		return slog.Default().With("subsystem", subsystem)
+	}
+	return slog.New(root).With("subsystem", subsystem)
+}
+
+// SetLevel sets the minimum log level for subsystem. Takes effect
+// immediately for every logger returned by For with that subsystem name.
+func SetLevel(subsystem string, level slog.Level) {
+	if levels == nil {
+		return
+	}
+	levels.SetLevel(subsystem, level)
+}
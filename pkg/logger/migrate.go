@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// migrateLegacyLog converts a pre-existing plain-text app.log (written by the
+// old slog.TextHandler) into the new JSON-lines format, once, on first run
+// under the new logger. If path doesn't look like legacy text, it's left
+// alone.
+func migrateLegacyLog(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	converted := make([]byte, 0, 64*1024)
+	migrated := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if json.Valid([]byte(line)) {
+			// Already JSON (or empty file) - nothing to migrate.
+			_ = file.Close()
+			return nil
+		}
+		migrated = true
+		entry := map[string]interface{}{
+			"time":   time.Now().Format(time.RFC3339Nano),
+			"level":  "INFO",
+			"msg":    line,
+			"legacy": true,
+		}
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		converted = append(converted, encoded...)
+		converted = append(converted, '\n')
+	}
+	_ = file.Close()
+
+	if !migrated {
+		return nil
+	}
+
+	return os.WriteFile(path, converted, 0644)
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// rotatingWriter is an io.Writer over app.log that rotates the file once it
+// reaches maxBytes, keeping up to maxFiles gzip-compressed backups and
+// pruning anything older than maxAge.
+type rotatingWriter struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	maxFiles int
+	maxAge   time.Duration
+
+	file *os.File
+	size int64
+}
+
+func newRotatingWriter(path string, maxBytes int64, maxFiles int, maxAge time.Duration) (*rotatingWriter, error) {
+	w := &rotatingWriter{
+		path:     path,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		maxAge:   maxAge,
+	}
+	if err := w.openFile(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) openFile() error {
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// Write appends p to the current log file, rotating first if it would push
+// the file past maxBytes.
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxBytes > 0 && w.size+int64(len(p)) > w.maxBytes {
+		if err := w.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotateLocked closes the current file, shifts existing gzip backups up by
+// one slot, gzips the just-closed file into slot 1, and opens a fresh
+// app.log. Callers must hold w.mu.
+func (w *rotatingWriter) rotateLocked() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file before rotation: %w", err)
+	}
+
+	oldest := fmt.Sprintf("%s.%d.gz", w.path, w.maxFiles)
+	_ = os.Remove(oldest)
+
+	for i := w.maxFiles - 1; i >= 1; i-- {
+		src := fmt.Sprintf("%s.%d.gz", w.path, i)
+		dst := fmt.Sprintf("%s.%d.gz", w.path, i+1)
+		if _, err := os.Stat(src); err == nil {
+			_ = os.Rename(src, dst)
+		}
+	}
+
+	rotated := w.path + ".1"
+	if err := os.Rename(w.path, rotated); err != nil {
+		return fmt.Errorf("failed to rename log file for rotation: %w", err)
+	}
+	if err := gzipAndRemove(rotated, rotated+".gz"); err != nil {
+		return fmt.Errorf("failed to compress rotated log file: %w", err)
+	}
+
+	w.pruneByAge()
+
+	return w.openFile()
+}
+
+// pruneByAge removes gzip backups older than maxAge. Failures are ignored:
+// a missed prune just means slightly more disk used until the next rotation.
+func (w *rotatingWriter) pruneByAge() {
+	if w.maxAge <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(w.path + ".*.gz")
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-w.maxAge)
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			_ = os.Remove(match)
+		}
+	}
+}
+
+func gzipAndRemove(srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dst.Close() }()
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = gz.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	_ = src.Close()
+	return os.Remove(srcPath)
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// LogEntry is one parsed line from app.log.
+type LogEntry struct {
+	Time      time.Time              `json:"time"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"msg"`
+	Subsystem string                 `json:"subsystem,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+func parseLogLine(line string) (LogEntry, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return LogEntry{}, err
+	}
+
+	entry := LogEntry{Fields: make(map[string]interface{})}
+	for key, value := range raw {
+		switch key {
+		case "time":
+			if s, ok := value.(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					entry.Time = t
+				}
+			}
+		case "level":
+			entry.Level, _ = value.(string)
+		case "msg":
+			entry.Message, _ = value.(string)
+		case "subsystem":
+			entry.Subsystem, _ = value.(string)
+		default:
+			entry.Fields[key] = value
+		}
+	}
+	return entry, nil
+}
+
+// TailLog returns the most recent n entries from app.log, optionally filtered
+// to a single subsystem ("" matches every subsystem). n <= 0 returns every
+// matching entry.
+func TailLog(subsystem string, n int) ([]LogEntry, error) {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	var matched []LogEntry
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		entry, err := parseLogLine(line)
+		if err != nil {
+			continue
+		}
+		if subsystem != "" && entry.Subsystem != subsystem {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	if n > 0 && len(matched) > n {
+		matched = matched[len(matched)-n:]
+	}
+	return matched, nil
+}
@@ -0,0 +1,371 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ParseDiagnostic reports a malformed construct found while parsing a
+// definition with ParseDefinitionStrict: an unterminated '[' block, or a
+// reference of an unrecognized type. An unterminated {type: value} tag is
+// not reported; it is left as plain text. Line and Column are
+// 1-based and count runes, so the editor UI can underline the offending
+// span directly.
+type ParseDiagnostic struct {
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+// knownReferenceTypes are the reference kinds the grammar accepts without
+// flagging a diagnostic. Anything else still parses as a TokenReference (so
+// rendering can fall back to the "unknown" TagRenderer) but is reported.
+var knownReferenceTypes = map[string]bool{
+	"word":     true,
+	"writer":   true,
+	"title":    true,
+	"footnote": true,
+	"ref":      true,
+}
+
+// trailingSlashRegex strips a trailing '\' or '/' left on a line by the
+// legacy block-quote convention (see ParseDefinition's quoteContent
+// cleanup); ParseDefinitionStrict applies the same cleanup to quote bodies.
+var trailingSlashRegex = regexp.MustCompile(`(?m)[\\/]$`)
+
+// runeScanner walks text rune-by-rune while tracking the 1-based line/column
+// of the current position, so every node the grammar produces can be
+// attributed to a source location for diagnostics.
+type runeScanner struct {
+	src  []rune
+	pos  int
+	line int
+	col  int
+}
+
+func newRuneScanner(text string) *runeScanner {
+	return &runeScanner{src: []rune(text), line: 1, col: 1}
+}
+
+func (s *runeScanner) eof() bool { return s.pos >= len(s.src) }
+
+func (s *runeScanner) peek() rune {
+	if s.eof() {
+		return 0
+	}
+	return s.src[s.pos]
+}
+
+func (s *runeScanner) peekAt(offset int) rune {
+	i := s.pos + offset
+	if i < 0 || i >= len(s.src) {
+		return 0
+	}
+	return s.src[i]
+}
+
+// mark captures enough of the scanner's state to both restore position on a
+// failed speculative parse and slice out the consumed text on success.
+type mark struct {
+	pos, line, col int
+}
+
+func (s *runeScanner) mark() mark { return mark{s.pos, s.line, s.col} }
+
+func (s *runeScanner) reset(m mark) { s.pos, s.line, s.col = m.pos, m.line, m.col }
+
+func (s *runeScanner) sliceSince(m mark) string { return string(s.src[m.pos:s.pos]) }
+
+func (s *runeScanner) advance() rune {
+	r := s.src[s.pos]
+	s.pos++
+	if r == '\n' {
+		s.line++
+		s.col = 1
+	} else {
+		s.col++
+	}
+	return r
+}
+
+func isEscapable(r rune) bool {
+	return r == '{' || r == '}' || r == '[' || r == ']' || r == ':' || r == '\\'
+}
+
+func isIdentRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
+
+// parseInline walks text looking for {type: value} reference tags, emitting
+// TokenText runs in between. It understands escape sequences (\{, \}, \[,
+// \], \:, \\) in both plain text and tag values, and a tag value may itself
+// contain a nested reference, attached to the outer token's Nested field
+// rather than being swallowed as plain text.
+func parseInline(text string) ([]Token, []ParseDiagnostic) {
+	s := newRuneScanner(text)
+	var tokens []Token
+	var diags []ParseDiagnostic
+	var textBuf strings.Builder
+
+	flushText := func() {
+		if textBuf.Len() > 0 {
+			tokens = append(tokens, Token{Type: TokenText, Content: textBuf.String()})
+			textBuf.Reset()
+		}
+	}
+
+	for !s.eof() {
+		r := s.peek()
+		switch {
+		case r == '\\' && isEscapable(s.peekAt(1)):
+			s.advance()
+			textBuf.WriteRune(s.advance())
+
+		case r == '{':
+			start := s.mark()
+			if tok, diag, ok := tryParseReference(s); ok {
+				flushText()
+				tokens = append(tokens, tok)
+				if diag != nil {
+					diags = append(diags, *diag)
+				}
+			} else {
+				s.reset(start)
+				textBuf.WriteRune(s.advance())
+			}
+
+		default:
+			textBuf.WriteRune(s.advance())
+		}
+	}
+
+	flushText()
+	return tokens, diags
+}
+
+// tryParseReference attempts to parse a {type: value} reference starting at
+// the scanner's current '{'. On success it consumes through the matching
+// '}' and returns the Token, plus a diagnostic if the type is unrecognized.
+// On failure (no identifier, no colon, or no closing '}' before a newline
+// or the end of text) it returns ok=false; the scanner's position is left
+// wherever the failed attempt stopped, so callers must save/restore it.
+func tryParseReference(s *runeScanner) (Token, *ParseDiagnostic, bool) {
+	start := s.mark()
+	line, col := s.line, s.col
+	s.advance() // consume '{'
+
+	var typeBuf strings.Builder
+	for !s.eof() && isIdentRune(s.peek()) {
+		typeBuf.WriteRune(s.advance())
+	}
+	refType := typeBuf.String()
+	if refType == "" {
+		return Token{}, nil, false
+	}
+
+	for !s.eof() && s.peek() == ' ' {
+		s.advance()
+	}
+	if s.eof() || s.peek() != ':' {
+		return Token{}, nil, false
+	}
+	s.advance() // consume ':'
+	for !s.eof() && s.peek() == ' ' {
+		s.advance()
+	}
+
+	var valueBuf strings.Builder
+	var nested []Token
+	closed := false
+
+	for !s.eof() {
+		r := s.peek()
+		if r == '\\' && isEscapable(s.peekAt(1)) {
+			s.advance()
+			valueBuf.WriteRune(s.advance())
+			continue
+		}
+		if r == '{' {
+			nestedStart := s.mark()
+			if tok, _, ok := tryParseReference(s); ok {
+				nested = append(nested, tok)
+				valueBuf.WriteString(tok.DisplayWord)
+			} else {
+				s.reset(nestedStart)
+				valueBuf.WriteRune(s.advance())
+			}
+			continue
+		}
+		if r == '}' {
+			s.advance()
+			closed = true
+			break
+		}
+		if r == '\n' {
+			// A reference tag doesn't span a line break; stop here
+			// rather than swallowing the rest of the text looking for '}'.
+			break
+		}
+		valueBuf.WriteRune(s.advance())
+	}
+
+	if !closed {
+		return Token{}, nil, false
+	}
+
+	value := strings.TrimSpace(valueBuf.String())
+	refType = strings.ToLower(refType)
+
+	tok := Token{
+		Type:        TokenReference,
+		Content:     s.sliceSince(start),
+		RefType:     refType,
+		RefWord:     value,
+		DisplayWord: value,
+		Nested:      nested,
+	}
+
+	var diag *ParseDiagnostic
+	if !knownReferenceTypes[refType] {
+		diag = &ParseDiagnostic{
+			Message: fmt.Sprintf("unrecognized reference type %q", refType),
+			Line:    line,
+			Column:  col,
+		}
+	}
+	return tok, diag, true
+}
+
+// bracketBody is one [ ... ] region found by splitBracketBodies, along with
+// the plain text since the previous body (or the start of the text).
+type bracketBody struct {
+	preText string
+	content string
+	line    int
+	col     int
+}
+
+// splitBracketBodies scans text for every top-level [ ... ] region,
+// supporting multiple bodies per definition rather than just the first.
+// Nested brackets inside a body are tracked by depth so they don't close it
+// early, and brackets consumed as part of a reference tag's value (e.g.
+// "{ref: see [1]}") are skipped over wholesale so they aren't mistaken for
+// body delimiters.
+func splitBracketBodies(text string) (bodies []bracketBody, trailing string, diags []ParseDiagnostic) {
+	s := newRuneScanner(text)
+	var preBuf strings.Builder
+
+	for !s.eof() {
+		r := s.peek()
+		switch {
+		case r == '\\' && isEscapable(s.peekAt(1)):
+			s.advance()
+			preBuf.WriteRune(s.advance())
+
+		case r == '{':
+			start := s.mark()
+			if tok, _, ok := tryParseReference(s); ok {
+				preBuf.WriteString(tok.Content)
+			} else {
+				s.reset(start)
+				preBuf.WriteRune(s.advance())
+			}
+
+		case r == '[':
+			line, col := s.line, s.col
+			s.advance()
+			depth := 1
+			var contentBuf strings.Builder
+			for !s.eof() && depth > 0 {
+				c := s.peek()
+				if c == '[' {
+					depth++
+				} else if c == ']' {
+					depth--
+					if depth == 0 {
+						s.advance()
+						break
+					}
+				}
+				contentBuf.WriteRune(s.advance())
+			}
+			if depth > 0 {
+				diags = append(diags, ParseDiagnostic{
+					Message: "unterminated '[' block",
+					Line:    line,
+					Column:  col,
+				})
+				preBuf.WriteString("[")
+				preBuf.WriteString(contentBuf.String())
+				continue
+			}
+			bodies = append(bodies, bracketBody{preText: preBuf.String(), content: contentBuf.String(), line: line, col: col})
+			preBuf.Reset()
+
+		default:
+			preBuf.WriteRune(s.advance())
+		}
+	}
+
+	trailing = preBuf.String()
+	return bodies, trailing, diags
+}
+
+// classifyBracketBody distinguishes a block quote from a poem body by
+// content, not by a caller-supplied isPoemType flag: a quote is introduced
+// by a newline immediately after the opening bracket (the "[ \n ... \n ]"
+// convention the legacy regex parser hardcoded); prose or verse starting on
+// the same line as the bracket is a poem body.
+func classifyBracketBody(content string) SegmentType {
+	if strings.HasPrefix(strings.TrimLeft(content, " \t"), "\n") {
+		return SegmentQuote
+	}
+	return SegmentPoem
+}
+
+// ParseDefinitionStrict parses definition text through the grammar
+// directly, rather than through ParseDefinition's legacy isPoemType-
+// dispatched regex path. It differs in three ways: quote vs. poem is
+// decided per bracket body by content (see classifyBracketBody); every
+// bracket body is returned, not just the first; and references of an
+// unrecognized type and unterminated '[' blocks are reported as
+// ParseDiagnostics with source position. An unterminated tag still falls
+// back to plain text with no diagnostic.
+func ParseDefinitionStrict(text string) ([]Segment, []ParseDiagnostic) {
+	bodies, trailing, diags := splitBracketBodies(text)
+
+	if len(bodies) == 0 {
+		tokens, tdiags := parseInline(text)
+		return []Segment{{Type: SegmentText, Content: text, Tokens: tokens}}, append(diags, tdiags...)
+	}
+
+	var segments []Segment
+	for _, b := range bodies {
+		if b.preText != "" {
+			tokens, tdiags := parseInline(b.preText)
+			diags = append(diags, tdiags...)
+			segments = append(segments, Segment{Type: SegmentText, Content: b.preText, Tokens: tokens})
+		}
+
+		segType := classifyBracketBody(b.content)
+		content := b.content
+		if segType == SegmentQuote {
+			content = trailingSlashRegex.ReplaceAllString(strings.Trim(content, "\n"), "")
+		} else {
+			content = strings.TrimSpace(content)
+		}
+
+		tokens, tdiags := parseInline(content)
+		diags = append(diags, tdiags...)
+		segments = append(segments, Segment{Type: segType, Content: content, Tokens: tokens})
+	}
+
+	if trailing != "" {
+		tokens, tdiags := parseInline(trailing)
+		diags = append(diags, tdiags...)
+		segments = append(segments, Segment{Type: SegmentText, Content: trailing, Tokens: tokens})
+	}
+
+	return segments, diags
+}
@@ -0,0 +1,142 @@
+package parser
+
+import "testing"
+
+func TestParseInlineReference(t *testing.T) {
+	tokens, diags := parseInline("written by {writer: Keats} in 1819")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+
+	var ref *Token
+	for i := range tokens {
+		if tokens[i].Type == TokenReference {
+			ref = &tokens[i]
+		}
+	}
+	if ref == nil {
+		t.Fatalf("expected a reference token, got %+v", tokens)
+	}
+	if ref.RefType != "writer" || ref.RefWord != "Keats" {
+		t.Errorf("got RefType=%q RefWord=%q, want writer/Keats", ref.RefType, ref.RefWord)
+	}
+}
+
+func TestParseInlineEscapedDelimiters(t *testing.T) {
+	tokens, diags := parseInline(`{ref: stanzas 2\:3 \} final}`)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(tokens) != 1 || tokens[0].Type != TokenReference {
+		t.Fatalf("expected a single reference token, got %+v", tokens)
+	}
+	if want := "stanzas 2:3 } final"; tokens[0].RefWord != want {
+		t.Errorf("RefWord = %q, want %q", tokens[0].RefWord, want)
+	}
+}
+
+func TestParseInlineNestedReference(t *testing.T) {
+	tokens, diags := parseInline("{ref: see also {title: Ode to a Nightingale}}")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(tokens) != 1 || tokens[0].Type != TokenReference {
+		t.Fatalf("expected a single outer reference token, got %+v", tokens)
+	}
+	if len(tokens[0].Nested) != 1 || tokens[0].Nested[0].RefType != "title" {
+		t.Fatalf("expected a nested title reference, got %+v", tokens[0].Nested)
+	}
+	if tokens[0].Nested[0].RefWord != "Ode to a Nightingale" {
+		t.Errorf("nested RefWord = %q", tokens[0].Nested[0].RefWord)
+	}
+}
+
+func TestParseInlineUnknownTypeDiagnostic(t *testing.T) {
+	tokens, diags := parseInline("{flavor: sonnet}")
+	if len(tokens) != 1 || tokens[0].RefType != "flavor" {
+		t.Fatalf("expected a flavor reference token, got %+v", tokens)
+	}
+	if len(diags) != 1 {
+		t.Fatalf("expected one diagnostic, got %+v", diags)
+	}
+	if diags[0].Line != 1 || diags[0].Column != 1 {
+		t.Errorf("diagnostic position = %d:%d, want 1:1", diags[0].Line, diags[0].Column)
+	}
+}
+
+func TestParseInlineUnterminatedTagLeftLiteral(t *testing.T) {
+	tokens, diags := parseInline("{word: no closing brace")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(tokens) != 1 || tokens[0].Type != TokenText {
+		t.Fatalf("expected the malformed tag to fall back to plain text, got %+v", tokens)
+	}
+}
+
+func TestSplitBracketBodiesMultiple(t *testing.T) {
+	bodies, trailing, diags := splitBracketBodies("Intro [First] middle [Second] tail")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 bodies, got %d (%+v)", len(bodies), bodies)
+	}
+	if bodies[0].content != "First" || bodies[0].preText != "Intro " {
+		t.Errorf("body[0] = %+v", bodies[0])
+	}
+	if bodies[1].content != "Second" || bodies[1].preText != " middle " {
+		t.Errorf("body[1] = %+v", bodies[1])
+	}
+	if trailing != " tail" {
+		t.Errorf("trailing = %q, want %q", trailing, " tail")
+	}
+}
+
+func TestSplitBracketBodiesUnterminated(t *testing.T) {
+	_, _, diags := splitBracketBodies("Some text [1 more text")
+	if len(diags) != 1 {
+		t.Fatalf("expected one diagnostic, got %+v", diags)
+	}
+}
+
+func TestClassifyBracketBody(t *testing.T) {
+	if got := classifyBracketBody("\nLine one\nLine two\n"); got != SegmentQuote {
+		t.Errorf("classifyBracketBody(quote-shaped) = %v, want %v", got, SegmentQuote)
+	}
+	if got := classifyBracketBody("Poem content"); got != SegmentPoem {
+		t.Errorf("classifyBracketBody(poem-shaped) = %v, want %v", got, SegmentPoem)
+	}
+}
+
+func TestParseDefinitionStrictMixedBodies(t *testing.T) {
+	text := "Written by {writer: Keats} [\nLine one\nLine two\n] and also [A single-line poem] end"
+	segments, diags := ParseDefinitionStrict(text)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+
+	var gotTypes []SegmentType
+	for _, seg := range segments {
+		gotTypes = append(gotTypes, seg.Type)
+	}
+	want := []SegmentType{SegmentText, SegmentQuote, SegmentText, SegmentPoem, SegmentText}
+	if len(gotTypes) != len(want) {
+		t.Fatalf("segment types = %v, want %v", gotTypes, want)
+	}
+	for i := range want {
+		if gotTypes[i] != want[i] {
+			t.Errorf("segment[%d].Type = %v, want %v", i, gotTypes[i], want[i])
+		}
+	}
+}
+
+func TestParseDefinitionStrictNoBrackets(t *testing.T) {
+	segments, diags := ParseDefinitionStrict("Just prose, no brackets here.")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(segments) != 1 || segments[0].Type != SegmentText {
+		t.Fatalf("expected a single text segment, got %+v", segments)
+	}
+}
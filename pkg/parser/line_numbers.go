@@ -0,0 +1,282 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LineNumberScheme recognizes and strips one particular convention anthology
+// sources use to print line numbers alongside poem text, and can reinsert
+// them again for numbered-rendering exports.
+type LineNumberScheme interface {
+	// Name identifies the scheme, e.g. for logging or a user-facing toggle.
+	Name() string
+	// Matches reports whether line contains a line number under this scheme,
+	// and if so, the line with the number removed and the number itself.
+	Matches(line string) (stripped string, number int, ok bool)
+	// Annotate reinserts number onto the (already-stripped) line in this
+	// scheme's format.
+	Annotate(line string, number int) string
+}
+
+// TrailingIntegerScheme matches the original hardcoded rule: two or more
+// spaces followed by a trailing integer, e.g. "Line of verse   5".
+type TrailingIntegerScheme struct{}
+
+var trailingIntegerRe = regexp.MustCompile(`^(.*?)\s{2,}(\d+)$`)
+
+func (TrailingIntegerScheme) Name() string { return "trailing-integer" }
+
+func (TrailingIntegerScheme) Matches(line string) (string, int, bool) {
+	m := trailingIntegerRe.FindStringSubmatch(line)
+	if m == nil {
+		return line, 0, false
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return line, 0, false
+	}
+	return m[1], n, true
+}
+
+func (TrailingIntegerScheme) Annotate(line string, number int) string {
+	return fmt.Sprintf("%s  %d", line, number)
+}
+
+// BracketedScheme matches numbers printed as a bracketed suffix, e.g.
+// "Line of verse [5]".
+type BracketedScheme struct{}
+
+var bracketedRe = regexp.MustCompile(`^(.*?)\s*\[(\d+)\]$`)
+
+func (BracketedScheme) Name() string { return "bracketed" }
+
+func (BracketedScheme) Matches(line string) (string, int, bool) {
+	m := bracketedRe.FindStringSubmatch(line)
+	if m == nil {
+		return line, 0, false
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return line, 0, false
+	}
+	return m[1], n, true
+}
+
+func (BracketedScheme) Annotate(line string, number int) string {
+	return fmt.Sprintf("%s [%d]", line, number)
+}
+
+// LeadingNumberScheme matches numbers printed at the start of the line,
+// e.g. "5   Line of verse".
+type LeadingNumberScheme struct{}
+
+var leadingNumberRe = regexp.MustCompile(`^\s*(\d+)\s{2,}(.*)$`)
+
+func (LeadingNumberScheme) Name() string { return "leading-number" }
+
+func (LeadingNumberScheme) Matches(line string) (string, int, bool) {
+	m := leadingNumberRe.FindStringSubmatch(line)
+	if m == nil {
+		return line, 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return line, 0, false
+	}
+	return m[2], n, true
+}
+
+func (LeadingNumberScheme) Annotate(line string, number int) string {
+	return fmt.Sprintf("%d  %s", number, line)
+}
+
+// RomanNumeralScheme matches roman numerals printed as a trailing suffix,
+// e.g. "Line of verse   V".
+type RomanNumeralScheme struct{}
+
+var romanNumeralRe = regexp.MustCompile(`(?i)^(.*?)\s{2,}([MDCLXVI]+)$`)
+
+func (RomanNumeralScheme) Name() string { return "roman-numeral" }
+
+func (RomanNumeralScheme) Matches(line string) (string, int, bool) {
+	m := romanNumeralRe.FindStringSubmatch(line)
+	if m == nil {
+		return line, 0, false
+	}
+	n, ok := romanToInt(strings.ToUpper(m[2]))
+	if !ok {
+		return line, 0, false
+	}
+	return m[1], n, true
+}
+
+func (RomanNumeralScheme) Annotate(line string, number int) string {
+	return fmt.Sprintf("%s  %s", line, intToRoman(number))
+}
+
+var romanValues = []struct {
+	symbol string
+	value  int
+}{
+	{"M", 1000}, {"CM", 900}, {"D", 500}, {"CD", 400},
+	{"C", 100}, {"XC", 90}, {"L", 50}, {"XL", 40},
+	{"X", 10}, {"IX", 9}, {"V", 5}, {"IV", 4}, {"I", 1},
+}
+
+func romanToInt(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	total := 0
+	for _, rv := range romanValues {
+		for strings.HasPrefix(s, rv.symbol) {
+			total += rv.value
+			s = s[len(rv.symbol):]
+		}
+	}
+	if s != "" {
+		return 0, false
+	}
+	return total, true
+}
+
+func intToRoman(n int) string {
+	var b strings.Builder
+	for _, rv := range romanValues {
+		for n >= rv.value {
+			b.WriteString(rv.symbol)
+			n -= rv.value
+		}
+	}
+	return b.String()
+}
+
+// schemes is the set DetectScheme tries, in priority order: more specific
+// formats (bracketed, roman numerals) are checked before the generic
+// trailing-integer rule so they aren't mistaken for it.
+var schemes = []LineNumberScheme{
+	BracketedScheme{},
+	RomanNumeralScheme{},
+	LeadingNumberScheme{},
+	TrailingIntegerScheme{},
+}
+
+// validStrides are the line intervals a real numbering convention is
+// expected to use (every line, every 5th, every 10th). A scheme whose
+// matched lines don't land on one of these strides is almost always a false
+// positive (e.g. dialogue that happens to end in a year).
+var validStrides = []int{1, 5, 10}
+
+// DetectScheme inspects text line-by-line against every known
+// LineNumberScheme and returns the first one whose matched line numbers
+// increase at a regular, plausible stride (every line, every 5th, or every
+// 10th), along with a confidence score in [0, 1] based on how many of the
+// candidate lines actually matched. It returns ok=false if no scheme clears
+// the bar of at least two regularly-strided matches.
+func DetectScheme(text string) (scheme LineNumberScheme, confidence float64, ok bool) {
+	lines := strings.Split(text, "\n")
+
+	for _, candidate := range schemes {
+		var numbers []int
+		var indices []int
+		for i, line := range lines {
+			if _, n, matched := candidate.Matches(line); matched {
+				numbers = append(numbers, n)
+				indices = append(indices, i)
+			}
+		}
+		if len(numbers) < 2 {
+			continue
+		}
+
+		stride, strideOK := regularStride(indices, numbers)
+		if !strideOK {
+			continue
+		}
+		_ = stride
+
+		return candidate, float64(len(numbers)) / float64(len(lines)), true
+	}
+
+	return nil, 0, false
+}
+
+// regularStride checks whether numbers increase by one of validStrides each
+// time indices advances by the same line gap, which is what a genuine
+// "every Nth line" numbering convention looks like.
+func regularStride(indices, numbers []int) (int, bool) {
+	if len(indices) < 2 {
+		return 0, false
+	}
+
+	lineGap := indices[1] - indices[0]
+	if lineGap <= 0 {
+		return 0, false
+	}
+	numberGap := numbers[1] - numbers[0]
+	if numberGap <= 0 {
+		return 0, false
+	}
+
+	validGap := false
+	for _, stride := range validStrides {
+		if numberGap == stride {
+			validGap = true
+			break
+		}
+	}
+	if !validGap {
+		return 0, false
+	}
+
+	for i := 2; i < len(indices); i++ {
+		if indices[i]-indices[i-1] != lineGap || numbers[i]-numbers[i-1] != numberGap {
+			return 0, false
+		}
+	}
+
+	return numberGap, true
+}
+
+// StripLineNumbersWith removes line numbers recognized by scheme, returning
+// the cleaned text and the numbers that were stripped (in line order), so
+// AnnotateLineNumbers can restore them later.
+func StripLineNumbersWith(text string, scheme LineNumberScheme) (string, []int) {
+	lines := strings.Split(text, "\n")
+	var numbers []int
+	result := make([]string, len(lines))
+
+	for i, line := range lines {
+		stripped, n, ok := scheme.Matches(line)
+		if !ok {
+			result[i] = line
+			continue
+		}
+		result[i] = stripped
+		numbers = append(numbers, n)
+	}
+
+	return strings.Join(result, "\n"), numbers
+}
+
+// AnnotateLineNumbers reinserts sequential line numbers (starting at 1) into
+// originalStripped using scheme's format, one per non-blank line. It's the
+// inverse of StripLineNumbersWith for the common case of strictly
+// sequential numbering, letting exports offer a numbered-poem toggle.
+func AnnotateLineNumbers(originalStripped string, scheme LineNumberScheme) string {
+	lines := strings.Split(originalStripped, "\n")
+	result := make([]string, len(lines))
+	n := 0
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			result[i] = line
+			continue
+		}
+		n++
+		result[i] = scheme.Annotate(line, n)
+	}
+	return strings.Join(result, "\n")
+}
@@ -0,0 +1,70 @@
+package parser
+
+import "testing"
+
+func TestDetectSchemeTrailingInteger(t *testing.T) {
+	text := "Line one   1\nLine two   2\nLine three   3"
+	scheme, confidence, ok := DetectScheme(text)
+	if !ok {
+		t.Fatalf("expected a scheme to be detected")
+	}
+	if scheme.Name() != "trailing-integer" {
+		t.Errorf("expected trailing-integer, got %s", scheme.Name())
+	}
+	if confidence <= 0 {
+		t.Errorf("expected positive confidence, got %f", confidence)
+	}
+}
+
+func TestDetectSchemeBracketed(t *testing.T) {
+	text := "Line one [5]\nLine two [10]\nLine three [15]"
+	scheme, _, ok := DetectScheme(text)
+	if !ok {
+		t.Fatalf("expected a scheme to be detected")
+	}
+	if scheme.Name() != "bracketed" {
+		t.Errorf("expected bracketed, got %s", scheme.Name())
+	}
+}
+
+func TestDetectSchemeRejectsIrregularNumbers(t *testing.T) {
+	text := "I was born in 1990\nMy lucky number is 7"
+	_, _, ok := DetectScheme(text)
+	if ok {
+		t.Errorf("expected no scheme to be detected for non-numbered prose")
+	}
+}
+
+func TestStripLineNumbersWithAndAnnotateRoundTrip(t *testing.T) {
+	text := "Line one   5\nLine two   10\nLine three   15"
+	scheme, _, ok := DetectScheme(text)
+	if !ok {
+		t.Fatalf("expected a scheme to be detected")
+	}
+
+	stripped, numbers := StripLineNumbersWith(text, scheme)
+	if stripped != "Line one\nLine two\nLine three" {
+		t.Errorf("unexpected stripped text: %q", stripped)
+	}
+	if len(numbers) != 3 || numbers[0] != 5 || numbers[2] != 15 {
+		t.Errorf("unexpected numbers: %v", numbers)
+	}
+
+	annotated := AnnotateLineNumbers(stripped, TrailingIntegerScheme{})
+	if annotated != "Line one  1\nLine two  2\nLine three  3" {
+		t.Errorf("unexpected annotated text: %q", annotated)
+	}
+}
+
+func TestRomanNumeralSchemeRoundTrip(t *testing.T) {
+	scheme := RomanNumeralScheme{}
+	stripped, n, ok := scheme.Matches("To be or not to be   IV")
+	if !ok || n != 4 || stripped != "To be or not to be" {
+		t.Errorf("unexpected match result: %q %d %v", stripped, n, ok)
+	}
+	// Annotate canonicalizes to a 2-space separator regardless of the
+	// original's width, same as TrailingIntegerScheme above.
+	if annotated := scheme.Annotate(stripped, n); annotated != "To be or not to be  IV" {
+		t.Errorf("unexpected annotate result: %q", annotated)
+	}
+}
@@ -30,6 +30,10 @@ type Token struct {
 	RefType     string    `json:"refType,omitempty"`
 	RefWord     string    `json:"refWord,omitempty"`
 	DisplayWord string    `json:"displayWord,omitempty"`
+	// Nested holds reference tokens found inside this token's value, e.g.
+	// {ref: see also {title: Foo}}. Only ever populated by the grammar-based
+	// parser (see ParseDefinitionStrict); ParseTokens never sets it.
+	Nested []Token `json:"nested,omitempty"`
 }
 
 // Segment represents a block of content (text, quote, or poem)
@@ -77,7 +81,10 @@ func ParseReferences(text string) []Reference {
 	return refs
 }
 
-// ParseAllTags extracts all tag-like structures from the text
+// ParseAllTags extracts all tag-like structures from the text: curly-brace
+// {key: value} tags, inline #hashtags and Bear-style #multi-word tags#,
+// :colon:separated:category: chains, and tags:/keywords: arrays from a
+// leading YAML frontmatter block.
 func ParseAllTags(text string) []Reference {
 	matches := genericRegex.FindAllStringSubmatch(text, -1)
 	var refs []Reference
@@ -91,6 +98,10 @@ func ParseAllTags(text string) []Reference {
 			})
 		}
 	}
+
+	refs = append(refs, parseHashtagsAndCategories(text)...)
+	refs = append(refs, parseFrontmatterTags(text)...)
+
 	return refs
 }
 
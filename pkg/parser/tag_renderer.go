@@ -0,0 +1,114 @@
+package parser
+
+import "strings"
+
+// TagRenderer turns a resolved Reference into the markup a particular
+// output format expects (Markdown small-caps, an HTML anchor, a LaTeX
+// macro, ...). Callers register renderers per Reference.Type ("word",
+// "writer", "title") and fall back to "unknown" for anything else.
+type TagRenderer interface {
+	Render(ref Reference) string
+}
+
+// TagRendererFunc adapts a plain function to the TagRenderer interface.
+type TagRendererFunc func(ref Reference) string
+
+func (f TagRendererFunc) Render(ref Reference) string {
+	return f(ref)
+}
+
+// TagRendererRegistry dispatches a Reference to the TagRenderer registered
+// for its Type, falling back to a renderer registered under "unknown" (or
+// the Reference's Original text, if even that is missing).
+type TagRendererRegistry struct {
+	renderers map[string]TagRenderer
+}
+
+// NewTagRendererRegistry creates an empty registry. Use Register to add
+// renderers, or start from one of the MarkdownTagRenderers/... helpers.
+func NewTagRendererRegistry() *TagRendererRegistry {
+	return &TagRendererRegistry{renderers: make(map[string]TagRenderer)}
+}
+
+// Register installs renderer as the handler for the given Reference.Type
+// ("word", "writer", "title", or "unknown" for the fallback case).
+func (r *TagRendererRegistry) Register(kind string, renderer TagRenderer) {
+	r.renderers[strings.ToLower(kind)] = renderer
+}
+
+// Render dispatches ref to the renderer registered for ref.Type, falling
+// back to "unknown", and finally to ref.Original if neither is registered.
+func (r *TagRendererRegistry) Render(ref Reference) string {
+	if renderer, ok := r.renderers[strings.ToLower(ref.Type)]; ok {
+		return renderer.Render(ref)
+	}
+	if renderer, ok := r.renderers["unknown"]; ok {
+		return renderer.Render(ref)
+	}
+	return ref.Original
+}
+
+// ReplaceWith runs ReplaceTags over text using r as the replacer.
+func (r *TagRendererRegistry) ReplaceWith(text string) string {
+	return ReplaceTags(text, r.Render)
+}
+
+// smallCapsRenderer renders every reference as bold upper-cased small caps,
+// matching the original hardcoded behavior of resolveTagsForMarkdown.
+var smallCapsRenderer = TagRendererFunc(func(ref Reference) string {
+	return "**<small>" + strings.ToUpper(ref.Value) + "</small>**"
+})
+
+// NewMarkdownTagRenderers returns a registry that renders every reference
+// kind as bold small-caps Markdown, the export format's original look.
+func NewMarkdownTagRenderers() *TagRendererRegistry {
+	r := NewTagRendererRegistry()
+	r.Register("word", smallCapsRenderer)
+	r.Register("writer", smallCapsRenderer)
+	r.Register("title", smallCapsRenderer)
+	r.Register("unknown", smallCapsRenderer)
+	return r
+}
+
+// NewHTMLTagRenderers returns a registry that renders every reference as a
+// <span> carrying the reference kind as a CSS class, for styling in the
+// single-file HTML export.
+func NewHTMLTagRenderers() *TagRendererRegistry {
+	render := TagRendererFunc(func(ref Reference) string {
+		return `<span class="ref ref-` + strings.ToLower(ref.Type) + `">` + ref.Value + `</span>`
+	})
+	r := NewTagRendererRegistry()
+	r.Register("word", render)
+	r.Register("writer", render)
+	r.Register("title", render)
+	r.Register("unknown", render)
+	return r
+}
+
+// NewLaTeXTagRenderers returns a registry that renders every reference as an
+// \emph{} macro, suitable for a LaTeX export pipeline.
+func NewLaTeXTagRenderers() *TagRendererRegistry {
+	render := TagRendererFunc(func(ref Reference) string {
+		return `\emph{` + ref.Value + `}`
+	})
+	r := NewTagRendererRegistry()
+	r.Register("word", render)
+	r.Register("writer", render)
+	r.Register("title", render)
+	r.Register("unknown", render)
+	return r
+}
+
+// NewPlainTagRenderers returns a registry that strips tags down to their
+// bare value, with no markup at all.
+func NewPlainTagRenderers() *TagRendererRegistry {
+	render := TagRendererFunc(func(ref Reference) string {
+		return ref.Value
+	})
+	r := NewTagRendererRegistry()
+	r.Register("word", render)
+	r.Register("writer", render)
+	r.Register("title", render)
+	r.Register("unknown", render)
+	return r
+}
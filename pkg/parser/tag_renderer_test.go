@@ -0,0 +1,31 @@
+package parser
+
+import "testing"
+
+func TestTagRendererRegistryFallsBackToUnknown(t *testing.T) {
+	registry := NewTagRendererRegistry()
+	registry.Register("unknown", TagRendererFunc(func(ref Reference) string {
+		return "[" + ref.Value + "]"
+	}))
+
+	out := registry.ReplaceWith("see {word: Keats}")
+	if out != "see [Keats]" {
+		t.Errorf("expected fallback renderer to be used, got %q", out)
+	}
+}
+
+func TestMarkdownTagRenderersMatchOriginalFormat(t *testing.T) {
+	registry := NewMarkdownTagRenderers()
+	out := registry.ReplaceWith("{writer: shakespeare}")
+	if out != "**<small>SHAKESPEARE</small>**" {
+		t.Errorf("unexpected markdown rendering: %q", out)
+	}
+}
+
+func TestHTMLTagRenderersProduceSpans(t *testing.T) {
+	registry := NewHTMLTagRenderers()
+	out := registry.ReplaceWith("{title: Hamlet}")
+	if out != `<span class="ref ref-title">Hamlet</span>` {
+		t.Errorf("unexpected html rendering: %q", out)
+	}
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Tag type values for the tag flavors ParseAllTags recognizes beyond the
+// curly-brace {word|writer|title: value} and generic {key: value} forms.
+const (
+	TagTypeHashtag     = "hashtag"
+	TagTypeCategory    = "category"
+	TagTypeFrontmatter = "frontmatter"
+)
+
+// bearTagPattern matches Bear-style multi-word tags terminated by a second
+// '#', e.g. "#multi word tags#". It requires at least one internal space so
+// it isn't also matched as two separate simple hashtags.
+var bearTagPattern = regexp.MustCompile(`#([A-Za-z0-9_][A-Za-z0-9_]*(?: [A-Za-z0-9_]+)+)#`)
+
+// hashtagPattern matches an inline #hashtag: a '#' not preceded by a word
+// character or another '#' (so it isn't part of a bear-style tag or a URL
+// fragment), followed by a run of word characters or hyphens.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\w#])#([A-Za-z][A-Za-z0-9_-]*)`)
+
+// categoryPattern matches a :colon:separated:tags: chain of two or more
+// segments, e.g. ":poetry:meter:sonnet:".
+var categoryPattern = regexp.MustCompile(`(?:^|\s):([A-Za-z0-9_]+(?::[A-Za-z0-9_]+)+):`)
+
+// parseHashtagsAndCategories extracts hashtag/category References from text.
+// Bear-style tags are matched first and their span masked out so the
+// simple-hashtag pass doesn't also pick up their opening '#'.
+func parseHashtagsAndCategories(text string) []Reference {
+	var refs []Reference
+
+	masked := []byte(text)
+	for _, m := range bearTagPattern.FindAllStringSubmatchIndex(text, -1) {
+		refs = append(refs, Reference{
+			Type:     TagTypeHashtag,
+			Value:    text[m[2]:m[3]],
+			Original: text[m[0]:m[1]],
+		})
+		for i := m[0]; i < m[1]; i++ {
+			masked[i] = ' '
+		}
+	}
+	maskedText := string(masked)
+
+	for _, m := range hashtagPattern.FindAllStringSubmatchIndex(maskedText, -1) {
+		refs = append(refs, Reference{
+			Type:     TagTypeHashtag,
+			Value:    maskedText[m[2]:m[3]],
+			Original: text[m[2]-1 : m[3]],
+		})
+	}
+
+	for _, m := range categoryPattern.FindAllStringSubmatchIndex(maskedText, -1) {
+		refs = append(refs, Reference{
+			Type:     TagTypeCategory,
+			Value:    maskedText[m[2]:m[3]],
+			Original: strings.TrimSpace(text[m[0]:m[1]]),
+		})
+	}
+
+	return refs
+}
+
+// parseFrontmatterTags extracts tags:/keywords: array entries from a YAML
+// frontmatter block (delimited by "---" lines) at the head of text, if
+// present. Both flow-list ("tags: [a, b]") and block-list
+// ("tags:\n  - a\n  - b") forms are supported. Each entry is returned as a
+// TagTypeFrontmatter Reference whose Value is "<key>:<entry>".
+func parseFrontmatterTags(text string) []Reference {
+	trimmed := strings.TrimLeft(text, "\n")
+	if !strings.HasPrefix(trimmed, "---\n") && !strings.HasPrefix(trimmed, "---\r\n") {
+		return nil
+	}
+
+	lines := strings.Split(trimmed, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil
+	}
+
+	var refs []Reference
+	currentKey := ""
+	for _, line := range lines[1:end] {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmedLine, "- ") {
+			if currentKey == "" {
+				continue
+			}
+			if value := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmedLine, "- ")), `"'`); value != "" {
+				refs = append(refs, Reference{Type: TagTypeFrontmatter, Value: currentKey + ":" + value, Original: line})
+			}
+			continue
+		}
+
+		idx := strings.Index(trimmedLine, ":")
+		if idx < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(trimmedLine[:idx]))
+		if key != "tags" && key != "keywords" {
+			currentKey = ""
+			continue
+		}
+		currentKey = key
+
+		rest := strings.TrimSpace(trimmedLine[idx+1:])
+		if rest == "" {
+			continue // a block list follows on subsequent "- " lines
+		}
+		rest = strings.TrimSuffix(strings.TrimPrefix(rest, "["), "]")
+		for _, entry := range strings.Split(rest, ",") {
+			if entry = strings.Trim(strings.TrimSpace(entry), `"'`); entry != "" {
+				refs = append(refs, Reference{Type: TagTypeFrontmatter, Value: key + ":" + entry, Original: line})
+			}
+		}
+	}
+
+	return refs
+}
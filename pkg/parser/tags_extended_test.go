@@ -0,0 +1,90 @@
+package parser
+
+import "testing"
+
+func TestParseAllTagsHashtag(t *testing.T) {
+	refs := ParseAllTags("a poem about #grief and loss")
+	found := false
+	for _, ref := range refs {
+		if ref.Type == TagTypeHashtag && ref.Value == "grief" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected hashtag 'grief', got %+v", refs)
+	}
+}
+
+func TestParseAllTagsBearStyleMultiWord(t *testing.T) {
+	refs := ParseAllTags("filed under #dark romanticism# for now")
+	found := false
+	for _, ref := range refs {
+		if ref.Type == TagTypeHashtag && ref.Value == "dark romanticism" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected bear-style tag 'dark romanticism', got %+v", refs)
+	}
+}
+
+func TestParseAllTagsCategoryChain(t *testing.T) {
+	refs := ParseAllTags("see also :poetry:meter:sonnet: for structure notes")
+	found := false
+	for _, ref := range refs {
+		if ref.Type == TagTypeCategory && ref.Value == "poetry:meter:sonnet" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected category 'poetry:meter:sonnet', got %+v", refs)
+	}
+}
+
+func TestParseAllTagsFrontmatterFlowList(t *testing.T) {
+	text := "---\ntags: [grief, loss]\nkeywords: [romantic]\n---\nBody text."
+	refs := ParseAllTags(text)
+	want := map[string]bool{"tags:grief": false, "tags:loss": false, "keywords:romantic": false}
+	for _, ref := range refs {
+		if ref.Type == TagTypeFrontmatter {
+			if _, ok := want[ref.Value]; ok {
+				want[ref.Value] = true
+			}
+		}
+	}
+	for value, ok := range want {
+		if !ok {
+			t.Errorf("expected frontmatter entry %q, got %+v", value, refs)
+		}
+	}
+}
+
+func TestParseAllTagsFrontmatterBlockList(t *testing.T) {
+	text := "---\ntags:\n  - grief\n  - loss\n---\nBody text."
+	refs := ParseAllTags(text)
+	count := 0
+	for _, ref := range refs {
+		if ref.Type == TagTypeFrontmatter {
+			count++
+		}
+	}
+	if count != 2 {
+		t.Errorf("expected 2 frontmatter entries, got %d (%+v)", count, refs)
+	}
+}
+
+func TestParseAllTagsStillFindsCurlyBraceTags(t *testing.T) {
+	refs := ParseAllTags("written by {writer: Keats} #grief")
+	sawWriter, sawHashtag := false, false
+	for _, ref := range refs {
+		if ref.Type == "writer" && ref.Value == "Keats" {
+			sawWriter = true
+		}
+		if ref.Type == TagTypeHashtag && ref.Value == "grief" {
+			sawHashtag = true
+		}
+	}
+	if !sawWriter || !sawHashtag {
+		t.Errorf("expected both a writer tag and a hashtag, got %+v", refs)
+	}
+}
@@ -0,0 +1,267 @@
+// Package paths resolves where the app's on-disk state lives, split per the
+// XDG base directory spec (and its macOS/Windows equivalents) rather than
+// the single hardcoded ~/.local/share/trueblocks/poetry directory earlier
+// versions used everywhere.
+package paths
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+const (
+	OrgName = "trueblocks"
+	AppName = "poetry"
+)
+
+// envHome is the POETRY_HOME override: when set, Config/Data/Cache all live
+// under it instead of the OS-specific locations below, for a portable
+// install (e.g. off a USB stick) that keeps everything in one folder.
+const envHome = "POETRY_HOME"
+
+// Paths is where the app's three kinds of on-disk state live:
+//   - Config: small, precious, user-editable state (.env, settings.json,
+//     search.json, history.json, secrets.enc) - worth syncing/backing up.
+//   - Data: state that would lose user data if wiped (poetry.db, the
+//     flags.bolt flag store, seeded images) - backed up, but not hand-edited.
+//   - Cache: anything regeneratable on demand (tts-cache/) - safe to wipe,
+//     and what backup tools conventionally skip.
+type Paths struct {
+	Config string
+	Data   string
+	Cache  string
+}
+
+var (
+	once       sync.Once
+	resolved   Paths
+	resolveErr error
+)
+
+// Current returns the process-wide resolved Paths, computing it (and
+// running the one-shot legacy-layout migration) the first time it's called.
+func Current() (Paths, error) {
+	once.Do(func() {
+		resolved, resolveErr = resolve()
+		if resolveErr == nil {
+			migrateLegacy(resolved)
+		}
+	})
+	return resolved, resolveErr
+}
+
+func resolve() (Paths, error) {
+	if home := os.Getenv(envHome); home != "" {
+		return Paths{
+			Config: filepath.Join(home, "config"),
+			Data:   filepath.Join(home, "data"),
+			Cache:  filepath.Join(home, "cache"),
+		}, nil
+	}
+
+	configRoot, err := os.UserConfigDir()
+	if err != nil {
+		return Paths{}, err
+	}
+	cacheRoot, err := os.UserCacheDir()
+	if err != nil {
+		return Paths{}, err
+	}
+	dataRoot, err := userDataDir()
+	if err != nil {
+		return Paths{}, err
+	}
+
+	return Paths{
+		Config: filepath.Join(configRoot, OrgName, AppName),
+		Data:   filepath.Join(dataRoot, OrgName, AppName),
+		Cache:  filepath.Join(cacheRoot, OrgName, AppName),
+	}, nil
+}
+
+// userDataDir returns the OS's conventional location for application data
+// that's neither configuration nor disposable cache, mirroring how
+// os.UserConfigDir/os.UserCacheDir pick their platform-specific roots - the
+// standard library has no UserDataDir of its own. It honors $XDG_DATA_HOME
+// on Linux and other non-Apple Unixes, same as os.UserConfigDir honors
+// $XDG_CONFIG_HOME there.
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		// %AppData% (roaming) is also the conventional home for app data on
+		// Windows, distinct from %LocalAppData% (what os.UserCacheDir returns).
+		return os.UserConfigDir()
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support"), nil
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			return dir, nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share"), nil
+	}
+}
+
+// ConfigDir, DataDir, and CacheDir return Current's three roots directly,
+// for callers (e.g. settings.NewManager) that want the directory itself
+// rather than one specific file under it.
+
+func ConfigDir() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return p.Config, nil
+}
+
+func DataDir() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return p.Data, nil
+}
+
+func CacheDir() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return p.Cache, nil
+}
+
+// EnvPath returns the fallback .env location main.go loads from when there's
+// no .env in the current working directory.
+func EnvPath() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p.Config, ".env"), nil
+}
+
+// SecretsPath returns the encrypted secrets store's location.
+func SecretsPath() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p.Config, "secrets.enc"), nil
+}
+
+// FlagsDBPath returns the BoltDB file path flagstore.BoltFlagStore opens by
+// default, for deployments that opt out of the cgo-dependent SQLite flag
+// store (see flagstore.BackendBolt). It lives in Data, alongside poetry.db,
+// since losing it means losing real has_image/has_tts sync state rather
+// than something cheaply regenerated.
+func FlagsDBPath() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p.Data, "flags.bolt"), nil
+}
+
+// DatabasePath returns poetry.db's location.
+func DatabasePath() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p.Data, "poetry.db"), nil
+}
+
+// ImagesDir returns the seeded/stored image library's location.
+func ImagesDir() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p.Data, "images"), nil
+}
+
+// TTSCacheDir returns the generated text-to-speech audio cache's location.
+func TTSCacheDir() (string, error) {
+	p, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p.Cache, "tts-cache"), nil
+}
+
+// legacyDir is the single combined directory every pre-Paths version of the
+// app used for all of config, data, and cache.
+func legacyDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", OrgName, AppName), nil
+}
+
+// legacyMove is one file or directory migrateLegacy relocates out of the
+// pre-Paths combined directory.
+type legacyMove struct {
+	name string // path relative to legacyDir()
+	dst  string // absolute destination under the new Config/Data/Cache split
+}
+
+// migrateLegacy moves an existing legacy (~/.local/share/trueblocks/poetry)
+// install's files into dst's new config/data/cache split, the first time
+// the new layout is resolved. It's best-effort and idempotent: a legacy
+// file that doesn't exist, or whose destination is already occupied, is
+// left alone rather than erroring, so a partially-completed migration (or a
+// legacy dir that was never actually populated) can't block startup. None
+// of settings.json/search.json/history.json reference absolute paths
+// internally, so moving the files is the whole migration - nothing inside
+// them needs rewriting.
+func migrateLegacy(dst Paths) {
+	legacy, err := legacyDir()
+	if err != nil || legacy == dst.Config {
+		return // couldn't resolve $HOME, or the config root is the legacy dir itself
+	}
+	if info, err := os.Stat(legacy); err != nil || !info.IsDir() {
+		return // no legacy install to migrate
+	}
+
+	moves := []legacyMove{
+		{".env", filepath.Join(dst.Config, ".env")},
+		{"settings.json", filepath.Join(dst.Config, "settings.json")},
+		{"search.json", filepath.Join(dst.Config, "search.json")},
+		{"history.json", filepath.Join(dst.Config, "history.json")},
+		{"secrets.enc", filepath.Join(dst.Config, "secrets.enc")},
+		{"poetry.db", filepath.Join(dst.Data, "poetry.db")},
+		{"images", filepath.Join(dst.Data, "images")},
+		{"flags.bolt", filepath.Join(dst.Data, "flags.bolt")},
+		{"tts-cache", filepath.Join(dst.Cache, "tts-cache")},
+	}
+
+	for _, mv := range moves {
+		src := filepath.Join(legacy, mv.name)
+		if _, err := os.Stat(src); err != nil {
+			continue // nothing at this legacy path
+		}
+		if _, err := os.Stat(mv.dst); err == nil {
+			continue // new location already populated; don't clobber it
+		}
+		if err := os.MkdirAll(filepath.Dir(mv.dst), 0755); err != nil {
+			slog.Warn("paths: failed to prepare migration destination", "path", mv.dst, "error", err)
+			continue
+		}
+		if err := os.Rename(src, mv.dst); err != nil {
+			slog.Warn("paths: failed to migrate legacy path", "from", src, "to", mv.dst, "error", err)
+			continue
+		}
+		slog.Info("paths: migrated legacy path to new location", "from", src, "to", mv.dst)
+	}
+}
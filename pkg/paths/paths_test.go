@@ -0,0 +1,111 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveHonorsPoetryHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(envHome, home)
+
+	p, err := resolve()
+	if err != nil {
+		t.Fatalf("resolve() error: %v", err)
+	}
+
+	if want := filepath.Join(home, "config"); p.Config != want {
+		t.Errorf("Config = %q, want %q", p.Config, want)
+	}
+	if want := filepath.Join(home, "data"); p.Data != want {
+		t.Errorf("Data = %q, want %q", p.Data, want)
+	}
+	if want := filepath.Join(home, "cache"); p.Cache != want {
+		t.Errorf("Cache = %q, want %q", p.Cache, want)
+	}
+}
+
+func TestResolveDefaultsNamespaceUnderOrgAndApp(t *testing.T) {
+	t.Setenv(envHome, "")
+
+	p, err := resolve()
+	if err != nil {
+		t.Fatalf("resolve() error: %v", err)
+	}
+
+	suffix := filepath.Join(OrgName, AppName)
+	for name, dir := range map[string]string{"Config": p.Config, "Data": p.Data, "Cache": p.Cache} {
+		if filepath.Base(filepath.Dir(dir)) != OrgName || filepath.Base(dir) != AppName {
+			t.Errorf("%s = %q, want it to end in %q", name, dir, suffix)
+		}
+	}
+}
+
+func TestMigrateLegacyMovesFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	legacy := filepath.Join(home, ".local", "share", OrgName, AppName)
+	if err := os.MkdirAll(filepath.Join(legacy, "images"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(legacy, "settings.json"), "settings")
+	writeFile(t, filepath.Join(legacy, "poetry.db"), "db")
+	writeFile(t, filepath.Join(legacy, "images", "poet.png"), "portrait")
+
+	dst := Paths{
+		Config: filepath.Join(home, "new-config"),
+		Data:   filepath.Join(home, "new-data"),
+		Cache:  filepath.Join(home, "new-cache"),
+	}
+	migrateLegacy(dst)
+
+	assertContent(t, filepath.Join(dst.Config, "settings.json"), "settings")
+	assertContent(t, filepath.Join(dst.Data, "poetry.db"), "db")
+	assertContent(t, filepath.Join(dst.Data, "images", "poet.png"), "portrait")
+}
+
+func TestMigrateLegacyDoesNotClobberExistingDestination(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	legacy := filepath.Join(home, ".local", "share", OrgName, AppName)
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(legacy, "settings.json"), "legacy settings")
+
+	dst := Paths{
+		Config: filepath.Join(home, "new-config"),
+		Data:   filepath.Join(home, "new-data"),
+		Cache:  filepath.Join(home, "new-cache"),
+	}
+	if err := os.MkdirAll(dst.Config, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dst.Config, "settings.json"), "current settings")
+
+	migrateLegacy(dst)
+
+	assertContent(t, filepath.Join(dst.Config, "settings.json"), "current settings")
+	assertContent(t, filepath.Join(legacy, "settings.json"), "legacy settings")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", path, got, want)
+	}
+}
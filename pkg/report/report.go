@@ -0,0 +1,52 @@
+// Package report collects structured, typed failures encountered while
+// exporting or generating data-quality reports, so callers get an auditable
+// trail of what went wrong instead of a silently swallowed error.
+package report
+
+import "fmt"
+
+// ReportError is a single failure recorded during an export or report run:
+// which category of work failed, which item (if any) it concerned, which
+// phase of the pipeline it happened in, and the underlying error.
+type ReportError struct {
+	Category string `json:"category"`
+	ItemID   int    `json:"itemId,omitempty"`
+	Phase    string `json:"phase"`
+	Message  string `json:"message"`
+	Err      error  `json:"-"`
+}
+
+func (e ReportError) Error() string {
+	if e.ItemID != 0 {
+		return fmt.Sprintf("[%s/%s] item %d: %s", e.Category, e.Phase, e.ItemID, e.Message)
+	}
+	return fmt.Sprintf("[%s/%s] %s", e.Category, e.Phase, e.Message)
+}
+
+func (e ReportError) Unwrap() error {
+	return e.Err
+}
+
+// Diagnostics accumulates ReportErrors across an export or report run.
+type Diagnostics []ReportError
+
+// Add records a failure for category (e.g. "export-json", "copy-image")
+// during phase (e.g. "unlinked-references", "write-item"), optionally tied
+// to itemID (0 when the failure isn't item-specific). A nil err is a no-op.
+func (d *Diagnostics) Add(category string, itemID int, phase string, err error) {
+	if err == nil {
+		return
+	}
+	*d = append(*d, ReportError{
+		Category: category,
+		ItemID:   itemID,
+		Phase:    phase,
+		Message:  err.Error(),
+		Err:      err,
+	})
+}
+
+// HasErrors reports whether any diagnostics were recorded.
+func (d Diagnostics) HasErrors() bool {
+	return len(d) > 0
+}
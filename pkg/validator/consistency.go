@@ -0,0 +1,188 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+	"github.com/TrueBlocks/trueblocks-poetry/pkg/parser"
+)
+
+// Severity classifies how serious a ValidationIssue is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ValidationIssue is one semantic inconsistency found by
+// ValidateItemConsistency, e.g. a tag with no matching link, or a poem whose
+// bracketed segment is empty once StripLineNumbers has run. Ref and
+// DestItemID are populated only for tag/link-shaped issues (see the per-code
+// comments below) so callers can reshape the issue list into a report-
+// specific structure without re-deriving the tag text or link target.
+type ValidationIssue struct {
+	ItemID     int      `json:"itemId"`
+	Severity   Severity `json:"severity"`
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Ref        string   `json:"ref,omitempty"`
+	DestItemID int      `json:"destItemId,omitempty"`
+}
+
+func newIssue(itemID int, severity Severity, code, message string) ValidationIssue {
+	return ValidationIssue{ItemID: itemID, Severity: severity, Code: code, Message: message}
+}
+
+// sourcePagePattern matches the expected "Title, p. N" shape of a source
+// citation; anything else fails the (e) check in ValidateItemConsistency.
+var sourcePagePattern = regexp.MustCompile(`^.+,\s*p\.\s*\S+$`)
+
+// ValidateItemConsistency runs the semantic checks labelled (a)-(f) in its
+// body: tag/link agreement in both directions, tag-kind
+// vs. linked-item-type agreement, poem segment well-formedness, source
+// citation shape, and unrecognized tag kinds. itemsByID must contain every
+// item reachable from links so link targets and tag kinds can be resolved;
+// links should be the full set of links touching item.ItemID (both
+// directions).
+func ValidateItemConsistency(item database.Item, links []database.Link, itemsByID map[int]database.Item) []ValidationIssue {
+	var issues []ValidationIssue
+
+	text := strings.Join([]string{
+		item.Definition.GetOrEmpty(),
+		item.Derivation.GetOrEmpty(),
+		item.Appendicies.GetOrEmpty(),
+	}, "\n")
+
+	refs := parser.ParseReferences(text)
+
+	outgoing := make(map[int]bool)
+	for _, link := range links {
+		if link.SourceItemID == item.ItemID {
+			outgoing[link.DestinationItemID] = true
+		}
+	}
+
+	// (a) every tag has a matching outgoing link, and (c) tag kind matches
+	// the linked item's Type.
+	referencedIDs := make(map[int]bool)
+	for _, ref := range refs {
+		matchWord := database.StripPossessive(ref.Value)
+		dest := findItemByWord(itemsByID, matchWord)
+		if dest == nil {
+			issue := newIssue(item.ItemID, SeverityWarning, "unresolved-tag",
+				fmt.Sprintf("tag %q does not match any item", ref.Original))
+			issue.Ref = ref.Value
+			issues = append(issues, issue)
+			continue
+		}
+		referencedIDs[dest.ItemID] = true
+
+		if !outgoing[dest.ItemID] {
+			issue := newIssue(item.ItemID, SeverityError, "unlinked-tag",
+				fmt.Sprintf("tag %q has no matching outgoing link to item %d", ref.Original, dest.ItemID))
+			issue.Ref = ref.Value
+			issue.DestItemID = dest.ItemID
+			issues = append(issues, issue)
+		}
+		if !tagKindMatches(ref.Type, dest.Type) {
+			issue := newIssue(item.ItemID, SeverityWarning, "tag-kind-mismatch",
+				fmt.Sprintf("tag %q is kind %q but linked item %d is type %q", ref.Original, ref.Type, dest.ItemID, dest.Type))
+			issue.Ref = ref.Value
+			issue.DestItemID = dest.ItemID
+			issues = append(issues, issue)
+		}
+	}
+
+	// (b) every outgoing link is referenced by at least one tag.
+	for destID := range outgoing {
+		if !referencedIDs[destID] {
+			issue := newIssue(item.ItemID, SeverityWarning, "unreferenced-link",
+				fmt.Sprintf("outgoing link to item %d has no matching tag in the definition", destID))
+			issue.DestItemID = destID
+			issues = append(issues, issue)
+		}
+	}
+
+	// (d) poem well-formedness.
+	def := item.Definition.GetOrEmpty()
+	if parser.IsPoem(item.Type, def) {
+		if strings.Count(def, "[") != 1 || strings.Count(def, "]") != 1 {
+			issues = append(issues, newIssue(item.ItemID, SeverityError, "malformed-poem",
+				"poem item does not have exactly one top-level bracketed segment"))
+		} else {
+			segments := parser.ParseDefinition(def, true)
+			if len(segments) == 1 && segments[0].Type == parser.SegmentPoem {
+				stripped := parser.StripLineNumbers(segments[0].Content)
+				if strings.TrimSpace(stripped) == "" {
+					issues = append(issues, newIssue(item.ItemID, SeverityError, "empty-poem-body",
+						"poem segment is empty after stripping line numbers"))
+				}
+			}
+		}
+	}
+
+	// (e) source citation shape: "Title, p. N".
+	if item.Source != nil && strings.TrimSpace(*item.Source) != "" {
+		citation := *item.Source
+		if item.SourcePg != nil && *item.SourcePg != "" {
+			citation = fmt.Sprintf("%s, p. %s", *item.Source, *item.SourcePg)
+		}
+		if !sourcePagePattern.MatchString(citation) {
+			issues = append(issues, newIssue(item.ItemID, SeverityInfo, "source-shape",
+				fmt.Sprintf("source citation %q does not parse as \"title, p. page\"", citation)))
+		}
+	}
+
+	// (f) unknown tag kind: a {key: value} tag whose key isn't one of the
+	// word/writer/title reference kinds or the hashtag/category/frontmatter
+	// flavors (which are indexed, not linked, and so have nothing to check
+	// against outgoing links). Mirrors the legacy items-service scan, which
+	// only considered Reference/Title/Writer items.
+	if item.Type == "Reference" || item.Type == "Title" || item.Type == "Writer" {
+		seenUnknown := make(map[string]bool)
+		for _, ref := range parser.ParseAllTags(text) {
+			known := ref.Type == "word" || ref.Type == "writer" || ref.Type == "title" ||
+				ref.Type == parser.TagTypeHashtag || ref.Type == parser.TagTypeCategory || ref.Type == parser.TagTypeFrontmatter
+			if known || seenUnknown[ref.Original] {
+				continue
+			}
+			seenUnknown[ref.Original] = true
+			issue := newIssue(item.ItemID, SeverityInfo, "unknown-tag",
+				fmt.Sprintf("tag %q is not a recognized tag type", ref.Original))
+			issue.Ref = ref.Original
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+// tagKindMatches reports whether a {word:}/{writer:}/{title:} tag kind is
+// consistent with the Type of the item it resolves to.
+func tagKindMatches(tagKind, itemType string) bool {
+	switch strings.ToLower(tagKind) {
+	case "writer":
+		return itemType == "Writer"
+	case "title":
+		return itemType == "Title"
+	case "word":
+		return itemType == "Reference" || itemType == "Other"
+	default:
+		return true
+	}
+}
+
+func findItemByWord(itemsByID map[int]database.Item, word string) *database.Item {
+	lower := strings.ToLower(word)
+	for id, item := range itemsByID {
+		if strings.ToLower(item.Word) == lower {
+			found := itemsByID[id]
+			return &found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/TrueBlocks/trueblocks-poetry/backend/database"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestValidateItemConsistencyFlagsUnlinkedTag(t *testing.T) {
+	item := database.Item{ItemID: 1, Word: "Ode", Type: "Title"}
+	item.Definition = database.NewLazyString(strPtr("written by {writer: Keats}"))
+
+	keats := database.Item{ItemID: 2, Word: "Keats", Type: "Writer"}
+	itemsByID := map[int]database.Item{1: item, 2: keats}
+
+	issues := ValidateItemConsistency(item, nil, itemsByID)
+
+	found := false
+	for _, issue := range issues {
+		if issue.Code == "unlinked-tag" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected unlinked-tag issue, got %+v", issues)
+	}
+}
+
+func TestValidateItemConsistencyFlagsUnreferencedLink(t *testing.T) {
+	item := database.Item{ItemID: 1, Word: "Ode", Type: "Title"}
+	itemsByID := map[int]database.Item{1: item, 2: {ItemID: 2, Word: "Keats", Type: "Writer"}}
+	links := []database.Link{{SourceItemID: 1, DestinationItemID: 2}}
+
+	issues := ValidateItemConsistency(item, links, itemsByID)
+
+	found := false
+	for _, issue := range issues {
+		if issue.Code == "unreferenced-link" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected unreferenced-link issue, got %+v", issues)
+	}
+}
+
+func TestValidateItemConsistencyNoIssuesWhenConsistent(t *testing.T) {
+	item := database.Item{ItemID: 1, Word: "Ode", Type: "Title"}
+	item.Definition = database.NewLazyString(strPtr("written by {writer: Keats}"))
+	itemsByID := map[int]database.Item{1: item, 2: {ItemID: 2, Word: "Keats", Type: "Writer"}}
+	links := []database.Link{{SourceItemID: 1, DestinationItemID: 2}}
+
+	issues := ValidateItemConsistency(item, links, itemsByID)
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %+v", issues)
+	}
+}